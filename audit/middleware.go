@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/Roshick/go-autumn-web/responsewriter"
+	"github.com/Roshick/go-autumn-web/tracing"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// Outcome summarizes the result of an audited request
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeDenied  Outcome = "denied"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event describes who did what to which resource with which outcome
+type Event struct {
+	Time time.Time `json:"time"`
+	// Actor is the subject of the JWT or the ID of the principal, empty for anonymous requests
+	Actor string `json:"actor,omitempty"`
+	// Action is the method and the route pattern of the request, e.g. "DELETE /users/{id}"
+	Action string `json:"action"`
+	// Resource holds the path parameters of the route, e.g. "id"
+	Resource  map[string]string `json:"resource,omitempty"`
+	Outcome   Outcome           `json:"outcome"`
+	Status    int               `json:"status"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// AuditMiddleware //
+
+type AuditMiddlewareOptions struct {
+	Sink AuditSink
+	// IncludedRoutes lists route patterns (see path.Match) of audited requests, e.g. "/admin/*". If
+	// empty, all routes are audited.
+	IncludedRoutes []string
+	// ExcludedRoutes lists route patterns of requests that are not audited, taking precedence over
+	// IncludedRoutes.
+	ExcludedRoutes []string
+	// ExcludedMethods lists HTTP methods of requests that are not audited.
+	ExcludedMethods []string
+	// ActorFn identifies the actor of the request. Defaults to ActorFromContext.
+	ActorFn func(req *http.Request) string
+	// RoutePatternFn extracts the matched route pattern after the request was handled. Defaults to
+	// metrics.RoutePattern.
+	RoutePatternFn func(req *http.Request) string
+	// Redactor masks sensitive path parameters of the resource. Defaults to logging.NewRedactor with
+	// default options.
+	Redactor logging.Redactor
+	// Async emits events in a separate goroutine with a context detached from the request, so slow
+	// sinks do not delay responses.
+	Async bool
+}
+
+func DefaultAuditMiddlewareOptions() *AuditMiddlewareOptions {
+	return &AuditMiddlewareOptions{
+		Sink:            NewLogSink(),
+		IncludedRoutes:  []string{},
+		ExcludedRoutes:  []string{},
+		ExcludedMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		ActorFn:         ActorFromContext,
+		RoutePatternFn:  metrics.RoutePattern,
+		Redactor:        logging.NewRedactor(nil),
+	}
+}
+
+// NewAuditMiddleware emits an audit event for every handled request matching the rules. The actor is
+// taken from the request context, so the middleware has to be placed after the ContextJWTMiddleware.
+func NewAuditMiddleware(opts *AuditMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultAuditMiddlewareOptions()
+	}
+	sink := opts.Sink
+	if sink == nil {
+		sink = NewLogSink()
+	}
+	actorFn := opts.ActorFn
+	if actorFn == nil {
+		actorFn = ActorFromContext
+	}
+	routePatternFn := opts.RoutePatternFn
+	if routePatternFn == nil {
+		routePatternFn = metrics.RoutePattern
+	}
+	redactor := opts.Redactor
+	if redactor == nil {
+		redactor = logging.NewRedactor(nil)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if slices.Contains(opts.ExcludedMethods, req.Method) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ww := responsewriter.Wrap(w)
+			next.ServeHTTP(ww, req)
+
+			route := routePatternFn(req)
+			if !shouldAuditRoute(route, opts) {
+				return
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			event := Event{
+				Time:     time.Now().UTC(),
+				Actor:    actorFn(req),
+				Action:   req.Method + " " + route,
+				Resource: pathParams(req, redactor),
+				Outcome:  outcomeOf(status),
+				Status:   status,
+			}
+			if requestID := tracing.RequestIDFromContext(req.Context()); requestID != nil {
+				event.RequestID = *requestID
+			}
+
+			if opts.Async {
+				ctx := contextutils.Detach(req.Context())
+				go emit(ctx, sink, event)
+				return
+			}
+			emit(req.Context(), sink, event)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func emit(ctx context.Context, sink AuditSink, event Event) {
+	if err := sink.Emit(ctx, event); err != nil {
+		aulogging.Logger.Ctx(ctx).Error().WithErr(err).Printf("failed to emit audit event for %s", event.Action)
+	}
+}
+
+// ActorFromContext returns the subject of the JWT in the request context, falling back to the ID of
+// the principal stored by the AuthorizationMiddleware
+func ActorFromContext(req *http.Request) string {
+	if token := auth.JWTFromContext(req.Context()); token != nil {
+		if subject, ok := token.Subject(); ok && subject != "" {
+			return subject
+		}
+	}
+	if principal := auth.PrincipalFromContext(req.Context()); principal != nil {
+		return principal.ID
+	}
+	return ""
+}
+
+func shouldAuditRoute(route string, opts *AuditMiddlewareOptions) bool {
+	for _, pattern := range opts.ExcludedRoutes {
+		if matched, _ := path.Match(pattern, route); matched {
+			return false
+		}
+	}
+	if len(opts.IncludedRoutes) == 0 {
+		return true
+	}
+	for _, pattern := range opts.IncludedRoutes {
+		if matched, _ := path.Match(pattern, route); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func pathParams(req *http.Request, redactor logging.Redactor) map[string]string {
+	routeCtx := chi.RouteContext(req.Context())
+	if routeCtx == nil || len(routeCtx.URLParams.Keys) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(routeCtx.URLParams.Keys))
+	for i, key := range routeCtx.URLParams.Keys {
+		if key == "*" || i >= len(routeCtx.URLParams.Values) {
+			continue
+		}
+		params[key] = redactor.RedactField(key, routeCtx.URLParams.Values[i])
+	}
+	return params
+}
+
+func outcomeOf(status int) Outcome {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	case status >= http.StatusBadRequest:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultAuditMiddlewareOptions(t *testing.T) {
+	opts := DefaultAuditMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Sink)
+	assert.NotNil(t, opts.ActorFn)
+	assert.NotNil(t, opts.RoutePatternFn)
+	assert.ElementsMatch(t, []string{http.MethodGet, http.MethodHead, http.MethodOptions}, opts.ExcludedMethods)
+	assert.False(t, opts.Async)
+}
+
+// recordingSink collects the emitted events
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) Emit(_ context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func newAuditedRouter(opts *AuditMiddlewareOptions) chi.Router {
+	router := chi.NewRouter()
+	router.Use(NewAuditMiddleware(opts))
+	router.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Post("/users/{id}/roles", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	router.Post("/admin/reindex", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	router.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return router
+}
+
+func TestNewAuditMiddleware(t *testing.T) {
+	t.Run("emits events for audited requests", func(t *testing.T) {
+		sink := &recordingSink{}
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = sink
+		router := newAuditedRouter(opts)
+
+		token, err := jwt.NewBuilder().Subject("user-1").Build()
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodDelete, "/users/42", nil)
+		ctx := auth.ContextWithJWT(req.Context(), token)
+		ctx = tracing.ContextWithRequestID(ctx, "request-id")
+		router.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+		require.Len(t, sink.events, 1)
+		event := sink.events[0]
+		assert.Equal(t, "user-1", event.Actor)
+		assert.Equal(t, "DELETE /users/{id}", event.Action)
+		assert.Equal(t, map[string]string{"id": "42"}, event.Resource)
+		assert.Equal(t, OutcomeSuccess, event.Outcome)
+		assert.Equal(t, http.StatusNoContent, event.Status)
+		assert.Equal(t, "request-id", event.RequestID)
+		assert.WithinDuration(t, time.Now(), event.Time, time.Minute)
+	})
+
+	t.Run("derives the outcome from the status", func(t *testing.T) {
+		sink := &recordingSink{}
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = sink
+		router := newAuditedRouter(opts)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/42/roles", nil)
+		req = req.WithContext(auth.ContextWithPrincipal(req.Context(), &auth.Principal{ID: "admin"}))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/reindex", nil))
+
+		require.Len(t, sink.events, 2)
+		assert.Equal(t, "admin", sink.events[0].Actor)
+		assert.Equal(t, OutcomeDenied, sink.events[0].Outcome)
+		assert.Empty(t, sink.events[1].Actor)
+		assert.Equal(t, OutcomeFailure, sink.events[1].Outcome)
+		assert.Nil(t, sink.events[1].Resource)
+	})
+
+	t.Run("applies route and method rules", func(t *testing.T) {
+		sink := &recordingSink{}
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = sink
+		opts.IncludedRoutes = []string{"/users/*", "/admin/*"}
+		opts.ExcludedRoutes = []string{"/admin/*"}
+		router := newAuditedRouter(opts)
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/reindex", nil))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/42/roles", nil))
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+
+		require.Len(t, sink.events, 1)
+		assert.Equal(t, "DELETE /users/{id}", sink.events[0].Action)
+	})
+
+	t.Run("emits asynchronously with detached context", func(t *testing.T) {
+		events := make(chan Event, 1)
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = AuditSinkFunc(func(ctx context.Context, event Event) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			events <- event
+			return nil
+		})
+		opts.Async = true
+		router := newAuditedRouter(opts)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/users/42", nil).WithContext(ctx))
+		cancel()
+
+		select {
+		case event := <-events:
+			assert.Equal(t, "DELETE /users/{id}", event.Action)
+		case <-time.After(time.Second):
+			t.Fatal("audit event not emitted")
+		}
+	})
+
+	t.Run("redacts sensitive path parameters", func(t *testing.T) {
+		sink := &recordingSink{}
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = sink
+		opts.Redactor = logging.NewRedactor(&logging.RedactorOptions{Fields: []string{"id"}})
+		router := newAuditedRouter(opts)
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+
+		require.Len(t, sink.events, 1)
+		assert.Equal(t, map[string]string{"id": logging.RedactedValue}, sink.events[0].Resource)
+	})
+
+	t.Run("does not fail requests on sink errors", func(t *testing.T) {
+		opts := DefaultAuditMiddlewareOptions()
+		opts.Sink = AuditSinkFunc(func(context.Context, Event) error {
+			return errors.New("sink unavailable")
+		})
+		router := newAuditedRouter(opts)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/logging"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+)
+
+// AuditSink receives the audit events emitted by the AuditMiddleware
+type AuditSink interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink
+type AuditSinkFunc func(ctx context.Context, event Event) error
+
+func (f AuditSinkFunc) Emit(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// LogSink //
+
+// LogSink writes audit events as info entries to the context logger
+type LogSink struct{}
+
+var _ AuditSink = (*LogSink)(nil)
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Emit(ctx context.Context, event Event) error {
+	logger := aulogging.Logger.Ctx(ctx).Info().
+		With(logging.LogFieldAuditActor, event.Actor).
+		With(logging.LogFieldAuditAction, event.Action).
+		With(logging.LogFieldAuditOutcome, string(event.Outcome)).
+		With(logging.LogFieldAuditStatus, strconv.Itoa(event.Status))
+	for key, value := range event.Resource {
+		logger = logger.With(logging.LogFieldAuditResourcePrefix+key, value)
+	}
+	logger.Printf("audit %s by %q: %s", event.Action, event.Actor, event.Outcome)
+	return nil
+}
+
+// ChannelSink //
+
+// ChannelSink sends audit events to a channel, e.g. to batch them in a separate goroutine. Emitting
+// blocks until the event is received or the context is done.
+type ChannelSink struct {
+	events chan<- Event
+}
+
+var _ AuditSink = (*ChannelSink)(nil)
+
+func NewChannelSink(events chan<- Event) *ChannelSink {
+	return &ChannelSink{
+		events: events,
+	}
+}
+
+func (s *ChannelSink) Emit(ctx context.Context, event Event) error {
+	select {
+	case s.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HTTPSink //
+
+// HTTPSink posts audit events as JSON to an endpoint, e.g. an audit service. Responses with a status
+// code of 300 or above are reported as errors.
+type HTTPSink struct {
+	client *http.Client
+	url    string
+}
+
+var _ AuditSink = (*HTTPSink)(nil)
+
+// NewHTTPSink creates a sink posting to the URL with the client, which defaults to http.DefaultClient.
+// Pass a client using the transports of this module to get logging, metrics and retries.
+func NewHTTPSink(client *http.Client, url string) *HTTPSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSink{
+		client: client,
+		url:    url,
+	}
+}
+
+func (s *HTTPSink) Emit(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(header.ContentType, "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("audit endpoint responded with status %d", res.StatusCode)
+	}
+	return nil
+}
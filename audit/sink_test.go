@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() Event {
+	return Event{
+		Time:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Actor:    "user-1",
+		Action:   "DELETE /users/{id}",
+		Resource: map[string]string{"id": "42"},
+		Outcome:  OutcomeSuccess,
+		Status:   http.StatusNoContent,
+	}
+}
+
+func TestLogSink(t *testing.T) {
+	recorder := testutils.NewTestLogger(t)
+
+	require.NoError(t, NewLogSink().Emit(context.Background(), testEvent()))
+
+	recorder.AssertLogged(t, slog.LevelInfo, "audit DELETE /users/{id}",
+		logging.LogFieldAuditActor, "user-1",
+		logging.LogFieldAuditOutcome, "success",
+		logging.LogFieldAuditStatus, "204",
+		logging.LogFieldAuditResourcePrefix+"id", "42",
+	)
+}
+
+func TestChannelSink(t *testing.T) {
+	t.Run("sends the event", func(t *testing.T) {
+		events := make(chan Event, 1)
+
+		require.NoError(t, NewChannelSink(events).Emit(context.Background(), testEvent()))
+
+		assert.Equal(t, testEvent(), <-events)
+	})
+
+	t.Run("aborts once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := NewChannelSink(make(chan Event)).Emit(ctx, testEvent())
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestHTTPSink(t *testing.T) {
+	t.Run("posts the event as JSON", func(t *testing.T) {
+		var received Event
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewHTTPSink(server.Client(), server.URL).Emit(context.Background(), testEvent()))
+
+		assert.Equal(t, testEvent(), received)
+	})
+
+	t.Run("reports error status codes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		err := NewHTTPSink(nil, server.URL).Emit(context.Background(), testEvent())
+
+		assert.ErrorContains(t, err, "503")
+	})
+}
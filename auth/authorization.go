@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+	"path"
+	"slices"
+)
+
+// All authorizes the request if every given function does, as the first non-nil principal
+// returned. Without any functions the request is rejected.
+func All(fns ...PrincipalAuthorizationFn) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		if len(fns) == 0 {
+			return nil, false
+		}
+		var principal *Principal
+		for _, fn := range fns {
+			current, ok := fn(req)
+			if !ok {
+				return nil, false
+			}
+			if principal == nil {
+				principal = current
+			}
+		}
+		return principal, true
+	}
+}
+
+// Any authorizes the request as the first function that does.
+func Any(fns ...PrincipalAuthorizationFn) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		for _, fn := range fns {
+			if principal, ok := fn(req); ok {
+				return principal, true
+			}
+		}
+		return nil, false
+	}
+}
+
+// Not authorizes the request if the given function does not. No principal is returned.
+func Not(fn PrincipalAuthorizationFn) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		_, ok := fn(req)
+		return nil, !ok
+	}
+}
+
+type AuthorizationRule struct {
+	// Methods restricts the rule to the given request methods. Empty matches all methods.
+	Methods []string
+	// PathPattern is matched against the request URL path, see path.Match. Empty matches all paths.
+	PathPattern     string
+	AuthorizationFn PrincipalAuthorizationFn
+}
+
+func (r AuthorizationRule) matches(req *http.Request) bool {
+	if len(r.Methods) > 0 && !slices.Contains(r.Methods, req.Method) {
+		return false
+	}
+	if r.PathPattern == "" {
+		return true
+	}
+	matched, _ := path.Match(r.PathPattern, req.URL.Path)
+	return matched
+}
+
+// ForRoutes applies the function of the first rule matching the request, and the fallback
+// function if no rule matches. A nil fallback rejects the request.
+func ForRoutes(rules []AuthorizationRule, fallback PrincipalAuthorizationFn) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		for _, rule := range rules {
+			if rule.matches(req) {
+				return rule.AuthorizationFn(req)
+			}
+		}
+		if fallback == nil {
+			return nil, false
+		}
+		return fallback(req)
+	}
+}
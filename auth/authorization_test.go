@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func allowAs(id string) PrincipalAuthorizationFn {
+	return func(*http.Request) (*Principal, bool) {
+		if id == "" {
+			return nil, true
+		}
+		return &Principal{ID: id}, true
+	}
+}
+
+func principalID(principal *Principal) string {
+	if principal == nil {
+		return ""
+	}
+	return principal.ID
+}
+
+func TestAll(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	tests := []struct {
+		name       string
+		fns        []PrincipalAuthorizationFn
+		expectedOK bool
+		expectedID string
+	}{
+		{name: "no functions", fns: nil, expectedOK: false},
+		{name: "all allow", fns: []PrincipalAuthorizationFn{allowAs(""), allowAs("first"), allowAs("second")}, expectedOK: true, expectedID: "first"},
+		{name: "one rejects", fns: []PrincipalAuthorizationFn{allowAs("first"), Anonymous(RejectAll())}, expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, ok := All(tt.fns...)(req)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedID, principalID(principal))
+		})
+	}
+}
+
+func TestAny(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	tests := []struct {
+		name       string
+		fns        []PrincipalAuthorizationFn
+		expectedOK bool
+		expectedID string
+	}{
+		{name: "no functions", fns: nil, expectedOK: false},
+		{name: "first match wins", fns: []PrincipalAuthorizationFn{Anonymous(RejectAll()), allowAs("second"), allowAs("third")}, expectedOK: true, expectedID: "second"},
+		{name: "all reject", fns: []PrincipalAuthorizationFn{Anonymous(RejectAll()), Anonymous(RejectAll())}, expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, ok := Any(tt.fns...)(req)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedID, principalID(principal))
+		})
+	}
+}
+
+func TestNot(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	principal, ok := Not(Anonymous(RejectAll()))(req)
+	assert.True(t, ok)
+	assert.Nil(t, principal)
+
+	principal, ok = Not(allowAs("user"))(req)
+	assert.False(t, ok)
+	assert.Nil(t, principal)
+}
+
+func TestForRoutes(t *testing.T) {
+	rules := []AuthorizationRule{
+		{Methods: []string{http.MethodGet}, PathPattern: "/public/*", AuthorizationFn: allowAs("")},
+		{PathPattern: "/admin/*", AuthorizationFn: allowAs("admin")},
+	}
+
+	tests := []struct {
+		name       string
+		fallback   PrincipalAuthorizationFn
+		method     string
+		path       string
+		expectedOK bool
+		expectedID string
+	}{
+		{name: "method and path match", method: http.MethodGet, path: "/public/docs", expectedOK: true},
+		{name: "method mismatch uses fallback", method: http.MethodPost, path: "/public/docs", expectedOK: false},
+		{name: "path match for any method", method: http.MethodDelete, path: "/admin/users", expectedOK: true, expectedID: "admin"},
+		{name: "nil fallback rejects", method: http.MethodGet, path: "/other", expectedOK: false},
+		{name: "fallback applies", fallback: allowAs("fallback"), method: http.MethodGet, path: "/other", expectedOK: true, expectedID: "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+
+			principal, ok := ForRoutes(rules, tt.fallback)(req)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedID, principalID(principal))
+		})
+	}
+}
@@ -7,6 +7,11 @@ import (
 	"github.com/lestrrat-go/jwx/v3/jwt"
 )
 
+func init() {
+	contextutils.RegisterValue[jwt.Token]()
+	contextutils.RegisterValue[Principal]()
+}
+
 func JWTFromContext(ctx context.Context) jwt.Token {
 	token := contextutils.GetValue[jwt.Token](ctx)
 	if token != nil {
@@ -18,3 +23,12 @@ func JWTFromContext(ctx context.Context) jwt.Token {
 func ContextWithJWT(ctx context.Context, token jwt.Token) context.Context {
 	return contextutils.WithValue(ctx, token)
 }
+
+// PrincipalFromContext returns the principal stored by the authorization middleware, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	return contextutils.GetValue[Principal](ctx)
+}
+
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return contextutils.WithValue(ctx, *principal)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/clock"
+)
+
+// CredentialsProvider provides the username and password of basic auth requests
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (username string, password string, err error)
+}
+
+// StaticCredentialsProvider provides fixed credentials
+type StaticCredentialsProvider struct {
+	Username string
+	Password string
+}
+
+func (p StaticCredentialsProvider) Credentials(context.Context) (string, string, error) {
+	return p.Username, p.Password, nil
+}
+
+// FileCredentialsProvider //
+
+type FileCredentialsProviderOptions struct {
+	// ReloadInterval is the minimum time between checks of the credential files for changes. Zero
+	// checks on every request.
+	ReloadInterval time.Duration
+	// Clock measures the reload interval. Defaults to the system clock.
+	Clock clock.Clock
+}
+
+func DefaultFileCredentialsProviderOptions() *FileCredentialsProviderOptions {
+	return &FileCredentialsProviderOptions{
+		ReloadInterval: 30 * time.Second,
+	}
+}
+
+// FileCredentialsProvider reads the credentials from files, e.g. the keys of a mounted Kubernetes
+// secret, and reloads them whenever the files change on disk. Trailing line breaks are ignored.
+type FileCredentialsProvider struct {
+	usernameFile string
+	passwordFile string
+	opts         *FileCredentialsProviderOptions
+
+	reloader *fileReloader[basicCredentials]
+}
+
+type basicCredentials struct {
+	username string
+	password string
+}
+
+var _ CredentialsProvider = (*FileCredentialsProvider)(nil)
+
+// NewFileCredentialsProvider reads the credentials initially, failing if the files cannot be read.
+func NewFileCredentialsProvider(usernameFile, passwordFile string, opts *FileCredentialsProviderOptions) (*FileCredentialsProvider, error) {
+	if opts == nil {
+		opts = DefaultFileCredentialsProviderOptions()
+	}
+
+	provider := &FileCredentialsProvider{
+		usernameFile: usernameFile,
+		passwordFile: passwordFile,
+		opts:         opts,
+	}
+	reloader, err := newFileReloader("basic auth credentials", []string{usernameFile, passwordFile}, opts.ReloadInterval, opts.Clock, provider.load)
+	if err != nil {
+		return nil, err
+	}
+	provider.reloader = reloader
+	return provider, nil
+}
+
+// Credentials returns the current credentials. If rotated credentials cannot be read, the previous
+// ones are kept.
+func (p *FileCredentialsProvider) Credentials(ctx context.Context) (string, string, error) {
+	credentials := p.reloader.get(ctx)
+	return credentials.username, credentials.password, nil
+}
+
+func (p *FileCredentialsProvider) load() (basicCredentials, error) {
+	username, err := os.ReadFile(p.usernameFile)
+	if err != nil {
+		return basicCredentials{}, fmt.Errorf("failed to read username file %q: %w", p.usernameFile, err)
+	}
+	password, err := os.ReadFile(p.passwordFile)
+	if err != nil {
+		return basicCredentials{}, fmt.Errorf("failed to read password file %q: %w", p.passwordFile, err)
+	}
+	return basicCredentials{
+		username: strings.TrimRight(string(username), "\r\n"),
+		password: strings.TrimRight(string(password), "\r\n"),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCredentialFiles(t *testing.T, username string, password string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	usernameFile := filepath.Join(dir, "username")
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(usernameFile, []byte(username), 0o600))
+	require.NoError(t, os.WriteFile(passwordFile, []byte(password), 0o600))
+	return usernameFile, passwordFile
+}
+
+func touch(t *testing.T, file string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+func TestStaticCredentialsProvider(t *testing.T) {
+	username, password, err := StaticCredentialsProvider{Username: "user", Password: "pass"}.Credentials(t.Context())
+
+	require.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+}
+
+func TestDefaultFileCredentialsProviderOptions(t *testing.T) {
+	opts := DefaultFileCredentialsProviderOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 30*time.Second, opts.ReloadInterval)
+}
+
+func TestNewFileCredentialsProvider(t *testing.T) {
+	t.Run("reads credentials without trailing line breaks", func(t *testing.T) {
+		usernameFile, passwordFile := writeCredentialFiles(t, "user\n", "pass\r\n")
+
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, nil)
+		require.NoError(t, err)
+
+		username, password, err := provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		usernameFile, _ := writeCredentialFiles(t, "user", "pass")
+
+		_, err := NewFileCredentialsProvider(usernameFile, filepath.Join(t.TempDir(), "missing"), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestFileCredentialsProvider_Credentials(t *testing.T) {
+	t.Run("reloads rotated credentials", func(t *testing.T) {
+		usernameFile, passwordFile := writeCredentialFiles(t, "user", "old")
+		opts := DefaultFileCredentialsProviderOptions()
+		opts.ReloadInterval = 0
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, opts)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(passwordFile, []byte("new"), 0o600))
+		touch(t, passwordFile, time.Now().Add(time.Minute))
+
+		_, password, err := provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "new", password)
+	})
+
+	t.Run("checks for changes at most once per reload interval", func(t *testing.T) {
+		usernameFile, passwordFile := writeCredentialFiles(t, "user", "old")
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(passwordFile, []byte("new"), 0o600))
+		touch(t, passwordFile, time.Now().Add(time.Minute))
+
+		_, password, err := provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "old", password)
+	})
+
+	t.Run("reloads once the reload interval has passed", func(t *testing.T) {
+		usernameFile, passwordFile := writeCredentialFiles(t, "user", "old")
+		fakeClock := testutils.NewFakeClock(time.Now())
+		opts := DefaultFileCredentialsProviderOptions()
+		opts.Clock = fakeClock
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, opts)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(passwordFile, []byte("new"), 0o600))
+		touch(t, passwordFile, time.Now().Add(time.Minute))
+
+		fakeClock.Advance(opts.ReloadInterval - time.Second)
+		_, password, err := provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "old", password)
+
+		fakeClock.Advance(time.Second)
+		_, password, err = provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "new", password)
+	})
+
+	t.Run("keeps previous credentials if files vanish", func(t *testing.T) {
+		usernameFile, passwordFile := writeCredentialFiles(t, "user", "old")
+		opts := DefaultFileCredentialsProviderOptions()
+		opts.ReloadInterval = 0
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, opts)
+		require.NoError(t, err)
+
+		require.NoError(t, os.Remove(passwordFile))
+
+		username, password, err := provider.Credentials(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "old", password)
+	})
+}
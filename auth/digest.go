@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type DigestAuthTransportOptions struct {
+}
+
+func DefaultDigestAuthTransportOptions() *DigestAuthTransportOptions {
+	return &DigestAuthTransportOptions{}
+}
+
+var _ http.RoundTripper = (*DigestAuthTransport)(nil)
+
+// DigestAuthTransport authenticates requests with RFC 7616 Digest access authentication. The first
+// request to a host is answered with a challenge, which is remembered per host to authenticate
+// subsequent requests directly until the server rejects its nonce. Only qop=auth is supported.
+type DigestAuthTransport struct {
+	base http.RoundTripper
+	opts *DigestAuthTransportOptions
+
+	credentials CredentialsProvider
+
+	m          sync.Mutex
+	challenges map[string]*digestNonce
+}
+
+// digestNonce is the challenge remembered for a host and the number of requests authenticated with it
+type digestNonce struct {
+	challenge  *digestChallenge
+	nonceCount uint32
+}
+
+func NewDigestAuthTransport(rt http.RoundTripper, username, password string, opts *DigestAuthTransportOptions) *DigestAuthTransport {
+	return NewDigestAuthTransportWithProvider(rt, StaticCredentialsProvider{Username: username, Password: password}, opts)
+}
+
+func NewDigestAuthTransportWithProvider(rt http.RoundTripper, credentials CredentialsProvider, opts *DigestAuthTransportOptions) *DigestAuthTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultDigestAuthTransportOptions()
+	}
+
+	return &DigestAuthTransport{
+		base: rt,
+		opts: opts,
+
+		credentials: credentials,
+		challenges:  make(map[string]*digestNonce),
+	}
+}
+
+func (t *DigestAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	username, password, err := t.credentials.Credentials(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain digest auth credentials: %w", err)
+	}
+
+	reqCopy := req.Clone(req.Context())
+	used, nonceCount := t.nextNonceCount(req.URL.Host)
+	if used != nil {
+		authorization, authErr := used.authorization(reqCopy, username, password, nonceCount)
+		if authErr != nil {
+			return nil, authErr
+		}
+		reqCopy.Header.Set("Authorization", authorization)
+	}
+
+	res, err := t.base.RoundTrip(reqCopy)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	challenge := findDigestChallenge(res.Header.Values("WWW-Authenticate"))
+	if challenge == nil || (used != nil && !challenge.stale && challenge.sameNonce(used)) {
+		// a fresh nonce that was rejected means the credentials are wrong
+		return res, nil
+	}
+	retryReq, ok := rewind(req)
+	if !ok {
+		return res, nil
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	nonceCount = t.setChallenge(req.URL.Host, challenge)
+	authorization, err := challenge.authorization(retryReq, username, password, nonceCount)
+	if err != nil {
+		return nil, err
+	}
+	retryReq.Header.Set("Authorization", authorization)
+	return t.base.RoundTrip(retryReq)
+}
+
+func (t *DigestAuthTransport) Describe() (string, string) {
+	return "digestauth", ""
+}
+
+func (t *DigestAuthTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (t *DigestAuthTransport) nextNonceCount(host string) (*digestChallenge, uint32) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	nonce, ok := t.challenges[host]
+	if !ok {
+		return nil, 0
+	}
+	nonce.nonceCount++
+	return nonce.challenge, nonce.nonceCount
+}
+
+func (t *DigestAuthTransport) setChallenge(host string, challenge *digestChallenge) uint32 {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.challenges[host] = &digestNonce{challenge: challenge, nonceCount: 1}
+	return 1
+}
+
+// rewind clones the request for sending it again, which requires its body to be replayable
+func rewind(req *http.Request) (*http.Request, bool) {
+	retryReq := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retryReq, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retryReq.Body = body
+	return retryReq, true
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       bool
+	stale     bool
+	hashFn    func() hash.Hash
+	session   bool
+}
+
+var digestHashFns = map[string]func() hash.Hash{
+	"MD5":         md5.New,
+	"SHA-256":     sha256.New,
+	"SHA-512-256": sha512.New512_256,
+}
+
+// findDigestChallenge returns the first supported Digest challenge of the WWW-Authenticate headers
+func findDigestChallenge(headerValues []string) *digestChallenge {
+	for _, headerValue := range headerValues {
+		for _, challengeParams := range parseChallenges(headerValue) {
+			if challenge := newDigestChallenge(challengeParams); challenge != nil {
+				return challenge
+			}
+		}
+	}
+	return nil
+}
+
+func newDigestChallenge(params map[string]string) *digestChallenge {
+	if !strings.EqualFold(params[""], "Digest") || params["nonce"] == "" {
+		return nil
+	}
+	challenge := &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+		stale:     strings.EqualFold(params["stale"], "true"),
+	}
+	if challenge.algorithm == "" {
+		challenge.algorithm = "MD5"
+	}
+	algorithm, session := strings.CutSuffix(strings.ToUpper(challenge.algorithm), "-SESS")
+	hashFn, ok := digestHashFns[algorithm]
+	if !ok {
+		return nil
+	}
+	challenge.hashFn = hashFn
+	challenge.session = session
+
+	if qop, hasQOP := params["qop"]; hasQOP {
+		qopOptions := strings.Split(qop, ",")
+		for i := range qopOptions {
+			qopOptions[i] = strings.TrimSpace(qopOptions[i])
+		}
+		if !slices.Contains(qopOptions, "auth") {
+			return nil
+		}
+		challenge.qop = true
+	}
+	return challenge
+}
+
+// sameNonce reports whether both challenges share realm and nonce
+func (c *digestChallenge) sameNonce(other *digestChallenge) bool {
+	return c.realm == other.realm && c.nonce == other.nonce
+}
+
+func (c *digestChallenge) hash(values ...string) string {
+	h := c.hashFn()
+	_, _ = io.WriteString(h, strings.Join(values, ":"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *digestChallenge) authorization(req *http.Request, username string, password string, nonceCount uint32) (string, error) {
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", fmt.Errorf("failed to generate digest auth cnonce: %w", err)
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	nc := fmt.Sprintf("%08x", nonceCount)
+	uri := req.URL.RequestURI()
+
+	ha1 := c.hash(username, c.realm, password)
+	if c.session {
+		ha1 = c.hash(ha1, c.nonce, cnonce)
+	}
+	ha2 := c.hash(req.Method, uri)
+
+	fields := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("algorithm=%s", c.algorithm),
+	}
+	if c.qop {
+		response := c.hash(ha1, c.nonce, nc, cnonce, "auth", ha2)
+		fields = append(fields,
+			fmt.Sprintf("response=%q", response),
+			"qop=auth",
+			fmt.Sprintf("nc=%s", nc),
+			fmt.Sprintf("cnonce=%q", cnonce),
+		)
+	} else {
+		fields = append(fields, fmt.Sprintf("response=%q", c.hash(ha1, c.nonce, ha2)))
+	}
+	if c.opaque != "" {
+		fields = append(fields, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// parseChallenges parses the challenges of a WWW-Authenticate header value into their parameters,
+// with the scheme stored under the empty key. Parameter names are lower-cased.
+func parseChallenges(headerValue string) []map[string]string {
+	var challenges []map[string]string
+	var current map[string]string
+	rest := headerValue
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return challenges
+		}
+		token := rest[:tokenLength(rest)]
+		rest = strings.TrimLeft(rest[len(token):], " \t")
+		if !strings.HasPrefix(rest, "=") || token == "" {
+			if token == "" {
+				// skip invalid characters
+				rest = rest[1:]
+				continue
+			}
+			current = map[string]string{"": token}
+			challenges = append(challenges, current)
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest = parseQuotedString(rest)
+		} else {
+			value = rest[:tokenLength(rest)]
+			rest = rest[len(value):]
+		}
+		if current != nil {
+			current[strings.ToLower(token)] = value
+		}
+	}
+}
+
+func tokenLength(s string) int {
+	for i, r := range s {
+		if r == ' ' || r == '\t' || r == ',' || r == '=' || r == '"' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// parseQuotedString returns the unescaped content of the quoted string s starts with and the rest of s
+func parseQuotedString(s string) (string, string) {
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+			}
+		case '"':
+			return value.String(), s[i+1:]
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return value.String(), ""
+}
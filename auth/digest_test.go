@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digestServer verifies RFC 7616 Digest authentication with qop=auth
+type digestServer struct {
+	t         *testing.T
+	algorithm string
+	hashFn    func() hash.Hash
+
+	m           sync.Mutex
+	realm       string
+	nonce       string
+	nonceCounts []string
+	challenges  int
+	bodies      []string
+}
+
+func newDigestServer(t *testing.T, algorithm string, hashFn func() hash.Hash) (*digestServer, *httptest.Server) {
+	s := &digestServer{t: t, algorithm: algorithm, hashFn: hashFn, realm: "test", nonce: "nonce-1"}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func (s *digestServer) hash(values ...string) string {
+	h := s.hashFn()
+	_, _ = io.WriteString(h, strings.Join(values, ":"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *digestServer) rotateNonce(nonce string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.nonce = nonce
+}
+
+// restart forgets the issued nonces and answers with a new realm, so previous challenges are not stale
+func (s *digestServer) restart(realm string, nonce string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.realm = realm
+	s.nonce = nonce
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	challenges := parseChallenges(r.Header.Get("Authorization"))
+	if len(challenges) == 1 && challenges[0][""] == "Digest" {
+		params := challenges[0]
+		ha1 := s.hash("user", s.realm, "secret")
+		ha2 := s.hash(r.Method, params["uri"])
+		expected := s.hash(ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+		if params["response"] == expected && params["opaque"] == "opaque-value" && params["uri"] == r.URL.RequestURI() {
+			if params["nonce"] == s.nonce {
+				body, _ := io.ReadAll(r.Body)
+				s.nonceCounts = append(s.nonceCounts, params["nc"])
+				s.bodies = append(s.bodies, string(body))
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			s.challenges++
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth", algorithm=%s, nonce="%s", opaque="opaque-value", stale=true`, s.realm, s.algorithm, s.nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	s.challenges++
+	w.Header().Add("WWW-Authenticate", `Basic realm="test"`)
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth,auth-int", algorithm=%s, nonce="%s", opaque="opaque-value"`, s.realm, s.algorithm, s.nonce))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func TestDefaultDigestAuthTransportOptions(t *testing.T) {
+	opts := DefaultDigestAuthTransportOptions()
+	require.NotNil(t, opts)
+}
+
+func TestDigestAuthTransport_RoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm string
+		hashFn    func() hash.Hash
+	}{
+		{"MD5", md5.New},
+		{"SHA-256", sha256.New},
+	} {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			digest, server := newDigestServer(t, tc.algorithm, tc.hashFn)
+			client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "secret", nil)}
+
+			for range 3 {
+				res, err := client.Get(server.URL + "/devices?id=1")
+				require.NoError(t, err)
+				_ = res.Body.Close()
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			}
+
+			assert.Equal(t, 1, digest.challenges)
+			assert.Equal(t, []string{"00000001", "00000002", "00000003"}, digest.nonceCounts)
+		})
+	}
+
+	t.Run("renews stale nonces", func(t *testing.T) {
+		digest, server := newDigestServer(t, "MD5", md5.New)
+		client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "secret", nil)}
+
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		digest.rotateNonce("nonce-2")
+		res, err = client.Get(server.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, digest.challenges)
+		assert.Equal(t, []string{"00000001", "00000001"}, digest.nonceCounts)
+	})
+
+	t.Run("remembers challenges per host", func(t *testing.T) {
+		first, firstServer := newDigestServer(t, "MD5", md5.New)
+		second, secondServer := newDigestServer(t, "MD5", md5.New)
+		second.restart("other", "nonce-other")
+		client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "secret", nil)}
+
+		for _, url := range []string{firstServer.URL, secondServer.URL, firstServer.URL, secondServer.URL} {
+			res, err := client.Get(url)
+			require.NoError(t, err)
+			_ = res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		}
+
+		assert.Equal(t, 1, first.challenges)
+		assert.Equal(t, 1, second.challenges)
+		assert.Equal(t, []string{"00000001", "00000002"}, second.nonceCounts)
+	})
+
+	t.Run("authenticates again for a new realm", func(t *testing.T) {
+		digest, server := newDigestServer(t, "MD5", md5.New)
+		client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "secret", nil)}
+
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		digest.restart("other", "nonce-2")
+		res, err = client.Get(server.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, digest.challenges)
+	})
+
+	t.Run("replays request bodies", func(t *testing.T) {
+		digest, server := newDigestServer(t, "MD5", md5.New)
+		client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "secret", nil)}
+
+		res, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"payload"}, digest.bodies)
+	})
+
+	t.Run("returns the challenge for wrong credentials", func(t *testing.T) {
+		digest, server := newDigestServer(t, "MD5", md5.New)
+		client := &http.Client{Transport: NewDigestAuthTransport(nil, "user", "wrong", nil)}
+
+		res, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, 2, digest.challenges)
+	})
+
+	t.Run("returns responses without digest challenge", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseToReturn: &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Header:     http.Header{"Www-Authenticate": []string{`Bearer realm="test"`}},
+			Body:       http.NoBody,
+		}}
+		transport := NewDigestAuthTransport(mockRT, "user", "secret", nil)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://localhost/api", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Empty(t, mockRT.capturedRequest.Header.Get("Authorization"))
+	})
+
+	t.Run("fails if the provider fails", func(t *testing.T) {
+		transport := NewDigestAuthTransportWithProvider(&MockRoundTripper{}, failingCredentialsProvider{}, nil)
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://localhost/api", nil))
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestFindDigestChallenge(t *testing.T) {
+	t.Run("parses quoted parameters", func(t *testing.T) {
+		challenge := findDigestChallenge([]string{`Basic realm="a, b", Digest realm="x\"y", nonce="n,1", algorithm=SHA-256-sess, qop="auth", opaque="o"`})
+
+		require.NotNil(t, challenge)
+		assert.Equal(t, `x"y`, challenge.realm)
+		assert.Equal(t, "n,1", challenge.nonce)
+		assert.Equal(t, "o", challenge.opaque)
+		assert.True(t, challenge.session)
+		assert.True(t, challenge.qop)
+	})
+
+	t.Run("defaults to MD5 without qop", func(t *testing.T) {
+		challenge := findDigestChallenge([]string{`Digest realm="test", nonce="abc"`})
+
+		require.NotNil(t, challenge)
+		assert.Equal(t, "MD5", challenge.algorithm)
+		assert.False(t, challenge.qop)
+	})
+
+	t.Run("skips unsupported challenges", func(t *testing.T) {
+		assert.Nil(t, findDigestChallenge([]string{`Digest realm="test", nonce="abc", qop="auth-int"`}))
+		assert.Nil(t, findDigestChallenge([]string{`Digest realm="test", nonce="abc", algorithm=SHA-1`}))
+		assert.Nil(t, findDigestChallenge([]string{`Bearer realm="test"`}))
+	})
+}
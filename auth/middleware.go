@@ -5,10 +5,12 @@ import (
 	"crypto/subtle"
 	"net/http"
 	"strings"
+	"time"
 
 	weberrors "github.com/Roshick/go-autumn-web/errors"
 	"github.com/Roshick/go-autumn-web/header"
 	"github.com/go-chi/render"
+	"github.com/lestrrat-go/jwx/v3/jws"
 	"github.com/lestrrat-go/jwx/v3/jwt"
 )
 
@@ -16,12 +18,48 @@ import (
 
 type AuthorizationFn func(*http.Request) bool
 
+// PrincipalAuthorizationFn reports whether the request is authorized and, if so, the principal it
+// was authorized as. Functions that do not identify a caller may return a nil principal.
+type PrincipalAuthorizationFn func(*http.Request) (*Principal, bool)
+
+// Anonymous adapts the function to a PrincipalAuthorizationFn that authorizes without principal,
+// e.g. to combine it with principal-returning functions.
+func Anonymous(fn AuthorizationFn) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		return nil, fn(req)
+	}
+}
+
+const (
+	AuthMethodBasic  = "basic"
+	AuthMethodBearer = "bearer"
+)
+
+// Principal describes the caller matched by a PrincipalAuthorizationFn.
+type Principal struct {
+	// ID identifies the caller, e.g. the basic auth username or the JWT subject.
+	ID string
+	// Method names the authentication method that matched, e.g. AuthMethodBasic.
+	Method string
+	// Claims holds additional attributes of the caller, e.g. the JWT claims.
+	Claims map[string]any
+}
+
 type AllowBasicAuthUserOptions struct {
 	Username string
 	Password string
 }
 
 func AllowBasicAuthUser(options AllowBasicAuthUserOptions) AuthorizationFn {
+	principalFn := AllowBasicAuthPrincipal(options)
+	return func(req *http.Request) bool {
+		_, ok := principalFn(req)
+		return ok
+	}
+}
+
+// AllowBasicAuthPrincipal is like AllowBasicAuthUser but returns the username as principal.
+func AllowBasicAuthPrincipal(options AllowBasicAuthUserOptions) PrincipalAuthorizationFn {
 	isBasicAuthUserCredentials := func(username string, password string) bool {
 		if username == "" || password == "" {
 			return false
@@ -39,12 +77,12 @@ func AllowBasicAuthUser(options AllowBasicAuthUserOptions) AuthorizationFn {
 		return usernameMatch && passwordMatch
 	}
 
-	return func(req *http.Request) bool {
+	return func(req *http.Request) (*Principal, bool) {
 		username, password, ok := req.BasicAuth()
-		if !ok {
-			return false
+		if !ok || !isBasicAuthUserCredentials(username, password) {
+			return nil, false
 		}
-		return isBasicAuthUserCredentials(username, password)
+		return &Principal{ID: username, Method: AuthMethodBasic}, true
 	}
 }
 
@@ -62,6 +100,32 @@ func AllowBearerTokenUser(opts AllowBearerTokenUserOptions) AuthorizationFn {
 	}
 }
 
+// AllowBearerTokenPrincipal is like AllowBearerTokenUser but returns the JWT subject and claims as
+// principal.
+func AllowBearerTokenPrincipal(opts AllowBearerTokenUserOptions) PrincipalAuthorizationFn {
+	return func(req *http.Request) (*Principal, bool) {
+		token, err := jwt.ParseRequest(req, opts.ParseOptions...)
+		if err != nil {
+			return nil, false
+		}
+		return principalFromJWT(token), true
+	}
+}
+
+func principalFromJWT(token jwt.Token) *Principal {
+	principal := &Principal{Method: AuthMethodBearer, Claims: make(map[string]any)}
+	if subject, ok := token.Subject(); ok {
+		principal.ID = subject
+	}
+	for _, key := range token.Keys() {
+		var value any
+		if err := token.Get(key, &value); err == nil {
+			principal.Claims[key] = value
+		}
+	}
+	return principal
+}
+
 func RejectAll() AuthorizationFn {
 	return func(req *http.Request) bool {
 		return false
@@ -70,7 +134,10 @@ func RejectAll() AuthorizationFn {
 
 type AuthorizationMiddlewareOptions struct {
 	AuthorizationFns []AuthorizationFn
-	ErrorResponse    render.Renderer
+	// PrincipalAuthorizationFns are tried after AuthorizationFns. The principal of the first one that
+	// authorizes the request is stored in the request context, see PrincipalFromContext.
+	PrincipalAuthorizationFns []PrincipalAuthorizationFn
+	ErrorResponse             render.Renderer
 }
 
 func DefaultAuthorizationMiddlewareOptions() *AuthorizationMiddlewareOptions {
@@ -93,7 +160,16 @@ func NewAuthorizationMiddleware(opts *AuthorizationMiddlewareOptions) func(next
 					return
 				}
 			}
-			if err := render.Render(w, req, opts.ErrorResponse); err != nil {
+			for _, authFn := range opts.PrincipalAuthorizationFns {
+				if principal, ok := authFn(req); ok {
+					if principal != nil {
+						req = req.WithContext(ContextWithPrincipal(req.Context(), principal))
+					}
+					next.ServeHTTP(w, req)
+					return
+				}
+			}
+			if err := weberrors.Render(w, req, opts.ErrorResponse); err != nil {
 				panic(err)
 			}
 		}
@@ -104,6 +180,15 @@ func NewAuthorizationMiddleware(opts *AuthorizationMiddlewareOptions) func(next
 // ContextJWTMiddleware //
 
 type ContextJWTMiddlewareOptions struct {
+	// KeyProvider enables signature verification, e.g. NewRemoteKeySetProvider. Without a key
+	// provider tokens are parsed without verifying their signature.
+	KeyProvider jws.KeyProvider
+	// Issuer and Audience are validated against the token claims if set.
+	Issuer   string
+	Audience string
+	// AcceptableSkew tolerates clock differences when validating time based claims.
+	AcceptableSkew time.Duration
+	// ErrorResponse is rendered if a bearer token is present but invalid.
 	ErrorResponse render.Renderer
 }
 
@@ -118,6 +203,20 @@ func NewContextJWTMiddleware(opts *ContextJWTMiddlewareOptions) func(next http.H
 		opts = DefaultContextJWTMiddlewareOptions()
 	}
 
+	parseOptions := []jwt.ParseOption{jwt.WithVerify(false)}
+	if opts.KeyProvider != nil {
+		parseOptions = []jwt.ParseOption{jwt.WithKeyProvider(opts.KeyProvider)}
+	}
+	if opts.Issuer != "" {
+		parseOptions = append(parseOptions, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parseOptions = append(parseOptions, jwt.WithAudience(opts.Audience))
+	}
+	if opts.AcceptableSkew > 0 {
+		parseOptions = append(parseOptions, jwt.WithAcceptableSkew(opts.AcceptableSkew))
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
 			authorization := req.Header.Get(header.Authorization)
@@ -126,9 +225,9 @@ func NewContextJWTMiddleware(opts *ContextJWTMiddlewareOptions) func(next http.H
 				return
 			}
 
-			token, err := jwt.ParseRequest(req, jwt.WithVerify(false))
+			token, err := jwt.ParseRequest(req, parseOptions...)
 			if err != nil {
-				if innerErr := render.Render(w, req, opts.ErrorResponse); innerErr != nil {
+				if innerErr := weberrors.Render(w, req, opts.ErrorResponse); innerErr != nil {
 					panic(innerErr)
 				}
 				return
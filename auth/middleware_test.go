@@ -1,11 +1,17 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +70,30 @@ func TestAllowBasicAuthUser(t *testing.T) {
 	}
 }
 
+func TestAllowBasicAuthPrincipal(t *testing.T) {
+	authFn := AllowBasicAuthPrincipal(AllowBasicAuthUserOptions{Username: "testuser", Password: "testpass"})
+
+	t.Run("valid credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth("testuser", "testpass")
+
+		principal, ok := authFn(req)
+		assert.True(t, ok)
+		require.NotNil(t, principal)
+		assert.Equal(t, "testuser", principal.ID)
+		assert.Equal(t, AuthMethodBasic, principal.Method)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth("testuser", "wrong")
+
+		principal, ok := authFn(req)
+		assert.False(t, ok)
+		assert.Nil(t, principal)
+	})
+}
+
 func TestRejectAll(t *testing.T) {
 	authFn := RejectAll()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -158,4 +188,184 @@ func TestNewAuthorizationMiddleware(t *testing.T) {
 		assert.True(t, handlerCalled)
 		assert.Equal(t, http.StatusOK, rr.Code)
 	})
+
+	t.Run("stores matched principal in context", func(t *testing.T) {
+		expected := &Principal{ID: "user-1", Method: "custom", Claims: map[string]any{"role": "admin"}}
+		opts := &AuthorizationMiddlewareOptions{
+			AuthorizationFns: []AuthorizationFn{RejectAll()},
+			PrincipalAuthorizationFns: []PrincipalAuthorizationFn{
+				func(*http.Request) (*Principal, bool) { return &Principal{ID: "ignored"}, false },
+				func(*http.Request) (*Principal, bool) { return expected, true },
+			},
+		}
+
+		var principal *Principal
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal = PrincipalFromContext(r.Context())
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		NewAuthorizationMiddleware(opts)(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.NotNil(t, principal)
+		assert.Equal(t, *expected, *principal)
+	})
+
+	t.Run("no principal in context for anonymous match", func(t *testing.T) {
+		opts := &AuthorizationMiddlewareOptions{
+			AuthorizationFns: []AuthorizationFn{
+				func(*http.Request) bool { return true },
+			},
+		}
+
+		principalFound := true
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principalFound = PrincipalFromContext(r.Context()) != nil
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		NewAuthorizationMiddleware(opts)(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.False(t, principalFound)
+	})
+}
+
+func TestAllowBearerTokenPrincipal(t *testing.T) {
+	token, err := jwt.NewBuilder().Subject("user-1").Claim("scope", "read").Build()
+	require.NoError(t, err)
+	serialized, err := jwt.NewSerializer().Serialize(token)
+	require.NoError(t, err)
+
+	authFn := AllowBearerTokenPrincipal(AllowBearerTokenUserOptions{
+		ParseOptions: []jwt.ParseOption{jwt.WithVerify(false)},
+	})
+
+	t.Run("valid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+string(serialized))
+
+		principal, ok := authFn(req)
+		assert.True(t, ok)
+		require.NotNil(t, principal)
+		assert.Equal(t, "user-1", principal.ID)
+		assert.Equal(t, AuthMethodBearer, principal.Method)
+		assert.Equal(t, "read", principal.Claims["scope"])
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		principal, ok := authFn(req)
+		assert.False(t, ok)
+		assert.Nil(t, principal)
+	})
+}
+
+func TestNewContextJWTMiddleware(t *testing.T) {
+	signingKey := []byte("test-secret")
+	keyProvider := jws.KeyProviderFunc(func(_ context.Context, sink jws.KeySink, _ *jws.Signature, _ *jws.Message) error {
+		sink.Key(jwa.HS256(), signingKey)
+		return nil
+	})
+
+	signedToken := func(t *testing.T, key []byte, issuer string, audience string) string {
+		token, err := jwt.NewBuilder().
+			Subject("user-1").
+			Issuer(issuer).
+			Audience([]string{audience}).
+			Expiration(time.Now().Add(time.Hour)).
+			Build()
+		require.NoError(t, err)
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256(), key))
+		require.NoError(t, err)
+		return string(signed)
+	}
+
+	tests := []struct {
+		name           string
+		opts           *ContextJWTMiddlewareOptions
+		authHeader     string
+		expectedStatus int
+		expectedToken  bool
+	}{
+		{
+			name:           "no token",
+			opts:           nil,
+			expectedStatus: http.StatusOK,
+			expectedToken:  false,
+		},
+		{
+			name:           "unverified mode accepts any signature",
+			opts:           nil,
+			authHeader:     "Bearer " + signedToken(t, []byte("other-secret"), "issuer", "audience"),
+			expectedStatus: http.StatusOK,
+			expectedToken:  true,
+		},
+		{
+			name:           "malformed token",
+			opts:           nil,
+			authHeader:     "Bearer invalid",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "verified mode accepts valid token",
+			opts:           &ContextJWTMiddlewareOptions{KeyProvider: keyProvider, Issuer: "issuer", Audience: "audience"},
+			authHeader:     "Bearer " + signedToken(t, signingKey, "issuer", "audience"),
+			expectedStatus: http.StatusOK,
+			expectedToken:  true,
+		},
+		{
+			name:           "verified mode rejects forged token",
+			opts:           &ContextJWTMiddlewareOptions{KeyProvider: keyProvider},
+			authHeader:     "Bearer " + signedToken(t, []byte("other-secret"), "issuer", "audience"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects wrong issuer",
+			opts:           &ContextJWTMiddlewareOptions{KeyProvider: keyProvider, Issuer: "expected"},
+			authHeader:     "Bearer " + signedToken(t, signingKey, "issuer", "audience"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rejects wrong audience",
+			opts:           &ContextJWTMiddlewareOptions{KeyProvider: keyProvider, Audience: "expected"},
+			authHeader:     "Bearer " + signedToken(t, signingKey, "issuer", "audience"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "custom error response",
+			opts: &ContextJWTMiddlewareOptions{
+				KeyProvider:   keyProvider,
+				ErrorResponse: weberrors.NewForbiddenResponse("invalid token"),
+			},
+			authHeader:     "Bearer " + signedToken(t, []byte("other-secret"), "issuer", "audience"),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token jwt.Token
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = JWTFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			opts := tt.opts
+			if opts != nil && opts.ErrorResponse == nil {
+				opts.ErrorResponse = DefaultContextJWTMiddlewareOptions().ErrorResponse
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			NewContextJWTMiddleware(opts)(testHandler).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedToken, token != nil)
+		})
+	}
 }
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/clock"
+)
+
+type MTLSTransportOptions struct {
+	// MinVersion is the minimum accepted TLS version.
+	MinVersion uint16
+	// ServerName overrides the host name used to verify the server certificate.
+	ServerName string
+	// ReloadInterval is the minimum time between checks of the certificate files for changes.
+	// Zero checks on every handshake. Since connections are reused, rotated certificates only
+	// apply to new connections.
+	ReloadInterval time.Duration
+	// Clock measures the reload interval. Defaults to the system clock.
+	Clock clock.Clock
+}
+
+func DefaultMTLSTransportOptions() *MTLSTransportOptions {
+	return &MTLSTransportOptions{
+		MinVersion:     tls.VersionTLS12,
+		ReloadInterval: time.Minute,
+	}
+}
+
+// NewMTLSTransport returns a transport presenting the client certificate from certFile and keyFile,
+// reloading it whenever the files change on disk. Server certificates are verified against the
+// PEM encoded certificates in caFile, or the system pool if caFile is empty.
+func NewMTLSTransport(certFile, keyFile, caFile string, opts *MTLSTransportOptions) (*http.Transport, error) {
+	if opts == nil {
+		opts = DefaultMTLSTransportOptions()
+	}
+
+	reloader, err := newFileReloader("client certificate", []string{certFile, keyFile}, opts.ReloadInterval, opts.Clock, func() (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		return &cert, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: opts.MinVersion,
+		ServerName: opts.ServerName,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.get(info.Context()), nil
+		},
+	}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %q: %w", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %q", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func (c *testCertificate) write(t *testing.T, certFile string, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600))
+	if keyFile != "" {
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	}
+}
+
+func TestNewMTLSTransport(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := newTestCertificate(t, "test-ca", nil)
+	ca.write(t, caFile, "")
+	server := newTestCertificate(t, "server", ca)
+	clientA := newTestCertificate(t, "client-a", ca)
+	clientB := newTestCertificate(t, "client-b", ca)
+	clientA.write(t, certFile, keyFile)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	requestCommonName := func(t *testing.T, transport http.RoundTripper) string {
+		res, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("with nil options uses defaults", func(t *testing.T) {
+		transport, err := NewMTLSTransport(certFile, keyFile, caFile, nil)
+		require.NoError(t, err)
+		defer transport.CloseIdleConnections()
+
+		assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+		assert.Equal(t, "client-a", requestCommonName(t, transport))
+	})
+
+	t.Run("reloads rotated certificate", func(t *testing.T) {
+		opts := DefaultMTLSTransportOptions()
+		opts.ReloadInterval = 0
+		transport, err := NewMTLSTransport(certFile, keyFile, caFile, opts)
+		require.NoError(t, err)
+		defer transport.CloseIdleConnections()
+
+		assert.Equal(t, "client-a", requestCommonName(t, transport))
+
+		clientB.write(t, certFile, keyFile)
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, future, future))
+		transport.CloseIdleConnections()
+
+		assert.Equal(t, "client-b", requestCommonName(t, transport))
+	})
+
+	t.Run("keeps previous certificate if reload fails", func(t *testing.T) {
+		brokenDir := t.TempDir()
+		brokenCertFile := filepath.Join(brokenDir, "client.crt")
+		brokenKeyFile := filepath.Join(brokenDir, "client.key")
+		clientA.write(t, brokenCertFile, brokenKeyFile)
+
+		opts := DefaultMTLSTransportOptions()
+		opts.ReloadInterval = 0
+		transport, err := NewMTLSTransport(brokenCertFile, brokenKeyFile, caFile, opts)
+		require.NoError(t, err)
+		defer transport.CloseIdleConnections()
+
+		require.NoError(t, os.WriteFile(brokenKeyFile, []byte("invalid"), 0o600))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(brokenKeyFile, future, future))
+
+		assert.Equal(t, "client-a", requestCommonName(t, transport))
+	})
+
+	t.Run("fails on missing certificate", func(t *testing.T) {
+		_, err := NewMTLSTransport(filepath.Join(dir, "missing.crt"), keyFile, caFile, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails on invalid CA file", func(t *testing.T) {
+		_, err := NewMTLSTransport(certFile, keyFile, keyFile, nil)
+		assert.Error(t, err)
+	})
+}
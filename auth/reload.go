@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/clock"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+)
+
+// fileReloader holds a value loaded from files, e.g. credentials or a certificate, and reloads it
+// whenever the files change on disk. The files are checked at most once per interval.
+type fileReloader[T any] struct {
+	// name describes the value in errors and logs, e.g. "client certificate"
+	name     string
+	files    []string
+	interval time.Duration
+	clock    clock.Clock
+	load     func() (T, error)
+
+	m         sync.Mutex
+	value     T
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// newFileReloader loads the value initially, failing if it cannot be loaded.
+func newFileReloader[T any](name string, files []string, interval time.Duration, c clock.Clock, load func() (T, error)) (*fileReloader[T], error) {
+	r := &fileReloader[T]{
+		name:     name,
+		files:    files,
+		interval: interval,
+		clock:    clock.OrSystem(c),
+		load:     load,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// get returns the current value. If the changed files cannot be loaded, the previous value is kept.
+func (r *fileReloader[T]) get(ctx context.Context) T {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.clock.Since(r.checkedAt) < r.interval {
+		return r.value
+	}
+	r.checkedAt = r.clock.Now()
+
+	modTime, err := r.latestModTime()
+	if err == nil && modTime.Equal(r.modTime) {
+		return r.value
+	}
+	if err == nil {
+		err = r.reload()
+	}
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("failed to reload %s, keeping the previous one", r.name)
+	}
+	return r.value
+}
+
+func (r *fileReloader[T]) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range r.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// reload must be called with r.m held, or before the reloader is shared.
+func (r *fileReloader[T]) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s files: %w", r.name, err)
+	}
+	value, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.value = value
+	r.modTime = modTime
+	r.checkedAt = r.clock.Now()
+	return nil
+}
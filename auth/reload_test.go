@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReloader(t *testing.T) {
+	newReloader := func(t *testing.T, interval time.Duration) (*fileReloader[string], string, *testutils.FakeClock) {
+		file := filepath.Join(t.TempDir(), "value")
+		require.NoError(t, os.WriteFile(file, []byte("v1"), 0o600))
+		fakeClock := testutils.NewFakeClock(time.Now())
+		reloader, err := newFileReloader("value", []string{file}, interval, fakeClock, func() (string, error) {
+			value, err := os.ReadFile(file)
+			if string(value) == "invalid" {
+				return "", errors.New("invalid value")
+			}
+			return string(value), err
+		})
+		require.NoError(t, err)
+		return reloader, file, fakeClock
+	}
+
+	t.Run("reloads changed files once the interval has passed", func(t *testing.T) {
+		reloader, file, fakeClock := newReloader(t, time.Minute)
+		require.NoError(t, os.WriteFile(file, []byte("v2"), 0o600))
+		touch(t, file, time.Now().Add(time.Minute))
+
+		assert.Equal(t, "v1", reloader.get(t.Context()))
+		fakeClock.Advance(time.Minute)
+		assert.Equal(t, "v2", reloader.get(t.Context()))
+	})
+
+	t.Run("keeps the previous value if loading fails", func(t *testing.T) {
+		reloader, file, _ := newReloader(t, 0)
+		require.NoError(t, os.WriteFile(file, []byte("invalid"), 0o600))
+		touch(t, file, time.Now().Add(time.Minute))
+
+		assert.Equal(t, "v1", reloader.get(t.Context()))
+	})
+
+	t.Run("fails if the initial load fails", func(t *testing.T) {
+		_, err := newFileReloader("value", []string{filepath.Join(t.TempDir(), "missing")}, 0, nil, func() (string, error) {
+			return "", nil
+		})
+		assert.Error(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/http"
 )
 
@@ -14,25 +15,42 @@ type BasicAuthTransport struct {
 	base http.RoundTripper
 	opts *BasicAuthTransportOptions
 
-	username string
-	password string
+	credentials CredentialsProvider
 }
 
 func (t *BasicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	username, password, err := t.credentials.Credentials(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain basic auth credentials: %w", err)
+	}
 	reqCopy := req.Clone(req.Context())
 
-	auth := t.username + ":" + t.password
+	auth := username + ":" + password
 	encoded := base64.StdEncoding.EncodeToString([]byte(auth))
 	reqCopy.Header.Set("Authorization", "Basic "+encoded)
 
 	return t.base.RoundTrip(reqCopy)
 }
 
+func (t *BasicAuthTransport) Describe() (string, string) {
+	return "basicauth", ""
+}
+
+func (t *BasicAuthTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
 func DefaultBasicAuthTransportOptions() *BasicAuthTransportOptions {
 	return &BasicAuthTransportOptions{}
 }
 
 func NewBasicAuthTransport(rt http.RoundTripper, username, password string, opts *BasicAuthTransportOptions) *BasicAuthTransport {
+	return NewBasicAuthTransportWithProvider(rt, StaticCredentialsProvider{Username: username, Password: password}, opts)
+}
+
+// NewBasicAuthTransportWithProvider obtains the credentials from the provider on every request, so
+// rotated credentials take effect without restarting, see FileCredentialsProvider.
+func NewBasicAuthTransportWithProvider(rt http.RoundTripper, credentials CredentialsProvider, opts *BasicAuthTransportOptions) *BasicAuthTransport {
 	if rt == nil {
 		rt = http.DefaultTransport
 	}
@@ -44,7 +62,6 @@ func NewBasicAuthTransport(rt http.RoundTripper, username, password string, opts
 		base: rt,
 		opts: opts,
 
-		username: username,
-		password: password,
+		credentials: credentials,
 	}
 }
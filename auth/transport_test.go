@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"context"
 	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,8 +54,7 @@ func TestNewBasicAuthTransport(t *testing.T) {
 
 		require.NotNil(t, transport)
 		assert.Equal(t, mockRT, transport.base)
-		assert.Equal(t, username, transport.username)
-		assert.Equal(t, password, transport.password)
+		assert.Equal(t, StaticCredentialsProvider{Username: username, Password: password}, transport.credentials)
 		assert.Equal(t, opts, transport.opts)
 	})
 
@@ -60,8 +63,7 @@ func TestNewBasicAuthTransport(t *testing.T) {
 
 		require.NotNil(t, transport)
 		assert.Equal(t, http.DefaultTransport, transport.base)
-		assert.Equal(t, username, transport.username)
-		assert.Equal(t, password, transport.password)
+		assert.Equal(t, StaticCredentialsProvider{Username: username, Password: password}, transport.credentials)
 		assert.NotNil(t, transport.opts)
 	})
 
@@ -204,3 +206,52 @@ func TestBasicAuthTransport_ImplementsRoundTripper(t *testing.T) {
 	var _ http.RoundTripper = transport
 	assert.Implements(t, (*http.RoundTripper)(nil), transport)
 }
+
+type failingCredentialsProvider struct{}
+
+func (failingCredentialsProvider) Credentials(context.Context) (string, string, error) {
+	return "", "", assert.AnError
+}
+
+func TestNewBasicAuthTransportWithProvider(t *testing.T) {
+	t.Run("uses the current credentials of the provider", func(t *testing.T) {
+		dir := t.TempDir()
+		usernameFile := filepath.Join(dir, "username")
+		passwordFile := filepath.Join(dir, "password")
+		require.NoError(t, os.WriteFile(usernameFile, []byte("user"), 0o600))
+		require.NoError(t, os.WriteFile(passwordFile, []byte("old"), 0o600))
+		opts := DefaultFileCredentialsProviderOptions()
+		opts.ReloadInterval = 0
+		provider, err := NewFileCredentialsProvider(usernameFile, passwordFile, opts)
+		require.NoError(t, err)
+
+		mockRT := &MockRoundTripper{}
+		transport := NewBasicAuthTransportWithProvider(mockRT, provider, nil)
+
+		_, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://localhost/api", nil))
+		require.NoError(t, err)
+		username, password, ok := mockRT.capturedRequest.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "old", password)
+
+		require.NoError(t, os.WriteFile(passwordFile, []byte("new"), 0o600))
+		touch(t, passwordFile, time.Now().Add(time.Minute))
+
+		_, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://localhost/api", nil))
+		require.NoError(t, err)
+		_, password, _ = mockRT.capturedRequest.BasicAuth()
+		assert.Equal(t, "new", password)
+	})
+
+	t.Run("fails if the provider fails", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewBasicAuthTransportWithProvider(mockRT, failingCredentialsProvider{}, nil)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://localhost/api", nil))
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Nil(t, res)
+		assert.Nil(t, mockRT.capturedRequest)
+	})
+}
@@ -0,0 +1,131 @@
+package caching
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a stored HTTP response. Entries returned by a Cache must be treated as read-only.
+type Entry struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"storedAt"`
+	// RequestHeader holds the request header values selected by the response's Vary header.
+	RequestHeader http.Header `json:"requestHeader,omitempty"`
+}
+
+// Cache is the storage backend used by the caching middleware and transport. Implementations
+// must be safe for concurrent use; external stores should treat backend failures as cache misses.
+type Cache interface {
+	Get(ctx context.Context, key string) (*Entry, bool)
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration)
+	Delete(ctx context.Context, key string)
+}
+
+// MemoryCache //
+
+type MemoryCacheOptions struct {
+	// MaxEntries limits the number of stored entries, evicting the least recently used entry
+	// once the limit is reached. Zero means unbounded.
+	MaxEntries int
+}
+
+func DefaultMemoryCacheOptions() *MemoryCacheOptions {
+	return &MemoryCacheOptions{
+		MaxEntries: 0,
+	}
+}
+
+type memoryCacheItem struct {
+	key       string
+	entry     *Entry
+	expiresAt time.Time
+}
+
+var _ Cache = (*MemoryCache)(nil)
+
+type MemoryCache struct {
+	opts *MemoryCacheOptions
+
+	items map[string]*list.Element
+	order *list.List
+	m     sync.Mutex
+}
+
+func NewMemoryCache(opts *MemoryCacheOptions) *MemoryCache {
+	if opts == nil {
+		opts = DefaultMemoryCacheOptions()
+	}
+
+	return &MemoryCache{
+		opts:  opts,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (*Entry, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := element.Value.(*memoryCacheItem)
+	if !item.expiresAt.IsZero() && !time.Now().Before(item.expiresAt) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return item.entry, true
+}
+
+// Set stores the entry under the given key. A ttl <= 0 stores the entry without expiration.
+func (c *MemoryCache) Set(_ context.Context, key string, entry *Entry, ttl time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	item := &memoryCacheItem{key: key, entry: entry}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, ok := c.items[key]; ok {
+		element.Value = item
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(item)
+
+	if c.opts.MaxEntries > 0 {
+		for c.order.Len() > c.opts.MaxEntries {
+			c.removeElement(c.order.Back())
+		}
+	}
+}
+
+func (c *MemoryCache) Delete(_ context.Context, key string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+func (c *MemoryCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return len(c.items)
+}
+
+func (c *MemoryCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*memoryCacheItem).key)
+}
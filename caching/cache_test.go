@@ -0,0 +1,97 @@
+package caching
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get missing entry", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+
+		entry, ok := cache.Get(ctx, "missing")
+
+		assert.False(t, ok)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("set and get entry", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+		expected := &Entry{StatusCode: http.StatusOK, Body: []byte("test")}
+
+		cache.Set(ctx, "key", expected, time.Minute)
+		entry, ok := cache.Get(ctx, "key")
+
+		require.True(t, ok)
+		assert.Equal(t, expected, entry)
+		assert.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("expired entry is removed", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+
+		cache.Set(ctx, "key", &Entry{StatusCode: http.StatusOK}, time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		_, ok := cache.Get(ctx, "key")
+
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("zero ttl never expires", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+
+		cache.Set(ctx, "key", &Entry{StatusCode: http.StatusOK}, 0)
+		time.Sleep(5 * time.Millisecond)
+		_, ok := cache.Get(ctx, "key")
+
+		assert.True(t, ok)
+	})
+
+	t.Run("delete entry", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+
+		cache.Set(ctx, "key", &Entry{StatusCode: http.StatusOK}, time.Minute)
+		cache.Delete(ctx, "key")
+		_, ok := cache.Get(ctx, "key")
+
+		assert.False(t, ok)
+	})
+
+	t.Run("evicts least recently used entry", func(t *testing.T) {
+		cache := NewMemoryCache(&MemoryCacheOptions{MaxEntries: 2})
+
+		cache.Set(ctx, "a", &Entry{StatusCode: http.StatusOK}, 0)
+		cache.Set(ctx, "b", &Entry{StatusCode: http.StatusOK}, 0)
+		_, _ = cache.Get(ctx, "a")
+		cache.Set(ctx, "c", &Entry{StatusCode: http.StatusOK}, 0)
+
+		_, okA := cache.Get(ctx, "a")
+		_, okB := cache.Get(ctx, "b")
+		_, okC := cache.Get(ctx, "c")
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("overwriting entry does not evict", func(t *testing.T) {
+		cache := NewMemoryCache(&MemoryCacheOptions{MaxEntries: 2})
+
+		cache.Set(ctx, "a", &Entry{StatusCode: http.StatusOK}, 0)
+		cache.Set(ctx, "b", &Entry{StatusCode: http.StatusOK}, 0)
+		cache.Set(ctx, "a", &Entry{StatusCode: http.StatusCreated}, 0)
+
+		entry, ok := cache.Get(ctx, "a")
+		require.True(t, ok)
+		assert.Equal(t, http.StatusCreated, entry.StatusCode)
+		assert.Equal(t, 2, cache.Len())
+	})
+}
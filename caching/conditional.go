@@ -0,0 +1,186 @@
+package caching
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/go-chi/render"
+)
+
+var (
+	// ErrMissingIfMatch is returned for requests required to carry an If-Match header without one
+	ErrMissingIfMatch = errors.New("missing If-Match header")
+	// ErrMalformedIfMatch is returned for If-Match headers that are not a list of entity tags
+	ErrMalformedIfMatch = errors.New("malformed If-Match header")
+)
+
+// ComputeETag returns a strong entity tag derived from the content of the body
+func ComputeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// VersionETag returns the strong entity tag of a resource version, e.g. a revision counter of a database
+// row. The version must not contain double quotes.
+func VersionETag(version string) string {
+	return `"` + version + `"`
+}
+
+type ifMatch struct {
+	etags []string
+}
+
+// ParseIfMatch returns the entity tags of an If-Match header value, or ["*"] for a wildcard.
+func ParseIfMatch(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return []string{"*"}, nil
+	}
+	var etags []string
+	for _, etag := range strings.Split(value, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "" {
+			continue
+		}
+		opaque := strings.TrimPrefix(etag, "W/")
+		if len(opaque) < 2 || opaque[0] != '"' || opaque[len(opaque)-1] != '"' || strings.Contains(opaque[1:len(opaque)-1], `"`) {
+			return nil, ErrMalformedIfMatch
+		}
+		etags = append(etags, etag)
+	}
+	if len(etags) == 0 {
+		return nil, ErrMalformedIfMatch
+	}
+	return etags, nil
+}
+
+// VersionFromContext returns the version the client expects to modify, i.e. the unquoted first strong
+// entity tag of the If-Match header stored by the If-Match middleware. It reports false for requests
+// without If-Match or with a wildcard.
+func VersionFromContext(ctx context.Context) (string, bool) {
+	value := contextutils.GetValue[ifMatch](ctx)
+	if value == nil {
+		return "", false
+	}
+	for _, etag := range value.etags {
+		if etag != "*" && !strings.HasPrefix(etag, "W/") {
+			return strings.Trim(etag, `"`), true
+		}
+	}
+	return "", false
+}
+
+// IfMatchSatisfied reports whether the If-Match header of the request matches the current entity tag
+// of the resource, using the strong comparison of RFC 9110. An empty current entity tag means the
+// resource does not exist. Requests without If-Match are always satisfied.
+func IfMatchSatisfied(req *http.Request, currentETag string) bool {
+	value := req.Header.Get(header.IfMatch)
+	if value == "" {
+		return true
+	}
+	etags, err := ParseIfMatch(value)
+	if err != nil || currentETag == "" {
+		return false
+	}
+	if etags[0] == "*" {
+		return true
+	}
+	if strings.HasPrefix(currentETag, "W/") {
+		return false
+	}
+	return slices.Contains(etags, currentETag)
+}
+
+// CheckIfMatch responds with 412 Precondition Failed and returns false if the If-Match header of the
+// request does not match the current entity tag, see IfMatchSatisfied. Handlers call it after loading
+// the resource and before modifying it.
+func CheckIfMatch(w http.ResponseWriter, req *http.Request, currentETag string) bool {
+	if IfMatchSatisfied(req, currentETag) {
+		return true
+	}
+	if err := weberrors.Render(w, req, weberrors.NewPreconditionFailedResponse("")); err != nil {
+		panic(err)
+	}
+	return false
+}
+
+// IfMatchMiddleware //
+
+type IfMatchMiddlewareOptions struct {
+	// Methods lists the request methods whose If-Match header is checked. Defaults to PUT, PATCH and
+	// DELETE.
+	Methods []string
+	// Required rejects requests without If-Match. Defaults to true.
+	Required bool
+	// ErrorResponseFn builds the response for rejected requests. Defaults to NewIfMatchErrorResponse.
+	ErrorResponseFn func(err error) render.Renderer
+}
+
+func DefaultIfMatchMiddlewareOptions() *IfMatchMiddlewareOptions {
+	return &IfMatchMiddlewareOptions{
+		Methods:         []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+		Required:        true,
+		ErrorResponseFn: NewIfMatchErrorResponse,
+	}
+}
+
+// NewIfMatchMiddleware enforces If-Match headers on mutating requests for optimistic locking and stores
+// them in the request context, see VersionFromContext. Comparing them to the current version of the
+// resource is left to the handler, see CheckIfMatch.
+func NewIfMatchMiddleware(opts *IfMatchMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultIfMatchMiddlewareOptions()
+	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = NewIfMatchErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !slices.Contains(opts.Methods, req.Method) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			value := req.Header.Get(header.IfMatch)
+			if value == "" {
+				if !opts.Required {
+					next.ServeHTTP(w, req)
+					return
+				}
+				if err := weberrors.Render(w, req, errorResponseFn(ErrMissingIfMatch)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			etags, err := ParseIfMatch(value)
+			if err != nil {
+				if err = weberrors.Render(w, req, errorResponseFn(err)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			ctx := contextutils.WithValue(req.Context(), ifMatch{etags: etags})
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// NewIfMatchErrorResponse responds with 428 Precondition Required for missing and 400 Bad Request for
+// malformed If-Match headers.
+func NewIfMatchErrorResponse(err error) render.Renderer {
+	if errors.Is(err, ErrMissingIfMatch) {
+		return weberrors.NewPreconditionRequiredResponse("Missing If-Match header")
+	}
+	return weberrors.NewBadRequestResponse("Malformed If-Match header")
+}
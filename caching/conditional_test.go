@@ -0,0 +1,204 @@
+package caching
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeETag(t *testing.T) {
+	etag := ComputeETag([]byte("hello"))
+
+	assert.Equal(t, etag, ComputeETag([]byte("hello")))
+	assert.NotEqual(t, etag, ComputeETag([]byte("world")))
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+}
+
+func TestVersionETag(t *testing.T) {
+	assert.Equal(t, `"7"`, VersionETag("7"))
+}
+
+func TestParseIfMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected []string
+		err      error
+	}{
+		{"single", `"v1"`, []string{`"v1"`}, nil},
+		{"list", `"v1", W/"v2" ,"v3"`, []string{`"v1"`, `W/"v2"`, `"v3"`}, nil},
+		{"wildcard", ` * `, []string{"*"}, nil},
+		{"unquoted", `v1`, nil, ErrMalformedIfMatch},
+		{"unterminated", `"v1`, nil, ErrMalformedIfMatch},
+		{"embedded quote", `"v"1"`, nil, ErrMalformedIfMatch},
+		{"empty list", ` , `, nil, ErrMalformedIfMatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			etags, err := ParseIfMatch(tc.value)
+
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.expected, etags)
+		})
+	}
+}
+
+func TestIfMatchSatisfied(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ifMatch     string
+		currentETag string
+		expected    bool
+	}{
+		{"without If-Match", "", `"v1"`, true},
+		{"matching", `"v1"`, `"v1"`, true},
+		{"matching in list", `"v0", "v1"`, `"v1"`, true},
+		{"mismatching", `"v0"`, `"v1"`, false},
+		{"weak candidate", `W/"v1"`, `"v1"`, false},
+		{"weak current", `"v1"`, `W/"v1"`, false},
+		{"wildcard on existing resource", `*`, `"v1"`, true},
+		{"wildcard on missing resource", `*`, "", false},
+		{"malformed", `v1`, `"v1"`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			assert.Equal(t, tc.expected, IfMatchSatisfied(req, tc.currentETag))
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	t.Run("passes matching requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+		req.Header.Set("If-Match", `"v1"`)
+		rr := httptest.NewRecorder()
+
+		assert.True(t, CheckIfMatch(rr, req, `"v1"`))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+
+	t.Run("responds with 412 on mismatch", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+		req.Header.Set("If-Match", `"v0"`)
+		rr := httptest.NewRecorder()
+
+		assert.False(t, CheckIfMatch(rr, req, `"v1"`))
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Precondition Failed")
+	})
+}
+
+func TestDefaultIfMatchMiddlewareOptions(t *testing.T) {
+	opts := DefaultIfMatchMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, []string{http.MethodPut, http.MethodPatch, http.MethodDelete}, opts.Methods)
+	assert.True(t, opts.Required)
+	assert.NotNil(t, opts.ErrorResponseFn)
+}
+
+func TestNewIfMatchMiddleware(t *testing.T) {
+	newHandler := func(version *string, found *bool) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*version, *found = VersionFromContext(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		middleware := NewIfMatchMiddleware(nil)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("stores the version of mutating requests", func(t *testing.T) {
+		var version string
+		var found bool
+		handler := NewIfMatchMiddleware(nil)(newHandler(&version, &found))
+
+		req := httptest.NewRequest(http.MethodPatch, "/items/1", nil)
+		req.Header.Set("If-Match", `W/"v0", "v1"`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.True(t, found)
+		assert.Equal(t, "v1", version)
+	})
+
+	t.Run("does not report a version for wildcards", func(t *testing.T) {
+		var version string
+		var found bool
+		handler := NewIfMatchMiddleware(nil)(newHandler(&version, &found))
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", "*")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.False(t, found)
+	})
+
+	t.Run("ignores other methods", func(t *testing.T) {
+		var version string
+		var found bool
+		handler := NewIfMatchMiddleware(nil)(newHandler(&version, &found))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.False(t, found)
+	})
+
+	t.Run("rejects missing If-Match with 428", func(t *testing.T) {
+		var version string
+		var found bool
+		handler := NewIfMatchMiddleware(nil)(newHandler(&version, &found))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/items/1", nil))
+
+		assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Missing If-Match header")
+	})
+
+	t.Run("passes missing If-Match if not required", func(t *testing.T) {
+		var version string
+		var found bool
+		opts := DefaultIfMatchMiddlewareOptions()
+		opts.Required = false
+		handler := NewIfMatchMiddleware(opts)(newHandler(&version, &found))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/items/1", nil))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.False(t, found)
+	})
+
+	t.Run("rejects malformed If-Match with 400", func(t *testing.T) {
+		var version string
+		var found bool
+		handler := NewIfMatchMiddleware(nil)(newHandler(&version, &found))
+
+		req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+		req.Header.Set("If-Match", "v1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Malformed If-Match header")
+	})
+}
@@ -0,0 +1,234 @@
+package caching
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+// ResponseCacheMiddleware //
+
+type ResponseCacheMiddlewareOptions struct {
+	// Cache stores the captured responses. Defaults to an unbounded in-memory cache.
+	Cache Cache
+	// TTL defines how long a captured response is served from the cache. Defaults to one minute.
+	TTL time.Duration
+	// VaryHeaders lists request headers whose values become part of the cache key.
+	VaryHeaders []string
+	// CacheableStatusCodes lists the response status codes that are stored. Defaults to 200.
+	CacheableStatusCodes []int
+}
+
+func DefaultResponseCacheMiddlewareOptions() *ResponseCacheMiddlewareOptions {
+	return &ResponseCacheMiddlewareOptions{
+		Cache:                NewMemoryCache(nil),
+		TTL:                  time.Minute,
+		VaryHeaders:          []string{},
+		CacheableStatusCodes: []int{http.StatusOK},
+	}
+}
+
+// NewResponseCacheMiddleware caches GET responses and answers conditional requests. Responses are
+// buffered completely before being sent, so the middleware is not suited for streaming endpoints.
+// Protocol upgrades and requests accepting server-sent events are passed through unbuffered.
+// Entries are shared between all clients: responses setting cookies are never stored, and requests
+// carrying Authorization or Cookie only use entries marked as public or s-maxage. The Vary header of
+// responses and the no-cache and no-store directives of requests are honored.
+func NewResponseCacheMiddleware(opts *ResponseCacheMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultResponseCacheMiddlewareOptions()
+	}
+	if opts.Cache == nil {
+		opts.Cache = NewMemoryCache(nil)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet || header.IsUpgradeRequest(req) || strings.Contains(req.Header.Get(header.Accept), "text/event-stream") {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			reqDirectives := parseCacheControl(req.Header.Get(header.CacheControl))
+			if _, noStore := reqDirectives["no-store"]; noStore {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ctx := req.Context()
+			credentials := hasCredentials(req)
+			key := requestCacheKey(req, opts.VaryHeaders)
+			if _, noCache := reqDirectives["no-cache"]; !noCache {
+				if entry, ok := opts.Cache.Get(ctx, key); ok && isServable(entry, req, credentials) {
+					writeEntry(w, req, entry)
+					return
+				}
+			}
+
+			bw := newBufferedResponseWriter()
+			next.ServeHTTP(bw, req)
+
+			entry := &Entry{
+				StatusCode:    bw.statusCode,
+				Header:        bw.header.Clone(),
+				Body:          bw.body.Bytes(),
+				StoredAt:      time.Now(),
+				RequestHeader: selectVaryHeaders(bw.header, req.Header),
+			}
+			if isStorable(entry, opts.CacheableStatusCodes, credentials) {
+				if entry.Header.Get(header.ETag) == "" {
+					entry.Header.Set(header.ETag, ComputeETag(entry.Body))
+				}
+				if entry.Header.Get(header.LastModified) == "" {
+					entry.Header.Set(header.LastModified, entry.StoredAt.UTC().Format(http.TimeFormat))
+				}
+				opts.Cache.Set(ctx, key, entry, opts.TTL)
+			}
+			writeEntry(w, req, entry)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func requestCacheKey(req *http.Request, varyHeaders []string) string {
+	var sb strings.Builder
+	sb.WriteString(req.Method)
+	sb.WriteString(" ")
+	sb.WriteString(req.URL.String())
+	for _, name := range varyHeaders {
+		sb.WriteString("\n")
+		sb.WriteString(http.CanonicalHeaderKey(name))
+		sb.WriteString(":")
+		sb.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return sb.String()
+}
+
+// hasCredentials reports whether the request identifies a user, so responses to it may be personal
+func hasCredentials(req *http.Request) bool {
+	return req.Header.Get(header.Authorization) != "" || req.Header.Get(header.Cookie) != ""
+}
+
+// sharedWithCredentials reports whether the response directives explicitly allow sharing a response
+// to a request carrying credentials
+func sharedWithCredentials(directives map[string]string) bool {
+	_, public := directives["public"]
+	_, sMaxAge := directives["s-maxage"]
+	return public || sMaxAge
+}
+
+func isServable(entry *Entry, req *http.Request, credentials bool) bool {
+	if !varyMatches(entry, req) {
+		return false
+	}
+	return !credentials || sharedWithCredentials(parseCacheControl(entry.Header.Get(header.CacheControl)))
+}
+
+func isStorable(entry *Entry, cacheableStatusCodes []int, credentials bool) bool {
+	if !slices.Contains(cacheableStatusCodes, entry.StatusCode) {
+		return false
+	}
+	if entry.Header.Get(header.SetCookie) != "" || strings.TrimSpace(entry.Header.Get(header.Vary)) == "*" {
+		return false
+	}
+	directives := parseCacheControl(entry.Header.Get(header.CacheControl))
+	_, noStore := directives["no-store"]
+	_, private := directives["private"]
+	if noStore || private {
+		return false
+	}
+	return !credentials || sharedWithCredentials(directives)
+}
+
+func writeEntry(w http.ResponseWriter, req *http.Request, entry *Entry) {
+	if isNotModified(req, entry) {
+		for _, name := range []string{header.CacheControl, header.ETag, header.Expires, header.LastModified, header.Vary} {
+			if values := entry.Header.Values(name); len(values) > 0 {
+				w.Header()[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+			}
+		}
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	for name, values := range entry.Header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	w.WriteHeader(entry.StatusCode)
+	_, _ = w.Write(entry.Body)
+}
+
+func isNotModified(req *http.Request, entry *Entry) bool {
+	if entry.StatusCode != http.StatusOK {
+		return false
+	}
+	if ifNoneMatch := req.Header.Get(header.IfNoneMatch); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, entry.Header.Get(header.ETag))
+	}
+	if ifModifiedSince := req.Header.Get(header.IfModifiedSince); ifModifiedSince != "" {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		lastModified, err := http.ParseTime(entry.Header.Get(header.LastModified))
+		if err != nil {
+			return false
+		}
+		return !lastModified.After(since)
+	}
+	return false
+}
+
+// etagMatches performs the weak comparison defined for If-None-Match.
+func etagMatches(candidates string, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(candidates) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(candidates, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter //
+
+type bufferedResponseWriter struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{
+		header:     make(http.Header),
+		statusCode: http.StatusOK,
+	}
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.statusCode = statusCode
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
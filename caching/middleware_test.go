@@ -0,0 +1,324 @@
+package caching
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultResponseCacheMiddlewareOptions(t *testing.T) {
+	opts := DefaultResponseCacheMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Cache)
+	assert.Equal(t, time.Minute, opts.TTL)
+	assert.Equal(t, []int{http.StatusOK}, opts.CacheableStatusCodes)
+}
+
+func TestNewResponseCacheMiddleware(t *testing.T) {
+	newHandler := func(calls *int, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("hello"))
+		})
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		middleware := NewResponseCacheMiddleware(nil)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("serves repeated GET requests from cache", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "hello", rr.Body.String())
+			assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+			assert.NotEmpty(t, rr.Header().Get("ETag"))
+			assert.NotEmpty(t, rr.Header().Get("Last-Modified"))
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not cache non-GET requests", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		for i := 0; i < 2; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/items", nil))
+			assert.Empty(t, rr.Header().Get("ETag"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("passes event stream requests through", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/events", nil)
+			req.Header.Set("Accept", "text/event-stream")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Empty(t, rr.Header().Get("ETag"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("passes upgrade requests through", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Empty(t, rr.Header().Get("ETag"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not cache non-cacheable status codes", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusInternalServerError))
+
+		for i := 0; i < 2; i++ {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("respects no-store from handler", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		for i := 0; i < 2; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("answers If-None-Match with 304", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+		etag := rr.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("If-None-Match", "W/"+etag)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("answers If-Modified-Since with 304", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+	})
+
+	t.Run("keeps ETag set by handler", func(t *testing.T) {
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, `"v1"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("vary headers are part of the cache key", func(t *testing.T) {
+		calls := 0
+		opts := DefaultResponseCacheMiddlewareOptions()
+		opts.VaryHeaders = []string{"Accept-Language"}
+		handler := NewResponseCacheMiddleware(opts)(newHandler(&calls, http.StatusOK))
+
+		for _, lang := range []string{"en", "de", "en"} {
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			req.Header.Set("Accept-Language", lang)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not share responses to requests with credentials", func(t *testing.T) {
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _, _ := r.BasicAuth()
+			_, _ = w.Write([]byte("profile of " + user))
+		}))
+
+		for _, user := range []string{"alice", "bob"} {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.SetBasicAuth(user, "secret")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, "profile of "+user, rr.Body.String())
+		}
+	})
+
+	t.Run("does not serve anonymous entries to requests with cookies", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Cookie", "session=sess-bob")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("shares public responses to requests with credentials", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", "public, max-age=60")
+			_, _ = w.Write([]byte("catalog"))
+		}))
+
+		for _, user := range []string{"alice", "bob"} {
+			req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+			req.SetBasicAuth(user, "secret")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, "catalog", rr.Body.String())
+		}
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not cache responses setting cookies", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Set-Cookie", "session=sess-alice")
+			_, _ = w.Write([]byte("hello"))
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("response vary headers separate cached variants", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+		}))
+
+		for _, lang := range []string{"en", "de"} {
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			req.Header.Set("Accept-Language", lang)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, lang, rr.Body.String())
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not cache responses varying on everything", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Vary", "*")
+			_, _ = w.Write([]byte("hello"))
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("request no-cache bypasses cached entries", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Cache-Control", "no-cache")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("request no-store neither serves nor stores entries", func(t *testing.T) {
+		calls := 0
+		handler := NewResponseCacheMiddleware(nil)(newHandler(&calls, http.StatusOK))
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Cache-Control", "no-store")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		req = httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Cache-Control", "no-store")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("expired entries are regenerated", func(t *testing.T) {
+		calls := 0
+		opts := DefaultResponseCacheMiddlewareOptions()
+		opts.TTL = time.Millisecond
+		handler := NewResponseCacheMiddleware(opts)(newHandler(&calls, http.StatusOK))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+		time.Sleep(5 * time.Millisecond)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestEtagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"a"`, `"a"`))
+	assert.True(t, etagMatches(`"b", W/"a"`, `"a"`))
+	assert.True(t, etagMatches(`*`, `"a"`))
+	assert.False(t, etagMatches(`"b"`, `"a"`))
+	assert.False(t, etagMatches(`"a"`, ""))
+}
@@ -0,0 +1,420 @@
+package caching
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachingTransport //
+
+type CachingTransportOptions struct {
+	// Cache stores the upstream responses. Defaults to an in-memory LRU cache with 1000 entries.
+	Cache Cache
+	// StaleRetention defines how long responses carrying validators (ETag, Last-Modified) are kept
+	// after they became stale, so they can be revalidated instead of fetched again.
+	StaleRetention time.Duration
+	// CacheableStatusCodes lists the response status codes that are stored. Defaults to 200.
+	CacheableStatusCodes []int
+}
+
+func DefaultCachingTransportOptions() *CachingTransportOptions {
+	return &CachingTransportOptions{
+		Cache:                NewMemoryCache(&MemoryCacheOptions{MaxEntries: 1000}),
+		StaleRetention:       time.Hour,
+		CacheableStatusCodes: []int{http.StatusOK},
+	}
+}
+
+var _ http.RoundTripper = (*CachingTransport)(nil)
+
+// CachingTransport implements the private cache subset of RFC 9111 for GET requests: freshness from
+// Cache-Control max-age or Expires, revalidation via If-None-Match/If-Modified-Since, Vary handling,
+// and invalidation of cached entries by successful unsafe requests to the same URL. As entries are
+// shared between all requests, responses to requests carrying Authorization are only stored if they
+// are explicitly marked as shareable by public, s-maxage or must-revalidate (RFC 9111 section 3.5).
+type CachingTransport struct {
+	base http.RoundTripper
+	opts *CachingTransportOptions
+}
+
+func NewCachingTransport(rt http.RoundTripper, opts *CachingTransportOptions) *CachingTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultCachingTransportOptions()
+	}
+	if opts.Cache == nil {
+		opts.Cache = NewMemoryCache(&MemoryCacheOptions{MaxEntries: 1000})
+	}
+
+	return &CachingTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key := "GET " + req.URL.String()
+
+	if req.Method != http.MethodGet {
+		res, err := t.base.RoundTrip(req)
+		if err == nil && req.Method != http.MethodHead && res.StatusCode < 400 {
+			t.opts.Cache.Delete(ctx, key)
+		}
+		return res, err
+	}
+
+	reqDirectives := parseCacheControl(req.Header.Get(header.CacheControl))
+	if _, ok := reqDirectives["no-store"]; ok {
+		return t.base.RoundTrip(req)
+	}
+
+	entry, ok := t.opts.Cache.Get(ctx, key)
+	if ok && !varyMatches(entry, req) {
+		entry, ok = nil, false
+	}
+	if ok {
+		_, noCache := reqDirectives["no-cache"]
+		if !noCache && isFresh(entry, time.Now()) {
+			return cachedResponse(req, entry), nil
+		}
+		if hasValidators(entry) {
+			return t.revalidate(req, key, entry)
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(req, key, res)
+}
+
+func (t *CachingTransport) Describe() (string, string) {
+	return "caching", ""
+}
+
+func (t *CachingTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (t *CachingTransport) revalidate(req *http.Request, key string, entry *Entry) (*http.Response, error) {
+	reqCopy := req.Clone(req.Context())
+	if etag := entry.Header.Get(header.ETag); etag != "" {
+		reqCopy.Header.Set(header.IfNoneMatch, etag)
+	}
+	if lastModified := entry.Header.Get(header.LastModified); lastModified != "" {
+		reqCopy.Header.Set(header.IfModifiedSince, lastModified)
+	}
+
+	res, err := t.base.RoundTrip(reqCopy)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusNotModified {
+		return t.store(req, key, res)
+	}
+	_ = res.Body.Close()
+
+	updated := &Entry{
+		StatusCode:    entry.StatusCode,
+		Header:        entry.Header.Clone(),
+		Body:          entry.Body,
+		StoredAt:      time.Now(),
+		RequestHeader: entry.RequestHeader,
+	}
+	for name, values := range res.Header {
+		updated.Header[name] = slices.Clone(values)
+	}
+	if ttl := t.storageTTL(updated); ttl > 0 {
+		t.opts.Cache.Set(req.Context(), key, updated, ttl)
+	}
+	return cachedResponse(req, updated), nil
+}
+
+func (t *CachingTransport) store(req *http.Request, key string, res *http.Response) (*http.Response, error) {
+	if !slices.Contains(t.opts.CacheableStatusCodes, res.StatusCode) {
+		return res, nil
+	}
+	resDirectives := parseCacheControl(res.Header.Get(header.CacheControl))
+	if _, ok := resDirectives["no-store"]; ok {
+		return res, nil
+	}
+	if strings.TrimSpace(res.Header.Get(header.Vary)) == "*" {
+		return res, nil
+	}
+	if req.Header.Get(header.Authorization) != "" && !sharedWithAuthorization(resDirectives) {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for caching: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &Entry{
+		StatusCode:    res.StatusCode,
+		Header:        res.Header.Clone(),
+		Body:          body,
+		StoredAt:      time.Now(),
+		RequestHeader: selectVaryHeaders(res.Header, req.Header),
+	}
+	if ttl := t.storageTTL(entry); ttl > 0 {
+		t.opts.Cache.Set(req.Context(), key, entry, ttl)
+	}
+	return res, nil
+}
+
+// sharedWithAuthorization reports whether the response directives allow storing a response to an
+// authorized request
+func sharedWithAuthorization(directives map[string]string) bool {
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := directives[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// storageTTL returns how long an entry is worth keeping: its remaining freshness plus the stale
+// retention if it can be revalidated.
+func (t *CachingTransport) storageTTL(entry *Entry) time.Duration {
+	ttl := freshnessLifetime(entry) - currentAge(entry, entry.StoredAt)
+	if ttl < 0 {
+		ttl = 0
+	}
+	if hasValidators(entry) {
+		ttl += t.opts.StaleRetention
+	}
+	return ttl
+}
+
+func cachedResponse(req *http.Request, entry *Entry) *http.Response {
+	res := entryResponse(req, entry)
+	res.Header.Set("Age", strconv.Itoa(int(currentAge(entry, time.Now()).Seconds())))
+	return res
+}
+
+func entryResponse(req *http.Request, entry *Entry) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+func isFresh(entry *Entry, now time.Time) bool {
+	directives := parseCacheControl(entry.Header.Get(header.CacheControl))
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+	return currentAge(entry, now) < freshnessLifetime(entry)
+}
+
+func freshnessLifetime(entry *Entry) time.Duration {
+	directives := parseCacheControl(entry.Header.Get(header.CacheControl))
+	if maxAge, ok := directives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if expiresValue := entry.Header.Get(header.Expires); expiresValue != "" {
+		expires, err := http.ParseTime(expiresValue)
+		if err != nil {
+			return 0
+		}
+		date := entry.StoredAt
+		if dateValue := entry.Header.Get("Date"); dateValue != "" {
+			if parsed, innerErr := http.ParseTime(dateValue); innerErr == nil {
+				date = parsed
+			}
+		}
+		return expires.Sub(date)
+	}
+	return 0
+}
+
+func currentAge(entry *Entry, now time.Time) time.Duration {
+	age := now.Sub(entry.StoredAt)
+	if ageValue := entry.Header.Get("Age"); ageValue != "" {
+		if seconds, err := strconv.Atoi(ageValue); err == nil {
+			age += time.Duration(seconds) * time.Second
+		}
+	}
+	return age
+}
+
+func hasValidators(entry *Entry) bool {
+	return entry.Header.Get(header.ETag) != "" || entry.Header.Get(header.LastModified) != ""
+}
+
+func selectVaryHeaders(resHeader http.Header, reqHeader http.Header) http.Header {
+	selected := make(http.Header)
+	for _, value := range resHeader.Values(header.Vary) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			selected[http.CanonicalHeaderKey(name)] = slices.Clone(reqHeader.Values(name))
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	return selected
+}
+
+func varyMatches(entry *Entry, req *http.Request) bool {
+	for name, values := range entry.RequestHeader {
+		if !slices.Equal(values, req.Header.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// CoalescingTransport //
+
+type CoalescingTransportOptions struct {
+	// KeyHeaders lists request headers whose values must be identical for requests to be coalesced.
+	KeyHeaders []string
+	// Timeout limits the shared upstream call in addition to the deadline of the request that started
+	// it, so a hung upstream does not block the waiters indefinitely. Zero disables it. Defaults to 30s.
+	Timeout time.Duration
+}
+
+func DefaultCoalescingTransportOptions() *CoalescingTransportOptions {
+	return &CoalescingTransportOptions{
+		KeyHeaders: []string{
+			header.Accept,
+			"Accept-Encoding",
+			"Accept-Language",
+			header.Authorization,
+			"Cookie",
+		},
+		Timeout: 30 * time.Second,
+	}
+}
+
+var _ http.RoundTripper = (*CoalescingTransport)(nil)
+
+// CoalescingTransport deduplicates concurrent identical GET requests. Only one request is sent
+// upstream; every waiter receives its own copy of the buffered response. The shared upstream call
+// is detached from the cancellation of individual callers, each of which stops waiting as soon as
+// its own context is done, but keeps the deadline of the request that started it.
+type CoalescingTransport struct {
+	base http.RoundTripper
+	opts *CoalescingTransportOptions
+
+	group singleflight.Group
+}
+
+func NewCoalescingTransport(rt http.RoundTripper, opts *CoalescingTransportOptions) *CoalescingTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultCoalescingTransportOptions()
+	}
+
+	return &CoalescingTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *CoalescingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	key := requestCacheKey(req, t.opts.KeyHeaders)
+	resultCh := t.group.DoChan(key, func() (any, error) {
+		sharedCtx, cancel := t.sharedContext(ctx)
+		defer cancel()
+
+		res, err := t.base.RoundTrip(req.Clone(sharedCtx))
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read coalesced response body: %w", err)
+		}
+		return &Entry{
+			StatusCode: res.StatusCode,
+			Header:     res.Header,
+			Body:       body,
+			StoredAt:   time.Now(),
+		}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-resultCh:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return entryResponse(req, result.Val.(*Entry)), nil
+	}
+}
+
+// sharedContext detaches the context from the cancellation of the caller, keeping its deadline and
+// applying the timeout
+func (t *CoalescingTransport) sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if t.opts.Timeout > 0 && (!ok || time.Until(deadline) > t.opts.Timeout) {
+		deadline, ok = time.Now().Add(t.opts.Timeout), true
+	}
+	if !ok {
+		return context.WithCancel(context.WithoutCancel(ctx))
+	}
+	return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+}
+
+func (t *CoalescingTransport) Describe() (string, string) {
+	return "coalescing", ""
+}
+
+func (t *CoalescingTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
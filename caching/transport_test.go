@@ -0,0 +1,503 @@
+package caching
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRoundTripper is a test double for http.RoundTripper
+type MockRoundTripper struct {
+	capturedRequests []*http.Request
+	responseFn       func(req *http.Request) *http.Response
+	errorToReturn    error
+}
+
+func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.capturedRequests = append(m.capturedRequests, req)
+	if m.errorToReturn != nil {
+		return nil, m.errorToReturn
+	}
+	if m.responseFn != nil {
+		return m.responseFn(req), nil
+	}
+	// Default response
+	return &http.Response{
+		StatusCode: 200,
+		Body:       http.NoBody,
+		Header:     make(http.Header),
+	}, nil
+}
+
+func newTestResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+type recordingCache struct {
+	Cache
+	ttls []time.Duration
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) {
+	c.ttls = append(c.ttls, ttl)
+	c.Cache.Set(ctx, key, entry, ttl)
+}
+
+func readBody(t *testing.T, res *http.Response) string {
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestDefaultCachingTransportOptions(t *testing.T) {
+	opts := DefaultCachingTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Cache)
+	assert.Equal(t, time.Hour, opts.StaleRetention)
+	assert.Equal(t, []int{http.StatusOK}, opts.CacheableStatusCodes)
+}
+
+func TestNewCachingTransport(t *testing.T) {
+	t.Run("with nil round tripper uses default", func(t *testing.T) {
+		transport := NewCachingTransport(nil, nil)
+
+		require.NotNil(t, transport)
+		assert.Equal(t, http.DefaultTransport, transport.base)
+		assert.NotNil(t, transport.opts)
+	})
+
+	t.Run("with custom round tripper", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		opts := DefaultCachingTransportOptions()
+
+		transport := NewCachingTransport(mockRT, opts)
+
+		assert.Equal(t, mockRT, transport.base)
+		assert.Equal(t, opts, transport.opts)
+	})
+}
+
+func TestCachingTransport_RoundTrip(t *testing.T) {
+	t.Run("serves fresh responses from cache", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"max-age=60"}}, "cached")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		for i := 0; i < 3; i++ {
+			res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "cached", readBody(t, res))
+		}
+		assert.Len(t, mockRT.capturedRequests, 1)
+	})
+
+	t.Run("uses Expires when max-age is missing", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			now := time.Now().UTC()
+			return newTestResponse(http.StatusOK, http.Header{
+				"Date":    []string{now.Format(http.TimeFormat)},
+				"Expires": []string{now.Add(time.Minute).Format(http.TimeFormat)},
+			}, "cached")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+			require.NoError(t, err)
+		}
+		assert.Len(t, mockRT.capturedRequests, 1)
+	})
+
+	t.Run("does not cache no-store responses", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"no-store, max-age=60"}}, "")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+			require.NoError(t, err)
+		}
+		assert.Len(t, mockRT.capturedRequests, 2)
+	})
+
+	t.Run("does not cache non-GET requests", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"max-age=60"}}, "")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest(http.MethodPost, "https://api.localhost/items", nil))
+			require.NoError(t, err)
+		}
+		assert.Len(t, mockRT.capturedRequests, 2)
+	})
+
+	t.Run("unsafe requests invalidate cached entry", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"max-age=60"}}, "")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodPut, "https://api.localhost/items", nil))
+		_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		assert.Len(t, mockRT.capturedRequests, 3)
+	})
+
+	t.Run("request no-cache forces revalidation", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				return newTestResponse(http.StatusNotModified, nil, "")
+			}
+			return newTestResponse(http.StatusOK, http.Header{
+				"Cache-Control": []string{"max-age=60"},
+				"Etag":          []string{`"v1"`},
+			}, "original")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req.Header.Set("Cache-Control", "no-cache")
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "original", readBody(t, res))
+		require.Len(t, mockRT.capturedRequests, 2)
+		assert.Equal(t, `"v1"`, mockRT.capturedRequests[1].Header.Get("If-None-Match"))
+	})
+
+	t.Run("revalidates stale responses with ETag", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				return newTestResponse(http.StatusNotModified, http.Header{"X-Revalidated": []string{"true"}}, "")
+			}
+			return newTestResponse(http.StatusOK, http.Header{
+				"Cache-Control": []string{"no-cache"},
+				"Etag":          []string{`"v1"`},
+			}, "original")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "original", readBody(t, res))
+		assert.Equal(t, "true", res.Header.Get("X-Revalidated"))
+		assert.Len(t, mockRT.capturedRequests, 2)
+	})
+
+	t.Run("replaces entry when revalidation returns new content", func(t *testing.T) {
+		version := "v1"
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{
+				"Cache-Control": []string{"no-cache"},
+				"Etag":          []string{`"` + version + `"`},
+			}, version)
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		_, _ = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		version = "v2"
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "v2", readBody(t, res))
+	})
+
+	t.Run("does not store revalidated entries without freshness or stale retention", func(t *testing.T) {
+		cache := NewMemoryCache(nil)
+		cache.Set(context.Background(), "GET https://api.localhost/items", &Entry{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Cache-Control": []string{"no-cache"}, "Etag": []string{`"v1"`}},
+			Body:       []byte("original"),
+			StoredAt:   time.Now(),
+		}, time.Minute)
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusNotModified, nil, "")
+		}}
+		opts := DefaultCachingTransportOptions()
+		opts.Cache = &recordingCache{Cache: cache}
+		opts.StaleRetention = 0
+		transport := NewCachingTransport(mockRT, opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "original", readBody(t, res))
+		assert.Empty(t, opts.Cache.(*recordingCache).ttls)
+	})
+
+	t.Run("does not share responses to authorized requests", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"max-age=60"}}, req.Header.Get("Authorization"))
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		alice := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		alice.Header.Set("Authorization", "Bearer alice")
+		_, err := transport.RoundTrip(alice)
+		require.NoError(t, err)
+		bob := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		bob.Header.Set("Authorization", "Bearer bob")
+		res, err := transport.RoundTrip(bob)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer bob", readBody(t, res))
+		assert.Len(t, mockRT.capturedRequests, 2)
+	})
+
+	t.Run("stores public responses to authorized requests", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{"Cache-Control": []string{"public, max-age=60"}}, "shared")
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		for range 2 {
+			req := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+			req.Header.Set("Authorization", "Bearer alice")
+			res, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, "shared", readBody(t, res))
+		}
+		assert.Len(t, mockRT.capturedRequests, 1)
+	})
+
+	t.Run("vary headers separate cached variants", func(t *testing.T) {
+		mockRT := &MockRoundTripper{responseFn: func(req *http.Request) *http.Response {
+			return newTestResponse(http.StatusOK, http.Header{
+				"Cache-Control": []string{"max-age=60"},
+				"Vary":          []string{"Accept-Language"},
+			}, req.Header.Get("Accept-Language"))
+		}}
+		transport := NewCachingTransport(mockRT, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req.Header.Set("Accept-Language", "en")
+		_, _ = transport.RoundTrip(req)
+
+		req = httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req.Header.Set("Accept-Language", "de")
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "de", readBody(t, res))
+		assert.Len(t, mockRT.capturedRequests, 2)
+	})
+
+	t.Run("propagates errors from underlying transport", func(t *testing.T) {
+		mockRT := &MockRoundTripper{errorToReturn: errors.New("network error")}
+		transport := NewCachingTransport(mockRT, nil)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
+
+func TestParseCacheControl(t *testing.T) {
+	directives := parseCacheControl(`max-age=60, No-Cache, private="Set-Cookie"`)
+
+	assert.Equal(t, "60", directives["max-age"])
+	assert.Contains(t, directives, "no-cache")
+	assert.Equal(t, "Set-Cookie", directives["private"])
+}
+
+func TestDefaultCoalescingTransportOptions(t *testing.T) {
+	opts := DefaultCoalescingTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Contains(t, opts.KeyHeaders, "Authorization")
+	assert.Contains(t, opts.KeyHeaders, "Cookie")
+	assert.Equal(t, 30*time.Second, opts.Timeout)
+}
+
+func TestNewCoalescingTransport(t *testing.T) {
+	t.Run("with nil round tripper uses default", func(t *testing.T) {
+		transport := NewCoalescingTransport(nil, nil)
+
+		require.NotNil(t, transport)
+		assert.Equal(t, http.DefaultTransport, transport.base)
+		assert.NotNil(t, transport.opts)
+	})
+}
+
+// BlockingRoundTripper releases all requests at once and counts upstream calls
+type BlockingRoundTripper struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (m *BlockingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.calls.Add(1)
+	<-m.release
+	return newTestResponse(http.StatusOK, http.Header{"X-Test": []string{"value"}}, "shared"), nil
+}
+
+func TestCoalescingTransport_RoundTrip(t *testing.T) {
+	t.Run("deduplicates concurrent identical requests", func(t *testing.T) {
+		mockRT := &BlockingRoundTripper{release: make(chan struct{})}
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		const waiters = 5
+		var started, done sync.WaitGroup
+		bodies := make([]string, waiters)
+		started.Add(waiters)
+		done.Add(waiters)
+		for i := 0; i < waiters; i++ {
+			go func(i int) {
+				defer done.Done()
+				started.Done()
+				res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+				if err == nil {
+					body, _ := io.ReadAll(res.Body)
+					bodies[i] = string(body)
+				}
+			}(i)
+		}
+		started.Wait()
+		time.Sleep(20 * time.Millisecond)
+		close(mockRT.release)
+		done.Wait()
+
+		assert.Equal(t, int32(1), mockRT.calls.Load())
+		for _, body := range bodies {
+			assert.Equal(t, "shared", body)
+		}
+	})
+
+	t.Run("waiters receive independent response copies", func(t *testing.T) {
+		mockRT := &BlockingRoundTripper{release: make(chan struct{})}
+		close(mockRT.release)
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		res1, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+		res1.Header.Set("X-Test", "modified")
+
+		res2, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+		assert.Equal(t, "value", res2.Header.Get("X-Test"))
+		assert.Equal(t, "shared", readBody(t, res2))
+	})
+
+	t.Run("does not coalesce requests with different key headers", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		req1 := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req1.Header.Set("Authorization", "Bearer a")
+		req2 := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req2.Header.Set("Authorization", "Bearer b")
+
+		assert.NotEqual(t, requestCacheKey(req1, transport.opts.KeyHeaders), requestCacheKey(req2, transport.opts.KeyHeaders))
+	})
+
+	t.Run("does not coalesce requests with different session cookies", func(t *testing.T) {
+		transport := NewCoalescingTransport(&MockRoundTripper{}, nil)
+
+		req1 := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req1.AddCookie(&http.Cookie{Name: "session", Value: "a"})
+		req2 := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil)
+		req2.AddCookie(&http.Cookie{Name: "session", Value: "b"})
+
+		assert.NotEqual(t, requestCacheKey(req1, transport.opts.KeyHeaders), requestCacheKey(req2, transport.opts.KeyHeaders))
+	})
+
+	t.Run("shared call keeps the deadline of the caller", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		deadline := time.Now().Add(time.Second)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+		require.NoError(t, err)
+
+		require.Len(t, mockRT.capturedRequests, 1)
+		sharedDeadline, ok := mockRT.capturedRequests[0].Context().Deadline()
+		require.True(t, ok)
+		assert.Equal(t, deadline, sharedDeadline)
+	})
+
+	t.Run("shared call is limited by the timeout", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewCoalescingTransport(mockRT, &CoalescingTransportOptions{Timeout: time.Minute})
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+
+		require.Len(t, mockRT.capturedRequests, 1)
+		sharedDeadline, ok := mockRT.capturedRequests[0].Context().Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), sharedDeadline, time.Second)
+	})
+
+	t.Run("passes non-GET requests through", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "https://api.localhost/items", nil)
+		_, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		require.Len(t, mockRT.capturedRequests, 1)
+		assert.Equal(t, req, mockRT.capturedRequests[0])
+	})
+
+	t.Run("waiter stops on own context cancellation", func(t *testing.T) {
+		mockRT := &BlockingRoundTripper{release: make(chan struct{})}
+		defer close(mockRT.release)
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx)
+
+		_, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("propagates errors to all waiters", func(t *testing.T) {
+		mockRT := &MockRoundTripper{errorToReturn: errors.New("network error")}
+		transport := NewCoalescingTransport(mockRT, nil)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+}
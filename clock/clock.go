@@ -0,0 +1,57 @@
+// Package clock abstracts time, so time-dependent middlewares and transports can be tested
+// deterministically, e.g. with testutils.FakeClock, instead of sleeping.
+package clock
+
+import "time"
+
+// Clock provides the current time and timers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer, see time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// System returns the clock of the operating system
+func System() Clock {
+	return systemClock{}
+}
+
+// OrSystem returns the clock, or the system clock if it is nil
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System()
+	}
+	return c
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
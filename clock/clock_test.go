@@ -0,0 +1,36 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystem(t *testing.T) {
+	c := System()
+
+	start := c.Now()
+	assert.WithinDuration(t, time.Now(), start, time.Second)
+	assert.GreaterOrEqual(t, c.Since(start), time.Duration(0))
+
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		assert.Fail(t, "timer did not fire")
+	}
+	assert.False(t, timer.Stop())
+	assert.True(t, c.NewTimer(time.Hour).Stop())
+}
+
+func TestOrSystem(t *testing.T) {
+	assert.Equal(t, System(), OrSystem(nil))
+
+	c := fixedClock{}
+	assert.Equal(t, c, OrSystem(c))
+}
+
+type fixedClock struct {
+	Clock
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/security"
+	"github.com/caarlos0/env/v11"
+	"github.com/sony/gobreaker/v2"
+)
+
+// Config holds the middleware and transport settings that deployments commonly tune. The env tags
+// follow the upper snake case keys used by go-autumn-configloader.
+type Config struct {
+	CORSAllowOrigin      string `env:"CORS_ALLOW_ORIGIN"`
+	CORSAllowCredentials bool   `env:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           int    `env:"CORS_MAX_AGE"`
+
+	RequestLogWarningStatusCodeThreshold int           `env:"REQUEST_LOG_WARNING_STATUS_CODE_THRESHOLD"`
+	RequestLogSlowRequestThreshold       time.Duration `env:"REQUEST_LOG_SLOW_REQUEST_THRESHOLD"`
+	RequestLogExcludedPaths              []string      `env:"REQUEST_LOG_EXCLUDED_PATHS"`
+
+	ConcurrencyLimitMaxInFlight  int           `env:"CONCURRENCY_LIMIT_MAX_IN_FLIGHT"`
+	ConcurrencyLimitMaxQueue     int           `env:"CONCURRENCY_LIMIT_MAX_QUEUE"`
+	ConcurrencyLimitQueueTimeout time.Duration `env:"CONCURRENCY_LIMIT_QUEUE_TIMEOUT"`
+	DeadlineMaxTimeout           time.Duration `env:"DEADLINE_MAX_TIMEOUT"`
+
+	CircuitBreakerMaxRequests uint32        `env:"CIRCUIT_BREAKER_MAX_REQUESTS"`
+	CircuitBreakerInterval    time.Duration `env:"CIRCUIT_BREAKER_INTERVAL"`
+	CircuitBreakerTimeout     time.Duration `env:"CIRCUIT_BREAKER_TIMEOUT"`
+	// CircuitBreakerMinRequests and CircuitBreakerFailureRatio define when the breaker trips.
+	CircuitBreakerMinRequests  uint32  `env:"CIRCUIT_BREAKER_MIN_REQUESTS"`
+	CircuitBreakerFailureRatio float64 `env:"CIRCUIT_BREAKER_FAILURE_RATIO"`
+
+	OutgoingMaxInFlightPerHost int           `env:"OUTGOING_MAX_IN_FLIGHT_PER_HOST"`
+	OutgoingRequestTimeout     time.Duration `env:"OUTGOING_REQUEST_TIMEOUT"`
+}
+
+// NewConfig returns a config holding the defaults of the respective option structs.
+func NewConfig() *Config {
+	cors := security.DefaultCORSMiddlewareOptions()
+	requestLogger := logging.DefaultRequestLoggerMiddlewareOptions()
+	concurrencyLimit := resiliency.DefaultConcurrencyLimitMiddlewareOptions()
+	deadline := resiliency.DefaultDeadlineMiddlewareOptions()
+	circuitBreaker := resiliency.DefaultCircuitBreakerTransportOptions()
+	outgoingConcurrencyLimit := resiliency.DefaultConcurrencyLimitTransportOptions()
+	outgoingTimeout := resiliency.DefaultTimeoutTransportOptions()
+
+	return &Config{
+		CORSAllowOrigin:      cors.AllowOrigin,
+		CORSAllowCredentials: cors.AllowCredentials,
+		CORSMaxAge:           cors.MaxAge,
+
+		RequestLogWarningStatusCodeThreshold: requestLogger.WarningStatusCodeThreshold,
+		RequestLogSlowRequestThreshold:       requestLogger.SlowRequestThreshold,
+		RequestLogExcludedPaths:              requestLogger.ExcludedPaths,
+
+		ConcurrencyLimitMaxInFlight:  concurrencyLimit.MaxInFlight,
+		ConcurrencyLimitMaxQueue:     concurrencyLimit.MaxQueue,
+		ConcurrencyLimitQueueTimeout: concurrencyLimit.QueueTimeout,
+		DeadlineMaxTimeout:           deadline.MaxTimeout,
+
+		CircuitBreakerMaxRequests:  circuitBreaker.MaxRequests,
+		CircuitBreakerInterval:     circuitBreaker.Interval,
+		CircuitBreakerTimeout:      circuitBreaker.Timeout,
+		CircuitBreakerMinRequests:  5,
+		CircuitBreakerFailureRatio: 0.6,
+
+		OutgoingMaxInFlightPerHost: outgoingConcurrencyLimit.MaxInFlightPerHost,
+		OutgoingRequestTimeout:     outgoingTimeout.Timeout,
+	}
+}
+
+// ObtainValuesFromEnv overrides the config with the values of all set environment variables and
+// validates the result.
+func (c *Config) ObtainValuesFromEnv() error {
+	if err := env.Parse(c); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.CORSAllowOrigin == "*" && c.CORSAllowCredentials {
+		errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS cannot be combined with wildcard CORS_ALLOW_ORIGIN"))
+	}
+	if c.CORSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("CORS_MAX_AGE must not be negative, got %d", c.CORSMaxAge))
+	}
+	if c.RequestLogWarningStatusCodeThreshold < 100 || c.RequestLogWarningStatusCodeThreshold > 599 {
+		errs = append(errs, fmt.Errorf("REQUEST_LOG_WARNING_STATUS_CODE_THRESHOLD must be a status code, got %d", c.RequestLogWarningStatusCodeThreshold))
+	}
+	if c.ConcurrencyLimitMaxInFlight < 1 {
+		errs = append(errs, fmt.Errorf("CONCURRENCY_LIMIT_MAX_IN_FLIGHT must be positive, got %d", c.ConcurrencyLimitMaxInFlight))
+	}
+	if c.ConcurrencyLimitMaxQueue < 0 {
+		errs = append(errs, fmt.Errorf("CONCURRENCY_LIMIT_MAX_QUEUE must not be negative, got %d", c.ConcurrencyLimitMaxQueue))
+	}
+	if c.CircuitBreakerFailureRatio <= 0 || c.CircuitBreakerFailureRatio > 1 {
+		errs = append(errs, fmt.Errorf("CIRCUIT_BREAKER_FAILURE_RATIO must be within (0, 1], got %g", c.CircuitBreakerFailureRatio))
+	}
+	if c.OutgoingMaxInFlightPerHost < 1 {
+		errs = append(errs, fmt.Errorf("OUTGOING_MAX_IN_FLIGHT_PER_HOST must be positive, got %d", c.OutgoingMaxInFlightPerHost))
+	}
+	for name, duration := range map[string]time.Duration{
+		"REQUEST_LOG_SLOW_REQUEST_THRESHOLD": c.RequestLogSlowRequestThreshold,
+		"CONCURRENCY_LIMIT_QUEUE_TIMEOUT":    c.ConcurrencyLimitQueueTimeout,
+		"DEADLINE_MAX_TIMEOUT":               c.DeadlineMaxTimeout,
+		"CIRCUIT_BREAKER_INTERVAL":           c.CircuitBreakerInterval,
+		"CIRCUIT_BREAKER_TIMEOUT":            c.CircuitBreakerTimeout,
+		"OUTGOING_REQUEST_TIMEOUT":           c.OutgoingRequestTimeout,
+	} {
+		if duration < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", name, duration))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) CORSMiddlewareOptions() *security.CORSMiddlewareOptions {
+	opts := security.DefaultCORSMiddlewareOptions()
+	opts.AllowOrigin = c.CORSAllowOrigin
+	opts.AllowCredentials = c.CORSAllowCredentials
+	opts.MaxAge = c.CORSMaxAge
+	return opts
+}
+
+func (c *Config) RequestLoggerMiddlewareOptions() *logging.RequestLoggerMiddlewareOptions {
+	opts := logging.DefaultRequestLoggerMiddlewareOptions()
+	opts.WarningStatusCodeThreshold = c.RequestLogWarningStatusCodeThreshold
+	opts.SlowRequestThreshold = c.RequestLogSlowRequestThreshold
+	opts.ExcludedPaths = c.RequestLogExcludedPaths
+	return opts
+}
+
+func (c *Config) ConcurrencyLimitMiddlewareOptions() *resiliency.ConcurrencyLimitMiddlewareOptions {
+	opts := resiliency.DefaultConcurrencyLimitMiddlewareOptions()
+	opts.MaxInFlight = c.ConcurrencyLimitMaxInFlight
+	opts.MaxQueue = c.ConcurrencyLimitMaxQueue
+	opts.QueueTimeout = c.ConcurrencyLimitQueueTimeout
+	return opts
+}
+
+func (c *Config) DeadlineMiddlewareOptions() *resiliency.DeadlineMiddlewareOptions {
+	opts := resiliency.DefaultDeadlineMiddlewareOptions()
+	opts.MaxTimeout = c.DeadlineMaxTimeout
+	return opts
+}
+
+func (c *Config) CircuitBreakerTransportOptions() *resiliency.CircuitBreakerTransportOptions {
+	minRequests := c.CircuitBreakerMinRequests
+	failureRatio := c.CircuitBreakerFailureRatio
+
+	opts := resiliency.DefaultCircuitBreakerTransportOptions()
+	opts.MaxRequests = c.CircuitBreakerMaxRequests
+	opts.Interval = c.CircuitBreakerInterval
+	opts.Timeout = c.CircuitBreakerTimeout
+	opts.ReadyToTrip = func(counts gobreaker.Counts) bool {
+		if counts.Requests == 0 {
+			return false
+		}
+		ratio := float64(counts.TotalFailures) / float64(counts.Requests)
+		return counts.Requests >= minRequests && ratio >= failureRatio
+	}
+	return opts
+}
+
+func (c *Config) ConcurrencyLimitTransportOptions() *resiliency.ConcurrencyLimitTransportOptions {
+	opts := resiliency.DefaultConcurrencyLimitTransportOptions()
+	opts.MaxInFlightPerHost = c.OutgoingMaxInFlightPerHost
+	return opts
+}
+
+func (c *Config) TimeoutTransportOptions() *resiliency.TimeoutTransportOptions {
+	opts := resiliency.DefaultTimeoutTransportOptions()
+	opts.Timeout = c.OutgoingRequestTimeout
+	return opts
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig(t *testing.T) {
+	c := NewConfig()
+
+	require.NotNil(t, c)
+	assert.Equal(t, "*", c.CORSAllowOrigin)
+	assert.Equal(t, 500, c.RequestLogWarningStatusCodeThreshold)
+	assert.Equal(t, 100, c.ConcurrencyLimitMaxInFlight)
+	assert.Equal(t, uint32(5), c.CircuitBreakerMaxRequests)
+	assert.Equal(t, 30*time.Second, c.OutgoingRequestTimeout)
+	assert.NoError(t, c.Validate())
+}
+
+func TestConfig_ObtainValuesFromEnv(t *testing.T) {
+	t.Run("overrides set values only", func(t *testing.T) {
+		t.Setenv("CORS_ALLOW_ORIGIN", "https://example.com")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		t.Setenv("REQUEST_LOG_SLOW_REQUEST_THRESHOLD", "2s")
+		t.Setenv("REQUEST_LOG_EXCLUDED_PATHS", "/health,/metrics")
+		t.Setenv("CIRCUIT_BREAKER_FAILURE_RATIO", "0.5")
+		t.Setenv("OUTGOING_REQUEST_TIMEOUT", "5s")
+
+		c := NewConfig()
+		require.NoError(t, c.ObtainValuesFromEnv())
+
+		assert.Equal(t, "https://example.com", c.CORSAllowOrigin)
+		assert.True(t, c.CORSAllowCredentials)
+		assert.Equal(t, 3600, c.CORSMaxAge)
+		assert.Equal(t, 2*time.Second, c.RequestLogSlowRequestThreshold)
+		assert.Equal(t, []string{"/health", "/metrics"}, c.RequestLogExcludedPaths)
+		assert.Equal(t, 0.5, c.CircuitBreakerFailureRatio)
+		assert.Equal(t, 5*time.Second, c.OutgoingRequestTimeout)
+		assert.Equal(t, 100, c.ConcurrencyLimitMaxInFlight)
+	})
+
+	t.Run("unparsable value", func(t *testing.T) {
+		t.Setenv("CONCURRENCY_LIMIT_MAX_IN_FLIGHT", "many")
+
+		assert.Error(t, NewConfig().ObtainValuesFromEnv())
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+		err := NewConfig().ObtainValuesFromEnv()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CORS_ALLOW_CREDENTIALS")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(c *Config)
+		expectedErr string
+	}{
+		{name: "negative CORS max age", modify: func(c *Config) { c.CORSMaxAge = -1 }, expectedErr: "CORS_MAX_AGE"},
+		{name: "invalid status code threshold", modify: func(c *Config) { c.RequestLogWarningStatusCodeThreshold = 42 }, expectedErr: "REQUEST_LOG_WARNING_STATUS_CODE_THRESHOLD"},
+		{name: "zero max in flight", modify: func(c *Config) { c.ConcurrencyLimitMaxInFlight = 0 }, expectedErr: "CONCURRENCY_LIMIT_MAX_IN_FLIGHT"},
+		{name: "negative queue", modify: func(c *Config) { c.ConcurrencyLimitMaxQueue = -1 }, expectedErr: "CONCURRENCY_LIMIT_MAX_QUEUE"},
+		{name: "failure ratio too high", modify: func(c *Config) { c.CircuitBreakerFailureRatio = 1.5 }, expectedErr: "CIRCUIT_BREAKER_FAILURE_RATIO"},
+		{name: "zero per host limit", modify: func(c *Config) { c.OutgoingMaxInFlightPerHost = 0 }, expectedErr: "OUTGOING_MAX_IN_FLIGHT_PER_HOST"},
+		{name: "negative duration", modify: func(c *Config) { c.DeadlineMaxTimeout = -time.Second }, expectedErr: "DEADLINE_MAX_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			tt.modify(c)
+
+			err := c.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestConfig_Options(t *testing.T) {
+	c := NewConfig()
+	c.CORSAllowOrigin = "https://example.com"
+	c.CORSAllowCredentials = true
+	c.RequestLogWarningStatusCodeThreshold = 400
+	c.ConcurrencyLimitMaxInFlight = 7
+	c.DeadlineMaxTimeout = 10 * time.Second
+	c.CircuitBreakerMinRequests = 2
+	c.CircuitBreakerFailureRatio = 0.5
+	c.OutgoingMaxInFlightPerHost = 3
+	c.OutgoingRequestTimeout = time.Second
+
+	cors := c.CORSMiddlewareOptions()
+	assert.Equal(t, "https://example.com", cors.AllowOrigin)
+	assert.True(t, cors.AllowCredentials)
+
+	assert.Equal(t, 400, c.RequestLoggerMiddlewareOptions().WarningStatusCodeThreshold)
+	assert.Equal(t, 7, c.ConcurrencyLimitMiddlewareOptions().MaxInFlight)
+	assert.Equal(t, 10*time.Second, c.DeadlineMiddlewareOptions().MaxTimeout)
+	assert.Equal(t, 3, c.ConcurrencyLimitTransportOptions().MaxInFlightPerHost)
+	assert.Equal(t, time.Second, c.TimeoutTransportOptions().Timeout)
+
+	breaker := c.CircuitBreakerTransportOptions()
+	assert.False(t, breaker.ReadyToTrip(gobreaker.Counts{}))
+	assert.False(t, breaker.ReadyToTrip(gobreaker.Counts{Requests: 1, TotalFailures: 1}))
+	assert.True(t, breaker.ReadyToTrip(gobreaker.Counts{Requests: 2, TotalFailures: 1}))
+	assert.False(t, breaker.ReadyToTrip(gobreaker.Counts{Requests: 4, TotalFailures: 1}))
+}
@@ -0,0 +1,79 @@
+package contextutils
+
+import (
+	"context"
+	"sync"
+)
+
+// Capturer captures a value of the context and returns a function storing it in another context, or nil
+// if the context does not hold the value
+type Capturer func(ctx context.Context) func(context.Context) context.Context
+
+var (
+	capturers  []Capturer
+	capturersM sync.RWMutex
+)
+
+// RegisterCapturer adds a capturer to the set of capturers used by Capture
+func RegisterCapturer(capturer Capturer) {
+	capturersM.Lock()
+	defer capturersM.Unlock()
+	capturers = append(capturers, capturer)
+}
+
+// RegisterValue makes Capture copy the value of type B stored with WithValue
+func RegisterValue[B any]() {
+	RegisterCapturer(func(ctx context.Context) func(context.Context) context.Context {
+		value := GetValue[B](ctx)
+		if value == nil {
+			return nil
+		}
+		captured := *value
+		return func(ctx context.Context) context.Context {
+			return WithValue(ctx, captured)
+		}
+	})
+}
+
+// Register makes Capture copy the value stored under the key
+func (k Key[B]) Register() {
+	RegisterCapturer(func(ctx context.Context) func(context.Context) context.Context {
+		value := k.GetValue(ctx)
+		if value == nil {
+			return nil
+		}
+		captured := *value
+		return func(ctx context.Context) context.Context {
+			return k.WithValue(ctx, captured)
+		}
+	})
+}
+
+// Snapshot holds the registered values of a context, see Capture
+type Snapshot struct {
+	appliers []func(context.Context) context.Context
+}
+
+// Capture copies the registered values of the context, e.g. request ID, trace context and JWT, so work
+// originating from a request can continue in worker pools or message consumers with a fresh context.
+// Packages of this module register their values on initialization.
+func Capture(ctx context.Context) Snapshot {
+	capturersM.RLock()
+	defer capturersM.RUnlock()
+
+	snapshot := Snapshot{}
+	for _, capturer := range capturers {
+		if applier := capturer(ctx); applier != nil {
+			snapshot.appliers = append(snapshot.appliers, applier)
+		}
+	}
+	return snapshot
+}
+
+// Apply returns a copy of the context holding the captured values
+func (s Snapshot) Apply(ctx context.Context) context.Context {
+	for _, applier := range s.appliers {
+		ctx = applier(ctx)
+	}
+	return ctx
+}
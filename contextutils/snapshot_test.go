@@ -0,0 +1,43 @@
+package contextutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedValue string
+
+type uncapturedValue string
+
+func TestCapture(t *testing.T) {
+	RegisterValue[capturedValue]()
+	capturedKey := DefineKey[string]("captured")
+	capturedKey.Register()
+
+	ctx := WithValue(context.Background(), capturedValue("value"))
+	ctx = WithValue(ctx, uncapturedValue("value"))
+	ctx = capturedKey.WithValue(ctx, "named")
+	ctx, cancel := context.WithCancel(ctx)
+	snapshot := Capture(ctx)
+	cancel()
+
+	restored := snapshot.Apply(context.Background())
+
+	assert.NoError(t, restored.Err())
+	assert.Equal(t, capturedValue("value"), MustGetValue[capturedValue](restored))
+	assert.Equal(t, "named", capturedKey.MustGetValue(restored))
+	assert.Nil(t, GetValue[uncapturedValue](restored))
+}
+
+func TestCapture_MissingValues(t *testing.T) {
+	RegisterValue[capturedValue]()
+
+	snapshot := Capture(context.Background())
+	restored := snapshot.Apply(WithValue(context.Background(), capturedValue("existing")))
+
+	require.NotNil(t, GetValue[capturedValue](restored))
+	assert.Equal(t, capturedValue("existing"), *GetValue[capturedValue](restored))
+}
@@ -21,3 +21,57 @@ func GetValue[B any](ctx context.Context) *B {
 func MustGetValue[B any](ctx context.Context) B {
 	return ctx.Value(contextKey[B]{}).(B)
 }
+
+type namedContextKey[B any] struct {
+	name string
+}
+
+// WithNamedValue stores the value under the name, so multiple values of the same type can be stored
+func WithNamedValue[B any](ctx context.Context, name string, value B) context.Context {
+	return context.WithValue(ctx, namedContextKey[B]{name: name}, value)
+}
+
+func GetNamedValue[B any](ctx context.Context, name string) *B {
+	if value := ctx.Value(namedContextKey[B]{name: name}); value != nil {
+		typedValue := value.(B)
+		return &typedValue
+	}
+	return nil
+}
+
+func MustGetNamedValue[B any](ctx context.Context, name string) B {
+	return ctx.Value(namedContextKey[B]{name: name}).(B)
+}
+
+// Key provides typed accessors for values stored under a name
+type Key[B any] struct {
+	name string
+}
+
+// DefineKey returns a key storing values of type B under the name, e.g.
+// var TenantID = contextutils.DefineKey[string]("tenantID")
+func DefineKey[B any](name string) Key[B] {
+	return Key[B]{name: name}
+}
+
+func (k Key[B]) Name() string {
+	return k.name
+}
+
+func (k Key[B]) WithValue(ctx context.Context, value B) context.Context {
+	return WithNamedValue(ctx, k.name, value)
+}
+
+func (k Key[B]) GetValue(ctx context.Context) *B {
+	return GetNamedValue[B](ctx, k.name)
+}
+
+func (k Key[B]) MustGetValue(ctx context.Context) B {
+	return MustGetNamedValue[B](ctx, k.name)
+}
+
+// Detach returns a context keeping the values of ctx, e.g. logger, request ID and JWT, but neither its
+// cancellation nor its deadline, so background work can outlive the request it was started by
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
@@ -3,6 +3,7 @@ package contextutils
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -133,3 +134,64 @@ func TestGenericTypeSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestNamedValue(t *testing.T) {
+	t.Run("stores multiple values of the same type", func(t *testing.T) {
+		ctx := WithNamedValue(context.Background(), "first", "one")
+		ctx = WithNamedValue(ctx, "second", "two")
+		ctx = WithValue(ctx, "unnamed")
+
+		first := GetNamedValue[string](ctx, "first")
+		second := GetNamedValue[string](ctx, "second")
+
+		require.NotNil(t, first)
+		require.NotNil(t, second)
+		assert.Equal(t, "one", *first)
+		assert.Equal(t, "two", *second)
+		assert.Equal(t, "unnamed", MustGetValue[string](ctx))
+	})
+
+	t.Run("distinguishes types of the same name", func(t *testing.T) {
+		ctx := WithNamedValue(context.Background(), "value", 42)
+
+		assert.Nil(t, GetNamedValue[string](ctx, "value"))
+		assert.Equal(t, 42, MustGetNamedValue[int](ctx, "value"))
+	})
+
+	t.Run("value does not exist", func(t *testing.T) {
+		ctx := context.Background()
+
+		assert.Nil(t, GetNamedValue[string](ctx, "missing"))
+		assert.Panics(t, func() {
+			MustGetNamedValue[string](ctx, "missing")
+		})
+	})
+}
+
+func TestDefineKey(t *testing.T) {
+	tenantID := DefineKey[string]("tenantID")
+	userID := DefineKey[string]("userID")
+
+	ctx := tenantID.WithValue(context.Background(), "tenant")
+	ctx = userID.WithValue(ctx, "user")
+
+	assert.Equal(t, "tenantID", tenantID.Name())
+	assert.Equal(t, "tenant", tenantID.MustGetValue(ctx))
+	require.NotNil(t, userID.GetValue(ctx))
+	assert.Equal(t, "user", *userID.GetValue(ctx))
+	assert.Equal(t, "tenant", *DefineKey[string]("tenantID").GetValue(ctx))
+	assert.Nil(t, DefineKey[string]("other").GetValue(ctx))
+}
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(WithValue(context.Background(), "value"), time.Hour)
+	detached := Detach(ctx)
+	cancel()
+
+	require.Error(t, ctx.Err())
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Equal(t, "value", MustGetValue[string](detached))
+}
@@ -0,0 +1,202 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// Code is a canonical error code as used by gRPC and google.rpc.Code. The values equal the gRPC codes,
+// so they can be converted with codes.Code(code) and errors.Code(grpcCode).
+type Code uint32
+
+const (
+	CodeOK                 Code = 0
+	CodeCanceled           Code = 1
+	CodeUnknown            Code = 2
+	CodeInvalidArgument    Code = 3
+	CodeDeadlineExceeded   Code = 4
+	CodeNotFound           Code = 5
+	CodeAlreadyExists      Code = 6
+	CodePermissionDenied   Code = 7
+	CodeResourceExhausted  Code = 8
+	CodeFailedPrecondition Code = 9
+	CodeAborted            Code = 10
+	CodeOutOfRange         Code = 11
+	CodeUnimplemented      Code = 12
+	CodeInternal           Code = 13
+	CodeUnavailable        Code = 14
+	CodeDataLoss           Code = 15
+	CodeUnauthenticated    Code = 16
+)
+
+// StatusClientClosedRequest is the non-standard status code used for canceled requests.
+const StatusClientClosedRequest = 499
+
+var codeNames = map[Code]string{
+	CodeOK:                 "OK",
+	CodeCanceled:           "CANCELLED",
+	CodeUnknown:            "UNKNOWN",
+	CodeInvalidArgument:    "INVALID_ARGUMENT",
+	CodeDeadlineExceeded:   "DEADLINE_EXCEEDED",
+	CodeNotFound:           "NOT_FOUND",
+	CodeAlreadyExists:      "ALREADY_EXISTS",
+	CodePermissionDenied:   "PERMISSION_DENIED",
+	CodeResourceExhausted:  "RESOURCE_EXHAUSTED",
+	CodeFailedPrecondition: "FAILED_PRECONDITION",
+	CodeAborted:            "ABORTED",
+	CodeOutOfRange:         "OUT_OF_RANGE",
+	CodeUnimplemented:      "UNIMPLEMENTED",
+	CodeInternal:           "INTERNAL",
+	CodeUnavailable:        "UNAVAILABLE",
+	CodeDataLoss:           "DATA_LOSS",
+	CodeUnauthenticated:    "UNAUTHENTICATED",
+}
+
+// String returns the google.rpc.Code name of the code, e.g. NOT_FOUND.
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+	return codeNames[CodeUnknown]
+}
+
+// ParseCode returns the code with the given google.rpc.Code name.
+func ParseCode(name string) (Code, bool) {
+	for code, codeName := range codeNames {
+		if codeName == name {
+			return code, true
+		}
+	}
+	return CodeUnknown, false
+}
+
+// codeMappings is the single table translating between codes and HTTP status codes. The first entry
+// of a code defines its status code and the first entry of a status code defines its code.
+var codeMappings = []struct {
+	code   Code
+	status int
+}{
+	{CodeOK, http.StatusOK},
+	{CodeInvalidArgument, http.StatusBadRequest},
+	{CodeFailedPrecondition, http.StatusBadRequest},
+	{CodeOutOfRange, http.StatusBadRequest},
+	{CodeUnauthenticated, http.StatusUnauthorized},
+	{CodePermissionDenied, http.StatusForbidden},
+	{CodeNotFound, http.StatusNotFound},
+	{CodeUnimplemented, http.StatusNotImplemented},
+	{CodeUnimplemented, http.StatusMethodNotAllowed},
+	{CodeDeadlineExceeded, http.StatusGatewayTimeout},
+	{CodeDeadlineExceeded, http.StatusRequestTimeout},
+	{CodeAlreadyExists, http.StatusConflict},
+	{CodeAborted, http.StatusConflict},
+	{CodeFailedPrecondition, http.StatusPreconditionFailed},
+	{CodeOutOfRange, http.StatusRequestedRangeNotSatisfiable},
+	{CodeFailedPrecondition, http.StatusPreconditionRequired},
+	{CodeResourceExhausted, http.StatusTooManyRequests},
+	{CodeResourceExhausted, http.StatusRequestEntityTooLarge},
+	{CodeInvalidArgument, http.StatusUnsupportedMediaType},
+	{CodeInvalidArgument, http.StatusUnprocessableEntity},
+	{CodeCanceled, StatusClientClosedRequest},
+	{CodeInternal, http.StatusInternalServerError},
+	{CodeUnknown, http.StatusInternalServerError},
+	{CodeDataLoss, http.StatusInternalServerError},
+	{CodeUnavailable, http.StatusServiceUnavailable},
+	{CodeUnavailable, http.StatusBadGateway},
+}
+
+// HTTPStatus returns the HTTP status code of the code, following the google.rpc.Code documentation.
+// Unknown codes map to 500 Internal Server Error.
+func (c Code) HTTPStatus() int {
+	for _, mapping := range codeMappings {
+		if mapping.code == c {
+			return mapping.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeFromHTTPStatus returns the code of the HTTP status code. Unmapped 2xx status codes map to
+// CodeOK, other unmapped 4xx status codes to CodeFailedPrecondition and everything else to CodeUnknown.
+func CodeFromHTTPStatus(status int) Code {
+	for _, mapping := range codeMappings {
+		if mapping.status == status {
+			return mapping.code
+		}
+	}
+	switch {
+	case status >= 200 && status < 300:
+		return CodeOK
+	case status >= 400 && status < 500:
+		return CodeFailedPrecondition
+	default:
+		return CodeUnknown
+	}
+}
+
+// CodeOf returns the code of the error response.
+func CodeOf(v ProblemRenderer) Code {
+	return CodeFromHTTPStatus(v.Problem().Status)
+}
+
+// NewCodeResponse creates an error response with the HTTP status code of the code and the code name
+// as application specific error code.
+func NewCodeResponse(code Code, message string) *ErrorResponse {
+	status := code.HTTPStatus()
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &ErrorResponse{
+		HTTPStatusCode: status,
+		StatusText:     http.StatusText(status),
+		Message:        message,
+		Code:           code.String(),
+	}
+}
+
+// CodedError is implemented by errors carrying a canonical code.
+type CodedError interface {
+	error
+	Code() Code
+}
+
+// NewCodedErrorMapping matches errors wrapping a CodedError and renders them with NewCodeResponse.
+// Only the own message of the CodedError is rendered, without the context added by wrapping errors or
+// the messages of the errors it wraps. Server-side codes (INTERNAL, UNKNOWN, DATA_LOSS) are rendered
+// with the status text only.
+func NewCodedErrorMapping() ErrorMapping {
+	return NewTypeErrorMapping(func(err CodedError) render.Renderer {
+		switch code := err.Code(); code {
+		case CodeInternal, CodeUnknown, CodeDataLoss:
+			return NewCodeResponse(code, "")
+		default:
+			return NewCodeResponse(code, codedErrorMessage(err))
+		}
+	})
+}
+
+// codedErrorMessage returns the message of the error without the message of the error it wraps
+func codedErrorMessage(err CodedError) string {
+	message := err.Error()
+	if cause := stderrors.Unwrap(err); cause != nil {
+		if own, ok := strings.CutSuffix(message, cause.Error()); ok {
+			message = strings.TrimSuffix(own, ": ")
+		}
+	}
+	return message
+}
+
+// CodeFromError returns the code of the first CodedError the error wraps, CodeOK for nil errors and
+// CodeUnknown otherwise.
+func CodeFromError(err error) Code {
+	if err == nil {
+		return CodeOK
+	}
+	var codedErr CodedError
+	if stderrors.As(err, &codedErr) {
+		return codedErr.Code()
+	}
+	return CodeUnknown
+}
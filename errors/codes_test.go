@@ -0,0 +1,206 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type quotaError struct{}
+
+func (e *quotaError) Error() string {
+	return "quota exceeded"
+}
+
+func (e *quotaError) Code() Code {
+	return CodeResourceExhausted
+}
+
+// wrappingCodedError carries a code and wraps the error causing it
+type wrappingCodedError struct {
+	code    Code
+	message string
+	cause   error
+}
+
+func (e *wrappingCodedError) Error() string {
+	return e.message + ": " + e.cause.Error()
+}
+
+func (e *wrappingCodedError) Unwrap() error {
+	return e.cause
+}
+
+func (e *wrappingCodedError) Code() Code {
+	return e.code
+}
+
+func TestCode_String(t *testing.T) {
+	assert.Equal(t, "NOT_FOUND", CodeNotFound.String())
+	assert.Equal(t, "CANCELLED", CodeCanceled.String())
+	assert.Equal(t, "UNKNOWN", Code(99).String())
+}
+
+func TestParseCode(t *testing.T) {
+	code, ok := ParseCode("RESOURCE_EXHAUSTED")
+	assert.True(t, ok)
+	assert.Equal(t, CodeResourceExhausted, code)
+
+	code, ok = ParseCode("NOPE")
+	assert.False(t, ok)
+	assert.Equal(t, CodeUnknown, code)
+}
+
+func TestCode_HTTPStatus(t *testing.T) {
+	testCases := []struct {
+		code   Code
+		status int
+	}{
+		{CodeOK, http.StatusOK},
+		{CodeCanceled, StatusClientClosedRequest},
+		{CodeUnknown, http.StatusInternalServerError},
+		{CodeInvalidArgument, http.StatusBadRequest},
+		{CodeDeadlineExceeded, http.StatusGatewayTimeout},
+		{CodeNotFound, http.StatusNotFound},
+		{CodeAlreadyExists, http.StatusConflict},
+		{CodePermissionDenied, http.StatusForbidden},
+		{CodeResourceExhausted, http.StatusTooManyRequests},
+		{CodeFailedPrecondition, http.StatusBadRequest},
+		{CodeAborted, http.StatusConflict},
+		{CodeOutOfRange, http.StatusBadRequest},
+		{CodeUnimplemented, http.StatusNotImplemented},
+		{CodeInternal, http.StatusInternalServerError},
+		{CodeUnavailable, http.StatusServiceUnavailable},
+		{CodeDataLoss, http.StatusInternalServerError},
+		{CodeUnauthenticated, http.StatusUnauthorized},
+		{Code(99), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.code.String(), func(t *testing.T) {
+			assert.Equal(t, tc.status, tc.code.HTTPStatus())
+		})
+	}
+}
+
+func TestCodeFromHTTPStatus(t *testing.T) {
+	testCases := []struct {
+		status int
+		code   Code
+	}{
+		{http.StatusOK, CodeOK},
+		{http.StatusNoContent, CodeOK},
+		{http.StatusBadRequest, CodeInvalidArgument},
+		{http.StatusUnauthorized, CodeUnauthenticated},
+		{http.StatusForbidden, CodePermissionDenied},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusMethodNotAllowed, CodeUnimplemented},
+		{http.StatusRequestTimeout, CodeDeadlineExceeded},
+		{http.StatusConflict, CodeAlreadyExists},
+		{http.StatusPreconditionFailed, CodeFailedPrecondition},
+		{http.StatusTeapot, CodeFailedPrecondition},
+		{http.StatusTooManyRequests, CodeResourceExhausted},
+		{StatusClientClosedRequest, CodeCanceled},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusNotImplemented, CodeUnimplemented},
+		{http.StatusBadGateway, CodeUnavailable},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusGatewayTimeout, CodeDeadlineExceeded},
+		{http.StatusHTTPVersionNotSupported, CodeUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.status), func(t *testing.T) {
+			assert.Equal(t, tc.code, CodeFromHTTPStatus(tc.status))
+		})
+	}
+}
+
+func TestCodeMappings_RoundTrip(t *testing.T) {
+	for code := range codeNames {
+		assert.Equal(t, code.HTTPStatus(), CodeFromHTTPStatus(code.HTTPStatus()).HTTPStatus(), "code %s", code)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, CodeNotFound, CodeOf(NewNotFoundResponse("")))
+	assert.Equal(t, CodeResourceExhausted, CodeOf(NewTooManyRequestsResponse("", 0)))
+	assert.Equal(t, CodeInvalidArgument, CodeOf(NewValidationErrorResponse("")))
+}
+
+func TestNewCodeResponse(t *testing.T) {
+	response := NewCodeResponse(CodeAlreadyExists, "")
+
+	assert.Equal(t, http.StatusConflict, response.HTTPStatusCode)
+	assert.Equal(t, "Conflict", response.StatusText)
+	assert.Equal(t, "Conflict", response.Message)
+	assert.Equal(t, "ALREADY_EXISTS", response.Code)
+	assert.Equal(t, CodeAlreadyExists, CodeOf(response))
+}
+
+func TestCodeFromError(t *testing.T) {
+	assert.Equal(t, CodeOK, CodeFromError(nil))
+	assert.Equal(t, CodeResourceExhausted, CodeFromError(fmt.Errorf("uploading: %w", &quotaError{})))
+	assert.Equal(t, CodeUnknown, CodeFromError(stderrors.New("boom")))
+}
+
+func TestNewCodedErrorMapping(t *testing.T) {
+	opts := &ErrorMapperMiddlewareOptions{
+		Mappings: []ErrorMapping{NewCodedErrorMapping()},
+	}
+	rr := httptest.NewRecorder()
+
+	NewErrorMapperMiddleware(opts)(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("uploading: %w", &quotaError{})
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/uploads", nil))
+
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"RESOURCE_EXHAUSTED"`)
+	assert.Contains(t, rr.Body.String(), "quota exceeded")
+	assert.NotContains(t, rr.Body.String(), "uploading")
+}
+
+func TestNewCodedErrorMapping_Messages(t *testing.T) {
+	serve := func(err error) string {
+		opts := &ErrorMapperMiddlewareOptions{
+			Mappings: []ErrorMapping{NewCodedErrorMapping()},
+		}
+		rr := httptest.NewRecorder()
+		NewErrorMapperMiddleware(opts)(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return err
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users", nil))
+		return rr.Body.String()
+	}
+
+	t.Run("renders only the own message of coded errors", func(t *testing.T) {
+		body := serve(fmt.Errorf("query users: %w", &wrappingCodedError{
+			code:    CodeNotFound,
+			message: "user not found",
+			cause:   stderrors.New("sql: no rows in result set"),
+		}))
+
+		assert.Contains(t, body, "user not found")
+		assert.NotContains(t, body, "query users")
+		assert.NotContains(t, body, "sql")
+	})
+
+	for _, code := range []Code{CodeInternal, CodeUnknown, CodeDataLoss} {
+		t.Run("renders the status text for "+code.String(), func(t *testing.T) {
+			body := serve(fmt.Errorf("query users: %w", &wrappingCodedError{
+				code:    code,
+				message: "connection to db-1.internal lost",
+				cause:   stderrors.New("dial tcp 10.0.0.1:5432"),
+			}))
+
+			assert.Contains(t, body, http.StatusText(http.StatusInternalServerError))
+			assert.NotContains(t, body, "db-1.internal")
+			assert.NotContains(t, body, "10.0.0.1")
+			assert.NotContains(t, body, "query users")
+		})
+	}
+}
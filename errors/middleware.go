@@ -0,0 +1,115 @@
+package errors
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/go-chi/render"
+)
+
+// HandlerFunc is an error-aware handler. Returned errors are rendered by the ErrorMapperMiddleware,
+// or as 500 Internal Server Error if no such middleware is installed.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := fn(w, r)
+	if err == nil {
+		return
+	}
+	if holder := contextutils.GetValue[*handlerError](r.Context()); holder != nil {
+		(*holder).err = err
+		return
+	}
+	if renderErr := Render(w, r, NewInternalServerErrorResponse("")); renderErr != nil {
+		panic(renderErr)
+	}
+}
+
+type handlerError struct {
+	err error
+}
+
+// ErrorMapping maps errors matched by MatchFn to the response returned by ResponseFn.
+type ErrorMapping struct {
+	MatchFn    func(err error) bool
+	ResponseFn func(err error) render.Renderer
+}
+
+// NewSentinelErrorMapping matches errors wrapping the target, see errors.Is.
+func NewSentinelErrorMapping(target error, responseFn func(err error) render.Renderer) ErrorMapping {
+	return ErrorMapping{
+		MatchFn: func(err error) bool {
+			return stderrors.Is(err, target)
+		},
+		ResponseFn: responseFn,
+	}
+}
+
+// NewTypeErrorMapping matches errors wrapping an error of type T, see errors.As.
+func NewTypeErrorMapping[T error](responseFn func(err T) render.Renderer) ErrorMapping {
+	return ErrorMapping{
+		MatchFn: func(err error) bool {
+			var target T
+			return stderrors.As(err, &target)
+		},
+		ResponseFn: func(err error) render.Renderer {
+			var target T
+			stderrors.As(err, &target)
+			return responseFn(target)
+		},
+	}
+}
+
+// ErrorMapperMiddleware //
+
+type ErrorMapperMiddlewareOptions struct {
+	// Mappings are evaluated in order, the first matching mapping renders the response.
+	Mappings []ErrorMapping
+	// FallbackResponseFn renders errors without matching mapping. Defaults to 500 Internal Server Error.
+	FallbackResponseFn func(err error) render.Renderer
+}
+
+func DefaultErrorMapperMiddlewareOptions() *ErrorMapperMiddlewareOptions {
+	return &ErrorMapperMiddlewareOptions{
+		Mappings: []ErrorMapping{},
+		FallbackResponseFn: func(error) render.Renderer {
+			return NewInternalServerErrorResponse("")
+		},
+	}
+}
+
+func NewErrorMapperMiddleware(opts *ErrorMapperMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultErrorMapperMiddlewareOptions()
+	}
+	if opts.FallbackResponseFn == nil {
+		opts.FallbackResponseFn = DefaultErrorMapperMiddlewareOptions().FallbackResponseFn
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			holder := &handlerError{}
+			req = req.WithContext(contextutils.WithValue(req.Context(), holder))
+
+			next.ServeHTTP(w, req)
+
+			if holder.err == nil {
+				return
+			}
+			if err := Render(w, req, mapError(holder.err, opts)); err != nil {
+				panic(err)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func mapError(err error, opts *ErrorMapperMiddlewareOptions) render.Renderer {
+	for _, mapping := range opts.Mappings {
+		if mapping.MatchFn(err) {
+			return mapping.ResponseFn(err)
+		}
+	}
+	return opts.FallbackResponseFn(err)
+}
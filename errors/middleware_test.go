@@ -0,0 +1,105 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMissing = stderrors.New("missing")
+
+type invalidInputError struct {
+	field string
+}
+
+func (e *invalidInputError) Error() string {
+	return "invalid " + e.field
+}
+
+func TestHandlerFunc(t *testing.T) {
+	t.Run("passes through successful handler", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("renders 500 without middleware", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return errMissing
+		}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestDefaultErrorMapperMiddlewareOptions(t *testing.T) {
+	opts := DefaultErrorMapperMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Empty(t, opts.Mappings)
+	assert.NotNil(t, opts.FallbackResponseFn)
+}
+
+func TestNewErrorMapperMiddleware(t *testing.T) {
+	opts := &ErrorMapperMiddlewareOptions{
+		Mappings: []ErrorMapping{
+			NewSentinelErrorMapping(errMissing, func(error) render.Renderer {
+				return NewForbiddenResponse("")
+			}),
+			NewTypeErrorMapping(func(err *invalidInputError) render.Renderer {
+				return NewBadRequestResponse(err.Error())
+			}),
+		},
+	}
+
+	serve := func(opts *ErrorMapperMiddlewareOptions, err error) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		NewErrorMapperMiddleware(opts)(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return err
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rr
+	}
+
+	t.Run("with nil options renders fallback", func(t *testing.T) {
+		rr := serve(nil, errMissing)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("maps wrapped sentinel errors", func(t *testing.T) {
+		rr := serve(opts, fmt.Errorf("loading item: %w", errMissing))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("maps error types", func(t *testing.T) {
+		rr := serve(opts, fmt.Errorf("decoding: %w", &invalidInputError{field: "name"}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "invalid name")
+	})
+
+	t.Run("renders fallback for unmapped errors", func(t *testing.T) {
+		rr := serve(opts, stderrors.New("boom"))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("does not touch successful responses", func(t *testing.T) {
+		rr := serve(opts, nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+}
@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"sync/atomic"
+)
+
+type ResponseOptions struct {
+	// IncludeRequestID embeds the request ID from the request context into rendered error responses.
+	IncludeRequestID bool
+	// IncludeTimestamp embeds the time of rendering as RFC 3339 UTC timestamp into rendered error responses.
+	IncludeTimestamp bool
+}
+
+func DefaultResponseOptions() *ResponseOptions {
+	return &ResponseOptions{}
+}
+
+var responseOptions atomic.Pointer[ResponseOptions]
+
+func init() {
+	responseOptions.Store(DefaultResponseOptions())
+}
+
+// SetResponseOptions configures the rendering of all error responses of this package. Nil restores
+// the defaults. It is meant to be called once during application startup.
+func SetResponseOptions(opts *ResponseOptions) {
+	if opts == nil {
+		opts = DefaultResponseOptions()
+	}
+	responseOptions.Store(opts)
+}
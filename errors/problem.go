@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/go-chi/render"
+)
+
+const ContentTypeProblemJSON = "application/problem+json"
+
+// Problem is the RFC 9457 problem details representation of an error response.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extension members
+	Code      string      `json:"code,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	Timestamp string      `json:"timestamp,omitempty"`
+	Errors    FieldErrors `json:"errors,omitempty"`
+}
+
+// ProblemRenderer is implemented by all error responses of this package.
+type ProblemRenderer interface {
+	render.Renderer
+	Problem() *Problem
+}
+
+func (e *ErrorResponse) Problem() *Problem {
+	return &Problem{
+		Type:      "about:blank",
+		Title:     e.StatusText,
+		Status:    e.HTTPStatusCode,
+		Detail:    e.Message,
+		Code:      e.Code,
+		RequestID: e.RequestID,
+		Timestamp: e.Timestamp,
+	}
+}
+
+func (e *ValidationErrorResponse) Problem() *Problem {
+	problem := e.ErrorResponse.Problem()
+	problem.Errors = e.Errors
+	return problem
+}
+
+// RenderProblem renders a copy of the given response as application/problem+json.
+func RenderProblem(w http.ResponseWriter, r *http.Request, v ProblemRenderer) error {
+	v = copyRenderer(v).(ProblemRenderer)
+	if err := v.Render(w, r); err != nil {
+		return err
+	}
+	problem := v.Problem()
+	problem.Instance = r.URL.Path
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(header.ContentType, ContentTypeProblemJSON)
+	w.WriteHeader(problem.Status)
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,63 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponse_Problem(t *testing.T) {
+	response := NewNotFoundResponse("Item not found")
+	response.Code = "item_not_found"
+
+	problem := response.Problem()
+
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "Item not found", problem.Detail)
+	assert.Equal(t, "item_not_found", problem.Code)
+}
+
+func TestValidationErrorResponse_Problem(t *testing.T) {
+	response := NewValidationErrorResponse("", FieldError{Field: "name", Code: "required", Message: "name is required"})
+
+	problem := response.Problem()
+
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "name", problem.Errors[0].Field)
+}
+
+func TestRenderProblem(t *testing.T) {
+	t.Run("renders problem details", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, RenderProblem(rr, req, NewNotFoundResponse("Item not found")))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, ContentTypeProblemJSON, rr.Header().Get("Content-Type"))
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, "Not Found", problem.Title)
+		assert.Equal(t, "Item not found", problem.Detail)
+		assert.Equal(t, "/items/1", problem.Instance)
+	})
+
+	t.Run("keeps response specific headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, RenderProblem(rr, req, NewTooManyRequestsResponse("", 2*time.Second)))
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	})
+}
@@ -1,8 +1,16 @@
 package errors
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/tracing"
 	"github.com/go-chi/render"
 )
 
@@ -11,13 +19,43 @@ type ErrorResponse struct {
 	HTTPStatusCode int    `json:"-"`
 	StatusText     string `json:"status"`
 	Message        string `json:"message"`
+	// Code is an optional application specific error code.
+	Code string `json:"code,omitempty"`
+	// RequestID and Timestamp are filled during rendering, see ResponseOptions.
+	RequestID string `json:"requestId,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
 }
 
 func (e *ErrorResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	opts := responseOptions.Load()
+	if opts.IncludeRequestID {
+		if requestID := tracing.RequestIDFromContext(r.Context()); requestID != nil {
+			e.RequestID = *requestID
+		}
+	}
+	if opts.IncludeTimestamp {
+		e.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
 	render.Status(r, e.HTTPStatusCode)
 	return nil
 }
 
+// Render renders a copy of the given response, so that per-request fields such as the request ID
+// do not leak between concurrent requests sharing the same response instance.
+func Render(w http.ResponseWriter, r *http.Request, v render.Renderer) error {
+	return render.Render(w, r, copyRenderer(v))
+}
+
+func copyRenderer(v render.Renderer) render.Renderer {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		cp := reflect.New(rv.Elem().Type())
+		cp.Elem().Set(rv.Elem())
+		return cp.Interface().(render.Renderer)
+	}
+	return v
+}
+
 // Common HTTP error responses
 
 // BadRequestResponse represents a 400 Bad Request error
@@ -74,6 +112,42 @@ func NewForbiddenResponse(message string) *ForbiddenResponse {
 	}
 }
 
+// NotFoundResponse represents a 404 Not Found error
+type NotFoundResponse struct {
+	ErrorResponse
+}
+
+func NewNotFoundResponse(message string) *NotFoundResponse {
+	if message == "" {
+		message = "Resource not found"
+	}
+	return &NotFoundResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusNotFound,
+			StatusText:     "Not Found",
+			Message:        message,
+		},
+	}
+}
+
+// MethodNotAllowedResponse represents a 405 Method Not Allowed error
+type MethodNotAllowedResponse struct {
+	ErrorResponse
+}
+
+func NewMethodNotAllowedResponse(message string) *MethodNotAllowedResponse {
+	if message == "" {
+		message = "Method not allowed"
+	}
+	return &MethodNotAllowedResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusMethodNotAllowed,
+			StatusText:     "Method Not Allowed",
+			Message:        message,
+		},
+	}
+}
+
 // RequestTimeoutResponse represents a 408 Request Timeout error
 type RequestTimeoutResponse struct {
 	ErrorResponse
@@ -92,6 +166,96 @@ func NewRequestTimeoutResponse(message string) *RequestTimeoutResponse {
 	}
 }
 
+// ConflictResponse represents a 409 Conflict error
+type ConflictResponse struct {
+	ErrorResponse
+}
+
+func NewConflictResponse(message string) *ConflictResponse {
+	if message == "" {
+		message = "Resource state conflict"
+	}
+	return &ConflictResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusConflict,
+			StatusText:     "Conflict",
+			Message:        message,
+		},
+	}
+}
+
+// RequestEntityTooLargeResponse represents a 413 Request Entity Too Large error
+type RequestEntityTooLargeResponse struct {
+	ErrorResponse
+}
+
+func NewRequestEntityTooLargeResponse(message string) *RequestEntityTooLargeResponse {
+	if message == "" {
+		message = "Request body too large"
+	}
+	return &RequestEntityTooLargeResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusRequestEntityTooLarge,
+			StatusText:     "Request Entity Too Large",
+			Message:        message,
+		},
+	}
+}
+
+// UnsupportedMediaTypeResponse represents a 415 Unsupported Media Type error
+type UnsupportedMediaTypeResponse struct {
+	ErrorResponse
+}
+
+func NewUnsupportedMediaTypeResponse(message string) *UnsupportedMediaTypeResponse {
+	if message == "" {
+		message = "Unsupported media type"
+	}
+	return &UnsupportedMediaTypeResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusUnsupportedMediaType,
+			StatusText:     "Unsupported Media Type",
+			Message:        message,
+		},
+	}
+}
+
+// UnprocessableEntityResponse represents a 422 Unprocessable Entity error
+type UnprocessableEntityResponse struct {
+	ErrorResponse
+}
+
+func NewUnprocessableEntityResponse(message string) *UnprocessableEntityResponse {
+	if message == "" {
+		message = "Request could not be processed"
+	}
+	return &UnprocessableEntityResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusUnprocessableEntity,
+			StatusText:     "Unprocessable Entity",
+			Message:        message,
+		},
+	}
+}
+
+// PreconditionFailedResponse represents a 412 Precondition Failed error
+type PreconditionFailedResponse struct {
+	ErrorResponse
+}
+
+func NewPreconditionFailedResponse(message string) *PreconditionFailedResponse {
+	if message == "" {
+		message = "Resource has been modified"
+	}
+	return &PreconditionFailedResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusPreconditionFailed,
+			StatusText:     "Precondition Failed",
+			Message:        message,
+		},
+	}
+}
+
 // PreconditionRequiredResponse represents a 428 Precondition Required error
 type PreconditionRequiredResponse struct {
 	ErrorResponse
@@ -110,6 +274,38 @@ func NewPreconditionRequiredResponse(message string) *PreconditionRequiredRespon
 	}
 }
 
+// TooManyRequestsResponse represents a 429 Too Many Requests error
+type TooManyRequestsResponse struct {
+	ErrorResponse
+	// RetryAfter is sent as Retry-After header in seconds if positive.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func NewTooManyRequestsResponse(message string, retryAfter time.Duration) *TooManyRequestsResponse {
+	if message == "" {
+		message = "Rate limit exceeded"
+	}
+	return &TooManyRequestsResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusTooManyRequests,
+			StatusText:     "Too Many Requests",
+			Message:        message,
+		},
+		RetryAfter: retryAfter,
+	}
+}
+
+func (e *TooManyRequestsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	setRetryAfter(w, e.RetryAfter)
+	return e.ErrorResponse.Render(w, r)
+}
+
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set(header.RetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+}
+
 // InternalServerErrorResponse represents a 500 Internal Server Error
 type InternalServerErrorResponse struct {
 	ErrorResponse
@@ -128,6 +324,67 @@ func NewInternalServerErrorResponse(message string) *InternalServerErrorResponse
 	}
 }
 
+// BadGatewayResponse represents a 502 Bad Gateway error
+type BadGatewayResponse struct {
+	ErrorResponse
+}
+
+func NewBadGatewayResponse(message string) *BadGatewayResponse {
+	if message == "" {
+		message = "Invalid response from upstream service"
+	}
+	return &BadGatewayResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusBadGateway,
+			StatusText:     "Bad Gateway",
+			Message:        message,
+		},
+	}
+}
+
+// ServiceUnavailableResponse represents a 503 Service Unavailable error
+type ServiceUnavailableResponse struct {
+	ErrorResponse
+	// RetryAfter is sent as Retry-After header in seconds if positive.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *ServiceUnavailableResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	setRetryAfter(w, e.RetryAfter)
+	return e.ErrorResponse.Render(w, r)
+}
+
+func NewServiceUnavailableResponse(message string) *ServiceUnavailableResponse {
+	if message == "" {
+		message = "Service temporarily unavailable"
+	}
+	return &ServiceUnavailableResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusServiceUnavailable,
+			StatusText:     "Service Unavailable",
+			Message:        message,
+		},
+	}
+}
+
+// GatewayTimeoutResponse represents a 504 Gateway Timeout error
+type GatewayTimeoutResponse struct {
+	ErrorResponse
+}
+
+func NewGatewayTimeoutResponse(message string) *GatewayTimeoutResponse {
+	if message == "" {
+		message = "Upstream service timeout"
+	}
+	return &GatewayTimeoutResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusGatewayTimeout,
+			StatusText:     "Gateway Timeout",
+			Message:        message,
+		},
+	}
+}
+
 // Convenience functions for common use cases
 
 func NewInvalidRequestBodyResponse() *BadRequestResponse {
@@ -150,6 +407,60 @@ func NewTimeoutResponse() *RequestTimeoutResponse {
 	return NewRequestTimeoutResponse("Request processing timeout")
 }
 
+func NewOverloadedResponse() *ServiceUnavailableResponse {
+	return NewServiceUnavailableResponse("Server is overloaded")
+}
+
+func NewLoadSheddingResponse(retryAfter time.Duration) *ServiceUnavailableResponse {
+	response := NewServiceUnavailableResponse("Server is shedding load")
+	response.RetryAfter = retryAfter
+	return response
+}
+
 func NewPanicRecoveryResponse() *InternalServerErrorResponse {
 	return NewInternalServerErrorResponse("An unexpected error occurred")
 }
+
+// Validation errors
+
+// FieldError describes why a single request field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldErrors collects field validation failures. Being an error itself, it can be returned from
+// validation code and turned into a ValidationErrorResponse.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// ValidationErrorResponse represents a 400 Bad Request error with field-level details
+type ValidationErrorResponse struct {
+	ErrorResponse
+	Errors FieldErrors `json:"errors"`
+}
+
+func NewValidationErrorResponse(message string, fieldErrors ...FieldError) *ValidationErrorResponse {
+	if message == "" {
+		message = "Request validation failed"
+	}
+	if fieldErrors == nil {
+		fieldErrors = FieldErrors{}
+	}
+	return &ValidationErrorResponse{
+		ErrorResponse: ErrorResponse{
+			HTTPStatusCode: http.StatusBadRequest,
+			StatusText:     "Bad Request",
+			Message:        message,
+		},
+		Errors: fieldErrors,
+	}
+}
@@ -0,0 +1,141 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponses(t *testing.T) {
+	testCases := []struct {
+		name       string
+		response   render.Renderer
+		statusCode int
+		message    string
+	}{
+		{"not found", NewNotFoundResponse(""), http.StatusNotFound, "Resource not found"},
+		{"method not allowed", NewMethodNotAllowedResponse(""), http.StatusMethodNotAllowed, "Method not allowed"},
+		{"conflict", NewConflictResponse("Item already exists"), http.StatusConflict, "Item already exists"},
+		{"precondition failed", NewPreconditionFailedResponse(""), http.StatusPreconditionFailed, "Resource has been modified"},
+		{"request entity too large", NewRequestEntityTooLargeResponse(""), http.StatusRequestEntityTooLarge, "Request body too large"},
+		{"unsupported media type", NewUnsupportedMediaTypeResponse(""), http.StatusUnsupportedMediaType, "Unsupported media type"},
+		{"unprocessable entity", NewUnprocessableEntityResponse(""), http.StatusUnprocessableEntity, "Request could not be processed"},
+		{"too many requests", NewTooManyRequestsResponse("", 0), http.StatusTooManyRequests, "Rate limit exceeded"},
+		{"bad gateway", NewBadGatewayResponse(""), http.StatusBadGateway, "Invalid response from upstream service"},
+		{"service unavailable", NewServiceUnavailableResponse(""), http.StatusServiceUnavailable, "Service temporarily unavailable"},
+		{"gateway timeout", NewGatewayTimeoutResponse(""), http.StatusGatewayTimeout, "Upstream service timeout"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			require.NoError(t, render.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), tc.response))
+
+			assert.Equal(t, tc.statusCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.message)
+			assert.Contains(t, rr.Body.String(), http.StatusText(tc.statusCode))
+		})
+	}
+}
+
+func TestTooManyRequestsResponse_Render(t *testing.T) {
+	t.Run("sets Retry-After in whole seconds", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		err := render.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), NewTooManyRequestsResponse("", 1500*time.Millisecond))
+
+		require.NoError(t, err)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+		assert.NotContains(t, rr.Body.String(), "RetryAfter")
+	})
+
+	t.Run("omits Retry-After without duration", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		err := render.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), NewTooManyRequestsResponse("", 0))
+
+		require.NoError(t, err)
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+	})
+}
+
+func TestErrorResponse_Render(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		return req.WithContext(tracing.ContextWithRequestID(req.Context(), "req-123"))
+	}
+
+	t.Run("omits request ID and timestamp by default", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, Render(rr, newRequest(), NewNotFoundResponse("")))
+
+		assert.NotContains(t, rr.Body.String(), "requestId")
+		assert.NotContains(t, rr.Body.String(), "timestamp")
+	})
+
+	t.Run("embeds request ID and timestamp if enabled", func(t *testing.T) {
+		SetResponseOptions(&ResponseOptions{IncludeRequestID: true, IncludeTimestamp: true})
+		defer SetResponseOptions(nil)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, Render(rr, newRequest(), NewNotFoundResponse("")))
+
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "req-123", body["requestId"])
+		_, err := time.Parse(time.RFC3339, body["timestamp"])
+		assert.NoError(t, err)
+	})
+
+	t.Run("embeds application error code", func(t *testing.T) {
+		response := NewConflictResponse("")
+		response.Code = "ITEM_EXISTS"
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, Render(rr, newRequest(), response))
+
+		assert.Contains(t, rr.Body.String(), `"code":"ITEM_EXISTS"`)
+	})
+
+	t.Run("does not modify shared response", func(t *testing.T) {
+		SetResponseOptions(&ResponseOptions{IncludeRequestID: true})
+		defer SetResponseOptions(nil)
+		response := NewNotFoundResponse("")
+
+		require.NoError(t, Render(httptest.NewRecorder(), newRequest(), response))
+
+		assert.Empty(t, response.RequestID)
+	})
+}
+
+func TestNewValidationErrorResponse(t *testing.T) {
+	rr := httptest.NewRecorder()
+	response := NewValidationErrorResponse("", FieldError{Field: "email", Code: "invalid_format", Message: "must be an email address"})
+
+	require.NoError(t, Render(rr, httptest.NewRequest(http.MethodPost, "/", nil), response))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{
+		"status": "Bad Request",
+		"message": "Request validation failed",
+		"errors": [{"field": "email", "code": "invalid_format", "message": "must be an email address"}]
+	}`, rr.Body.String())
+}
+
+func TestFieldErrors_Error(t *testing.T) {
+	err := FieldErrors{
+		{Field: "name", Code: "required", Message: "is required"},
+		{Field: "age", Code: "min", Message: "must be positive"},
+	}
+
+	assert.Equal(t, "validation failed: name: is required; age: must be positive", err.Error())
+}
@@ -1,18 +1,50 @@
 package header
 
 const (
-	Accept                        = "Accept"
-	AccessControlAllowOrigin      = "Access-Control-Allow-Origin"
-	AccessControlAllowMethods     = "Access-Control-Allow-Methods"
-	AccessControlAllowHeaders     = "Access-Control-Allow-Headers"
-	AccessControlAllowCredentials = "Access-Control-Allow-Credentials"
-	AccessControlExposeHeaders    = "Access-Control-Expose-Headers"
-	Authorization                 = "Authorization"
-	CacheControl                  = "Cache-Control"
-	ContentType                   = "Content-Type"
-	ContentSecurityPolicy         = "Content-Security-Policy"
-	ETag                          = "ETag"
-	IfMatch                       = "If-Match"
-	Location                      = "Location"
-	XRequestID                    = "X-Request-ID"
+	Accept                             = "Accept"
+	AcceptLanguage                     = "Accept-Language"
+	AccessControlAllowOrigin           = "Access-Control-Allow-Origin"
+	AccessControlAllowMethods          = "Access-Control-Allow-Methods"
+	AccessControlAllowHeaders          = "Access-Control-Allow-Headers"
+	AccessControlAllowPrivateNetwork   = "Access-Control-Allow-Private-Network"
+	AccessControlAllowCredentials      = "Access-Control-Allow-Credentials"
+	AccessControlExposeHeaders         = "Access-Control-Expose-Headers"
+	AccessControlMaxAge                = "Access-Control-Max-Age"
+	AccessControlRequestHeaders        = "Access-Control-Request-Headers"
+	AccessControlRequestMethod         = "Access-Control-Request-Method"
+	AccessControlRequestPrivateNetwork = "Access-Control-Request-Private-Network"
+	Authorization                      = "Authorization"
+	CacheControl                       = "Cache-Control"
+	Connection                         = "Connection"
+	ContentLanguage                    = "Content-Language"
+	ContentType                        = "Content-Type"
+	ContentSecurityPolicy              = "Content-Security-Policy"
+	Cookie                             = "Cookie"
+	ETag                               = "ETag"
+	Expires                            = "Expires"
+	Forwarded                          = "Forwarded"
+	IfMatch                            = "If-Match"
+	IfModifiedSince                    = "If-Modified-Since"
+	IfNoneMatch                        = "If-None-Match"
+	LastEventID                        = "Last-Event-ID"
+	LastModified                       = "Last-Modified"
+	Location                           = "Location"
+	Origin                             = "Origin"
+	RetryAfter                         = "Retry-After"
+	SetCookie                          = "Set-Cookie"
+	StripeSignature                    = "Stripe-Signature"
+	Upgrade                            = "Upgrade"
+	Vary                               = "Vary"
+	XAccelBuffering                    = "X-Accel-Buffering"
+	XForwardedFor                      = "X-Forwarded-For"
+	XHubSignature256                   = "X-Hub-Signature-256"
+	XParentRequestID                   = "X-Parent-Request-ID"
+	XRealIP                            = "X-Real-IP"
+	XRequestPriority                   = "X-Request-Priority"
+	XRequestTimeout                    = "X-Request-Timeout"
+	XRequestID                         = "X-Request-ID"
+	XTenantID                          = "X-Tenant-ID"
+	XWebhookEvent                      = "X-Webhook-Event"
+	XWebhookID                         = "X-Webhook-ID"
+	XWebhookSignature                  = "X-Webhook-Signature"
 )
@@ -0,0 +1,21 @@
+package header
+
+import (
+	"net/http"
+	"strings"
+)
+
+// IsUpgradeRequest reports whether the request asks to switch protocols, e.g. to WebSocket.
+func IsUpgradeRequest(req *http.Request) bool {
+	if req.Header.Get(Upgrade) == "" {
+		return false
+	}
+	for _, value := range req.Header.Values(Connection) {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package header
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUpgradeRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected bool
+	}{
+		{name: "websocket upgrade", headers: map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"}, expected: true},
+		{name: "connection token list", headers: map[string]string{"Connection": "keep-alive, upgrade", "Upgrade": "websocket"}, expected: true},
+		{name: "missing upgrade header", headers: map[string]string{"Connection": "Upgrade"}, expected: false},
+		{name: "missing connection token", headers: map[string]string{"Connection": "keep-alive", "Upgrade": "websocket"}, expected: false},
+		{name: "plain request", headers: map[string]string{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			assert.Equal(t, tt.expected, IsUpgradeRequest(req))
+		})
+	}
+}
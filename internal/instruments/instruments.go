@@ -0,0 +1,73 @@
+// Package instruments reuses OpenTelemetry instruments between instances of middlewares and
+// transports, so instantiating them repeatedly neither registers duplicate instruments nor makes the
+// SDK warn about conflicting ones.
+package instruments
+
+import (
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+type key struct {
+	provider  metric.MeterProvider
+	meterName string
+	kind      string
+	name      string
+}
+
+var registry sync.Map
+
+// Float64Histogram returns the histogram of the meter of the provider, creating it on first use. The
+// options of later calls for an existing instrument are ignored.
+func Float64Histogram(provider metric.MeterProvider, meterName string, name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return lookup(provider, key{meterName: meterName, kind: "Float64Histogram", name: name}, func() (metric.Float64Histogram, error) {
+		return provider.Meter(meterName).Float64Histogram(name, opts...)
+	})
+}
+
+// Int64Histogram returns the histogram of the meter of the provider, creating it on first use. The
+// options of later calls for an existing instrument are ignored.
+func Int64Histogram(provider metric.MeterProvider, meterName string, name string, opts ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return lookup(provider, key{meterName: meterName, kind: "Int64Histogram", name: name}, func() (metric.Int64Histogram, error) {
+		return provider.Meter(meterName).Int64Histogram(name, opts...)
+	})
+}
+
+// Int64Counter returns the counter of the meter of the provider, creating it on first use. The
+// options of later calls for an existing instrument are ignored.
+func Int64Counter(provider metric.MeterProvider, meterName string, name string, opts ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return lookup(provider, key{meterName: meterName, kind: "Int64Counter", name: name}, func() (metric.Int64Counter, error) {
+		return provider.Meter(meterName).Int64Counter(name, opts...)
+	})
+}
+
+// Int64ObservableGauge returns the observable gauge of the meter of the provider, creating it on
+// first use. The options of later calls for an existing instrument are ignored, so values have to be
+// observed by callbacks registered with the meter instead of callback options.
+func Int64ObservableGauge(provider metric.MeterProvider, meterName string, name string, opts ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	return lookup(provider, key{meterName: meterName, kind: "Int64ObservableGauge", name: name}, func() (metric.Int64ObservableGauge, error) {
+		return provider.Meter(meterName).Int64ObservableGauge(name, opts...)
+	})
+}
+
+// lookup returns the registered instrument or registers the created one. Failed creations are not
+// registered, but their instrument is returned, since the API returns usable no-op instruments along
+// with errors.
+func lookup[T any](provider metric.MeterProvider, k key, create func() (T, error)) (T, error) {
+	if provider == nil || !reflect.TypeOf(provider).Comparable() {
+		return create()
+	}
+	k.provider = provider
+
+	if instrument, ok := registry.Load(k); ok {
+		return instrument.(T), nil
+	}
+	instrument, err := create()
+	if err != nil {
+		return instrument, err
+	}
+	actual, _ := registry.LoadOrStore(k, instrument)
+	return actual.(T), nil
+}
@@ -0,0 +1,90 @@
+package instruments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInt64Counter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	first, err := Int64Counter(provider, "test", "test.total")
+	require.NoError(t, err)
+	second, err := Int64Counter(provider, "test", "test.total")
+	require.NoError(t, err)
+	other, err := Int64Counter(provider, "other", "test.total")
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.NotSame(t, first, other)
+
+	first.Add(context.Background(), 1)
+	second.Add(context.Background(), 2)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(3), sum.DataPoints[0].Value)
+}
+
+func TestFloat64Histogram(t *testing.T) {
+	provider := sdkmetric.NewMeterProvider()
+	otherProvider := sdkmetric.NewMeterProvider()
+
+	first, err := Float64Histogram(provider, "test", "test.duration")
+	require.NoError(t, err)
+	second, err := Float64Histogram(provider, "test", "test.duration")
+	require.NoError(t, err)
+	other, err := Float64Histogram(otherProvider, "test", "test.duration")
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+	assert.NotSame(t, first, other)
+}
+
+func TestInt64Histogram(t *testing.T) {
+	provider := sdkmetric.NewMeterProvider()
+
+	first, err := Int64Histogram(provider, "test", "test.attempts")
+	require.NoError(t, err)
+	second, err := Int64Histogram(provider, "test", "test.attempts")
+	require.NoError(t, err)
+
+	assert.Same(t, first, second)
+}
+
+func TestInt64ObservableGauge(t *testing.T) {
+	provider := sdkmetric.NewMeterProvider()
+
+	first, err := Int64ObservableGauge(provider, "test", "test.state")
+	require.NoError(t, err)
+	second, err := Int64ObservableGauge(provider, "test", "test.state")
+	require.NoError(t, err)
+	other, err := Int64ObservableGauge(provider, "other", "test.state")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+func TestLookup_Error(t *testing.T) {
+	provider := sdkmetric.NewMeterProvider()
+
+	// invalid instrument names are rejected, but a usable instrument is returned
+	instrument, err := Int64Counter(provider, "test", "1nvalid")
+	assert.Error(t, err)
+	assert.NotNil(t, instrument)
+
+	_, err = Int64Counter(provider, "test", "1nvalid")
+	assert.Error(t, err)
+}
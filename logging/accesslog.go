@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogFormat selects the fields and message of the entries logged by the RequestLoggerMiddleware
+type AccessLogFormat string
+
+const (
+	// AccessLogFormatDefault logs the fields of this package, see LogFieldRequestMethod and others.
+	AccessLogFormatDefault AccessLogFormat = "default"
+	// AccessLogFormatCommon logs the request in Common Log Format as message.
+	AccessLogFormatCommon AccessLogFormat = "common"
+	// AccessLogFormatCombined logs the request in Apache Combined Log Format as message.
+	AccessLogFormatCombined AccessLogFormat = "combined"
+	// AccessLogFormatECS logs the fields with Elastic Common Schema names, see ECSFieldHTTPRequestMethod and others.
+	AccessLogFormatECS AccessLogFormat = "ecs"
+)
+
+const commonLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// accessLogEntry holds what is known about a request once it has been handled
+type accessLogEntry struct {
+	req      *http.Request
+	start    time.Time
+	elapsed  time.Duration
+	status   int
+	bytes    int
+	redactor Redactor
+}
+
+func (e accessLogEntry) defaultFields() []any {
+	return []any{
+		LogFieldRequestMethod, e.req.Method,
+		LogFieldResponseStatus, e.status,
+		LogFieldURLPath, e.req.URL.Path,
+		LogFieldUserAgent, e.req.UserAgent(),
+		LogFieldLogger, "request.incoming",
+		LogFieldEventDuration, e.elapsed.Milliseconds(),
+	}
+}
+
+func (e accessLogEntry) defaultMessage() string {
+	return fmt.Sprintf("response %s %s -> %d (%d ms)", e.req.Method, e.req.URL.Path, e.status, e.elapsed.Milliseconds())
+}
+
+func (e accessLogEntry) ecsFields() []any {
+	fields := []any{
+		ECSFieldHTTPRequestMethod, e.req.Method,
+		ECSFieldHTTPResponseStatusCode, e.status,
+		ECSFieldHTTPResponseBodyBytes, e.bytes,
+		ECSFieldHTTPVersion, strings.TrimPrefix(e.req.Proto, "HTTP/"),
+		ECSFieldURLPath, e.req.URL.Path,
+		ECSFieldUserAgentOriginal, e.req.UserAgent(),
+		ECSFieldClientAddress, remoteHost(e.req),
+		ECSFieldLogLogger, "request.incoming",
+		ECSFieldEventDuration, e.elapsed.Nanoseconds(),
+	}
+	if _, query, ok := strings.Cut(e.redactor.RedactURL(e.req.URL), "?"); ok {
+		fields = append(fields, ECSFieldURLQuery, query)
+	}
+	return fields
+}
+
+// commonLogLine formats the request as `host ident user [time] "request" status bytes`
+func (e accessLogEntry) commonLogLine() string {
+	user := "-"
+	if e.req.URL.User != nil && e.req.URL.User.Username() != "" {
+		user = e.req.URL.User.Username()
+	} else if username, _, ok := e.req.BasicAuth(); ok && username != "" {
+		user = username
+	}
+	bytes := "-"
+	if e.bytes > 0 {
+		bytes = strconv.Itoa(e.bytes)
+	}
+	requestURI := e.redactor.RedactURL(e.req.URL)
+	return fmt.Sprintf("%s - %s [%s] %s %d %s",
+		remoteHost(e.req), user, e.start.Format(commonLogTimeLayout),
+		strconv.Quote(e.req.Method+" "+requestURI+" "+e.req.Proto), e.status, bytes)
+}
+
+// combinedLogLine appends the quoted referer and user agent to the common log line
+func (e accessLogEntry) combinedLogLine() string {
+	return fmt.Sprintf("%s %s %s", e.commonLogLine(), quoteOrDash(e.req.Referer()), quoteOrDash(e.req.UserAgent()))
+}
+
+func quoteOrDash(value string) string {
+	if value == "" {
+		return `"-"`
+	}
+	return strconv.Quote(value)
+}
+
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
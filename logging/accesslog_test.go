@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/Roshick/go-autumn-slog"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestLoggerMiddleware_Format(t *testing.T) {
+	aulogging.Logger = logging.New()
+
+	serve := func(format AccessLogFormat, req *http.Request) slog.Record {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.Format = format
+		opts.Redactor = NewRedactor(&RedactorOptions{QueryParameters: []string{"token"}})
+		handler := newCapturingHandler()
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *handler.records, 1)
+		return (*handler.records)[0]
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/items?token=secret&page=2", nil)
+		req.RemoteAddr = "192.0.2.1:51234"
+		req.Header.Set("User-Agent", "curl/8.0")
+		req.Header.Set("Referer", "https://example.com/")
+		req.SetBasicAuth("alice", "password")
+		return req
+	}
+
+	t.Run("logs default fields", func(t *testing.T) {
+		record := serve(AccessLogFormatDefault, newRequest())
+
+		assert.Contains(t, record.Message, "response POST /items -> 201")
+		assert.True(t, hasAttr(record, LogFieldRequestMethod, slog.StringValue(http.MethodPost)))
+		assert.True(t, hasAttr(record, LogFieldResponseStatus, slog.IntValue(http.StatusCreated)))
+	})
+
+	t.Run("logs common log format", func(t *testing.T) {
+		record := serve(AccessLogFormatCommon, newRequest())
+
+		assert.Regexp(t, regexp.MustCompile(
+			`^192\.0\.2\.1 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /items\?page=2&token=xxxxx HTTP/1\.1" 201 7$`),
+			record.Message)
+		assert.True(t, hasAttr(record, LogFieldLogger, slog.StringValue("request.incoming")))
+		assert.False(t, hasAttr(record, LogFieldRequestMethod, slog.StringValue(http.MethodPost)))
+	})
+
+	t.Run("logs combined log format", func(t *testing.T) {
+		record := serve(AccessLogFormatCombined, newRequest())
+
+		assert.Regexp(t, regexp.MustCompile(
+			`^192\.0\.2\.1 - alice \[.+\] "POST /items\?page=2&token=xxxxx HTTP/1\.1" 201 7 "https://example\.com/" "curl/8\.0"$`),
+			record.Message)
+	})
+
+	t.Run("logs dashes for missing values", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.RemoteAddr = "192.0.2.1"
+
+		record := serve(AccessLogFormatCombined, req)
+
+		assert.Regexp(t, regexp.MustCompile(`^192\.0\.2\.1 - - \[.+\] "GET /items HTTP/1\.1" 201 7 "-" "-"$`), record.Message)
+	})
+
+	t.Run("logs elastic common schema fields", func(t *testing.T) {
+		record := serve(AccessLogFormatECS, newRequest())
+
+		assert.True(t, hasAttr(record, ECSFieldHTTPRequestMethod, slog.StringValue(http.MethodPost)))
+		assert.True(t, hasAttr(record, ECSFieldHTTPResponseStatusCode, slog.IntValue(http.StatusCreated)))
+		assert.True(t, hasAttr(record, ECSFieldHTTPResponseBodyBytes, slog.IntValue(7)))
+		assert.True(t, hasAttr(record, ECSFieldHTTPVersion, slog.StringValue("1.1")))
+		assert.True(t, hasAttr(record, ECSFieldURLPath, slog.StringValue("/items")))
+		assert.True(t, hasAttr(record, ECSFieldURLQuery, slog.StringValue("page=2&token=xxxxx")))
+		assert.True(t, hasAttr(record, ECSFieldUserAgentOriginal, slog.StringValue("curl/8.0")))
+		assert.True(t, hasAttr(record, ECSFieldClientAddress, slog.StringValue("192.0.2.1")))
+		assert.True(t, hasAttr(record, ECSFieldLogLogger, slog.StringValue("request.incoming")))
+		assert.False(t, hasAttr(record, LogFieldRequestMethod, slog.StringValue(http.MethodPost)))
+	})
+}
@@ -9,6 +9,41 @@ const (
 	LogFieldEventDuration  = "event-duration"
 	LogFieldLogger         = "logger"
 	LogFieldStackTrace     = "stack-trace"
+	LogFieldIncidentID     = "incident-id"
 	LogFieldTraceID        = "trace-id"
 	LogFieldSpanID         = "span-id"
+	LogFieldSlowRequest    = "slow_request"
+	LogFieldTenantID       = "tenant-id"
+	LogFieldRetryAttempt   = "retry-attempt"
+	LogFieldRetryDelay     = "retry-delay"
+
+	LogFieldResponseBody          = "response-body"
+	LogFieldResponseBodyTruncated = "response-body-truncated"
+
+	LogFieldAuditActor   = "audit-actor"
+	LogFieldAuditAction  = "audit-action"
+	LogFieldAuditOutcome = "audit-outcome"
+	LogFieldAuditStatus  = "audit-status"
+	// LogFieldAuditResourcePrefix prefixes the names of the path parameters of audited requests.
+	LogFieldAuditResourcePrefix = "audit-resource-"
+
+	// LogFieldRequestHeaderPrefix prefixes the lower-cased names of logged request headers.
+	LogFieldRequestHeaderPrefix = "request-header-"
 )
+
+// Field names of the Elastic Common Schema, see AccessLogFormatECS
+const (
+	ECSFieldHTTPRequestMethod      = "http.request.method"
+	ECSFieldHTTPResponseStatusCode = "http.response.status_code"
+	ECSFieldHTTPResponseBodyBytes  = "http.response.body.bytes"
+	ECSFieldHTTPVersion            = "http.version"
+	ECSFieldURLPath                = "url.path"
+	ECSFieldURLQuery               = "url.query"
+	ECSFieldUserAgentOriginal      = "user_agent.original"
+	ECSFieldClientAddress          = "client.address"
+	ECSFieldLogLogger              = "log.logger"
+	ECSFieldEventDuration          = "event.duration"
+)
+
+// RedactedValue replaces sensitive values in log entries.
+const RedactedValue = "xxxxx"
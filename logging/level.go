@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	auloggingapi "github.com/StephanHCB/go-autumn-logging/api"
+)
+
+// LevelFn determines the log level of a handled request from its response status code, the
+// transport error (always nil for incoming requests) and its duration.
+type LevelFn func(status int, err error, duration time.Duration) slog.Level
+
+// NewStatusCodeLevelFn logs errors and responses with a status code at or above the threshold as
+// warnings, everything else as info. This is the default behaviour of the request loggers.
+func NewStatusCodeLevelFn(warningStatusCodeThreshold int) LevelFn {
+	return func(status int, err error, _ time.Duration) slog.Level {
+		if err != nil || status >= warningStatusCodeThreshold {
+			return slog.LevelWarn
+		}
+		return slog.LevelInfo
+	}
+}
+
+func leveledLogger(ctx context.Context, level slog.Level) auloggingapi.LeveledLoggingImplementation {
+	logger := aulogging.Logger.Ctx(ctx)
+	switch {
+	case level >= slog.LevelError:
+		return logger.Error()
+	case level >= slog.LevelWarn:
+		return logger.Warn()
+	case level >= slog.LevelInfo:
+		return logger.Info()
+	default:
+		return logger.Debug()
+	}
+}
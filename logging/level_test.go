@@ -0,0 +1,19 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStatusCodeLevelFn(t *testing.T) {
+	levelFn := NewStatusCodeLevelFn(http.StatusInternalServerError)
+
+	assert.Equal(t, slog.LevelInfo, levelFn(http.StatusOK, nil, 0))
+	assert.Equal(t, slog.LevelInfo, levelFn(http.StatusNotFound, nil, 0))
+	assert.Equal(t, slog.LevelWarn, levelFn(http.StatusInternalServerError, nil, 0))
+	assert.Equal(t, slog.LevelWarn, levelFn(0, errors.New("connection refused"), 0))
+}
@@ -2,12 +2,19 @@ package logging
 
 import (
 	"context"
+	"log/slog"
+	"mime"
 	"net/http"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/clock"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/responsewriter"
 	aulogging "github.com/StephanHCB/go-autumn-logging"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 // ContextLoggerMiddleware //
@@ -38,6 +45,28 @@ func NewContextLoggerMiddleware(opts *ContextLoggerMiddlewareOptions) func(http.
 	}
 }
 
+// LoggerFieldsMiddleware //
+
+// NewLoggerFieldsMiddleware adds the fields computed by the extractor, given as alternating keys and
+// values, to the context logger, e.g. the API version or the calling client application. Mount it per
+// route to enrich only the logs of these routes.
+func NewLoggerFieldsMiddleware(extractor func(req *http.Request) []any) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+
+			if logger := logging.FromContext(ctx); logger != nil && extractor != nil {
+				if fields := extractor(req); len(fields) > 0 {
+					ctx = logging.ContextWithLogger(ctx, logger.With(fields...))
+				}
+			}
+
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
 // ContextCancellationLoggerMiddleware //
 
 type ContextCancellationLoggerMiddlewareOptions struct {
@@ -85,12 +114,46 @@ func NewContextCancellationLoggerMiddleware(opts *ContextCancellationLoggerMiddl
 type RequestLoggerMiddlewareOptions struct {
 	// WarningStatusCodeThreshold defines the status code boundary above which
 	// responses are logged as warnings instead of info. Defaults to 500 (5xx errors).
+	// Ignored if LevelFn is set.
 	WarningStatusCodeThreshold int
+	// LevelFn determines the log level of each request. Defaults to NewStatusCodeLevelFn
+	// with the WarningStatusCodeThreshold.
+	LevelFn LevelFn
+	// ExcludedPaths lists URL path patterns (see path.Match) of requests that are not logged, e.g. health checks.
+	ExcludedPaths []string
+	// ExcludedMethods lists HTTP methods of requests that are not logged.
+	ExcludedMethods []string
+	// SamplerFn decides whether a request that is not excluded is logged. Nil logs every request.
+	SamplerFn SamplerFn
+	// SlowRequestThreshold defines the duration above which requests are logged at least as
+	// warnings, regardless of their status code. Zero disables slow request detection.
+	SlowRequestThreshold time.Duration
+	// LoggedHeaders lists request headers added to the log entry as "request-header-<name>" fields.
+	LoggedHeaders []string
+	// Redactor masks sensitive values of logged headers. Defaults to NewRedactor with default options.
+	Redactor Redactor
+	// Format selects the fields and message of the log entries. Defaults to AccessLogFormatDefault.
+	Format AccessLogFormat
+	// MaxLoggedResponseBodyBytes logs up to this many bytes of response bodies in the "response-body"
+	// field. The body is teed while streaming without buffering more, so flushing and downloads keep
+	// working. JSON bodies are redacted, truncated ones are replaced by RedactedValue. Zero disables
+	// body logging.
+	MaxLoggedResponseBodyBytes int
+	// SettingsRegistry allows changing settings at runtime. If set, its settings replace
+	// WarningStatusCodeThreshold and SlowRequestThreshold and additionally apply a minimum level
+	// and sample rate.
+	SettingsRegistry *RequestLoggerSettingsRegistry
+	// Clock measures the request durations. Defaults to the system clock.
+	Clock clock.Clock
 }
 
 func DefaultRequestLoggerMiddlewareOptions() *RequestLoggerMiddlewareOptions {
 	return &RequestLoggerMiddlewareOptions{
 		WarningStatusCodeThreshold: 500,
+		Format:                     AccessLogFormatDefault,
+		ExcludedPaths:              []string{},
+		ExcludedMethods:            []string{},
+		LoggedHeaders:              []string{},
 	}
 }
 
@@ -98,35 +161,139 @@ func NewRequestLoggerMiddleware(opts *RequestLoggerMiddlewareOptions) func(next
 	if opts == nil {
 		opts = DefaultRequestLoggerMiddlewareOptions()
 	}
+	levelFn := opts.LevelFn
+	if levelFn == nil {
+		levelFn = NewStatusCodeLevelFn(opts.WarningStatusCodeThreshold)
+	}
+	redactor := opts.Redactor
+	if redactor == nil {
+		redactor = NewRedactor(nil)
+	}
+	clk := clock.OrSystem(opts.Clock)
 
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
-			ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
-			t1 := time.Now()
+			ww := responsewriter.Wrap(w)
+			var body *responsewriter.CappedBuffer
+			if opts.MaxLoggedResponseBodyBytes > 0 {
+				body = responsewriter.NewCappedBuffer(opts.MaxLoggedResponseBodyBytes)
+				ww.Tee(body)
+			}
+			t1 := clk.Now()
 
 			next.ServeHTTP(ww, req)
 
+			elapsed := clk.Since(t1)
+			settings := RequestLoggerSettings{
+				MinLevel:                   slog.LevelDebug,
+				WarningStatusCodeThreshold: opts.WarningStatusCodeThreshold,
+				SampleRate:                 1,
+				SlowRequestThreshold:       opts.SlowRequestThreshold,
+			}
+			currentLevelFn := levelFn
+			if opts.SettingsRegistry != nil {
+				settings = opts.SettingsRegistry.Settings()
+				if opts.LevelFn == nil {
+					currentLevelFn = NewStatusCodeLevelFn(settings.WarningStatusCodeThreshold)
+				}
+			}
+
+			// Upgraded connections are hijacked, so neither the status nor the duration of the
+			// connection reflect request processing.
+			status := ww.Status()
+			upgraded := header.IsUpgradeRequest(req) && (status == 0 || status == http.StatusSwitchingProtocols)
+			if upgraded {
+				status = http.StatusSwitchingProtocols
+			}
+
+			slow := !upgraded && settings.SlowRequestThreshold > 0 && elapsed > settings.SlowRequestThreshold
+			level := currentLevelFn(status, nil, elapsed)
+			if slow && level < slog.LevelWarn {
+				level = slog.LevelWarn
+			}
+			if level < settings.MinLevel {
+				return
+			}
+
+			// Warnings and errors are always logged
+			if level < slog.LevelWarn && (!shouldLogRequest(req, opts) || !settings.sampled()) {
+				return
+			}
+
 			ctx := req.Context()
 			if logger := logging.FromContext(ctx); logger != nil {
-				duration := time.Since(t1).Milliseconds()
-
-				logger = logger.With(
-					LogFieldRequestMethod, req.Method,
-					LogFieldResponseStatus, ww.Status(),
-					LogFieldURLPath, req.URL.Path,
-					LogFieldUserAgent, req.UserAgent(),
-					LogFieldLogger, "request.incoming",
-					LogFieldEventDuration, duration,
-				)
-				subCtx := logging.ContextWithLogger(ctx, logger)
+				entry := accessLogEntry{
+					req:      req,
+					start:    t1,
+					elapsed:  elapsed,
+					status:   status,
+					bytes:    ww.BytesWritten(),
+					redactor: redactor,
+				}
 
-				if ww.Status() >= opts.WarningStatusCodeThreshold {
-					aulogging.Logger.Ctx(subCtx).Warn().Printf("response %s %s -> %d (%d ms)", req.Method, req.URL.Path, ww.Status(), duration)
-					return
+				var message string
+				switch opts.Format {
+				case AccessLogFormatCommon:
+					logger = logger.With(LogFieldLogger, "request.incoming")
+					message = entry.commonLogLine()
+				case AccessLogFormatCombined:
+					logger = logger.With(LogFieldLogger, "request.incoming")
+					message = entry.combinedLogLine()
+				case AccessLogFormatECS:
+					logger = logger.With(entry.ecsFields()...)
+					message = entry.defaultMessage()
+				default:
+					logger = logger.With(entry.defaultFields()...)
+					message = entry.defaultMessage()
+				}
+				if slow {
+					logger = logger.With(LogFieldSlowRequest, true)
+				}
+				if len(opts.LoggedHeaders) > 0 {
+					logger = logger.With(headerFields(req.Header, opts.LoggedHeaders, redactor)...)
 				}
-				aulogging.Logger.Ctx(subCtx).Info().Printf("response %s %s -> %d (%d ms)", req.Method, req.URL.Path, ww.Status(), duration)
+				if body != nil && !upgraded {
+					logger = logger.With(responseBodyFields(ww.Header(), body, redactor)...)
+				}
+				subCtx := logging.ContextWithLogger(ctx, logger)
+
+				leveledLogger(subCtx, level).Print(message)
 			}
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+func shouldLogRequest(req *http.Request, opts *RequestLoggerMiddlewareOptions) bool {
+	if slices.Contains(opts.ExcludedMethods, req.Method) {
+		return false
+	}
+	for _, pattern := range opts.ExcludedPaths {
+		if matched, _ := path.Match(pattern, req.URL.Path); matched {
+			return false
+		}
+	}
+	return opts.SamplerFn == nil || opts.SamplerFn(req)
+}
+
+func responseBodyFields(responseHeader http.Header, body *responsewriter.CappedBuffer, redactor Redactor) []any {
+	content := body.Bytes()
+	if isJSON(responseHeader.Get(header.ContentType)) {
+		// Truncated documents cannot be parsed, so their sensitive fields cannot be masked
+		if body.Truncated() {
+			content = []byte(RedactedValue)
+		} else {
+			content = redactor.RedactJSON(content)
+		}
+	}
+	fields := []any{LogFieldResponseBody, string(content)}
+	if body.Truncated() {
+		fields = append(fields, LogFieldResponseBodyTruncated, true)
+	}
+	return fields
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
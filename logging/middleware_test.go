@@ -1,11 +1,19 @@
 package logging
 
 import (
+	"bufio"
 	"context"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/Roshick/go-autumn-slog"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -44,6 +52,56 @@ func TestNewContextLoggerMiddleware(t *testing.T) {
 	})
 }
 
+func TestNewLoggerFieldsMiddleware(t *testing.T) {
+	serve := func(extractor func(req *http.Request) []any) []slog.Record {
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/v2/items", nil)
+		req.Header.Set("X-Client-App", "mobile")
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewLoggerFieldsMiddleware(extractor)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.FromContext(r.Context()).Info("handled")
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		return *handler.records
+	}
+
+	t.Run("adds extracted fields to the context logger", func(t *testing.T) {
+		records := serve(func(req *http.Request) []any {
+			return []any{"api-version", "v2", "client-app", req.Header.Get("X-Client-App")}
+		})
+
+		require.Len(t, records, 1)
+		assert.True(t, hasAttr(records[0], "api-version", slog.StringValue("v2")))
+		assert.True(t, hasAttr(records[0], "client-app", slog.StringValue("mobile")))
+	})
+
+	t.Run("keeps the logger without fields", func(t *testing.T) {
+		records := serve(func(*http.Request) []any {
+			return nil
+		})
+
+		require.Len(t, records, 1)
+		assert.Equal(t, 0, records[0].NumAttrs())
+	})
+
+	t.Run("with nil extractor", func(t *testing.T) {
+		records := serve(nil)
+
+		require.Len(t, records, 1)
+	})
+
+	t.Run("without context logger", func(t *testing.T) {
+		handlerCalled := false
+		NewLoggerFieldsMiddleware(func(*http.Request) []any {
+			return []any{"api-version", "v2"}
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.True(t, handlerCalled)
+	})
+}
+
 func TestDefaultContextCancellationLoggerMiddlewareOptions(t *testing.T) {
 	opts := DefaultContextCancellationLoggerMiddlewareOptions()
 
@@ -78,3 +136,340 @@ func TestNewContextCancellationLoggerMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 	})
 }
+
+func TestDefaultRequestLoggerMiddlewareOptions(t *testing.T) {
+	opts := DefaultRequestLoggerMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 500, opts.WarningStatusCodeThreshold)
+	assert.Empty(t, opts.ExcludedPaths)
+	assert.Empty(t, opts.ExcludedMethods)
+	assert.Nil(t, opts.SamplerFn)
+}
+
+func TestNewRequestLoggerMiddleware(t *testing.T) {
+	aulogging.Logger = logging.New()
+
+	serve := func(opts *RequestLoggerMiddlewareOptions, method string, target string, status int) []slog.Record {
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(method, target, nil)
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		return *handler.records
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		records := serve(nil, http.MethodGet, "/items", http.StatusOK)
+
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelInfo, records[0].Level)
+	})
+
+	t.Run("logs server errors as warnings", func(t *testing.T) {
+		records := serve(nil, http.MethodGet, "/items", http.StatusInternalServerError)
+
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelWarn, records[0].Level)
+	})
+
+	t.Run("skips excluded paths", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.ExcludedPaths = []string{"/health/*"}
+
+		assert.Empty(t, serve(opts, http.MethodGet, "/health/live", http.StatusOK))
+		assert.Len(t, serve(opts, http.MethodGet, "/items", http.StatusOK), 1)
+	})
+
+	t.Run("skips excluded methods", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.ExcludedMethods = []string{http.MethodOptions}
+
+		assert.Empty(t, serve(opts, http.MethodOptions, "/items", http.StatusOK))
+	})
+
+	t.Run("skips requests rejected by sampler", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.SamplerFn = NewProbabilitySampler(0)
+
+		assert.Empty(t, serve(opts, http.MethodGet, "/items", http.StatusOK))
+	})
+
+	t.Run("always logs responses at warning threshold", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.ExcludedPaths = []string{"/health/*"}
+		opts.SamplerFn = NewProbabilitySampler(0)
+
+		assert.Len(t, serve(opts, http.MethodGet, "/health/live", http.StatusServiceUnavailable), 1)
+	})
+
+	t.Run("uses custom level function", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.LevelFn = func(status int, _ error, _ time.Duration) slog.Level {
+			if status == http.StatusNotFound {
+				return slog.LevelDebug
+			}
+			return slog.LevelError
+		}
+
+		records := serve(opts, http.MethodGet, "/missing", http.StatusNotFound)
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelDebug, records[0].Level)
+
+		records = serve(opts, http.MethodGet, "/items", http.StatusOK)
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelError, records[0].Level)
+	})
+
+	t.Run("logs slow requests as warnings", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.SlowRequestThreshold = time.Nanosecond
+		opts.SamplerFn = NewProbabilitySampler(0)
+
+		records := serve(opts, http.MethodGet, "/items", http.StatusOK)
+
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelWarn, records[0].Level)
+		assert.True(t, hasAttr(records[0], LogFieldSlowRequest, slog.BoolValue(true)))
+	})
+
+	t.Run("does not mark fast requests as slow", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.SlowRequestThreshold = time.Hour
+
+		records := serve(opts, http.MethodGet, "/items", http.StatusOK)
+
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelInfo, records[0].Level)
+		assert.False(t, hasAttr(records[0], LogFieldSlowRequest, slog.BoolValue(true)))
+	})
+
+	t.Run("applies settings registry changes to running middleware", func(t *testing.T) {
+		registry, err := NewRequestLoggerSettingsRegistry(DefaultRequestLoggerSettings())
+		require.NoError(t, err)
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.SettingsRegistry = registry
+
+		records := serve(opts, http.MethodGet, "/items", http.StatusNotFound)
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelInfo, records[0].Level)
+
+		settings := registry.Settings()
+		settings.WarningStatusCodeThreshold = 400
+		require.NoError(t, registry.Update(settings))
+
+		records = serve(opts, http.MethodGet, "/items", http.StatusNotFound)
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelWarn, records[0].Level)
+
+		settings.SampleRate = 0
+		require.NoError(t, registry.Update(settings))
+
+		assert.Empty(t, serve(opts, http.MethodGet, "/items", http.StatusOK))
+		assert.Len(t, serve(opts, http.MethodGet, "/items", http.StatusNotFound), 1)
+
+		settings.MinLevel = slog.LevelError
+		require.NoError(t, registry.Update(settings))
+
+		assert.Empty(t, serve(opts, http.MethodGet, "/items", http.StatusNotFound))
+	})
+
+	t.Run("logs configured headers with redaction", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.LoggedHeaders = []string{"Authorization", "X-Tenant", "X-Missing"}
+		opts.Redactor = NewRedactor(&RedactorOptions{Headers: []string{"x-tenant"}})
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("X-Tenant", "acme")
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *handler.records, 1)
+		record := (*handler.records)[0]
+		assert.True(t, hasAttr(record, "request-header-authorization", slog.StringValue("Bearer token")))
+		assert.True(t, hasAttr(record, "request-header-x-tenant", slog.StringValue(RedactedValue)))
+		assert.False(t, hasAttr(record, "request-header-x-missing", slog.StringValue("")))
+	})
+
+	t.Run("logs redacted JSON response bodies", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.MaxLoggedResponseBodyBytes = 1024
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"name":"gopher","password":"secret"}`))
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *handler.records, 1)
+		record := (*handler.records)[0]
+		assert.True(t, hasAttr(record, LogFieldResponseBody, slog.StringValue(`{"name":"gopher","password":"xxxxx"}`)))
+		assert.False(t, hasAttr(record, LogFieldResponseBodyTruncated, slog.BoolValue(true)))
+	})
+
+	t.Run("masks truncated JSON response bodies", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.MaxLoggedResponseBodyBytes = 16
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"password":"secret","name":"gopher"}`))
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *handler.records, 1)
+		record := (*handler.records)[0]
+		assert.True(t, hasAttr(record, LogFieldResponseBody, slog.StringValue(RedactedValue)))
+		assert.True(t, hasAttr(record, LogFieldResponseBodyTruncated, slog.BoolValue(true)))
+	})
+
+	t.Run("logs the first bytes of streamed response bodies", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.MaxLoggedResponseBodyBytes = 8
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/download", nil)
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+		rr := httptest.NewRecorder()
+
+		NewRequestLoggerMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			for range 100 {
+				_, _ = w.Write([]byte("chunk\n"))
+				w.(http.Flusher).Flush()
+			}
+		})).ServeHTTP(rr, req)
+
+		assert.True(t, rr.Flushed)
+		assert.Equal(t, 600, rr.Body.Len())
+		require.Len(t, *handler.records, 1)
+		record := (*handler.records)[0]
+		assert.True(t, hasAttr(record, LogFieldResponseBody, slog.StringValue("chunk\nch")))
+		assert.True(t, hasAttr(record, LogFieldResponseBodyTruncated, slog.BoolValue(true)))
+	})
+
+	t.Run("does not log response bodies by default", func(t *testing.T) {
+		handler := newCapturingHandler()
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req = req.WithContext(logging.ContextWithLogger(req.Context(), slog.New(handler)))
+
+		NewRequestLoggerMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("hello"))
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *handler.records, 1)
+		assert.False(t, hasAttr((*handler.records)[0], LogFieldResponseBody, slog.StringValue("hello")))
+	})
+}
+
+func hasAttr(record slog.Record, key string, value slog.Value) bool {
+	found := false
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == key && attr.Value.Equal(value) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+type capturingHandler struct {
+	records *[]slog.Record
+	attrs   []slog.Attr
+}
+
+func newCapturingHandler() *capturingHandler {
+	return &capturingHandler{records: &[]slog.Record{}}
+}
+
+func (h *capturingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *capturingHandler) Handle(_ context.Context, record slog.Record) error {
+	record = record.Clone()
+	record.AddAttrs(h.attrs...)
+	*h.records = append(*h.records, record)
+	return nil
+}
+
+func (h *capturingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &capturingHandler{records: h.records, attrs: append(slices.Clone(h.attrs), attrs...)}
+}
+
+func (h *capturingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func TestNewRequestLoggerMiddleware_WrappedResponseWriter(t *testing.T) {
+	aulogging.Logger = logging.New()
+
+	// serve runs the handler behind the middleware on a real connection, which supports
+	// Hijacker, Flusher and ReaderFrom, and returns the records logged once it completed.
+	serve := func(t *testing.T, opts *RequestLoggerMiddlewareOptions, handlerFn http.HandlerFunc, rawRequest string) (string, []slog.Record) {
+		handler := newCapturingHandler()
+		done := make(chan struct{})
+		wrapped := NewRequestLoggerMiddleware(opts)(handlerFn)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(done)
+			wrapped.ServeHTTP(w, r.WithContext(logging.ContextWithLogger(r.Context(), slog.New(handler))))
+		}))
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = io.WriteString(conn, rawRequest)
+		require.NoError(t, err)
+		statusLine, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+
+		<-done
+		return statusLine, *handler.records
+	}
+
+	t.Run("passes through optional interfaces", func(t *testing.T) {
+		_, records := serve(t, nil, func(w http.ResponseWriter, r *http.Request) {
+			_, isFlusher := w.(http.Flusher)
+			_, isHijacker := w.(http.Hijacker)
+			_, isReaderFrom := w.(io.ReaderFrom)
+			assert.True(t, isFlusher)
+			assert.True(t, isHijacker)
+			assert.True(t, isReaderFrom)
+			w.WriteHeader(http.StatusOK)
+		}, "GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+
+		require.Len(t, records, 1)
+	})
+
+	t.Run("logs hijacked upgrades as switching protocols", func(t *testing.T) {
+		opts := DefaultRequestLoggerMiddlewareOptions()
+		opts.SlowRequestThreshold = time.Nanosecond
+
+		statusLine, records := serve(t, opts, func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+			_ = buf.Flush()
+		}, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+
+		assert.Contains(t, statusLine, "101")
+		require.Len(t, records, 1)
+		assert.Equal(t, slog.LevelInfo, records[0].Level)
+		assert.True(t, hasAttr(records[0], LogFieldResponseStatus, slog.IntValue(http.StatusSwitchingProtocols)))
+		assert.False(t, hasAttr(records[0], LogFieldSlowRequest, slog.BoolValue(true)))
+	})
+}
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+// Redactor masks sensitive values before they are logged. Configure one and share it between the
+// request loggers and the audit middleware, so privacy rules do not drift between layers.
+type Redactor interface {
+	// RedactHeader returns the loggable value of the header
+	RedactHeader(name string, value string) string
+	// RedactURL returns the loggable form of the URL
+	RedactURL(u *url.URL) string
+	// RedactField returns the loggable value of a named value, e.g. a path parameter
+	RedactField(name string, value string) string
+	// RedactJSON masks the values of sensitive fields at any depth of a JSON document
+	RedactJSON(body []byte) []byte
+}
+
+type RedactorOptions struct {
+	// Headers lists headers (case-insensitive) whose values are masked.
+	Headers []string
+	// QueryParameters lists query parameters (case-insensitive) whose values are masked. URL user info
+	// passwords are always masked.
+	QueryParameters []string
+	// Fields lists JSON fields and named values (case-insensitive) whose values are masked.
+	Fields []string
+}
+
+func DefaultRedactorOptions() *RedactorOptions {
+	return &RedactorOptions{
+		Headers: []string{
+			header.Authorization,
+			"Cookie",
+			"Proxy-Authorization",
+			"Set-Cookie",
+		},
+		QueryParameters: []string{
+			"access_token",
+			"api_key",
+			"apikey",
+			"password",
+			"secret",
+			"token",
+		},
+		Fields: []string{
+			"access_token",
+			"client_secret",
+			"password",
+			"refresh_token",
+			"secret",
+			"token",
+		},
+	}
+}
+
+// DefaultRedactor replaces sensitive values by RedactedValue
+type DefaultRedactor struct {
+	opts *RedactorOptions
+}
+
+var _ Redactor = (*DefaultRedactor)(nil)
+
+func NewRedactor(opts *RedactorOptions) *DefaultRedactor {
+	if opts == nil {
+		opts = DefaultRedactorOptions()
+	}
+
+	return &DefaultRedactor{
+		opts: opts,
+	}
+}
+
+func (r *DefaultRedactor) RedactHeader(name string, value string) string {
+	if containsFold(r.opts.Headers, name) {
+		return RedactedValue
+	}
+	return value
+}
+
+func (r *DefaultRedactor) RedactURL(u *url.URL) string {
+	return redactURL(u, r.opts.QueryParameters)
+}
+
+func (r *DefaultRedactor) RedactField(name string, value string) string {
+	if containsFold(r.opts.Fields, name) {
+		return RedactedValue
+	}
+	return value
+}
+
+// RedactJSON replaces documents that cannot be parsed, e.g. malformed or partial ones, by RedactedValue
+func (r *DefaultRedactor) RedactJSON(body []byte) []byte {
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return []byte(RedactedValue)
+	}
+	if !r.redactJSONValue(document) {
+		return body
+	}
+
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return []byte(RedactedValue)
+	}
+	return bytes.TrimSuffix(redacted.Bytes(), []byte("\n"))
+}
+
+// redactJSONValue masks sensitive fields in place and reports whether it changed anything
+func (r *DefaultRedactor) redactJSONValue(value any) bool {
+	changed := false
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, element := range typedValue {
+			if containsFold(r.opts.Fields, key) {
+				typedValue[key] = RedactedValue
+				changed = true
+				continue
+			}
+			changed = r.redactJSONValue(element) || changed
+		}
+	case []any:
+		for _, element := range typedValue {
+			changed = r.redactJSONValue(element) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	mathrand "math/rand/v2"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/clock"
+)
+
+// SamplerFn decides whether a handled request is logged.
+type SamplerFn func(req *http.Request) bool
+
+// NewProbabilitySampler logs the given fraction of requests. Rates <= 0 log nothing, rates >= 1 log everything.
+func NewProbabilitySampler(rate float64) SamplerFn {
+	return func(*http.Request) bool {
+		if rate >= 1 {
+			return true
+		}
+		return rate > 0 && mathrand.Float64() < rate
+	}
+}
+
+// NewRateLimitSampler logs at most perSecond requests within each one-second window.
+func NewRateLimitSampler(perSecond int) SamplerFn {
+	return NewRateLimitSamplerWithClock(perSecond, clock.System())
+}
+
+// NewRateLimitSamplerWithClock is like NewRateLimitSampler but measures the windows with the clock.
+func NewRateLimitSamplerWithClock(perSecond int, c clock.Clock) SamplerFn {
+	var m sync.Mutex
+	var windowStart time.Time
+	var count int
+
+	return func(*http.Request) bool {
+		m.Lock()
+		defer m.Unlock()
+
+		now := c.Now()
+		if now.Sub(windowStart) >= time.Second {
+			windowStart = now
+			count = 0
+		}
+		if count >= perSecond {
+			return false
+		}
+		count++
+		return true
+	}
+}
+
+type RouteSamplingRule struct {
+	// PathPattern is matched against the request URL path, see path.Match.
+	PathPattern string
+	Sampler     SamplerFn
+}
+
+// NewRouteSampler applies the sampler of the first rule matching the request URL path, and the
+// fallback sampler if no rule matches. A nil fallback logs everything.
+func NewRouteSampler(rules []RouteSamplingRule, fallback SamplerFn) SamplerFn {
+	return func(req *http.Request) bool {
+		for _, rule := range rules {
+			if matched, _ := path.Match(rule.PathPattern, req.URL.Path); matched {
+				return rule.Sampler(req)
+			}
+		}
+		if fallback == nil {
+			return true
+		}
+		return fallback(req)
+	}
+}
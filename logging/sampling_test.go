@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProbabilitySampler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	t.Run("rate zero logs nothing", func(t *testing.T) {
+		sampler := NewProbabilitySampler(0)
+		for i := 0; i < 100; i++ {
+			assert.False(t, sampler(req))
+		}
+	})
+
+	t.Run("rate one logs everything", func(t *testing.T) {
+		sampler := NewProbabilitySampler(1)
+		for i := 0; i < 100; i++ {
+			assert.True(t, sampler(req))
+		}
+	})
+}
+
+func TestNewRateLimitSampler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	sampler := NewRateLimitSampler(2)
+
+	assert.True(t, sampler(req))
+	assert.True(t, sampler(req))
+	assert.False(t, sampler(req))
+}
+
+func TestNewRouteSampler(t *testing.T) {
+	never := func(*http.Request) bool { return false }
+
+	t.Run("matching rule decides", func(t *testing.T) {
+		sampler := NewRouteSampler([]RouteSamplingRule{{PathPattern: "/api/*", Sampler: never}}, nil)
+
+		assert.False(t, sampler(httptest.NewRequest(http.MethodGet, "/api/items", nil)))
+		assert.True(t, sampler(httptest.NewRequest(http.MethodGet, "/other", nil)))
+	})
+
+	t.Run("fallback applies without matching rule", func(t *testing.T) {
+		sampler := NewRouteSampler(nil, never)
+
+		assert.False(t, sampler(httptest.NewRequest(http.MethodGet, "/other", nil)))
+	})
+}
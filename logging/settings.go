@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// RequestLoggerSettings holds the request logger settings that can be changed at runtime.
+type RequestLoggerSettings struct {
+	// MinLevel suppresses request logs below the level.
+	MinLevel slog.Level
+	// WarningStatusCodeThreshold replaces the option of the same name.
+	WarningStatusCodeThreshold int
+	// SampleRate is the fraction of requests logged below warn level, see NewProbabilitySampler.
+	SampleRate float64
+	// SlowRequestThreshold replaces the option of the same name.
+	SlowRequestThreshold time.Duration
+}
+
+func DefaultRequestLoggerSettings() RequestLoggerSettings {
+	return RequestLoggerSettings{
+		MinLevel:                   slog.LevelDebug,
+		WarningStatusCodeThreshold: 500,
+		SampleRate:                 1,
+	}
+}
+
+func (s RequestLoggerSettings) Validate() error {
+	var errs []error
+	if s.WarningStatusCodeThreshold < 100 || s.WarningStatusCodeThreshold > 599 {
+		errs = append(errs, fmt.Errorf("warning status code threshold must be a status code, got %d", s.WarningStatusCodeThreshold))
+	}
+	if s.SampleRate < 0 || s.SampleRate > 1 {
+		errs = append(errs, fmt.Errorf("sample rate must be within [0, 1], got %g", s.SampleRate))
+	}
+	if s.SlowRequestThreshold < 0 {
+		errs = append(errs, fmt.Errorf("slow request threshold must not be negative, got %s", s.SlowRequestThreshold))
+	}
+	return errors.Join(errs...)
+}
+
+// RequestLoggerSettingsRegistry shares settings between running request logger middlewares.
+// Updates apply atomically to all subsequent requests.
+type RequestLoggerSettingsRegistry struct {
+	settings atomic.Pointer[RequestLoggerSettings]
+}
+
+func NewRequestLoggerSettingsRegistry(settings RequestLoggerSettings) (*RequestLoggerSettingsRegistry, error) {
+	r := &RequestLoggerSettingsRegistry{}
+	if err := r.Update(settings); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RequestLoggerSettingsRegistry) Settings() RequestLoggerSettings {
+	return *r.settings.Load()
+}
+
+func (r *RequestLoggerSettingsRegistry) Update(settings RequestLoggerSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	r.settings.Store(&settings)
+	return nil
+}
+
+func (s RequestLoggerSettings) sampled() bool {
+	if s.SampleRate >= 1 {
+		return true
+	}
+	return s.SampleRate > 0 && mathrand.Float64() < s.SampleRate
+}
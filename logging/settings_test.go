@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRequestLoggerSettings(t *testing.T) {
+	settings := DefaultRequestLoggerSettings()
+
+	assert.Equal(t, slog.LevelDebug, settings.MinLevel)
+	assert.Equal(t, 500, settings.WarningStatusCodeThreshold)
+	assert.Equal(t, float64(1), settings.SampleRate)
+	assert.NoError(t, settings.Validate())
+}
+
+func TestRequestLoggerSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *RequestLoggerSettings)
+	}{
+		{name: "invalid status code threshold", modify: func(s *RequestLoggerSettings) { s.WarningStatusCodeThreshold = 0 }},
+		{name: "negative sample rate", modify: func(s *RequestLoggerSettings) { s.SampleRate = -0.1 }},
+		{name: "sample rate above one", modify: func(s *RequestLoggerSettings) { s.SampleRate = 1.1 }},
+		{name: "negative slow request threshold", modify: func(s *RequestLoggerSettings) { s.SlowRequestThreshold = -time.Second }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultRequestLoggerSettings()
+			tt.modify(&settings)
+			assert.Error(t, settings.Validate())
+		})
+	}
+}
+
+func TestRequestLoggerSettingsRegistry(t *testing.T) {
+	t.Run("rejects invalid initial settings", func(t *testing.T) {
+		_, err := NewRequestLoggerSettingsRegistry(RequestLoggerSettings{})
+		assert.Error(t, err)
+	})
+
+	t.Run("updates settings", func(t *testing.T) {
+		registry, err := NewRequestLoggerSettingsRegistry(DefaultRequestLoggerSettings())
+		require.NoError(t, err)
+
+		settings := registry.Settings()
+		settings.SampleRate = 0.5
+		require.NoError(t, registry.Update(settings))
+		assert.Equal(t, 0.5, registry.Settings().SampleRate)
+	})
+
+	t.Run("keeps settings on invalid update", func(t *testing.T) {
+		registry, err := NewRequestLoggerSettingsRegistry(DefaultRequestLoggerSettings())
+		require.NoError(t, err)
+
+		settings := registry.Settings()
+		settings.SampleRate = 2
+		assert.Error(t, registry.Update(settings))
+		assert.Equal(t, float64(1), registry.Settings().SampleRate)
+	})
+}
@@ -3,9 +3,13 @@ package logging
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
-	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/clock"
 )
 
 // RequestLoggerTransport //
@@ -13,19 +17,40 @@ import (
 type RequestLoggerTransportOptions struct {
 	// WarningStatusCodeThreshold defines the status code boundary above which
 	// responses are logged as warnings instead of info. Defaults to 500 (5xx errors).
+	// Ignored if LevelFn is set.
 	WarningStatusCodeThreshold int
+	// LevelFn determines the log level of each request. Defaults to NewStatusCodeLevelFn
+	// with the WarningStatusCodeThreshold.
+	LevelFn LevelFn
+	// RedactedQueryParameters lists query parameters (case-insensitive) whose values are masked
+	// in the logged URL. URL user info passwords are always masked.
+	RedactedQueryParameters []string
+	// LoggedHeaders lists request headers added to the log entry as "request-header-<name>" fields.
+	LoggedHeaders []string
+	// RedactedHeaders lists logged request headers whose values are masked.
+	RedactedHeaders []string
+	// Redactor masks sensitive values, replacing RedactedQueryParameters and RedactedHeaders if set.
+	Redactor Redactor
+	// Clock measures the request durations. Defaults to the system clock.
+	Clock clock.Clock
 }
 
 var _ http.RoundTripper = (*RequestLoggerTransport)(nil)
 
 type RequestLoggerTransport struct {
-	base http.RoundTripper
-	opts *RequestLoggerTransportOptions
+	base     http.RoundTripper
+	opts     *RequestLoggerTransportOptions
+	redactor Redactor
+	clock    clock.Clock
 }
 
 func DefaultRequestLoggerTransportOptions() *RequestLoggerTransportOptions {
+	redactorOpts := DefaultRedactorOptions()
 	return &RequestLoggerTransportOptions{
 		WarningStatusCodeThreshold: 500,
+		RedactedQueryParameters:    redactorOpts.QueryParameters,
+		LoggedHeaders:              []string{},
+		RedactedHeaders:            redactorOpts.Headers,
 	}
 }
 
@@ -37,29 +62,97 @@ func NewRequestLoggerTransport(rt http.RoundTripper, opts *RequestLoggerTranspor
 		opts = DefaultRequestLoggerTransportOptions()
 	}
 
+	redactor := opts.Redactor
+	if redactor == nil {
+		redactor = NewRedactor(&RedactorOptions{
+			Headers:         opts.RedactedHeaders,
+			QueryParameters: opts.RedactedQueryParameters,
+			Fields:          DefaultRedactorOptions().Fields,
+		})
+	}
+
 	return &RequestLoggerTransport{
-		base: rt,
-		opts: opts,
+		base:     rt,
+		opts:     opts,
+		redactor: redactor,
+		clock:    clock.OrSystem(opts.Clock),
 	}
 }
 
 func (t *RequestLoggerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	startTime := time.Now()
+	startTime := t.clock.Now()
 	res, err := t.base.RoundTrip(req)
 	statusCode := 0
 	if res != nil {
 		statusCode = res.StatusCode
 	}
 
-	t.logResponse(req.Context(), req.Method, req.URL.String(), statusCode, err, startTime)
+	ctx := req.Context()
+	if len(t.opts.LoggedHeaders) > 0 {
+		if logger := logging.FromContext(ctx); logger != nil {
+			ctx = logging.ContextWithLogger(ctx, logger.With(headerFields(req.Header, t.opts.LoggedHeaders, t.redactor)...))
+		}
+	}
+	t.logResponse(ctx, req.Method, t.redactor.RedactURL(req.URL), statusCode, err, startTime)
 	return res, err
 }
 
+func (t *RequestLoggerTransport) Describe() (string, string) {
+	return "logging", ""
+}
+
+func (t *RequestLoggerTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// headerFields returns the logged headers present in h as redacted log fields
+func headerFields(h http.Header, loggedHeaders []string, redactor Redactor) []any {
+	fields := make([]any, 0, 2*len(loggedHeaders))
+	for _, name := range loggedHeaders {
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		value := redactor.RedactHeader(name, strings.Join(values, ", "))
+		fields = append(fields, LogFieldRequestHeaderPrefix+strings.ToLower(name), value)
+	}
+	return fields
+}
+
 func (t *RequestLoggerTransport) logResponse(ctx context.Context, method string, requestUrl string, responseStatusCode int, err error, startTime time.Time) {
-	reqDuration := time.Now().Sub(startTime).Milliseconds()
-	if err != nil || responseStatusCode >= t.opts.WarningStatusCodeThreshold {
-		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("request %s %s -> %d (%d ms)", method, requestUrl, responseStatusCode, reqDuration)
-		return
+	elapsed := t.clock.Since(startTime)
+	levelFn := t.opts.LevelFn
+	if levelFn == nil {
+		levelFn = NewStatusCodeLevelFn(t.opts.WarningStatusCodeThreshold)
 	}
-	aulogging.Logger.Ctx(ctx).Info().Printf("request %s %s -> %d (%d ms)", method, requestUrl, responseStatusCode, reqDuration)
+	level := levelFn(responseStatusCode, err, elapsed)
+	leveledLogger(ctx, level).WithErr(err).Printf("request %s %s -> %d (%d ms)", method, requestUrl, responseStatusCode, elapsed.Milliseconds())
+}
+
+// redactURL masks the values of the given query parameters as well as any user info password.
+func redactURL(u *url.URL, params []string) string {
+	redacted := *u
+	if redacted.RawQuery != "" && len(params) > 0 {
+		query := redacted.Query()
+		changed := false
+		for key, values := range query {
+			if !containsFold(params, key) {
+				continue
+			}
+			for i := range values {
+				values[i] = RedactedValue
+			}
+			changed = true
+		}
+		if changed {
+			redacted.RawQuery = query.Encode()
+		}
+	}
+	return redacted.Redacted()
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(candidate string) bool {
+		return strings.EqualFold(candidate, value)
+	})
 }
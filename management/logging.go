@@ -0,0 +1,71 @@
+package management
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/go-chi/render"
+)
+
+type requestLoggerSettingsBody struct {
+	MinLevel                   slog.Level `json:"minLevel"`
+	WarningStatusCodeThreshold int        `json:"warningStatusCodeThreshold"`
+	SampleRate                 float64    `json:"sampleRate"`
+	SlowRequestThreshold       string     `json:"slowRequestThreshold"`
+}
+
+func newRequestLoggerSettingsBody(settings logging.RequestLoggerSettings) requestLoggerSettingsBody {
+	return requestLoggerSettingsBody{
+		MinLevel:                   settings.MinLevel,
+		WarningStatusCodeThreshold: settings.WarningStatusCodeThreshold,
+		SampleRate:                 settings.SampleRate,
+		SlowRequestThreshold:       settings.SlowRequestThreshold.String(),
+	}
+}
+
+// NewRequestLoggerSettingsHandler returns the current settings on GET and applies the fields sent
+// on PATCH, e.g. {"minLevel":"WARN","sampleRate":0.1,"slowRequestThreshold":"2s"}.
+func NewRequestLoggerSettingsHandler(registry *logging.RequestLoggerSettingsRegistry) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		settings := registry.Settings()
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPatch:
+			body := newRequestLoggerSettingsBody(settings)
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				renderSettingsError(w, req, weberrors.NewBadRequestResponse("Invalid request body"))
+				return
+			}
+			slowRequestThreshold, err := time.ParseDuration(body.SlowRequestThreshold)
+			if err != nil {
+				renderSettingsError(w, req, weberrors.NewBadRequestResponse("Invalid slow request threshold"))
+				return
+			}
+			settings = logging.RequestLoggerSettings{
+				MinLevel:                   body.MinLevel,
+				WarningStatusCodeThreshold: body.WarningStatusCodeThreshold,
+				SampleRate:                 body.SampleRate,
+				SlowRequestThreshold:       slowRequestThreshold,
+			}
+			if err = registry.Update(settings); err != nil {
+				renderSettingsError(w, req, weberrors.NewUnprocessableEntityResponse(err.Error()))
+				return
+			}
+		default:
+			renderSettingsError(w, req, weberrors.NewMethodNotAllowedResponse(""))
+			return
+		}
+		render.JSON(w, req, newRequestLoggerSettingsBody(settings))
+	}
+	return http.HandlerFunc(fn)
+}
+
+func renderSettingsError(w http.ResponseWriter, req *http.Request, response render.Renderer) {
+	if err := weberrors.Render(w, req, response); err != nil {
+		panic(err)
+	}
+}
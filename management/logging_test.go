@@ -0,0 +1,84 @@
+package management
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestLoggerSettingsHandler(t *testing.T) {
+	newRegistry := func(t *testing.T) *logging.RequestLoggerSettingsRegistry {
+		registry, err := logging.NewRequestLoggerSettingsRegistry(logging.DefaultRequestLoggerSettings())
+		require.NoError(t, err)
+		return registry
+	}
+
+	send := func(handler http.Handler, method string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/logging/request", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("get returns current settings", func(t *testing.T) {
+		rr := send(NewRequestLoggerSettingsHandler(newRegistry(t)), http.MethodGet, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "DEBUG", body["minLevel"])
+		assert.Equal(t, float64(500), body["warningStatusCodeThreshold"])
+		assert.Equal(t, float64(1), body["sampleRate"])
+		assert.Equal(t, "0s", body["slowRequestThreshold"])
+	})
+
+	t.Run("patch applies sent fields", func(t *testing.T) {
+		registry := newRegistry(t)
+		rr := send(NewRequestLoggerSettingsHandler(registry), http.MethodPatch, `{"minLevel":"WARN","sampleRate":0.1,"slowRequestThreshold":"2s"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		settings := registry.Settings()
+		assert.Equal(t, slog.LevelWarn, settings.MinLevel)
+		assert.Equal(t, 0.1, settings.SampleRate)
+		assert.Equal(t, 2*time.Second, settings.SlowRequestThreshold)
+		assert.Equal(t, 500, settings.WarningStatusCodeThreshold)
+	})
+
+	t.Run("patch rejects malformed body", func(t *testing.T) {
+		rr := send(NewRequestLoggerSettingsHandler(newRegistry(t)), http.MethodPatch, `{`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("patch rejects invalid duration", func(t *testing.T) {
+		rr := send(NewRequestLoggerSettingsHandler(newRegistry(t)), http.MethodPatch, `{"slowRequestThreshold":"soon"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("patch rejects invalid settings", func(t *testing.T) {
+		registry := newRegistry(t)
+		rr := send(NewRequestLoggerSettingsHandler(registry), http.MethodPatch, `{"sampleRate":3}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Equal(t, float64(1), registry.Settings().SampleRate)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		rr := send(NewRequestLoggerSettingsHandler(newRegistry(t)), http.MethodDelete, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	})
+
+	t.Run("mounted by router", func(t *testing.T) {
+		opts := newTestRouterOptions()
+		opts.RequestLoggerSettings = newRegistry(t)
+
+		rr := serve(t, NewRouter(opts), "/logging/request")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,162 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type RouterOptions struct {
+	// Authorization protects all management endpoints. Defaults to rejecting every request, so
+	// callers have to configure who may access them.
+	Authorization *auth.AuthorizationMiddlewareOptions
+	// Version is reported by the info endpoint. Defaults to the main module version of the build.
+	Version string
+	// Config is dumped by the env endpoint, with values of keys matching RedactedKeys replaced.
+	// Nil disables the endpoint.
+	Config any
+	// RedactedKeys lists case-insensitive substrings of config keys whose values are redacted.
+	RedactedKeys []string
+	// EnablePprof mounts the net/http/pprof handlers below /debug.
+	EnablePprof bool
+	// MetricsHandler is mounted at /metrics if set, e.g. a Prometheus handler.
+	MetricsHandler http.Handler
+	// RequestLoggerSettings is exposed at /logging/request if set, see NewRequestLoggerSettingsHandler.
+	RequestLoggerSettings *logging.RequestLoggerSettingsRegistry
+}
+
+func DefaultRouterOptions() *RouterOptions {
+	return &RouterOptions{
+		Authorization: auth.DefaultAuthorizationMiddlewareOptions(),
+		RedactedKeys:  []string{"password", "secret", "token", "key", "credential", "authorization"},
+		EnablePprof:   true,
+	}
+}
+
+// NewRouter returns a router serving operational endpoints, intended to be served on a separate
+// port. Further endpoints can be added to the returned router.
+func NewRouter(opts *RouterOptions) chi.Router {
+	if opts == nil {
+		opts = DefaultRouterOptions()
+	}
+
+	r := chi.NewRouter()
+	r.Use(auth.NewAuthorizationMiddleware(opts.Authorization))
+	r.Use(middleware.NoCache)
+
+	r.Get("/info", newInfoHandler(opts.Version))
+	if opts.Config != nil {
+		r.Get("/env", newEnvHandler(opts.Config, opts.RedactedKeys))
+	}
+	if opts.EnablePprof {
+		r.Mount("/debug", middleware.Profiler())
+	}
+	if opts.MetricsHandler != nil {
+		r.Handle("/metrics", opts.MetricsHandler)
+	}
+	if opts.RequestLoggerSettings != nil {
+		r.Handle("/logging/request", NewRequestLoggerSettingsHandler(opts.RequestLoggerSettings))
+	}
+	return r
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Module    string `json:"module"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{}
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Version = buildInfo.Main.Version
+	info.Module = buildInfo.Main.Path
+	info.GoVersion = buildInfo.GoVersion
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func newInfoHandler(version string) http.HandlerFunc {
+	info := ReadBuildInfo()
+	if version != "" {
+		info.Version = version
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		render.JSON(w, req, info)
+	}
+}
+
+func newEnvHandler(config any, redactedKeys []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		sanitized, err := sanitize(config, redactedKeys)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, req, sanitized)
+	}
+}
+
+// sanitize converts the value into its generic JSON representation and redacts the values of
+// all object keys containing one of the redacted keys.
+func sanitize(v any, redactedKeys []string) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err = json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return redact(generic, redactedKeys), nil
+}
+
+func redact(v any, redactedKeys []string) any {
+	switch typed := v.(type) {
+	case map[string]any:
+		for key, value := range typed {
+			if isRedactedKey(key, redactedKeys) {
+				typed[key] = logging.RedactedValue
+			} else {
+				typed[key] = redact(value, redactedKeys)
+			}
+		}
+	case []any:
+		for i, value := range typed {
+			typed[i] = redact(value, redactedKeys)
+		}
+	}
+	return v
+}
+
+func isRedactedKey(key string, redactedKeys []string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, redactedKey := range redactedKeys {
+		if strings.Contains(lowerKey, strings.ToLower(redactedKey)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func allowAll(*http.Request) bool {
+	return true
+}
+
+func newTestRouterOptions() *RouterOptions {
+	opts := DefaultRouterOptions()
+	opts.Authorization = &auth.AuthorizationMiddlewareOptions{
+		AuthorizationFns: []auth.AuthorizationFn{allowAll},
+	}
+	return opts
+}
+
+func serve(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestDefaultRouterOptions(t *testing.T) {
+	opts := DefaultRouterOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Authorization)
+	assert.True(t, opts.EnablePprof)
+	assert.Contains(t, opts.RedactedKeys, "password")
+}
+
+func TestNewRouter(t *testing.T) {
+	t.Run("with nil options rejects all requests", func(t *testing.T) {
+		rr := serve(t, NewRouter(nil), "/info")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("info", func(t *testing.T) {
+		opts := newTestRouterOptions()
+		opts.Version = "1.2.3"
+
+		rr := serve(t, NewRouter(opts), "/info")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var info BuildInfo
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+		assert.Equal(t, "1.2.3", info.Version)
+		assert.NotEmpty(t, info.GoVersion)
+	})
+
+	t.Run("env redacts sensitive keys", func(t *testing.T) {
+		opts := newTestRouterOptions()
+		opts.Config = struct {
+			Port     int
+			Database struct {
+				User     string
+				Password string
+			}
+			APIKeys []string
+		}{
+			Port: 8080,
+			Database: struct {
+				User     string
+				Password string
+			}{User: "app", Password: "hunter2"},
+			APIKeys: []string{"abc"},
+		}
+
+		rr := serve(t, NewRouter(opts), "/env")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var env map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &env))
+		assert.Equal(t, float64(8080), env["Port"])
+		assert.Equal(t, "app", env["Database"].(map[string]any)["User"])
+		assert.Equal(t, logging.RedactedValue, env["Database"].(map[string]any)["Password"])
+		assert.Equal(t, logging.RedactedValue, env["APIKeys"])
+		assert.NotContains(t, rr.Body.String(), "hunter2")
+	})
+
+	t.Run("env disabled without config", func(t *testing.T) {
+		rr := serve(t, NewRouter(newTestRouterOptions()), "/env")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		rr := serve(t, NewRouter(newTestRouterOptions()), "/debug/pprof/cmdline")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		opts := newTestRouterOptions()
+		opts.EnablePprof = false
+		rr = serve(t, NewRouter(opts), "/debug/pprof/cmdline")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		rr := serve(t, NewRouter(newTestRouterOptions()), "/metrics")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		opts := newTestRouterOptions()
+		opts.MetricsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("# metrics"))
+		})
+		rr = serve(t, NewRouter(opts), "/metrics")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "# metrics", rr.Body.String())
+	})
+}
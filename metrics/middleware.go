@@ -1,33 +1,61 @@
 package metrics
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/internal/instruments"
+	"github.com/Roshick/go-autumn-web/responsewriter"
 	aulogging "github.com/StephanHCB/go-autumn-logging"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RequestMetricsMiddleware //
 
-type RequestMetricsMiddlewareOptions struct{}
+type RequestMetricsMiddlewareOptions struct {
+	// RoutePatternFn extracts the matched route pattern after the request was handled.
+	// Defaults to RoutePattern, which supports chi and net/http ServeMux.
+	RoutePatternFn func(req *http.Request) string
+	// MaxRoutes caps the number of distinct http.route values recorded. Further routes are recorded
+	// as OverflowRoute. Zero disables the cap. Defaults to 500.
+	MaxRoutes int
+	// OverflowRoute is recorded for routes beyond MaxRoutes. Defaults to "other".
+	OverflowRoute string
+	// UnmatchedRoute is recorded for requests that did not match any route. Defaults to "unmatched".
+	UnmatchedRoute string
+	// Exemplars links recorded durations to the sampled trace of the request, so dashboards can jump
+	// from a latency spike to example traces. The span must be started by a middleware running before
+	// this one, e.g. otelhttp, and the meter provider decides which exemplars are kept. Defaults to true.
+	Exemplars bool
+}
 
 func DefaultRequestMetricsMiddlewareOptions() *RequestMetricsMiddlewareOptions {
-	return &RequestMetricsMiddlewareOptions{}
+	return &RequestMetricsMiddlewareOptions{
+		RoutePatternFn: RoutePattern,
+		MaxRoutes:      500,
+		OverflowRoute:  "other",
+		UnmatchedRoute: "unmatched",
+		Exemplars:      true,
+	}
 }
 
+// NewRequestMetricsMiddleware records the duration of handled requests. It may be instantiated several
+// times, e.g. per route group, since all instances record into the same instrument.
 func NewRequestMetricsMiddleware(opts *RequestMetricsMiddlewareOptions) func(next http.Handler) http.Handler {
 	if opts == nil {
 		opts = DefaultRequestMetricsMiddlewareOptions()
 	}
 
-	meter := otel.GetMeterProvider().Meter("server")
-	httpServerReqDuration, err := meter.Float64Histogram(
+	httpServerReqDuration, err := instruments.Float64Histogram(otel.GetMeterProvider(), "server",
 		"http.server.request.duration",
 		metric.WithDescription("Duration of HTTP server requests in seconds, partitioned by status code, method, and route."),
 	)
@@ -37,24 +65,102 @@ func NewRequestMetricsMiddleware(opts *RequestMetricsMiddlewareOptions) func(nex
 			return next
 		}
 	}
+	routePatternFn := opts.RoutePatternFn
+	if routePatternFn == nil {
+		routePatternFn = RoutePattern
+	}
+	routes := newRouteGuard(opts)
 
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
 			start := time.Now()
-			ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+			ww := responsewriter.Wrap(w)
 			next.ServeHTTP(ww, req)
 
-			routeCtx := chi.RouteContext(req.Context())
-			routePattern := strings.Join(routeCtx.RoutePatterns, "")
-			routePattern = strings.Replace(routePattern, "/*/", "/", -1)
+			routePattern := routePatternFn(req)
+			status := ww.Status()
+			if status == 0 && header.IsUpgradeRequest(req) {
+				// Hijacked connections write their status directly to the connection.
+				status = http.StatusSwitchingProtocols
+			}
 
+			recordCtx := req.Context()
+			if !opts.Exemplars {
+				recordCtx = trace.ContextWithSpanContext(recordCtx, trace.SpanContext{})
+			}
 			duration := float64(time.Since(start).Microseconds()) / 1000000
-			httpServerReqDuration.Record(req.Context(), duration, metric.WithAttributes(
+			httpServerReqDuration.Record(recordCtx, duration, metric.WithAttributes(
 				attribute.String("http.request.method", req.Method),
-				attribute.Int("http.response.status_code", ww.Status()),
-				attribute.String("http.route", routePattern),
+				attribute.Int("http.response.status_code", status),
+				attribute.String("http.route", routes.normalize(routePattern)),
+				attribute.String("network.protocol.name", "http"),
+				attribute.String("network.protocol.version", ProtocolVersion(req)),
 			))
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+// RoutePattern returns the route pattern matched by chi, falling back to the pattern matched by
+// net/http ServeMux without its method prefix.
+func RoutePattern(req *http.Request) string {
+	if routeCtx := chi.RouteContext(req.Context()); routeCtx != nil {
+		routePattern := strings.Join(routeCtx.RoutePatterns, "")
+		return strings.Replace(routePattern, "/*/", "/", -1)
+	}
+	if _, routePattern, ok := strings.Cut(req.Pattern, " "); ok {
+		return strings.TrimLeft(routePattern, " ")
+	}
+	return req.Pattern
+}
+
+// ProtocolVersion returns the HTTP version of the request as recorded by OTel semantic conventions,
+// e.g. "1.1" or "2".
+func ProtocolVersion(req *http.Request) string {
+	if req.ProtoMajor >= 2 {
+		return strconv.Itoa(req.ProtoMajor)
+	}
+	return fmt.Sprintf("%d.%d", req.ProtoMajor, req.ProtoMinor)
+}
+
+// routeGuard bounds the cardinality of the http.route attribute.
+type routeGuard struct {
+	opts *RequestMetricsMiddlewareOptions
+
+	mu   sync.RWMutex
+	seen map[string]struct{}
+}
+
+func newRouteGuard(opts *RequestMetricsMiddlewareOptions) *routeGuard {
+	return &routeGuard{
+		opts: opts,
+		seen: make(map[string]struct{}),
+	}
+}
+
+func (g *routeGuard) normalize(route string) string {
+	if route == "" {
+		return g.opts.UnmatchedRoute
+	}
+	if g.opts.MaxRoutes <= 0 {
+		return route
+	}
+
+	g.mu.RLock()
+	_, ok := g.seen[route]
+	g.mu.RUnlock()
+	if ok {
+		return route
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok = g.seen[route]; ok {
+		return route
+	}
+	if len(g.seen) >= g.opts.MaxRoutes {
+		return g.opts.OverflowRoute
+	}
+	g.seen[route] = struct{}{}
+	return route
+}
@@ -1,6 +1,11 @@
 package metrics
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -8,11 +13,21 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestDefaultRequestMetricsMiddlewareOptions(t *testing.T) {
 	opts := DefaultRequestMetricsMiddlewareOptions()
+
 	require.NotNil(t, opts)
+	assert.NotNil(t, opts.RoutePatternFn)
+	assert.Equal(t, 500, opts.MaxRoutes)
+	assert.Equal(t, "other", opts.OverflowRoute)
+	assert.Equal(t, "unmatched", opts.UnmatchedRoute)
+	assert.True(t, opts.Exemplars)
 }
 
 func TestNewRequestMetricsMiddleware(t *testing.T) {
@@ -103,3 +118,250 @@ func TestNewRequestMetricsMiddleware(t *testing.T) {
 		}
 	})
 }
+
+func TestRouteGuard_Normalize(t *testing.T) {
+	t.Run("normalizes unmatched routes", func(t *testing.T) {
+		guard := newRouteGuard(DefaultRequestMetricsMiddlewareOptions())
+
+		assert.Equal(t, "unmatched", guard.normalize(""))
+	})
+
+	t.Run("caps distinct routes", func(t *testing.T) {
+		opts := DefaultRequestMetricsMiddlewareOptions()
+		opts.MaxRoutes = 2
+		guard := newRouteGuard(opts)
+
+		assert.Equal(t, "/a", guard.normalize("/a"))
+		assert.Equal(t, "/b", guard.normalize("/b"))
+		assert.Equal(t, "other", guard.normalize("/c"))
+		assert.Equal(t, "/a", guard.normalize("/a"))
+	})
+
+	t.Run("zero disables cap", func(t *testing.T) {
+		opts := DefaultRequestMetricsMiddlewareOptions()
+		opts.MaxRoutes = 0
+		guard := newRouteGuard(opts)
+
+		for i := 0; i < 1000; i++ {
+			route := fmt.Sprintf("/items/%d", i)
+			assert.Equal(t, route, guard.normalize(route))
+		}
+	})
+}
+
+func TestRoutePattern(t *testing.T) {
+	capture := func(router http.Handler, target string) string {
+		var routePattern string
+		capturing := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req)
+				routePattern = RoutePattern(req)
+			})
+		}
+		capturing(router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		return routePattern
+	}
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	t.Run("chi router", func(t *testing.T) {
+		r := chi.NewRouter()
+		var routePattern string
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req)
+				routePattern = RoutePattern(req)
+			})
+		})
+		r.Get("/items/{id}", noop)
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		assert.Equal(t, "/items/{id}", routePattern)
+	})
+
+	t.Run("net/http ServeMux", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /items/{id}", noop)
+		mux.HandleFunc("/other", noop)
+
+		assert.Equal(t, "/items/{id}", capture(mux, "/items/1"))
+		assert.Equal(t, "/other", capture(mux, "/other"))
+	})
+
+	t.Run("without router", func(t *testing.T) {
+		assert.Empty(t, capture(http.HandlerFunc(noop), "/items/1"))
+	})
+}
+
+func TestNewRequestMetricsMiddleware_WithoutChi(t *testing.T) {
+	t.Run("works with net/http ServeMux", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		rr := httptest.NewRecorder()
+
+		assert.NotPanics(t, func() {
+			NewRequestMetricsMiddleware(nil)(mux).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test", nil))
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("uses custom route pattern function", func(t *testing.T) {
+		called := false
+		opts := DefaultRequestMetricsMiddlewareOptions()
+		opts.RoutePatternFn = func(req *http.Request) string {
+			called = true
+			return "/custom"
+		}
+
+		NewRequestMetricsMiddleware(opts)(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.True(t, called)
+	})
+}
+
+func TestNewRequestMetricsMiddleware_Upgrade(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	done := make(chan struct{})
+	wrapped := NewRequestMetricsMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !assert.True(t, ok) {
+			return
+		}
+		conn, buf, err := hijacker.Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		_ = buf.Flush()
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		wrapped.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+	require.NoError(t, err)
+	statusLine, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, statusLine, "101")
+	<-done
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, histogram.DataPoints, 1)
+	status, ok := histogram.DataPoints[0].Attributes.Value("http.response.status_code")
+	require.True(t, ok)
+	assert.Equal(t, int64(http.StatusSwitchingProtocols), status.AsInt64())
+}
+
+func TestNewRequestMetricsMiddleware_SharedInstruments(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	strictOpts := DefaultRequestMetricsMiddlewareOptions()
+	strictOpts.MaxRoutes = 1
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(NewRequestMetricsMiddleware(nil))
+		r.Get("/items", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(NewRequestMetricsMiddleware(strictOpts))
+		r.Get("/auth", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	for _, path := range []string{"/items", "/auth"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	assert.Len(t, histogram.DataPoints, 2)
+}
+
+func TestNewRequestMetricsMiddleware_Exemplars(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	record := func(t *testing.T, opts *RequestMetricsMiddlewareOptions) metricdata.HistogramDataPoint[float64] {
+		reader := sdkmetric.NewManualReader()
+		previous := otel.GetMeterProvider()
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+		defer otel.SetMeterProvider(previous)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(trace.ContextWithSpanContext(req.Context(), spanCtx))
+		NewRequestMetricsMiddleware(opts)(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+		histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+		require.True(t, ok)
+		require.Len(t, histogram.DataPoints, 1)
+		return histogram.DataPoints[0]
+	}
+
+	t.Run("links the sampled trace", func(t *testing.T) {
+		dataPoint := record(t, nil)
+
+		require.Len(t, dataPoint.Exemplars, 1)
+		assert.Equal(t, spanCtx.TraceID().String(), trace.TraceID(dataPoint.Exemplars[0].TraceID).String())
+		assert.Equal(t, spanCtx.SpanID().String(), trace.SpanID(dataPoint.Exemplars[0].SpanID).String())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		opts := DefaultRequestMetricsMiddlewareOptions()
+		opts.Exemplars = false
+
+		dataPoint := record(t, opts)
+
+		assert.Empty(t, dataPoint.Exemplars)
+	})
+}
+
+func TestProtocolVersion(t *testing.T) {
+	tests := []struct {
+		proto    string
+		major    int
+		minor    int
+		expected string
+	}{
+		{"HTTP/1.0", 1, 0, "1.0"},
+		{"HTTP/1.1", 1, 1, "1.1"},
+		{"HTTP/2.0", 2, 0, "2"},
+		{"HTTP/3.0", 3, 0, "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Proto, req.ProtoMajor, req.ProtoMinor = tt.proto, tt.major, tt.minor
+
+			assert.Equal(t, tt.expected, ProtocolVersion(req))
+		})
+	}
+}
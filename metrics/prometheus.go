@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+)
+
+type PrometheusHandlerOptions struct {
+	// Registry receives the exported metrics. Defaults to a new registry including the Go runtime
+	// and process collectors.
+	Registry *prometheus.Registry
+	// SetGlobalMeterProvider installs the exporting meter provider as the global otel meter
+	// provider, which the middlewares and transports of this module use. Defaults to true.
+	SetGlobalMeterProvider bool
+	// Exemplars records exemplars of sampled traces and exposes them to scrapers negotiating the
+	// OpenMetrics format. Defaults to true.
+	Exemplars bool
+}
+
+func DefaultPrometheusHandlerOptions() *PrometheusHandlerOptions {
+	return &PrometheusHandlerOptions{
+		SetGlobalMeterProvider: true,
+		Exemplars:              true,
+	}
+}
+
+// NewPrometheusHandler exports otel metrics in the Prometheus format, for services scraped
+// without an otel collector. The returned handler is typically mounted at /metrics.
+func NewPrometheusHandler(opts *PrometheusHandlerOptions) (http.Handler, *sdkmetric.MeterProvider, error) {
+	if opts == nil {
+		opts = DefaultPrometheusHandlerOptions()
+	}
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, err
+	}
+	exemplarFilter := exemplar.AlwaysOffFilter
+	if opts.Exemplars {
+		exemplarFilter = exemplar.TraceBasedFilter
+	}
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter), sdkmetric.WithExemplarFilter(exemplarFilter))
+	if opts.SetGlobalMeterProvider {
+		otel.SetMeterProvider(provider)
+	}
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: opts.Exemplars}), provider, nil
+}
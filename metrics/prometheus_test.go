@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestDefaultPrometheusHandlerOptions(t *testing.T) {
+	opts := DefaultPrometheusHandlerOptions()
+
+	require.NotNil(t, opts)
+	assert.Nil(t, opts.Registry)
+	assert.True(t, opts.SetGlobalMeterProvider)
+	assert.True(t, opts.Exemplars)
+}
+
+func TestNewPrometheusHandler(t *testing.T) {
+	scrape := func(t *testing.T, handler http.Handler) string {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr.Body.String()
+	}
+
+	t.Run("exports metrics of the returned provider", func(t *testing.T) {
+		opts := DefaultPrometheusHandlerOptions()
+		opts.Registry = prometheus.NewRegistry()
+		opts.SetGlobalMeterProvider = false
+
+		handler, provider, err := NewPrometheusHandler(opts)
+		require.NoError(t, err)
+		defer func() { _ = provider.Shutdown(context.Background()) }()
+
+		counter, err := provider.Meter("test").Int64Counter("test.requests.total")
+		require.NoError(t, err)
+		counter.Add(context.Background(), 3)
+
+		body := scrape(t, handler)
+		assert.Contains(t, body, "test_requests_total")
+		assert.NotContains(t, body, "go_goroutines")
+	})
+
+	t.Run("exposes exemplars in the OpenMetrics format", func(t *testing.T) {
+		opts := DefaultPrometheusHandlerOptions()
+		opts.Registry = prometheus.NewRegistry()
+		opts.SetGlobalMeterProvider = false
+
+		handler, provider, err := NewPrometheusHandler(opts)
+		require.NoError(t, err)
+		defer func() { _ = provider.Shutdown(context.Background()) }()
+
+		histogram, err := provider.Meter("test").Float64Histogram("test.duration")
+		require.NoError(t, err)
+		traceID := trace.TraceID{0x0a, 0x0b}
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     trace.SpanID{0x0c},
+			TraceFlags: trace.FlagsSampled,
+		}))
+		histogram.Record(ctx, 0.2)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), traceID.String())
+	})
+
+	t.Run("with nil options includes runtime collectors", func(t *testing.T) {
+		handler, provider, err := NewPrometheusHandler(nil)
+		require.NoError(t, err)
+		defer func() { _ = provider.Shutdown(context.Background()) }()
+
+		assert.Contains(t, scrape(t, handler), "go_goroutines")
+	})
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Roshick/go-autumn-web/internal/instruments"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -13,10 +17,56 @@ import (
 
 // RequestMetricsTransport //
 
-type RequestMetricsTransportOptions struct{}
+type RequestMetricsTransportOptions struct {
+	// URLTemplateFn returns the template of the request URL, e.g. "/users/{id}", which is recorded as
+	// url.template attribute to group metrics by endpoint. Empty templates are not recorded. Defaults
+	// to nil, which records no templates, as raw URLs would explode the cardinality.
+	URLTemplateFn func(req *http.Request) string
+	// DurationBuckets are the bucket boundaries of the request duration histogram in seconds. Defaults
+	// to the boundaries recommended by the OpenTelemetry semantic conventions. Transports with the same
+	// client name share their instruments, so the boundaries of the first one apply.
+	DurationBuckets []float64
+}
 
 func DefaultRequestMetricsTransportOptions() *RequestMetricsTransportOptions {
-	return &RequestMetricsTransportOptions{}
+	return &RequestMetricsTransportOptions{
+		DurationBuckets: []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10},
+	}
+}
+
+// NewStaticURLTemplateFn returns a URLTemplateFn matching the request path against the templates in
+// order. Segments in braces match any single path segment, e.g. "/users/{id}/orders".
+func NewStaticURLTemplateFn(templates ...string) func(req *http.Request) string {
+	splitTemplates := make([][]string, 0, len(templates))
+	for _, template := range templates {
+		splitTemplates = append(splitTemplates, strings.Split(strings.Trim(template, "/"), "/"))
+	}
+
+	return func(req *http.Request) string {
+		segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		for i, templateSegments := range splitTemplates {
+			if matchesURLTemplate(segments, templateSegments) {
+				return templates[i]
+			}
+		}
+		return ""
+	}
+}
+
+func matchesURLTemplate(segments []string, templateSegments []string) bool {
+	if len(segments) != len(templateSegments) {
+		return false
+	}
+	for i, templateSegment := range templateSegments {
+		isParameter := strings.HasPrefix(templateSegment, "{") && strings.HasSuffix(templateSegment, "}")
+		if !isParameter && templateSegment != segments[i] {
+			return false
+		}
+		if isParameter && segments[i] == "" {
+			return false
+		}
+	}
+	return true
 }
 
 type RequestMetricsTransport struct {
@@ -26,10 +76,13 @@ type RequestMetricsTransport struct {
 
 	httpClientCounts    metric.Int64Counter
 	httpClientErrCounts metric.Int64Counter
+	httpClientDuration  metric.Float64Histogram
 	httpClientReqBytes  metric.Float64Histogram
 	httpClientResBytes  metric.Float64Histogram
 }
 
+// NewRequestMetricsTransport records metrics of outgoing requests. Creating several transports for the
+// same client name is safe, they record into the same instruments.
 func NewRequestMetricsTransport(base http.RoundTripper, clientName string, opts *RequestMetricsTransportOptions) *RequestMetricsTransport {
 	if base == nil {
 		base = http.DefaultTransport
@@ -52,21 +105,29 @@ func (t *RequestMetricsTransport) init() {
 	if t.clientName != "" {
 		meterName = fmt.Sprintf("http.client.%s", strings.ReplaceAll(t.clientName, "-", "_"))
 	}
-	meter := otel.GetMeterProvider().Meter(meterName)
+	provider := otel.GetMeterProvider()
 
-	t.httpClientCounts, _ = meter.Int64Counter(
+	t.httpClientCounts, _ = instruments.Int64Counter(provider, meterName,
 		"http.client.request.total",
 		metric.WithDescription("Total number of HTTP client requests by method and status code"),
 	)
-	t.httpClientErrCounts, _ = meter.Int64Counter(
+	t.httpClientErrCounts, _ = instruments.Int64Counter(provider, meterName,
 		"http.client.request.errors.total",
 		metric.WithDescription("Total number of HTTP client request errors by method and status code"),
 	)
-	t.httpClientReqBytes, _ = meter.Float64Histogram(
+	durationOpts := []metric.Float64HistogramOption{
+		metric.WithDescription("Duration of HTTP client requests in seconds"),
+		metric.WithUnit("s"),
+	}
+	if len(t.opts.DurationBuckets) > 0 {
+		durationOpts = append(durationOpts, metric.WithExplicitBucketBoundaries(t.opts.DurationBuckets...))
+	}
+	t.httpClientDuration, _ = instruments.Float64Histogram(provider, meterName, "http.client.request.duration", durationOpts...)
+	t.httpClientReqBytes, _ = instruments.Float64Histogram(provider, meterName,
 		"http.client.request.size",
 		metric.WithDescription("Size of HTTP client request bodies in bytes"),
 	)
-	t.httpClientResBytes, _ = meter.Float64Histogram(
+	t.httpClientResBytes, _ = instruments.Float64Histogram(provider, meterName,
 		"http.client.response.size",
 		metric.WithDescription("Size of HTTP client response bodies in bytes"),
 	)
@@ -75,19 +136,26 @@ func (t *RequestMetricsTransport) init() {
 func (t *RequestMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	t.recordRequest(req.Context(), req)
 
+	start := time.Now()
 	resp, err := t.base.RoundTrip(req)
-	t.recordResponse(req.Context(), req, resp, err)
+	t.recordResponse(req.Context(), req, resp, err, time.Since(start))
 
 	return resp, err
 }
 
-func (t *RequestMetricsTransport) recordRequest(ctx context.Context, req *http.Request) {
-	attributes := []attribute.KeyValue{
-		attribute.String("http.request.method", req.Method),
-	}
-	if t.clientName != "" {
-		attributes = append(attributes, attribute.String("client.name", t.clientName))
+func (t *RequestMetricsTransport) Describe() (string, string) {
+	if t.clientName == "" {
+		return "metrics", ""
 	}
+	return "metrics", fmt.Sprintf("client=%s", t.clientName)
+}
+
+func (t *RequestMetricsTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (t *RequestMetricsTransport) recordRequest(ctx context.Context, req *http.Request) {
+	attributes := t.requestAttributes(req)
 
 	size := int(req.ContentLength)
 	if size > 0 {
@@ -95,24 +163,20 @@ func (t *RequestMetricsTransport) recordRequest(ctx context.Context, req *http.R
 	}
 }
 
-func (t *RequestMetricsTransport) recordResponse(ctx context.Context, req *http.Request, resp *http.Response, err error) {
+func (t *RequestMetricsTransport) recordResponse(ctx context.Context, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
 	var statusCode, size int
 	if resp != nil {
 		statusCode = resp.StatusCode
 		size = int(resp.ContentLength)
 	}
 
-	attributes := []attribute.KeyValue{
-		attribute.String("http.request.method", req.Method),
-	}
+	attributes := t.requestAttributes(req)
 	if statusCode > 0 {
 		attributes = append(attributes, attribute.Int("http.response.status_code", statusCode))
 	}
-	if t.clientName != "" {
-		attributes = append(attributes, attribute.String("client.name", t.clientName))
-	}
 
 	t.httpClientCounts.Add(ctx, 1, metric.WithAttributes(attributes...))
+	t.httpClientDuration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attributes...))
 	if err != nil {
 		t.httpClientErrCounts.Add(ctx, 1, metric.WithAttributes(attributes...))
 	}
@@ -120,3 +184,39 @@ func (t *RequestMetricsTransport) recordResponse(ctx context.Context, req *http.
 		t.httpClientResBytes.Record(ctx, float64(size), metric.WithAttributes(attributes...))
 	}
 }
+
+func (t *RequestMetricsTransport) requestAttributes(req *http.Request) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+	}
+	if host := req.URL.Hostname(); host != "" {
+		attributes = append(attributes, attribute.String("server.address", host))
+	}
+	if port := serverPort(req.URL); port > 0 {
+		attributes = append(attributes, attribute.Int("server.port", port))
+	}
+	if t.opts.URLTemplateFn != nil {
+		if template := t.opts.URLTemplateFn(req); template != "" {
+			attributes = append(attributes, attribute.String("url.template", template))
+		}
+	}
+	if t.clientName != "" {
+		attributes = append(attributes, attribute.String("client.name", t.clientName))
+	}
+	return attributes
+}
+
+// serverPort returns the port of the URL, falling back to the default port of its scheme
+func serverPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	default:
+		return 0
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Roshick/go-autumn-web/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MockRoundTripper is a test double for http.RoundTripper
@@ -36,6 +39,13 @@ func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	}, nil
 }
 
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 func TestDefaultRequestMetricsTransportOptions(t *testing.T) {
 	opts := DefaultRequestMetricsTransportOptions()
 	require.NotNil(t, opts)
@@ -58,6 +68,7 @@ func TestNewRequestMetricsTransport(t *testing.T) {
 		// Verify metrics are initialized
 		assert.NotNil(t, transport.httpClientCounts)
 		assert.NotNil(t, transport.httpClientErrCounts)
+		assert.NotNil(t, transport.httpClientDuration)
 		assert.NotNil(t, transport.httpClientReqBytes)
 		assert.NotNil(t, transport.httpClientResBytes)
 	})
@@ -286,7 +297,7 @@ func TestRequestMetricsTransport_RecordMethods(t *testing.T) {
 		}
 
 		assert.NotPanics(t, func() {
-			transport.recordResponse(ctx, req, resp, nil)
+			transport.recordResponse(ctx, req, resp, nil, time.Millisecond)
 		})
 	})
 
@@ -297,7 +308,7 @@ func TestRequestMetricsTransport_RecordMethods(t *testing.T) {
 		err := errors.New("test error")
 
 		assert.NotPanics(t, func() {
-			transport.recordResponse(ctx, req, nil, err)
+			transport.recordResponse(ctx, req, nil, err, time.Millisecond)
 		})
 	})
 
@@ -311,11 +322,95 @@ func TestRequestMetricsTransport_RecordMethods(t *testing.T) {
 		}
 
 		assert.NotPanics(t, func() {
-			transport.recordResponse(ctx, req, resp, nil)
+			transport.recordResponse(ctx, req, resp, nil, time.Millisecond)
 		})
 	})
 }
 
+func TestRequestMetricsTransport_Attributes(t *testing.T) {
+	recorder := testutils.NewMetricsRecorder(t)
+	opts := DefaultRequestMetricsTransportOptions()
+	opts.URLTemplateFn = NewStaticURLTemplateFn("/users/{id}", "/users/{id}/orders")
+	transport := NewRequestMetricsTransport(&MockRoundTripper{}, "users", opts)
+
+	for _, target := range []string{"https://api.localhost/users/1", "https://api.localhost/users/2", "http://api.localhost:8080/users/3/orders", "https://api.localhost/health"} {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+	}
+
+	recorder.AssertCounter(t, "http.client.request.total", 2,
+		attribute.String("server.address", "api.localhost"),
+		attribute.Int("server.port", 443),
+		attribute.String("url.template", "/users/{id}"),
+		attribute.String("client.name", "users"))
+	recorder.AssertCounter(t, "http.client.request.total", 1,
+		attribute.Int("server.port", 8080),
+		attribute.String("url.template", "/users/{id}/orders"))
+	recorder.AssertCounter(t, "http.client.request.total", 4,
+		attribute.String("server.address", "api.localhost"))
+}
+
+func TestRequestMetricsTransport_Duration(t *testing.T) {
+	recorder := testutils.NewMetricsRecorder(t)
+	opts := DefaultRequestMetricsTransportOptions()
+	opts.DurationBuckets = []float64{0.01, 0.1, 1}
+	transport := NewRequestMetricsTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		time.Sleep(20 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+	}), "slow", opts)
+
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+	require.NoError(t, err)
+
+	recorder.AssertHistogram(t, "http.client.request.duration", 1,
+		attribute.String("http.request.method", http.MethodGet),
+		attribute.Int("http.response.status_code", http.StatusOK))
+	recorder.AssertHistogramRange(t, "http.client.request.duration", 0.02, 1)
+}
+
+func TestRequestMetricsTransport_SharedInstruments(t *testing.T) {
+	recorder := testutils.NewMetricsRecorder(t)
+	first := NewRequestMetricsTransport(&MockRoundTripper{}, "users", nil)
+	second := NewRequestMetricsTransport(&MockRoundTripper{}, "users", nil)
+	other := NewRequestMetricsTransport(&MockRoundTripper{}, "orders", nil)
+
+	assert.Same(t, first.httpClientCounts, second.httpClientCounts)
+	assert.Same(t, first.httpClientDuration, second.httpClientDuration)
+	assert.NotSame(t, first.httpClientCounts, other.httpClientCounts)
+
+	for _, transport := range []*RequestMetricsTransport{first, second} {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/users", nil))
+		require.NoError(t, err)
+	}
+
+	recorder.AssertCounter(t, "http.client.request.total", 2, attribute.String("client.name", "users"))
+}
+
+func TestNewStaticURLTemplateFn(t *testing.T) {
+	templateFn := NewStaticURLTemplateFn("/users/{id}", "/users/me/settings", "/users/{id}/{section}")
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"/users/42", "/users/{id}"},
+		{"/users/42/", "/users/{id}"},
+		{"/users/me/settings", "/users/me/settings"},
+		{"/users/42/orders", "/users/{id}/{section}"},
+		{"/users", ""},
+		{"/users//orders", ""},
+		{"/orders/42", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://api.localhost"+tc.path, nil)
+
+			assert.Equal(t, tc.expected, templateFn(req))
+		})
+	}
+}
+
 func TestRequestMetricsTransport_ImplementsRoundTripper(t *testing.T) {
 	transport := NewRequestMetricsTransport(nil, "interface-test", nil)
 
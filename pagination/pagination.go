@@ -0,0 +1,236 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/go-chi/render"
+)
+
+// Page holds the pagination parameters of a list request. Either Number or Cursor is used, depending
+// on the endpoint. The form tags allow embedding it into structs decoded from query parameters; call
+// Normalize afterwards to apply defaults and caps.
+type Page struct {
+	// Number is the 1-based page number
+	Number int `form:"page" json:"page"`
+	Size   int `form:"size" json:"size"`
+	// Cursor is the opaque position to continue from, empty for the first page
+	Cursor string `form:"cursor" json:"cursor,omitempty"`
+}
+
+// Offset returns the number of items preceding the page
+func (p Page) Offset() int {
+	return max(p.Number-1, 0) * p.Size
+}
+
+// Normalize applies the default page number and size to unset values and caps the size
+func (p Page) Normalize(opts *PaginationOptions) Page {
+	if opts == nil {
+		opts = DefaultPaginationOptions()
+	}
+	if p.Number < 1 {
+		p.Number = 1
+	}
+	if p.Size < 1 {
+		p.Size = opts.DefaultSize
+	}
+	if opts.MaxSize > 0 && p.Size > opts.MaxSize {
+		p.Size = opts.MaxSize
+	}
+	return p
+}
+
+type PaginationOptions struct {
+	PageParam   string
+	SizeParam   string
+	CursorParam string
+	// DefaultSize is used for requests without size. Defaults to 20.
+	DefaultSize int
+	// MaxSize caps requested sizes. Zero disables the cap. Defaults to 100.
+	MaxSize int
+}
+
+func DefaultPaginationOptions() *PaginationOptions {
+	return &PaginationOptions{
+		PageParam:   "page",
+		SizeParam:   "size",
+		CursorParam: "cursor",
+		DefaultSize: 20,
+		MaxSize:     100,
+	}
+}
+
+// Parse reads the pagination parameters from the query of the request. Malformed or non-positive
+// numbers result in weberrors.FieldErrors, sizes above MaxSize are capped.
+func Parse(req *http.Request, opts *PaginationOptions) (Page, error) {
+	if opts == nil {
+		opts = DefaultPaginationOptions()
+	}
+	query := req.URL.Query()
+
+	var page Page
+	var fieldErrors weberrors.FieldErrors
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{
+		{opts.PageParam, &page.Number},
+		{opts.SizeParam, &page.Size},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			fieldErrors = append(fieldErrors, weberrors.FieldError{
+				Field:   param.name,
+				Code:    "invalid_value",
+				Message: fmt.Sprintf("must be a positive integer, got %q", value),
+			})
+			continue
+		}
+		*param.target = number
+	}
+	if len(fieldErrors) > 0 {
+		return Page{}, fieldErrors
+	}
+	page.Cursor = query.Get(opts.CursorParam)
+	return page.Normalize(opts), nil
+}
+
+// Envelope is the standard response body of list endpoints
+type Envelope[T any] struct {
+	Items []T `json:"items"`
+	// Total is the number of items of all pages, if known
+	Total *int `json:"total,omitempty"`
+	// NextCursor continues cursor-based pagination, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewEnvelope creates the envelope of a page of a list with known total
+func NewEnvelope[T any](items []T, total int) Envelope[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Envelope[T]{Items: items, Total: &total}
+}
+
+// NewCursorEnvelope creates the envelope of a page of cursor-based pagination
+func NewCursorEnvelope[T any](items []T, nextCursor string) Envelope[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Envelope[T]{Items: items, NextCursor: nextCursor}
+}
+
+// Links returns the value of the RFC 8288 Link header with first, prev, next and last relations for the
+// page of a list with the total number of items. Other query parameters of the URL are kept.
+func Links(u *url.URL, page Page, total int, opts *PaginationOptions) string {
+	if opts == nil {
+		opts = DefaultPaginationOptions()
+	}
+	page = page.Normalize(opts)
+	lastPage := max((total+page.Size-1)/page.Size, 1)
+
+	links := []string{link(u, opts, page.Size, 1, "first")}
+	if page.Number > 1 {
+		links = append(links, link(u, opts, page.Size, min(page.Number-1, lastPage), "prev"))
+	}
+	if page.Number < lastPage {
+		links = append(links, link(u, opts, page.Size, page.Number+1, "next"))
+	}
+	links = append(links, link(u, opts, page.Size, lastPage, "last"))
+	return strings.Join(links, ", ")
+}
+
+// CursorLinks returns the value of the Link header with the next relation, or an empty string on the
+// last page.
+func CursorLinks(u *url.URL, page Page, nextCursor string, opts *PaginationOptions) string {
+	if opts == nil {
+		opts = DefaultPaginationOptions()
+	}
+	if nextCursor == "" {
+		return ""
+	}
+	page = page.Normalize(opts)
+	nextURL := *u
+	query := nextURL.Query()
+	query.Del(opts.PageParam)
+	query.Set(opts.CursorParam, nextCursor)
+	query.Set(opts.SizeParam, strconv.Itoa(page.Size))
+	nextURL.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="next"`, nextURL.String())
+}
+
+func link(u *url.URL, opts *PaginationOptions, size int, number int, rel string) string {
+	pageURL := *u
+	query := pageURL.Query()
+	query.Set(opts.PageParam, strconv.Itoa(number))
+	query.Set(opts.SizeParam, strconv.Itoa(size))
+	pageURL.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, pageURL.String(), rel)
+}
+
+// FromContext returns the page parsed by the pagination middleware, or the first page with the default
+// size.
+func FromContext(ctx context.Context) Page {
+	if page := contextutils.GetValue[Page](ctx); page != nil {
+		return *page
+	}
+	return Page{}.Normalize(nil)
+}
+
+// PaginationMiddleware //
+
+type PaginationMiddlewareOptions struct {
+	Pagination *PaginationOptions
+	// ErrorResponseFn builds the response for invalid parameters. Defaults to a
+	// weberrors.ValidationErrorResponse listing them.
+	ErrorResponseFn func(err error) render.Renderer
+}
+
+func DefaultPaginationMiddlewareOptions() *PaginationMiddlewareOptions {
+	return &PaginationMiddlewareOptions{
+		Pagination:      DefaultPaginationOptions(),
+		ErrorResponseFn: newParamsErrorResponse,
+	}
+}
+
+// NewPaginationMiddleware parses the pagination parameters of list requests and stores them in the
+// request context, see FromContext.
+func NewPaginationMiddleware(opts *PaginationMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultPaginationMiddlewareOptions()
+	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = newParamsErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			page, err := Parse(req, opts.Pagination)
+			if err != nil {
+				if err = weberrors.Render(w, req, errorResponseFn(err)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			ctx := contextutils.WithValue(req.Context(), page)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func newParamsErrorResponse(err error) render.Renderer {
+	fieldErrors, _ := err.(weberrors.FieldErrors)
+	return weberrors.NewValidationErrorResponse("Invalid pagination parameters", fieldErrors...)
+}
@@ -0,0 +1,141 @@
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPaginationOptions(t *testing.T) {
+	opts := DefaultPaginationOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, "page", opts.PageParam)
+	assert.Equal(t, "size", opts.SizeParam)
+	assert.Equal(t, "cursor", opts.CursorParam)
+	assert.Equal(t, 20, opts.DefaultSize)
+	assert.Equal(t, 100, opts.MaxSize)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected Page
+	}{
+		{"defaults", "", Page{Number: 1, Size: 20}},
+		{"page and size", "page=3&size=50", Page{Number: 3, Size: 50}},
+		{"caps size", "size=1000", Page{Number: 1, Size: 100}},
+		{"cursor", "cursor=abc&size=10", Page{Number: 1, Size: 10, Cursor: "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := Parse(httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil), nil)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, page)
+		})
+	}
+
+	t.Run("rejects invalid numbers", func(t *testing.T) {
+		_, err := Parse(httptest.NewRequest(http.MethodGet, "/items?page=0&size=many", nil), nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "page: must be a positive integer")
+		assert.Contains(t, err.Error(), "size: must be a positive integer")
+	})
+
+	t.Run("custom parameter names", func(t *testing.T) {
+		opts := DefaultPaginationOptions()
+		opts.PageParam, opts.SizeParam = "p", "limit"
+
+		page, err := Parse(httptest.NewRequest(http.MethodGet, "/items?p=2&limit=5", nil), opts)
+
+		require.NoError(t, err)
+		assert.Equal(t, Page{Number: 2, Size: 5}, page)
+	})
+}
+
+func TestPage_Offset(t *testing.T) {
+	assert.Equal(t, 0, Page{Number: 1, Size: 20}.Offset())
+	assert.Equal(t, 40, Page{Number: 3, Size: 20}.Offset())
+	assert.Equal(t, 0, Page{}.Offset())
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Run("with total", func(t *testing.T) {
+		body, err := json.Marshal(NewEnvelope([]string{"a"}, 42))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":["a"],"total":42}`, string(body))
+	})
+
+	t.Run("with cursor and without items", func(t *testing.T) {
+		body, err := json.Marshal(NewCursorEnvelope[string](nil, "next"))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"items":[],"next_cursor":"next"}`, string(body))
+	})
+}
+
+func TestLinks(t *testing.T) {
+	u, _ := url.Parse("https://api.example.com/items?status=open&page=2&size=10")
+
+	t.Run("middle page", func(t *testing.T) {
+		links := Links(u, Page{Number: 2, Size: 10}, 35, nil)
+
+		assert.Equal(t, `<https://api.example.com/items?page=1&size=10&status=open>; rel="first", `+
+			`<https://api.example.com/items?page=1&size=10&status=open>; rel="prev", `+
+			`<https://api.example.com/items?page=3&size=10&status=open>; rel="next", `+
+			`<https://api.example.com/items?page=4&size=10&status=open>; rel="last"`, links)
+	})
+
+	t.Run("single page", func(t *testing.T) {
+		links := Links(u, Page{Number: 1, Size: 10}, 0, nil)
+
+		assert.Equal(t, `<https://api.example.com/items?page=1&size=10&status=open>; rel="first", `+
+			`<https://api.example.com/items?page=1&size=10&status=open>; rel="last"`, links)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		assert.Equal(t, `<https://api.example.com/items?cursor=abc&size=10&status=open>; rel="next"`,
+			CursorLinks(u, Page{Size: 10}, "abc", nil))
+		assert.Empty(t, CursorLinks(u, Page{Size: 10}, "", nil))
+	})
+}
+
+func TestNewPaginationMiddleware(t *testing.T) {
+	t.Run("stores the page in the context", func(t *testing.T) {
+		var page Page
+		handler := NewPaginationMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page = FromContext(r.Context())
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?page=2", nil))
+
+		assert.Equal(t, Page{Number: 2, Size: 20}, page)
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		handlerCalled := false
+		handler := NewPaginationMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		}))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items?size=-1", nil))
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"size"`)
+	})
+}
+
+func TestFromContext_WithoutMiddleware(t *testing.T) {
+	assert.Equal(t, Page{Number: 1, Size: 20}, FromContext(t.Context()))
+}
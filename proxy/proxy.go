@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/tracing"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/go-chi/render"
+	"github.com/sony/gobreaker/v2"
+)
+
+// ReverseProxy //
+
+type ReverseProxyOptions struct {
+	// Transport sends the upstream requests and is wrapped by the transports configured below.
+	// Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// ClientName names the upstream in metrics, see metrics.NewRequestMetricsTransport.
+	ClientName string
+
+	// The options of the transports applied to upstream requests, from outermost to innermost. A nil
+	// value disables the transport.
+	RequestIDHeaderTransportOptions *tracing.RequestIDHeaderTransportOptions
+	RequestLoggerTransportOptions   *logging.RequestLoggerTransportOptions
+	CircuitBreakerTransportOptions  *resiliency.CircuitBreakerTransportOptions
+	RetryTransportOptions           *resiliency.RetryTransportOptions
+	RequestMetricsTransportOptions  *metrics.RequestMetricsTransportOptions
+
+	// PreserveHost forwards the Host header of the inbound request instead of the host of the target.
+	PreserveHost bool
+	// SetRequestHeaders are set on upstream requests, replacing inbound values.
+	SetRequestHeaders map[string]string
+	// RemoveRequestHeaders are removed from upstream requests, e.g. internal credentials.
+	RemoveRequestHeaders []string
+	// RemoveResponseHeaders are removed from upstream responses, e.g. headers revealing the upstream.
+	RemoveResponseHeaders []string
+	// FlushInterval flushes buffered response bodies periodically, negative values after each write.
+	// Event streams and responses of unknown length are always flushed immediately.
+	FlushInterval time.Duration
+
+	// ErrorResponse is rendered if the upstream cannot be reached. Defaults to 502 Bad Gateway.
+	ErrorResponse render.Renderer
+	// TimeoutResponse is rendered if the upstream request times out. Defaults to 504 Gateway Timeout.
+	TimeoutResponse render.Renderer
+	// UnavailableResponse is rendered if the circuit breaker is open. Defaults to 503 Service Unavailable.
+	UnavailableResponse render.Renderer
+}
+
+func DefaultReverseProxyOptions() *ReverseProxyOptions {
+	return &ReverseProxyOptions{
+		Transport:                       http.DefaultTransport,
+		RequestIDHeaderTransportOptions: tracing.DefaultRequestIDHeaderTransportOptions(),
+		RequestLoggerTransportOptions:   logging.DefaultRequestLoggerTransportOptions(),
+		CircuitBreakerTransportOptions:  resiliency.DefaultCircuitBreakerTransportOptions(),
+		RetryTransportOptions:           resiliency.DefaultRetryTransportOptions(),
+		RequestMetricsTransportOptions:  metrics.DefaultRequestMetricsTransportOptions(),
+		SetRequestHeaders:               map[string]string{},
+		RemoveRequestHeaders:            []string{},
+		RemoveResponseHeaders:           []string{},
+		ErrorResponse:                   weberrors.NewBadGatewayResponse(""),
+		TimeoutResponse:                 weberrors.NewGatewayTimeoutResponse(""),
+		UnavailableResponse:             weberrors.NewServiceUnavailableResponse(""),
+	}
+}
+
+// NewReverseProxy creates a reverse proxy forwarding requests to the target, joining the target path
+// with the request path. Upstream requests pass through the transports of this module and carry
+// X-Forwarded headers. Upstream failures are rendered as error responses, while failures after the
+// response has started abort the connection, so streamed responses are never corrupted.
+func NewReverseProxy(target *url.URL, opts *ReverseProxyOptions) *httputil.ReverseProxy {
+	if opts == nil {
+		opts = DefaultReverseProxyOptions()
+	}
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+			if opts.PreserveHost {
+				r.Out.Host = r.In.Host
+			}
+			for name, value := range opts.SetRequestHeaders {
+				r.Out.Header.Set(name, value)
+			}
+			for _, name := range opts.RemoveRequestHeaders {
+				r.Out.Header.Del(name)
+			}
+		},
+		Transport: newTransport(opts),
+		ModifyResponse: func(res *http.Response) error {
+			for _, name := range opts.RemoveResponseHeaders {
+				res.Header.Del(name)
+			}
+			return nil
+		},
+		FlushInterval: opts.FlushInterval,
+		ErrorHandler:  newErrorHandler(opts),
+	}
+}
+
+// newTransport stacks the configured transports around the base transport, satisfying
+// transportstack.DefaultOrderingRules
+func newTransport(opts *ReverseProxyOptions) http.RoundTripper {
+	rt := opts.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts.RequestMetricsTransportOptions != nil {
+		rt = metrics.NewRequestMetricsTransport(rt, opts.ClientName, opts.RequestMetricsTransportOptions)
+	}
+	if opts.RetryTransportOptions != nil {
+		rt = resiliency.NewRetryTransport(rt, opts.RetryTransportOptions)
+	}
+	if opts.CircuitBreakerTransportOptions != nil {
+		rt = resiliency.NewCircuitBreakerTransport(rt, opts.CircuitBreakerTransportOptions)
+	}
+	if opts.RequestLoggerTransportOptions != nil {
+		rt = logging.NewRequestLoggerTransport(rt, opts.RequestLoggerTransportOptions)
+	}
+	if opts.RequestIDHeaderTransportOptions != nil {
+		rt = tracing.NewRequestIDHeaderTransport(rt, opts.RequestIDHeaderTransportOptions)
+	}
+	return rt
+}
+
+func newErrorHandler(opts *ReverseProxyOptions) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, req *http.Request, err error) {
+		if errors.Is(err, context.Canceled) {
+			// The client went away, nobody is listening anymore
+			aulogging.Logger.Ctx(req.Context()).Info().WithErr(err).Printf("proxy request %s %s canceled by client", req.Method, req.URL.Path)
+			return
+		}
+
+		response := opts.ErrorResponse
+		var netErr net.Error
+		switch {
+		case errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests):
+			response = opts.UnavailableResponse
+		case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+			response = opts.TimeoutResponse
+		}
+		if response == nil {
+			response = weberrors.NewBadGatewayResponse("")
+		}
+
+		aulogging.Logger.Ctx(req.Context()).Warn().WithErr(err).Printf("proxy request %s %s failed", req.Method, req.URL.Path)
+		if renderErr := weberrors.Render(w, req, response); renderErr != nil {
+			panic(renderErr)
+		}
+	}
+}
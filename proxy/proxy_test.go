@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/Roshick/go-autumn-web/transportstack"
+	"github.com/sony/gobreaker/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	target, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return target
+}
+
+func TestDefaultReverseProxyOptions(t *testing.T) {
+	opts := DefaultReverseProxyOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, http.DefaultTransport, opts.Transport)
+	assert.NotNil(t, opts.RequestIDHeaderTransportOptions)
+	assert.NotNil(t, opts.RequestLoggerTransportOptions)
+	assert.NotNil(t, opts.RequestMetricsTransportOptions)
+	assert.NotNil(t, opts.CircuitBreakerTransportOptions)
+	assert.NotNil(t, opts.RetryTransportOptions)
+	assert.NotNil(t, opts.ErrorResponse)
+	assert.NotNil(t, opts.TimeoutResponse)
+	assert.NotNil(t, opts.UnavailableResponse)
+}
+
+func TestNewReverseProxy_TransportOrder(t *testing.T) {
+	reverseProxy := NewReverseProxy(mustParseURL(t, "http://upstream.localhost"), nil)
+
+	rt, err := transportstack.Compose(reverseProxy.Transport, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"requestid", "logging", "breaker", "retry", "metrics", "base"}, layerNames(transportstack.Describe(rt)))
+}
+
+func layerNames(layers []transportstack.Layer) []string {
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+func TestNewReverseProxy(t *testing.T) {
+	t.Run("forwards requests with rewritten headers", func(t *testing.T) {
+		var upstreamReq *http.Request
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			upstreamReq = r
+			w.Header().Set("X-Upstream-Version", "1.2.3")
+			w.Header().Set(header.ContentType, "text/plain")
+			_, _ = w.Write([]byte("upstream"))
+		}))
+		defer upstream.Close()
+
+		opts := DefaultReverseProxyOptions()
+		opts.SetRequestHeaders = map[string]string{"X-Gateway": "true"}
+		opts.RemoveRequestHeaders = []string{"X-Internal-Token"}
+		opts.RemoveResponseHeaders = []string{"X-Upstream-Version"}
+		proxy := NewReverseProxy(mustParseURL(t, upstream.URL+"/api"), opts)
+
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.localhost/users?page=2", nil)
+		req.Header.Set("X-Internal-Token", "secret")
+		req = req.WithContext(tracing.ContextWithRequestID(req.Context(), "request-id"))
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "upstream", w.Body.String())
+		assert.Empty(t, w.Header().Get("X-Upstream-Version"))
+		require.NotNil(t, upstreamReq)
+		assert.Equal(t, "/api/users", upstreamReq.URL.Path)
+		assert.Equal(t, "page=2", upstreamReq.URL.RawQuery)
+		assert.Equal(t, "true", upstreamReq.Header.Get("X-Gateway"))
+		assert.Empty(t, upstreamReq.Header.Get("X-Internal-Token"))
+		assert.Equal(t, "request-id", upstreamReq.Header.Get(header.XRequestID))
+		assert.Equal(t, "gateway.localhost", upstreamReq.Header.Get("X-Forwarded-Host"))
+		assert.Equal(t, mustParseURL(t, upstream.URL).Host, upstreamReq.Host)
+	})
+
+	t.Run("preserves the inbound host", func(t *testing.T) {
+		var host string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host = r.Host
+		}))
+		defer upstream.Close()
+
+		opts := DefaultReverseProxyOptions()
+		opts.PreserveHost = true
+		proxy := NewReverseProxy(mustParseURL(t, upstream.URL), opts)
+		proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://gateway.localhost/", nil))
+
+		assert.Equal(t, "gateway.localhost", host)
+	})
+
+	t.Run("retries failed idempotent requests", func(t *testing.T) {
+		calls := 0
+		opts := DefaultReverseProxyOptions()
+		opts.RetryTransportOptions.InitialBackoff = time.Millisecond
+		opts.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection reset")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: http.NoBody}, nil
+		})
+		proxy := NewReverseProxy(mustParseURL(t, "http://upstream.localhost"), opts)
+		w := httptest.NewRecorder()
+
+		proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestNewReverseProxy_Errors(t *testing.T) {
+	serve := func(opts *ReverseProxyOptions, err error) *httptest.ResponseRecorder {
+		opts.RetryTransportOptions = nil
+		opts.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, err
+		})
+		proxy := NewReverseProxy(mustParseURL(t, "http://upstream.localhost"), opts)
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	t.Run("renders bad gateway for connection errors", func(t *testing.T) {
+		w := serve(DefaultReverseProxyOptions(), errors.New("connection refused"))
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("renders gateway timeout for timeouts", func(t *testing.T) {
+		w := serve(DefaultReverseProxyOptions(), context.DeadlineExceeded)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("renders service unavailable for open circuit breakers", func(t *testing.T) {
+		w := serve(DefaultReverseProxyOptions(), gobreaker.ErrOpenState)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("opens the circuit breaker after repeated failures", func(t *testing.T) {
+		opts := DefaultReverseProxyOptions()
+		opts.CircuitBreakerTransportOptions = &resiliency.CircuitBreakerTransportOptions{
+			Settings: gobreaker.Settings{
+				ReadyToTrip: func(counts gobreaker.Counts) bool {
+					return counts.ConsecutiveFailures >= 1
+				},
+			},
+		}
+		opts.RetryTransportOptions = nil
+		calls := 0
+		opts.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return nil, errors.New("connection refused")
+		})
+		proxy := NewReverseProxy(mustParseURL(t, "http://upstream.localhost"), opts)
+
+		first := httptest.NewRecorder()
+		proxy.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+		second := httptest.NewRecorder()
+		proxy.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusBadGateway, first.Code)
+		assert.Equal(t, http.StatusServiceUnavailable, second.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("writes nothing for requests canceled by the client", func(t *testing.T) {
+		w := serve(DefaultReverseProxyOptions(), context.Canceled)
+
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestNewReverseProxy_Streaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(header.ContentType, "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	gateway := httptest.NewServer(NewReverseProxy(mustParseURL(t, upstream.URL), nil))
+	defer gateway.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gateway.URL, nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	buf := make([]byte, len("data: first\n\n"))
+	_, err = io.ReadFull(res.Body, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "data: first\n\n", string(buf))
+}
@@ -0,0 +1,133 @@
+package rendering
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+type languageRange struct {
+	tag     string
+	quality float64
+}
+
+// specificity ranks exact tags above ranges the locale extends, e.g. "en" for "en-US", above locales
+// the range extends, e.g. "en-US" for "en", above *. Negative results mean no match.
+func (l languageRange) specificity(locale string) int {
+	switch {
+	case l.tag == locale:
+		return 3
+	case strings.HasPrefix(locale, l.tag+"-"):
+		return 2
+	case strings.HasPrefix(l.tag, locale+"-"):
+		return 1
+	case l.tag == "*":
+		return 0
+	default:
+		return -1
+	}
+}
+
+func parseAcceptLanguage(acceptLanguage string) []languageRange {
+	var ranges []languageRange
+	for _, field := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(field), ";")
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+		if tag == "" {
+			continue
+		}
+		ranges = append(ranges, languageRange{tag: tag, quality: parseQuality(params)})
+	}
+	return ranges
+}
+
+// NegotiateLocale returns the supported locale the request accepts with the highest quality, preferring
+// closer matches and then earlier locales on ties. Language ranges also match more and less specific
+// locales, e.g. "de-AT" matches a supported "de". Without Accept-Language header the first locale is
+// returned. If the request accepts none of the locales, the result is empty.
+func NegotiateLocale(req *http.Request, supported ...string) string {
+	acceptLanguage := req.Header.Get(header.AcceptLanguage)
+	if strings.TrimSpace(acceptLanguage) == "" {
+		if len(supported) == 0 {
+			return ""
+		}
+		return supported[0]
+	}
+	ranges := parseAcceptLanguage(acceptLanguage)
+
+	best, bestQuality, bestSpecificity := "", 0.0, -1
+	for _, locale := range supported {
+		quality, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.specificity(strings.ToLower(locale)); s > specificity {
+				quality, specificity = r.quality, s
+			}
+		}
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = locale, quality, specificity
+		}
+	}
+	return best
+}
+
+func init() {
+	contextutils.RegisterValue[Locale]()
+}
+
+type Locale string
+
+func LocaleFromContext(ctx context.Context) *string {
+	locale := contextutils.GetValue[Locale](ctx)
+	if locale != nil {
+		localeString := string(*locale)
+		return &localeString
+	}
+	return nil
+}
+
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return contextutils.WithValue(ctx, Locale(locale))
+}
+
+// LocaleMiddleware //
+
+type LocaleMiddlewareOptions struct {
+	// SupportedLocales lists the locales the service offers, e.g. "en" or "de-DE". The first one is used
+	// if the request accepts none of them.
+	SupportedLocales []string
+}
+
+func DefaultLocaleMiddlewareOptions() *LocaleMiddlewareOptions {
+	return &LocaleMiddlewareOptions{
+		SupportedLocales: []string{"en"},
+	}
+}
+
+// NewLocaleMiddleware negotiates the locale of the request against the supported locales, stores it in
+// the request context and announces it in the Content-Language response header
+func NewLocaleMiddleware(opts *LocaleMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultLocaleMiddlewareOptions()
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			locale := NegotiateLocale(req, opts.SupportedLocales...)
+			if locale == "" && len(opts.SupportedLocales) > 0 {
+				locale = opts.SupportedLocales[0]
+			}
+			if locale == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			w.Header().Set(header.ContentLanguage, locale)
+			w.Header().Add(header.Vary, header.AcceptLanguage)
+			next.ServeHTTP(w, req.WithContext(ContextWithLocale(req.Context(), locale)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
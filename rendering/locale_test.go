@@ -0,0 +1,107 @@
+package rendering
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	supported := []string{"en", "de", "fr-CH"}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		supported      []string
+		expected       string
+	}{
+		{name: "no accept language header", acceptLanguage: "", supported: supported, expected: "en"},
+		{name: "exact match", acceptLanguage: "de", supported: supported, expected: "de"},
+		{name: "highest quality wins", acceptLanguage: "en;q=0.5, de;q=0.8", supported: supported, expected: "de"},
+		{name: "order of supported locales breaks ties", acceptLanguage: "de, en", supported: supported, expected: "en"},
+		{name: "more specific range matches language", acceptLanguage: "de-AT", supported: supported, expected: "de"},
+		{name: "language matches more specific locale", acceptLanguage: "fr", supported: supported, expected: "fr-CH"},
+		{name: "closer match breaks ties", acceptLanguage: "en-US", supported: []string{"en", "en-US"}, expected: "en-US"},
+		{name: "wildcard", acceptLanguage: "*", supported: supported, expected: "en"},
+		{name: "specific range overrides wildcard", acceptLanguage: "*;q=0.8, en;q=0.1", supported: supported, expected: "de"},
+		{name: "zero quality excludes", acceptLanguage: "en;q=0, *;q=0.1", supported: supported, expected: "de"},
+		{name: "nothing acceptable", acceptLanguage: "ja", supported: supported, expected: ""},
+		{name: "case insensitive", acceptLanguage: "FR-ch", supported: supported, expected: "fr-CH"},
+		{name: "underscore separators", acceptLanguage: "fr_CH", supported: supported, expected: "fr-CH"},
+		{name: "no supported locales", acceptLanguage: "", supported: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set(header.AcceptLanguage, tt.acceptLanguage)
+			}
+			assert.Equal(t, tt.expected, NegotiateLocale(req, tt.supported...))
+		})
+	}
+}
+
+func TestLocaleFromContext(t *testing.T) {
+	t.Run("locale exists", func(t *testing.T) {
+		locale := LocaleFromContext(ContextWithLocale(context.Background(), "de"))
+
+		require.NotNil(t, locale)
+		assert.Equal(t, "de", *locale)
+	})
+
+	t.Run("locale does not exist", func(t *testing.T) {
+		assert.Nil(t, LocaleFromContext(context.Background()))
+	})
+}
+
+func TestNewLocaleMiddleware(t *testing.T) {
+	var locale *string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = LocaleFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("stores the negotiated locale", func(t *testing.T) {
+		middleware := NewLocaleMiddleware(&LocaleMiddlewareOptions{SupportedLocales: []string{"en", "de"}})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header.AcceptLanguage, "de-DE, en;q=0.5")
+		w := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(w, req)
+
+		require.NotNil(t, locale)
+		assert.Equal(t, "de", *locale)
+		assert.Equal(t, "de", w.Header().Get(header.ContentLanguage))
+		assert.Equal(t, header.AcceptLanguage, w.Header().Get(header.Vary))
+	})
+
+	t.Run("falls back to the first supported locale", func(t *testing.T) {
+		middleware := NewLocaleMiddleware(nil)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header.AcceptLanguage, "ja")
+		w := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(w, req)
+
+		require.NotNil(t, locale)
+		assert.Equal(t, "en", *locale)
+		assert.Equal(t, "en", w.Header().Get(header.ContentLanguage))
+	})
+
+	t.Run("passes requests without supported locales", func(t *testing.T) {
+		locale = nil
+		middleware := NewLocaleMiddleware(&LocaleMiddlewareOptions{})
+		w := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Nil(t, locale)
+		assert.Empty(t, w.Header().Get(header.ContentLanguage))
+	})
+}
@@ -0,0 +1,94 @@
+package rendering
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeXML         = "application/xml"
+	MediaTypeProblemJSON = "application/problem+json"
+	MediaTypePlainText   = "text/plain"
+)
+
+type mediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// specificity ranks exact types above subtype wildcards above */*.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.mainType == "*":
+		return 0
+	case m.subType == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	mainType, subType, _ := strings.Cut(mediaType, "/")
+	return (m.mainType == "*" || m.mainType == mainType) && (m.subType == "*" || m.subType == subType)
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, field := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(field), ";")
+		mainType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+		if !ok || mainType == "" || subType == "" {
+			continue
+		}
+		ranges = append(ranges, mediaRange{mainType: mainType, subType: subType, quality: parseQuality(params)})
+	}
+	return ranges
+}
+
+// parseQuality returns the q parameter of the semicolon-separated parameters, defaulting to 1
+func parseQuality(params string) float64 {
+	quality := 1.0
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+				quality = q
+			}
+		}
+	}
+	return quality
+}
+
+// NegotiateMediaType returns the offered media type the request accepts with the highest quality,
+// preferring earlier offers on ties. Without Accept header the first offer is returned. If the
+// request accepts none of the offers, the result is empty.
+func NegotiateMediaType(req *http.Request, offered ...string) string {
+	accept := req.Header.Get(header.Accept)
+	if strings.TrimSpace(accept) == "" {
+		if len(offered) == 0 {
+			return ""
+		}
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offered {
+		quality, specificity := 0.0, -1
+		for _, r := range ranges {
+			if r.matches(offer) && r.specificity() > specificity {
+				quality, specificity = r.quality, r.specificity()
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
@@ -0,0 +1,43 @@
+package rendering
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	offered := []string{MediaTypeJSON, MediaTypeXML, MediaTypePlainText}
+
+	tests := []struct {
+		name     string
+		accept   string
+		offered  []string
+		expected string
+	}{
+		{name: "no accept header", accept: "", offered: offered, expected: MediaTypeJSON},
+		{name: "exact match", accept: "application/xml", offered: offered, expected: MediaTypeXML},
+		{name: "highest quality wins", accept: "application/json;q=0.5, text/plain", offered: offered, expected: MediaTypePlainText},
+		{name: "offer order breaks ties", accept: "application/xml, application/json", offered: offered, expected: MediaTypeJSON},
+		{name: "subtype wildcard", accept: "text/*", offered: offered, expected: MediaTypePlainText},
+		{name: "full wildcard", accept: "*/*", offered: offered, expected: MediaTypeJSON},
+		{name: "specific range overrides wildcard", accept: "*/*;q=0.8, application/json;q=0.1", offered: offered, expected: MediaTypeXML},
+		{name: "zero quality excludes", accept: "application/json;q=0, */*;q=0.1", offered: offered, expected: MediaTypeXML},
+		{name: "nothing acceptable", accept: "image/png", offered: offered, expected: ""},
+		{name: "case insensitive", accept: "Application/XML", offered: offered, expected: MediaTypeXML},
+		{name: "ignores invalid ranges", accept: "invalid, text/plain", offered: offered, expected: MediaTypePlainText},
+		{name: "no offers", accept: "", offered: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.expected, NegotiateMediaType(req, tt.offered...))
+		})
+	}
+}
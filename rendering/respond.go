@@ -0,0 +1,78 @@
+package rendering
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/go-chi/render"
+)
+
+// Respond writes the payload with the given status, encoded in the media type negotiated from the
+// Accept header. JSON and XML are offered for every payload, plain text additionally for strings
+// and fmt.Stringer values. JSON is used if the request accepts none of them.
+func Respond(w http.ResponseWriter, req *http.Request, status int, v any) error {
+	offered := []string{MediaTypeJSON, MediaTypeXML}
+	text, isText := plainText(v)
+	if isText {
+		offered = append(offered, MediaTypePlainText)
+	}
+
+	var body []byte
+	var err error
+	mediaType := NegotiateMediaType(req, offered...)
+	switch mediaType {
+	case MediaTypeXML:
+		body, err = xml.Marshal(v)
+	case MediaTypePlainText:
+		body = []byte(text)
+	default:
+		mediaType = MediaTypeJSON
+		body, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(header.ContentType, mediaType+"; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+func plainText(v any) (string, bool) {
+	switch typed := v.(type) {
+	case string:
+		return typed, true
+	case fmt.Stringer:
+		return typed.String(), true
+	default:
+		return "", false
+	}
+}
+
+// RespondError renders an error response of the errors package as problem+json, JSON or XML,
+// depending on the Accept header. Other renderers are rendered by weberrors.Render.
+func RespondError(w http.ResponseWriter, req *http.Request, response render.Renderer) error {
+	problemRenderer, ok := response.(weberrors.ProblemRenderer)
+	if !ok {
+		return weberrors.Render(w, req, response)
+	}
+
+	switch NegotiateMediaType(req, MediaTypeJSON, MediaTypeProblemJSON, MediaTypeXML) {
+	case MediaTypeProblemJSON:
+		return weberrors.RenderProblem(w, req, problemRenderer)
+	case MediaTypeXML:
+		return weberrors.Render(w, withContentType(req, render.ContentTypeXML), response)
+	default:
+		return weberrors.Render(w, withContentType(req, render.ContentTypeJSON), response)
+	}
+}
+
+func withContentType(req *http.Request, contentType render.ContentType) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), render.ContentTypeCtxKey, contentType))
+}
@@ -0,0 +1,108 @@
+package rendering
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   string `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+type version struct{}
+
+func (version) String() string {
+	return "1.0.0"
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		name                string
+		accept              string
+		payload             any
+		expectedContentType string
+		expectedBody        string
+	}{
+		{name: "json by default", payload: item{ID: "1", Name: "a"}, expectedContentType: "application/json; charset=utf-8", expectedBody: `{"id":"1","name":"a"}`},
+		{name: "xml", accept: "application/xml", payload: item{ID: "1", Name: "a"}, expectedContentType: "application/xml; charset=utf-8", expectedBody: `<item><id>1</id><name>a</name></item>`},
+		{name: "plain text string", accept: "text/plain", payload: "hello", expectedContentType: "text/plain; charset=utf-8", expectedBody: "hello"},
+		{name: "plain text stringer", accept: "text/plain", payload: version{}, expectedContentType: "text/plain; charset=utf-8", expectedBody: "1.0.0"},
+		{name: "plain text not offered for structs", accept: "text/plain", payload: item{ID: "1"}, expectedContentType: "application/json; charset=utf-8", expectedBody: `{"id":"1","name":""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			require.NoError(t, Respond(rr, req, http.StatusCreated, tt.payload))
+
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+
+	t.Run("returns marshal errors before writing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		err := Respond(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, func() {})
+
+		assert.Error(t, err)
+		assert.False(t, rr.Flushed)
+		assert.Empty(t, rr.Body.String())
+	})
+}
+
+func TestRespondError(t *testing.T) {
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+	}{
+		{name: "json by default", expectedContentType: "application/json"},
+		{name: "problem json", accept: "application/problem+json", expectedContentType: weberrors.ContentTypeProblemJSON},
+		{name: "xml", accept: "application/xml", expectedContentType: "application/xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			require.NoError(t, RespondError(rr, req, weberrors.NewNotFoundResponse("Item not found")))
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Header().Get("Content-Type"), tt.expectedContentType)
+			assert.Contains(t, rr.Body.String(), "Item not found")
+		})
+	}
+
+	t.Run("problem json includes field errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		rr := httptest.NewRecorder()
+
+		response := weberrors.NewValidationErrorResponse("", weberrors.FieldError{Field: "name", Code: "required", Message: "name is required"})
+		require.NoError(t, RespondError(rr, req, response))
+
+		var problem weberrors.Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		require.Len(t, problem.Errors, 1)
+		assert.Equal(t, "name", problem.Errors[0].Field)
+	})
+}
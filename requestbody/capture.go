@@ -0,0 +1,104 @@
+package requestbody
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/go-chi/render"
+)
+
+type rawBody []byte
+
+// FromContext returns the raw body captured by the capture middleware or Capture.
+func FromContext(ctx context.Context) ([]byte, bool) {
+	if body := contextutils.GetValue[rawBody](ctx); body != nil {
+		return *body, true
+	}
+	return nil, false
+}
+
+// Capture reads the body of the request, limited to maxBodySize bytes unless zero, and returns the
+// request with the raw body in its context and a fresh req.Body and req.GetBody, so parsers can still
+// read it. Bodies captured before are returned without reading the body again.
+func Capture(w http.ResponseWriter, req *http.Request, maxBodySize int64) (*http.Request, []byte, error) {
+	if body, ok := FromContext(req.Context()); ok {
+		return req, body, nil
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		reader := req.Body
+		if maxBodySize > 0 {
+			reader = http.MaxBytesReader(w, req.Body, maxBodySize)
+		}
+		var err error
+		if body, err = io.ReadAll(reader); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req = req.WithContext(contextutils.WithValue(req.Context(), rawBody(body)))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return req, body, nil
+}
+
+// CaptureMiddleware //
+
+type CaptureMiddlewareOptions struct {
+	// MaxBodySize limits the size of captured bodies in bytes. Zero disables the limit. Defaults to 1 MiB.
+	MaxBodySize int64
+	// ErrorResponseFn builds the response for bodies that cannot be read. Defaults to NewCaptureErrorResponse.
+	ErrorResponseFn func(err error) render.Renderer
+}
+
+func DefaultCaptureMiddlewareOptions() *CaptureMiddlewareOptions {
+	return &CaptureMiddlewareOptions{
+		MaxBodySize:     1 << 20,
+		ErrorResponseFn: NewCaptureErrorResponse,
+	}
+}
+
+// NewCaptureMiddleware buffers the request body, so signature verification, audit logging or
+// idempotency hashing can access the raw bytes via FromContext while parsers still read req.Body.
+func NewCaptureMiddleware(opts *CaptureMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultCaptureMiddlewareOptions()
+	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = NewCaptureErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			capturedReq, _, err := Capture(w, req, opts.MaxBodySize)
+			if err != nil {
+				if err = weberrors.Render(w, req, errorResponseFn(err)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			next.ServeHTTP(w, capturedReq)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// NewCaptureErrorResponse responds with 413 Request Entity Too Large for oversized bodies and 400 Bad
+// Request otherwise.
+func NewCaptureErrorResponse(err error) render.Renderer {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return weberrors.NewRequestEntityTooLargeResponse("")
+	}
+	return weberrors.NewBadRequestResponse("Failed to read request body")
+}
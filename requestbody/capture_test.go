@@ -0,0 +1,107 @@
+package requestbody
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	Name string `json:"name"`
+}
+
+func TestDefaultCaptureMiddlewareOptions(t *testing.T) {
+	opts := DefaultCaptureMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, int64(1<<20), opts.MaxBodySize)
+	assert.NotNil(t, opts.ErrorResponseFn)
+}
+
+func TestNewCaptureMiddleware(t *testing.T) {
+	t.Run("provides the raw body while parsers still read the body", func(t *testing.T) {
+		var raw []byte
+		var parsed payload
+		handler := NewCaptureMiddleware(nil)(validation.NewContextRequestBodyMiddleware[payload](nil)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				raw, _ = FromContext(r.Context())
+				parsed = validation.RequestBodyFromContext[payload](r.Context())
+			}),
+		))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"gopher"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, `{"name":"gopher"}`, string(raw))
+		assert.Equal(t, "gopher", parsed.Name)
+	})
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		opts := DefaultCaptureMiddlewareOptions()
+		opts.MaxBodySize = 4
+		handlerCalled := false
+		handler := NewCaptureMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		}))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too large")))
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("captures requests without body", func(t *testing.T) {
+		var captured bool
+		var raw []byte
+		handler := NewCaptureMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, captured = FromContext(r.Context())
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.True(t, captured)
+		assert.Empty(t, raw)
+	})
+}
+
+func TestCapture(t *testing.T) {
+	t.Run("restores body and GetBody", func(t *testing.T) {
+		req, body, err := Capture(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("content")), 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, "content", string(body))
+		assert.Equal(t, int64(7), req.ContentLength)
+		first, _ := io.ReadAll(req.Body)
+		replay, err := req.GetBody()
+		require.NoError(t, err)
+		second, _ := io.ReadAll(replay)
+		assert.Equal(t, "content", string(first))
+		assert.Equal(t, "content", string(second))
+	})
+
+	t.Run("returns bodies captured before without reading again", func(t *testing.T) {
+		req, _, err := Capture(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("content")), 0)
+		require.NoError(t, err)
+		_, _ = io.ReadAll(req.Body)
+
+		_, body, err := Capture(httptest.NewRecorder(), req, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(body))
+	})
+}
+
+func TestFromContext_WithoutCapture(t *testing.T) {
+	body, ok := FromContext(t.Context())
+
+	assert.False(t, ok)
+	assert.Nil(t, body)
+}
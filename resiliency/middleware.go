@@ -1,47 +1,419 @@
 package resiliency
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	mathrand "math/rand/v2"
 	"net/http"
+	"runtime"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/internal/instruments"
 	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/responsewriter"
 	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // PanicRecoveryMiddleware //
 
 type PanicRecoveryMiddlewareOptions struct {
 	ErrorResponse render.Renderer
+	// ErrorResponseFn builds the response from the recovered panic, e.g. NewPanicErrorResponse to
+	// include the incident ID. Takes precedence over ErrorResponse.
+	ErrorResponseFn func(recovered *RecoveredPanic) render.Renderer
+	// CaptureStackTrace logs the stack trace of the panic. Production deployments may disable it to
+	// keep stack traces out of their logs. Defaults to true.
+	CaptureStackTrace bool
+	// MaxStackTraceBytes truncates logged stack traces. Zero disables truncation. Defaults to 16 KiB.
+	MaxStackTraceBytes int
 }
 
 func DefaultPanicRecoveryMiddlewareOptions() *PanicRecoveryMiddlewareOptions {
 	return &PanicRecoveryMiddlewareOptions{
-		ErrorResponse: weberrors.NewPanicRecoveryResponse(),
+		ErrorResponse:      weberrors.NewPanicRecoveryResponse(),
+		CaptureStackTrace:  true,
+		MaxStackTraceBytes: 16 << 10,
 	}
 }
 
+// RecoveredPanic describes a panic recovered by the panic recovery middleware
+type RecoveredPanic struct {
+	// Value is the value passed to panic
+	Value any
+	// IncidentID identifies the log entry of the panic
+	IncidentID string
+	// StackTrace is empty if stack traces are not captured
+	StackTrace string
+}
+
+// NewPanicRecoveryMiddleware recovers from panics of the handler, logs them with an incident ID and
+// responds with 500 Internal Server Error, unless the handler already started its response.
+// Recovered panics are counted by the http.server.panics.count metric per route.
 func NewPanicRecoveryMiddleware(opts *PanicRecoveryMiddlewareOptions) func(next http.Handler) http.Handler {
 	if opts == nil {
 		opts = DefaultPanicRecoveryMiddlewareOptions()
 	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = func(*RecoveredPanic) render.Renderer {
+			return opts.ErrorResponse
+		}
+	}
+
+	panics, _ := instruments.Int64Counter(otel.GetMeterProvider(), "server",
+		"http.server.panics.count",
+		metric.WithDescription("Number of panics recovered from HTTP server handlers by method and route"),
+	)
 
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
+			ww := responsewriter.Wrap(w)
 			defer func() {
 				ctx := req.Context()
 				rvr := recover()
 				if rvr != nil && rvr != http.ErrAbortHandler {
-					aulogging.Logger.Ctx(ctx).Error().With(logging.LogFieldStackTrace, string(debug.Stack())).Print("recovered from panic")
-					if err := render.Render(w, req, opts.ErrorResponse); err != nil {
+					recovered := &RecoveredPanic{
+						Value:      rvr,
+						IncidentID: newIncidentID(),
+					}
+					if opts.CaptureStackTrace {
+						recovered.StackTrace = truncateStackTrace(debug.Stack(), opts.MaxStackTraceBytes)
+					}
+
+					panics.Add(ctx, 1, metric.WithAttributes(
+						attribute.String("http.request.method", req.Method),
+						attribute.String("http.route", routePattern(req)),
+					))
+
+					logEvent := aulogging.Logger.Ctx(ctx).Error().With(logging.LogFieldIncidentID, recovered.IncidentID)
+					if recovered.StackTrace != "" {
+						logEvent = logEvent.With(logging.LogFieldStackTrace, recovered.StackTrace)
+					}
+					if ww.Written() || ww.Hijacked() {
+						// A second status line would corrupt the response sent so far.
+						logEvent.Print("recovered from panic after the response was started, skipping error response")
+						return
+					}
+					logEvent.Print("recovered from panic")
+
+					if err := weberrors.Render(ww, req, errorResponseFn(recovered)); err != nil {
 						panic(err)
 					}
 				}
 			}()
 
+			next.ServeHTTP(ww, req)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// PanicErrorResponse is a 500 Internal Server Error referencing the incident of a recovered panic,
+// which allows support to find the corresponding log entry.
+type PanicErrorResponse struct {
+	weberrors.ErrorResponse
+	IncidentID string `json:"incidentId"`
+}
+
+func NewPanicErrorResponse(recovered *RecoveredPanic) render.Renderer {
+	return &PanicErrorResponse{
+		ErrorResponse: weberrors.NewPanicRecoveryResponse().ErrorResponse,
+		IncidentID:    recovered.IncidentID,
+	}
+}
+
+func newIncidentID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func truncateStackTrace(stack []byte, maxBytes int) string {
+	if maxBytes <= 0 || len(stack) <= maxBytes {
+		return string(stack)
+	}
+	return string(stack[:maxBytes]) + "\n... (truncated)"
+}
+
+// routePattern returns the route matched by chi or net/http ServeMux so far, or "unmatched"
+func routePattern(req *http.Request) string {
+	if routeCtx := chi.RouteContext(req.Context()); routeCtx != nil {
+		if pattern := routeCtx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	if _, pattern, ok := strings.Cut(req.Pattern, " "); ok {
+		return strings.TrimLeft(pattern, " ")
+	}
+	if req.Pattern != "" {
+		return req.Pattern
+	}
+	return "unmatched"
+}
+
+// ConcurrencyLimitMiddleware //
+
+type ConcurrencyLimitMiddlewareOptions struct {
+	// MaxInFlight limits the number of requests handled simultaneously. Defaults to 100.
+	MaxInFlight int
+	// MaxQueue limits the number of requests waiting for a free slot. Further requests are rejected
+	// immediately. Defaults to 100.
+	MaxQueue int
+	// QueueTimeout defines how long a request waits for a free slot before it is rejected. Defaults to 1s.
+	QueueTimeout  time.Duration
+	ErrorResponse render.Renderer
+}
+
+func DefaultConcurrencyLimitMiddlewareOptions() *ConcurrencyLimitMiddlewareOptions {
+	return &ConcurrencyLimitMiddlewareOptions{
+		MaxInFlight:   100,
+		MaxQueue:      100,
+		QueueTimeout:  time.Second,
+		ErrorResponse: weberrors.NewOverloadedResponse(),
+	}
+}
+
+func NewConcurrencyLimitMiddleware(opts *ConcurrencyLimitMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultConcurrencyLimitMiddlewareOptions()
+	}
+	slots := make(chan struct{}, max(opts.MaxInFlight, 1))
+	var queued atomic.Int64
+
+	acquire := func(req *http.Request) bool {
+		select {
+		case slots <- struct{}{}:
+			return true
+		default:
+		}
+		if queued.Add(1) > int64(opts.MaxQueue) {
+			queued.Add(-1)
+			return false
+		}
+		defer queued.Add(-1)
+
+		timer := time.NewTimer(opts.QueueTimeout)
+		defer timer.Stop()
+		select {
+		case slots <- struct{}{}:
+			return true
+		case <-timer.C:
+			return false
+		case <-req.Context().Done():
+			return false
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !acquire(req) {
+				aulogging.Logger.Ctx(req.Context()).Warn().Printf("rejected request %s %s: concurrency limit reached", req.Method, req.URL.Path)
+				if err := weberrors.Render(w, req, opts.ErrorResponse); err != nil {
+					panic(err)
+				}
+				return
+			}
+			defer func() { <-slots }()
+
+			next.ServeHTTP(w, req)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// LoadSheddingMiddleware //
+
+// PressureProbe reports the current load of the service, e.g. a queue length or the goroutine count.
+type PressureProbe func() float64
+
+// GoroutineCountProbe reports the number of goroutines as pressure.
+func GoroutineCountProbe() float64 {
+	return float64(runtime.NumGoroutine())
+}
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityHigh
+)
+
+// NewHeaderPriorityFn treats requests as high priority if the given header equals "high"
+// (case-insensitive), and as low priority otherwise.
+func NewHeaderPriorityFn(headerName string) func(req *http.Request) Priority {
+	return func(req *http.Request) Priority {
+		if strings.EqualFold(req.Header.Get(headerName), "high") {
+			return PriorityHigh
+		}
+		return PriorityLow
+	}
+}
+
+type LoadSheddingMiddlewareOptions struct {
+	// Probe reports the current pressure. Defaults to GoroutineCountProbe.
+	Probe PressureProbe
+	// LowThreshold is the pressure above which low priority requests start being shed. The shed
+	// fraction grows linearly until HighThreshold, above which all low priority requests are shed.
+	// Defaults to 5000 and 10000.
+	LowThreshold  float64
+	HighThreshold float64
+	// PriorityFn extracts the priority of a request. High priority requests are never shed.
+	// Defaults to NewHeaderPriorityFn with the X-Request-Priority header.
+	PriorityFn    func(req *http.Request) Priority
+	ErrorResponse render.Renderer
+}
+
+func DefaultLoadSheddingMiddlewareOptions() *LoadSheddingMiddlewareOptions {
+	return &LoadSheddingMiddlewareOptions{
+		Probe:         GoroutineCountProbe,
+		LowThreshold:  5000,
+		HighThreshold: 10000,
+		PriorityFn:    NewHeaderPriorityFn(header.XRequestPriority),
+		ErrorResponse: weberrors.NewLoadSheddingResponse(time.Second),
+	}
+}
+
+func NewLoadSheddingMiddleware(opts *LoadSheddingMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultLoadSheddingMiddlewareOptions()
+	}
+	probe := opts.Probe
+	if probe == nil {
+		probe = GoroutineCountProbe
+	}
+	priorityFn := opts.PriorityFn
+	if priorityFn == nil {
+		priorityFn = NewHeaderPriorityFn(header.XRequestPriority)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if priorityFn(req) == PriorityLow && mathrand.Float64() < shedFraction(probe(), opts.LowThreshold, opts.HighThreshold) {
+				aulogging.Logger.Ctx(req.Context()).Warn().Printf("shed request %s %s", req.Method, req.URL.Path)
+				if err := weberrors.Render(w, req, opts.ErrorResponse); err != nil {
+					panic(err)
+				}
+				return
+			}
+
 			next.ServeHTTP(w, req)
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+func shedFraction(pressure float64, low float64, high float64) float64 {
+	switch {
+	case pressure <= low:
+		return 0
+	case pressure >= high:
+		return 1
+	default:
+		return (pressure - low) / (high - low)
+	}
+}
+
+// DeadlineMiddleware //
+
+type DeadlineMiddlewareOptions struct {
+	// HeaderName carries the timeout budget of the caller, see ParseTimeout.
+	HeaderName string
+	// MaxTimeout caps the accepted budget. Zero accepts every budget. Defaults to 60s.
+	MaxTimeout time.Duration
+}
+
+func DefaultDeadlineMiddlewareOptions() *DeadlineMiddlewareOptions {
+	return &DeadlineMiddlewareOptions{
+		HeaderName: header.XRequestTimeout,
+		MaxTimeout: 60 * time.Second,
+	}
+}
+
+// NewDeadlineMiddleware derives the request context deadline from the remaining timeout budget sent
+// by the caller. Use DeadlinePropagationTransport to forward the remaining budget to upstreams.
+func NewDeadlineMiddleware(opts *DeadlineMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultDeadlineMiddlewareOptions()
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			value := req.Header.Get(opts.HeaderName)
+			if value == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			timeout, err := ParseTimeout(value)
+			if err != nil {
+				aulogging.Logger.Ctx(req.Context()).Info().WithErr(err).Printf("ignoring invalid %s header", opts.HeaderName)
+				next.ServeHTTP(w, req)
+				return
+			}
+			if opts.MaxTimeout > 0 && timeout > opts.MaxTimeout {
+				timeout = opts.MaxTimeout
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+var timeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// ParseTimeout parses timeouts in grpc-timeout format (up to 8 digits followed by one of the units
+// H, M, S, m, u, n, e.g. "250m") as well as Go duration strings (e.g. "1.5s"). Timeouts have to be
+// positive and representable as time.Duration.
+func ParseTimeout(value string) (time.Duration, error) {
+	if len(value) >= 2 && len(value) <= 9 {
+		if unit, ok := timeoutUnits[value[len(value)-1]]; ok {
+			if amount, err := strconv.ParseUint(value[:len(value)-1], 10, 64); err == nil {
+				if amount > uint64(math.MaxInt64/unit) {
+					return 0, fmt.Errorf("timeout %q out of range", value)
+				}
+				return positiveTimeout(value, time.Duration(amount)*unit)
+			}
+		}
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q", value)
+	}
+	return positiveTimeout(value, timeout)
+}
+
+func positiveTimeout(value string, timeout time.Duration) (time.Duration, error) {
+	if timeout <= 0 {
+		return 0, fmt.Errorf("non-positive timeout %q", value)
+	}
+	return timeout, nil
+}
+
+// FormatTimeout formats a timeout in grpc-timeout format with millisecond precision, rounding up.
+func FormatTimeout(timeout time.Duration) string {
+	milliseconds := (timeout + time.Millisecond - 1) / time.Millisecond
+	return strconv.FormatInt(int64(milliseconds), 10) + "m"
+}
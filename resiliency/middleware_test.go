@@ -1,12 +1,19 @@
 package resiliency
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestDefaultPanicRecoveryMiddlewareOptions(t *testing.T) {
@@ -14,6 +21,9 @@ func TestDefaultPanicRecoveryMiddlewareOptions(t *testing.T) {
 
 	require.NotNil(t, opts)
 	assert.NotNil(t, opts.ErrorResponse)
+	assert.Nil(t, opts.ErrorResponseFn)
+	assert.True(t, opts.CaptureStackTrace)
+	assert.Equal(t, 16<<10, opts.MaxStackTraceBytes)
 }
 
 func TestNewPanicRecoveryMiddleware(t *testing.T) {
@@ -84,4 +94,353 @@ func TestNewPanicRecoveryMiddleware(t *testing.T) {
 		// No response should be written when ErrAbortHandler is panicked
 		assert.Equal(t, http.StatusOK, rr.Code) // Actually, httptest.ResponseRecorder defaults to 200 if WriteHeader isn't called
 	})
+
+	t.Run("custom renderer receives the recovered panic", func(t *testing.T) {
+		var recovered *RecoveredPanic
+		opts := DefaultPanicRecoveryMiddlewareOptions()
+		opts.ErrorResponseFn = func(r *RecoveredPanic) render.Renderer {
+			recovered = r
+			return NewPanicErrorResponse(r)
+		}
+		middleware := NewPanicRecoveryMiddleware(opts)
+
+		rr := httptest.NewRecorder()
+		middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("test panic")
+		})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.NotNil(t, recovered)
+		assert.Equal(t, "test panic", recovered.Value)
+		assert.Len(t, recovered.IncidentID, 16)
+		assert.Contains(t, recovered.StackTrace, "goroutine")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, recovered.IncidentID, body["incidentId"])
+		assert.Equal(t, "An unexpected error occurred", body["message"])
+	})
+
+	t.Run("skips the error response after the response was started", func(t *testing.T) {
+		rendered := false
+		opts := DefaultPanicRecoveryMiddlewareOptions()
+		opts.ErrorResponseFn = func(r *RecoveredPanic) render.Renderer {
+			rendered = true
+			return opts.ErrorResponse
+		}
+		middleware := NewPanicRecoveryMiddleware(opts)
+
+		rr := httptest.NewRecorder()
+		assert.NotPanics(t, func() {
+			middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte("partial"))
+				panic("test panic")
+			})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+
+		assert.False(t, rendered)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "partial", rr.Body.String())
+	})
+
+	t.Run("stack trace capture policy", func(t *testing.T) {
+		panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("test panic")
+		})
+		capture := func(opts *PanicRecoveryMiddlewareOptions) *RecoveredPanic {
+			var recovered *RecoveredPanic
+			opts.ErrorResponseFn = func(r *RecoveredPanic) render.Renderer {
+				recovered = r
+				return opts.ErrorResponse
+			}
+			NewPanicRecoveryMiddleware(opts)(panicking).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			return recovered
+		}
+
+		opts := DefaultPanicRecoveryMiddlewareOptions()
+		opts.MaxStackTraceBytes = 64
+		recovered := capture(opts)
+		require.NotNil(t, recovered)
+		assert.True(t, strings.HasSuffix(recovered.StackTrace, "... (truncated)"))
+		assert.Len(t, recovered.StackTrace, 64+len("\n... (truncated)"))
+
+		opts = DefaultPanicRecoveryMiddlewareOptions()
+		opts.CaptureStackTrace = false
+		recovered = capture(opts)
+		require.NotNil(t, recovered)
+		assert.Empty(t, recovered.StackTrace)
+		assert.NotEmpty(t, recovered.IncidentID)
+	})
+}
+
+func TestNewPanicRecoveryMiddleware_Metrics(t *testing.T) {
+	reader := installMetricReader(t)
+
+	r := chi.NewRouter()
+	r.Use(NewPanicRecoveryMiddleware(nil))
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("test panic")
+	})
+	for range 2 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var points []metricdata.DataPoint[int64]
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if m.Name == "http.server.panics.count" {
+				points = append(points, m.Data.(metricdata.Sum[int64]).DataPoints...)
+			}
+		}
+	}
+	require.Len(t, points, 1)
+	assert.Equal(t, int64(2), points[0].Value)
+	route, _ := points[0].Attributes.Value("http.route")
+	assert.Equal(t, "/items/{id}", route.AsString())
+}
+
+func TestDefaultConcurrencyLimitMiddlewareOptions(t *testing.T) {
+	opts := DefaultConcurrencyLimitMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 100, opts.MaxInFlight)
+	assert.Equal(t, 100, opts.MaxQueue)
+	assert.Equal(t, time.Second, opts.QueueTimeout)
+	assert.NotNil(t, opts.ErrorResponse)
+}
+
+func TestNewConcurrencyLimitMiddleware(t *testing.T) {
+	newBlockingHandler := func() (http.Handler, chan struct{}, chan struct{}) {
+		started := make(chan struct{}, 10)
+		unblock := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-unblock
+			w.WriteHeader(http.StatusOK)
+		})
+		return handler, started, unblock
+	}
+	serveAsync := func(handler http.Handler) chan int {
+		codes := make(chan int, 1)
+		go func() {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes <- rr.Code
+		}()
+		return codes
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		middleware := NewConcurrencyLimitMiddleware(nil)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("rejects requests when saturated", func(t *testing.T) {
+		opts := DefaultConcurrencyLimitMiddlewareOptions()
+		opts.MaxInFlight = 1
+		opts.MaxQueue = 0
+		blocking, started, unblock := newBlockingHandler()
+		handler := NewConcurrencyLimitMiddleware(opts)(blocking)
+
+		first := serveAsync(handler)
+		<-started
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+		close(unblock)
+		assert.Equal(t, http.StatusOK, <-first)
+	})
+
+	t.Run("queued request is served once a slot is free", func(t *testing.T) {
+		opts := DefaultConcurrencyLimitMiddlewareOptions()
+		opts.MaxInFlight = 1
+		opts.MaxQueue = 1
+		opts.QueueTimeout = 5 * time.Second
+		blocking, started, unblock := newBlockingHandler()
+		handler := NewConcurrencyLimitMiddleware(opts)(blocking)
+
+		first := serveAsync(handler)
+		<-started
+		second := serveAsync(handler)
+		time.Sleep(20 * time.Millisecond)
+
+		close(unblock)
+		assert.Equal(t, http.StatusOK, <-first)
+		assert.Equal(t, http.StatusOK, <-second)
+	})
+
+	t.Run("queued request is rejected after timeout", func(t *testing.T) {
+		opts := DefaultConcurrencyLimitMiddlewareOptions()
+		opts.MaxInFlight = 1
+		opts.MaxQueue = 1
+		opts.QueueTimeout = 10 * time.Millisecond
+		blocking, started, unblock := newBlockingHandler()
+		handler := NewConcurrencyLimitMiddleware(opts)(blocking)
+
+		first := serveAsync(handler)
+		<-started
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+		close(unblock)
+		assert.Equal(t, http.StatusOK, <-first)
+	})
+}
+
+func TestDefaultLoadSheddingMiddlewareOptions(t *testing.T) {
+	opts := DefaultLoadSheddingMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Probe)
+	assert.Equal(t, 5000.0, opts.LowThreshold)
+	assert.Equal(t, 10000.0, opts.HighThreshold)
+	assert.NotNil(t, opts.PriorityFn)
+	assert.NotNil(t, opts.ErrorResponse)
+}
+
+func TestNewLoadSheddingMiddleware(t *testing.T) {
+	newHandler := func(pressure float64) http.Handler {
+		opts := DefaultLoadSheddingMiddlewareOptions()
+		opts.Probe = func() float64 { return pressure }
+		opts.LowThreshold = 10
+		opts.HighThreshold = 20
+		return NewLoadSheddingMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	serve := func(handler http.Handler, priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if priority != "" {
+			req.Header.Set("X-Request-Priority", priority)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		middleware := NewLoadSheddingMiddleware(nil)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("passes requests below threshold", func(t *testing.T) {
+		rr := serve(newHandler(5), "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("sheds low priority requests above high threshold", func(t *testing.T) {
+		rr := serve(newHandler(25), "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("never sheds high priority requests", func(t *testing.T) {
+		rr := serve(newHandler(25), "high")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestShedFraction(t *testing.T) {
+	assert.Equal(t, 0.0, shedFraction(5, 10, 20))
+	assert.Equal(t, 0.5, shedFraction(15, 10, 20))
+	assert.Equal(t, 1.0, shedFraction(20, 10, 20))
+	assert.Equal(t, 1.0, shedFraction(30, 10, 20))
+}
+
+func TestDefaultDeadlineMiddlewareOptions(t *testing.T) {
+	opts := DefaultDeadlineMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, "X-Request-Timeout", opts.HeaderName)
+	assert.Equal(t, 60*time.Second, opts.MaxTimeout)
+}
+
+func TestNewDeadlineMiddleware(t *testing.T) {
+	serve := func(opts *DeadlineMiddlewareOptions, timeout string) (time.Time, bool) {
+		var deadline time.Time
+		var ok bool
+		handler := NewDeadlineMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if timeout != "" {
+			req.Header.Set("X-Request-Timeout", timeout)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return deadline, ok
+	}
+
+	t.Run("without header", func(t *testing.T) {
+		_, ok := serve(nil, "")
+
+		assert.False(t, ok)
+	})
+
+	t.Run("derives deadline from header", func(t *testing.T) {
+		deadline, ok := serve(nil, "1500m")
+
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(1500*time.Millisecond), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("caps deadline at max timeout", func(t *testing.T) {
+		deadline, ok := serve(nil, "2H")
+
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("ignores invalid header", func(t *testing.T) {
+		_, ok := serve(nil, "soon")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestParseTimeout(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"250m", 250 * time.Millisecond},
+		{"2S", 2 * time.Second},
+		{"1M", time.Minute},
+		{"1H", time.Hour},
+		{"100u", 100 * time.Microsecond},
+		{"5n", 5 * time.Nanosecond},
+		{"1.5s", 1500 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			timeout, err := ParseTimeout(tc.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, timeout)
+		})
+	}
+
+	for _, value := range []string{"", "m", "abc", "-1s", "10x", "0S", "0s", "99999999H"} {
+		t.Run("invalid "+value, func(t *testing.T) {
+			_, err := ParseTimeout(value)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFormatTimeout(t *testing.T) {
+	assert.Equal(t, "1500m", FormatTimeout(1500*time.Millisecond))
+	assert.Equal(t, "1m", FormatTimeout(time.Microsecond))
 }
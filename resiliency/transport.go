@@ -1,27 +1,88 @@
 package resiliency
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	slogging "github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/clock"
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/internal/instruments"
+	"github.com/Roshick/go-autumn-web/logging"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
 	"github.com/sony/gobreaker/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
+// CircuitBreakerTransport //
+
 type CircuitBreakerTransportOptions struct {
+	// Settings configure the breaker. Its Name labels metrics and logs, its OnStateChange is called on
+	// every transition and defaults to LogCircuitBreakerStateChange. Its intervals and timeout are
+	// measured by gobreaker with the system clock.
 	gobreaker.Settings
 }
 
 var _ http.RoundTripper = (*CircuitBreakerTransport)(nil)
 
+// CircuitBreakerTransport rejects requests while the breaker is open. It reports the state of the
+// breaker as circuit_breaker.state gauge (0 closed, 1 half-open, 2 open) and counts rejected requests.
+// Transports that are discarded before the end of the process have to be closed, so their breaker
+// is no longer reported.
 type CircuitBreakerTransport struct {
 	base http.RoundTripper
 	cb   *gobreaker.CircuitBreaker[*http.Response]
+
+	rejectedRequests  metric.Int64Counter
+	stateRegistration metric.Registration
 }
 
 func (t *CircuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	return t.cb.Execute(func() (*http.Response, error) {
+	res, err := t.cb.Execute(func() (*http.Response, error) {
 		return t.base.RoundTrip(req)
 	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		t.rejectedRequests.Add(req.Context(), 1, metric.WithAttributes(
+			attribute.String("circuit_breaker.name", t.cb.Name()),
+			attribute.String("circuit_breaker.state", t.cb.State().String()),
+		))
+	}
+	return res, err
+}
+
+func (t *CircuitBreakerTransport) Describe() (string, string) {
+	return "breaker", fmt.Sprintf("name=%s", t.cb.Name())
+}
+
+func (t *CircuitBreakerTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// Close stops reporting the state of the breaker. The transport keeps working.
+func (t *CircuitBreakerTransport) Close() error {
+	if t.stateRegistration == nil {
+		return nil
+	}
+	return t.stateRegistration.Unregister()
+}
+
+// State returns the current state of the breaker.
+func (t *CircuitBreakerTransport) State() gobreaker.State {
+	return t.cb.State()
 }
 
 func DefaultCircuitBreakerTransportOptions() *CircuitBreakerTransportOptions {
@@ -35,10 +96,20 @@ func DefaultCircuitBreakerTransportOptions() *CircuitBreakerTransportOptions {
 				failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
 				return counts.Requests >= 5 && failureRatio >= 0.6
 			},
+			OnStateChange: LogCircuitBreakerStateChange,
 		},
 	}
 }
 
+// LogCircuitBreakerStateChange logs transitions to open as warnings and all others as info.
+func LogCircuitBreakerStateChange(name string, from gobreaker.State, to gobreaker.State) {
+	logger := aulogging.Logger.NoCtx().Info()
+	if to == gobreaker.StateOpen {
+		logger = aulogging.Logger.NoCtx().Warn()
+	}
+	logger.Printf("circuit breaker '%s' changed from %s to %s", name, from, to)
+}
+
 func NewCircuitBreakerTransport(rt http.RoundTripper, opts *CircuitBreakerTransportOptions) *CircuitBreakerTransport {
 	if rt == nil {
 		rt = http.DefaultTransport
@@ -48,8 +119,1031 @@ func NewCircuitBreakerTransport(rt http.RoundTripper, opts *CircuitBreakerTransp
 	}
 
 	cb := gobreaker.NewCircuitBreaker[*http.Response](opts.Settings)
+
+	provider := otel.GetMeterProvider()
+	rejectedRequests, _ := instruments.Int64Counter(provider, "circuit_breaker",
+		"circuit_breaker.rejected.total",
+		metric.WithDescription("Total number of requests rejected by an open or half-open circuit breaker"),
+	)
+	stateGauge, _ := instruments.Int64ObservableGauge(provider, "circuit_breaker",
+		"circuit_breaker.state",
+		metric.WithDescription("State of the circuit breaker: 0 closed, 1 half-open, 2 open"),
+	)
+	stateRegistration, _ := provider.Meter("circuit_breaker").RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		observer.ObserveInt64(stateGauge, int64(cb.State()), metric.WithAttributes(attribute.String("circuit_breaker.name", cb.Name())))
+		return nil
+	}, stateGauge)
+
 	return &CircuitBreakerTransport{
+		base:              rt,
+		cb:                cb,
+		rejectedRequests:  rejectedRequests,
+		stateRegistration: stateRegistration,
+	}
+}
+
+// ConcurrencyLimitTransport //
+
+type ConcurrencyLimitTransportOptions struct {
+	// MaxInFlightPerHost limits the number of simultaneous requests per target host. A request holds
+	// its slot until the response body is closed. Defaults to 10.
+	MaxInFlightPerHost int
+}
+
+func DefaultConcurrencyLimitTransportOptions() *ConcurrencyLimitTransportOptions {
+	return &ConcurrencyLimitTransportOptions{
+		MaxInFlightPerHost: 10,
+	}
+}
+
+var _ http.RoundTripper = (*ConcurrencyLimitTransport)(nil)
+
+type ConcurrencyLimitTransport struct {
+	base http.RoundTripper
+	opts *ConcurrencyLimitTransportOptions
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func NewConcurrencyLimitTransport(rt http.RoundTripper, opts *ConcurrencyLimitTransportOptions) *ConcurrencyLimitTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultConcurrencyLimitTransportOptions()
+	}
+
+	return &ConcurrencyLimitTransport{
+		base:  rt,
+		opts:  opts,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (t *ConcurrencyLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots := t.hostSlots(req.URL.Host)
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	release := sync.OnceFunc(func() { <-slots })
+	res, err := t.base.RoundTrip(req)
+	if err != nil || res.Body == nil {
+		release()
+		return res, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+func (t *ConcurrencyLimitTransport) Describe() (string, string) {
+	return "concurrencylimit", fmt.Sprintf("maxInFlightPerHost=%d", t.opts.MaxInFlightPerHost)
+}
+
+func (t *ConcurrencyLimitTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (t *ConcurrencyLimitTransport) hostSlots(host string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slots, ok := t.slots[host]
+	if !ok {
+		slots = make(chan struct{}, max(t.opts.MaxInFlightPerHost, 1))
+		t.slots[host] = slots
+	}
+	return slots
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// HedgingTransport //
+
+type HedgingTransportOptions struct {
+	// Delay defines how long to wait for an attempt before issuing the next one. Defaults to 100ms.
+	Delay time.Duration
+	// MaxHedges limits the number of additional attempts. Defaults to 1.
+	MaxHedges int
+	// Methods lists the idempotent methods eligible for hedging. Defaults to GET, HEAD and OPTIONS.
+	Methods []string
+}
+
+func DefaultHedgingTransportOptions() *HedgingTransportOptions {
+	return &HedgingTransportOptions{
+		Delay:     100 * time.Millisecond,
+		MaxHedges: 1,
+		Methods:   []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+	}
+}
+
+var _ http.RoundTripper = (*HedgingTransport)(nil)
+
+// HedgingTransport issues additional identical requests if an attempt has not completed within the
+// configured delay, or failed before. The first successful response (no error, status below 500)
+// is returned and all other attempts are canceled.
+type HedgingTransport struct {
+	base http.RoundTripper
+	opts *HedgingTransportOptions
+}
+
+func NewHedgingTransport(rt http.RoundTripper, opts *HedgingTransportOptions) *HedgingTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultHedgingTransportOptions()
+	}
+
+	return &HedgingTransport{
 		base: rt,
-		cb:   cb,
+		opts: opts,
+	}
+}
+
+var errHedgesExhausted = errors.New("hedges exhausted")
+
+type hedgeResult struct {
+	attempt int
+	res     *http.Response
+	err     error
+}
+
+func (t *HedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.MaxHedges <= 0 || !slices.Contains(t.opts.Methods, req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	results := make(chan hedgeResult, t.opts.MaxHedges+1)
+	var cancels []context.CancelFunc
+
+	launch := func() error {
+		if len(cancels) > t.opts.MaxHedges {
+			return errHedgesExhausted
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		attemptCtx, cancel := context.WithCancel(ctx)
+		attemptReq := req.Clone(attemptCtx)
+		if len(cancels) > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return err
+			}
+			attemptReq.Body = body
+		}
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			res, err := t.base.RoundTrip(attemptReq)
+			results <- hedgeResult{attempt: attempt, res: res, err: err}
+		}()
+		return nil
+	}
+	if err := launch(); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(t.opts.Delay)
+	defer timer.Stop()
+
+	pending := 1
+	var last hedgeResult
+	for {
+		select {
+		case <-timer.C:
+			if launch() == nil {
+				pending++
+				timer.Reset(t.opts.Delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil && result.res.StatusCode < 500 {
+				discardResult(last)
+				t.finish(cancels, result, results, pending)
+				return result.res, nil
+			}
+			discardResult(last)
+			last = result
+			if launch() == nil {
+				pending++
+				timer.Reset(t.opts.Delay)
+			} else if pending == 0 {
+				t.finish(cancels, last, results, pending)
+				return last.res, last.err
+			}
+		}
+	}
+}
+
+func (t *HedgingTransport) Describe() (string, string) {
+	return "hedging", fmt.Sprintf("delay=%s, maxHedges=%d", t.opts.Delay, t.opts.MaxHedges)
+}
+
+func (t *HedgingTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// finish cancels all attempts except the winning one, whose context is canceled once its response
+// body is closed, and discards the responses of attempts still running.
+func (t *HedgingTransport) finish(cancels []context.CancelFunc, winner hedgeResult, results chan hedgeResult, pending int) {
+	for attempt, cancel := range cancels {
+		if attempt != winner.attempt {
+			cancel()
+		}
+	}
+	if winner.res != nil && winner.res.Body != nil {
+		winner.res.Body = &releasingBody{ReadCloser: winner.res.Body, release: sync.OnceFunc(cancels[winner.attempt])}
+	} else {
+		cancels[winner.attempt]()
+	}
+	go func() {
+		for ; pending > 0; pending-- {
+			discardResult(<-results)
+		}
+	}()
+}
+
+func discardResult(result hedgeResult) {
+	discardResponse(result.res)
+}
+
+// FallbackTransport //
+
+type FallbackTransportOptions struct {
+	// FallbackURL replaces scheme and host of failed requests, which are then retried once. Requests
+	// with a body are only retried if it can be replayed via GetBody.
+	FallbackURL *url.URL
+	// FallbackRoundTripper sends the requests to the FallbackURL. Defaults to the wrapped round tripper,
+	// set it if that one would reject fallback requests as well, e.g. because it contains a circuit breaker.
+	FallbackRoundTripper http.RoundTripper
+	// FallbackResponseFn returns a canned response if the request still failed, e.g. because no
+	// FallbackURL is configured or the fallback failed as well.
+	FallbackResponseFn func(req *http.Request) *http.Response
+	// ShouldFallbackFn decides whether a result counts as failure. Defaults to transport errors
+	// (including an open circuit breaker) and 5xx responses.
+	ShouldFallbackFn func(res *http.Response, err error) bool
+	// OnFallbackFn is called before a fallback is used, e.g. to log or record metrics. Defaults to
+	// logging a warning.
+	OnFallbackFn func(req *http.Request, res *http.Response, err error)
+}
+
+func DefaultFallbackTransportOptions() *FallbackTransportOptions {
+	return &FallbackTransportOptions{
+		ShouldFallbackFn: DefaultShouldFallback,
+		OnFallbackFn: func(req *http.Request, res *http.Response, err error) {
+			statusCode := 0
+			if res != nil {
+				statusCode = res.StatusCode
+			}
+			aulogging.Logger.Ctx(req.Context()).Warn().WithErr(err).Printf("request %s %s -> %d failed, using fallback", req.Method, req.URL.Redacted(), statusCode)
+		},
+	}
+}
+
+func DefaultShouldFallback(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= 500
+}
+
+var _ http.RoundTripper = (*FallbackTransport)(nil)
+
+type FallbackTransport struct {
+	base http.RoundTripper
+	opts *FallbackTransportOptions
+}
+
+func NewFallbackTransport(rt http.RoundTripper, opts *FallbackTransportOptions) *FallbackTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultFallbackTransportOptions()
+	}
+	if opts.ShouldFallbackFn == nil {
+		opts.ShouldFallbackFn = DefaultShouldFallback
+	}
+
+	return &FallbackTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *FallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if !t.opts.ShouldFallbackFn(res, err) {
+		return res, err
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if (t.opts.FallbackURL == nil || !replayable) && t.opts.FallbackResponseFn == nil {
+		return res, err
+	}
+	if t.opts.OnFallbackFn != nil {
+		t.opts.OnFallbackFn(req, res, err)
+	}
+
+	if t.opts.FallbackURL != nil && replayable {
+		fallbackReq := req.Clone(req.Context())
+		fallbackReq.URL.Scheme = t.opts.FallbackURL.Scheme
+		fallbackReq.URL.Host = t.opts.FallbackURL.Host
+		fallbackReq.Host = ""
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			fallbackReq.Body = body
+		}
+
+		fallbackRT := t.opts.FallbackRoundTripper
+		if fallbackRT == nil {
+			fallbackRT = t.base
+		}
+		discardResponse(res)
+		res, err = fallbackRT.RoundTrip(fallbackReq)
+		if !t.opts.ShouldFallbackFn(res, err) {
+			return res, err
+		}
+	}
+
+	if t.opts.FallbackResponseFn != nil {
+		discardResponse(res)
+		return t.opts.FallbackResponseFn(req), nil
+	}
+	return res, err
+}
+
+func (t *FallbackTransport) Describe() (string, string) {
+	if t.opts.FallbackURL == nil {
+		return "fallback", ""
+	}
+	return "fallback", fmt.Sprintf("url=%s", t.opts.FallbackURL.Redacted())
+}
+
+func (t *FallbackTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func discardResponse(res *http.Response) {
+	if res != nil && res.Body != nil {
+		_ = res.Body.Close()
+	}
+}
+
+// FaultInjectionTransport //
+
+// ErrInjectedFault is returned by the FaultInjectionTransport for injected connection errors.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultRule describes the faults injected into requests matching Host and PathPattern. Each fault
+// is injected with its own probability between 0 and 1.
+type FaultRule struct {
+	// Host matches the request URL host. Empty matches every host.
+	Host string
+	// PathPattern is matched against the request URL path, see path.Match. Empty matches every path.
+	PathPattern string
+
+	LatencyProbability float64
+	Latency            time.Duration
+
+	ErrorProbability float64
+	// Error is returned instead of sending the request. Defaults to ErrInjectedFault.
+	Error error
+
+	StatusCodeProbability float64
+	// StatusCode is returned instead of sending the request.
+	StatusCode int
+}
+
+type FaultInjectionTransportOptions struct {
+	// Rules are evaluated in order, the first matching rule applies.
+	Rules []FaultRule
+	// Enabled defines whether faults are injected initially, see FaultInjectionTransport.SetEnabled.
+	Enabled bool
+}
+
+func DefaultFaultInjectionTransportOptions() *FaultInjectionTransportOptions {
+	return &FaultInjectionTransportOptions{
+		Rules:   []FaultRule{},
+		Enabled: true,
+	}
+}
+
+var _ http.RoundTripper = (*FaultInjectionTransport)(nil)
+
+// FaultInjectionTransport injects latency, connection errors and error status codes for chaos
+// experiments. It is meant for testing and staging environments.
+type FaultInjectionTransport struct {
+	base http.RoundTripper
+	opts *FaultInjectionTransportOptions
+
+	enabled atomic.Bool
+}
+
+func NewFaultInjectionTransport(rt http.RoundTripper, opts *FaultInjectionTransportOptions) *FaultInjectionTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultFaultInjectionTransportOptions()
+	}
+
+	t := &FaultInjectionTransport{
+		base: rt,
+		opts: opts,
+	}
+	t.enabled.Store(opts.Enabled)
+	return t
+}
+
+// SetEnabled turns fault injection on or off at runtime.
+func (t *FaultInjectionTransport) SetEnabled(enabled bool) {
+	t.enabled.Store(enabled)
+}
+
+func (t *FaultInjectionTransport) Enabled() bool {
+	return t.enabled.Load()
+}
+
+func (t *FaultInjectionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.enabled.Load() {
+		return t.base.RoundTrip(req)
+	}
+	rule := t.matchRule(req)
+	if rule == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	if rule.Latency > 0 && mathrand.Float64() < rule.LatencyProbability {
+		timer := time.NewTimer(rule.Latency)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	if mathrand.Float64() < rule.ErrorProbability {
+		if rule.Error != nil {
+			return nil, rule.Error
+		}
+		return nil, ErrInjectedFault
+	}
+	if rule.StatusCode != 0 && mathrand.Float64() < rule.StatusCodeProbability {
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", rule.StatusCode, http.StatusText(rule.StatusCode)),
+			StatusCode: rule.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *FaultInjectionTransport) Describe() (string, string) {
+	return "faultinjection", fmt.Sprintf("enabled=%t, rules=%d", t.enabled.Load(), len(t.opts.Rules))
+}
+
+func (t *FaultInjectionTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (t *FaultInjectionTransport) matchRule(req *http.Request) *FaultRule {
+	for i := range t.opts.Rules {
+		rule := &t.opts.Rules[i]
+		if rule.Host != "" && !strings.EqualFold(rule.Host, req.URL.Host) {
+			continue
+		}
+		if rule.PathPattern != "" {
+			if matched, _ := path.Match(rule.PathPattern, req.URL.Path); !matched {
+				continue
+			}
+		}
+		return rule
+	}
+	return nil
+}
+
+// TimeoutTransport //
+
+type TimeoutTransportOptions struct {
+	// Timeout applies to every request whose host has no override. Zero disables it. Defaults to 30s.
+	Timeout time.Duration
+	// HostTimeouts overrides the timeout per request URL host.
+	HostTimeouts map[string]time.Duration
+}
+
+func DefaultTimeoutTransportOptions() *TimeoutTransportOptions {
+	return &TimeoutTransportOptions{
+		Timeout:      30 * time.Second,
+		HostTimeouts: map[string]time.Duration{},
+	}
+}
+
+var _ http.RoundTripper = (*TimeoutTransport)(nil)
+
+// TimeoutTransport bounds each request including reading its response body. A sooner deadline
+// already present in the request context is kept.
+type TimeoutTransport struct {
+	base http.RoundTripper
+	opts *TimeoutTransportOptions
+}
+
+func NewTimeoutTransport(rt http.RoundTripper, opts *TimeoutTransportOptions) *TimeoutTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultTimeoutTransportOptions()
+	}
+
+	return &TimeoutTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout, ok := t.opts.HostTimeouts[req.URL.Host]
+	if !ok {
+		timeout = t.opts.Timeout
+	}
+	if timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx := req.Context()
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil || res.Body == nil {
+		cancel()
+		return res, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: sync.OnceFunc(cancel)}
+	return res, nil
+}
+
+func (t *TimeoutTransport) Describe() (string, string) {
+	return "timeout", fmt.Sprintf("timeout=%s, hostTimeouts=%d", t.opts.Timeout, len(t.opts.HostTimeouts))
+}
+
+func (t *TimeoutTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// DeadlinePropagationTransport //
+
+type DeadlinePropagationTransportOptions struct {
+	// HeaderName carries the remaining timeout budget, see FormatTimeout.
+	HeaderName string
+}
+
+func DefaultDeadlinePropagationTransportOptions() *DeadlinePropagationTransportOptions {
+	return &DeadlinePropagationTransportOptions{
+		HeaderName: header.XRequestTimeout,
+	}
+}
+
+var _ http.RoundTripper = (*DeadlinePropagationTransport)(nil)
+
+// DeadlinePropagationTransport sends the time remaining until the request context deadline to
+// upstreams, the counterpart of the DeadlineMiddleware.
+type DeadlinePropagationTransport struct {
+	base http.RoundTripper
+	opts *DeadlinePropagationTransportOptions
+}
+
+func NewDeadlinePropagationTransport(rt http.RoundTripper, opts *DeadlinePropagationTransportOptions) *DeadlinePropagationTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultDeadlinePropagationTransportOptions()
+	}
+
+	return &DeadlinePropagationTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *DeadlinePropagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+
+	reqCopy := req.Clone(req.Context())
+	reqCopy.Header.Set(t.opts.HeaderName, FormatTimeout(remaining))
+	return t.base.RoundTrip(reqCopy)
+}
+
+func (t *DeadlinePropagationTransport) Describe() (string, string) {
+	return "deadline", fmt.Sprintf("header=%s", t.opts.HeaderName)
+}
+
+func (t *DeadlinePropagationTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// RetryTransport //
+
+type RetryTransportOptions struct {
+	// MaxRetries limits the number of retries after the first attempt. Defaults to 2.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled for every further retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. Defaults to 2s.
+	MaxBackoff time.Duration
+	// Methods lists the idempotent methods eligible for retries. Defaults to GET, HEAD, OPTIONS, PUT and DELETE.
+	Methods []string
+	// ShouldRetryFn decides whether a result is retried. Defaults to DefaultShouldRetry.
+	ShouldRetryFn func(res *http.Response, err error) bool
+	// Observer is notified about retries and the final attempt. Defaults to NewRetryMetricsObserver.
+	Observer RetryObserver
+	// Clock times the backoff between attempts. Defaults to the system clock.
+	Clock clock.Clock
+}
+
+func DefaultRetryTransportOptions() *RetryTransportOptions {
+	return &RetryTransportOptions{
+		MaxRetries:     2,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Methods:        []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete},
+		ShouldRetryFn:  DefaultShouldRetry,
+	}
+}
+
+// DefaultShouldRetry retries transport errors (except cancellation) and 502, 503 and 504 responses
+func DefaultShouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return res.StatusCode == http.StatusBadGateway ||
+		res.StatusCode == http.StatusServiceUnavailable ||
+		res.StatusCode == http.StatusGatewayTimeout
+}
+
+var _ http.RoundTripper = (*RetryTransport)(nil)
+
+// RetryTransport retries failed idempotent requests with exponential backoff and full jitter.
+// Requests with a body are only retried if it can be replayed via GetBody.
+type RetryTransport struct {
+	base http.RoundTripper
+	opts *RetryTransportOptions
+}
+
+func NewRetryTransport(rt http.RoundTripper, opts *RetryTransportOptions) *RetryTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultRetryTransportOptions()
+	}
+	if opts.ShouldRetryFn == nil {
+		opts.ShouldRetryFn = DefaultShouldRetry
+	}
+	if opts.Observer == nil {
+		opts.Observer = NewRetryMetricsObserver()
+	}
+	opts.Clock = clock.OrSystem(opts.Clock)
+
+	return &RetryTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !replayable || !slices.Contains(t.opts.Methods, req.Method) {
+		return t.base.RoundTrip(req)
+	}
+
+	backoff := t.opts.InitialBackoff
+	var totalDelay time.Duration
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				t.opts.Observer.OnComplete(req, attempt, totalDelay, nil, err)
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		res, err := t.base.RoundTrip(attemptReq)
+		if attempt > t.opts.MaxRetries || !t.opts.ShouldRetryFn(res, err) {
+			t.opts.Observer.OnComplete(req, attempt, totalDelay, res, err)
+			return res, err
+		}
+
+		delay := mathrand.N(backoff + 1)
+		t.opts.Observer.OnRetry(req, attempt, delay, res, err)
+		timer := t.opts.Clock.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			t.opts.Observer.OnComplete(req, attempt, totalDelay, res, err)
+			if err == nil {
+				return res, nil
+			}
+			return nil, err
+		case <-timer.C():
+		}
+		totalDelay += delay
+		discardResponse(res)
+		backoff = min(2*backoff, t.opts.MaxBackoff)
+	}
+}
+
+func (t *RetryTransport) Describe() (string, string) {
+	return "retry", fmt.Sprintf("maxRetries=%d, initialBackoff=%s, maxBackoff=%s", t.opts.MaxRetries, t.opts.InitialBackoff, t.opts.MaxBackoff)
+}
+
+func (t *RetryTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// RetryObserver is notified by the RetryTransport, e.g. to record metrics or logs. Other transports
+// retrying requests may notify it as well.
+type RetryObserver interface {
+	// OnRetry is called after a failed attempt, before waiting for the delay of the next one.
+	OnRetry(req *http.Request, attempt int, delay time.Duration, res *http.Response, err error)
+	// OnComplete is called once with the result of the final attempt and the total delay between attempts.
+	OnComplete(req *http.Request, attempts int, totalDelay time.Duration, res *http.Response, err error)
+}
+
+// RetryMetricsObserver records retries as metrics and logs them. Metrics are http.client.retry.total
+// counting retries, http.client.retry.attempts recording the final attempt number and
+// http.client.retry.delay recording the total delay between attempts in seconds.
+type RetryMetricsObserver struct {
+	retries    metric.Int64Counter
+	attempts   metric.Int64Histogram
+	totalDelay metric.Float64Histogram
+}
+
+var _ RetryObserver = (*RetryMetricsObserver)(nil)
+
+func NewRetryMetricsObserver() *RetryMetricsObserver {
+	provider := otel.GetMeterProvider()
+	retries, _ := instruments.Int64Counter(provider, "http.client.retry",
+		"http.client.retry.total",
+		metric.WithDescription("Total number of retried HTTP client requests"),
+	)
+	attempts, _ := instruments.Int64Histogram(provider, "http.client.retry",
+		"http.client.retry.attempts",
+		metric.WithDescription("Number of attempts of HTTP client requests eligible for retries"),
+		metric.WithExplicitBucketBoundaries(1, 2, 3, 4, 5, 10),
+	)
+	totalDelay, _ := instruments.Float64Histogram(provider, "http.client.retry",
+		"http.client.retry.delay",
+		metric.WithDescription("Total delay between attempts of HTTP client requests in seconds"),
+		metric.WithUnit("s"),
+	)
+
+	return &RetryMetricsObserver{
+		retries:    retries,
+		attempts:   attempts,
+		totalDelay: totalDelay,
+	}
+}
+
+func (o *RetryMetricsObserver) OnRetry(req *http.Request, attempt int, delay time.Duration, res *http.Response, err error) {
+	o.retries.Add(req.Context(), 1, metric.WithAttributes(retryAttributes(req)...))
+	aulogging.Logger.Ctx(retryLogContext(req, attempt, delay)).Info().WithErr(err).Printf("request %s %s -> %d failed in attempt %d, retrying in %d ms",
+		req.Method, req.URL.Redacted(), statusCode(res), attempt, delay.Milliseconds())
+}
+
+func (o *RetryMetricsObserver) OnComplete(req *http.Request, attempts int, totalDelay time.Duration, res *http.Response, err error) {
+	attributes := metric.WithAttributes(retryAttributes(req)...)
+	o.attempts.Record(req.Context(), int64(attempts), attributes)
+	o.totalDelay.Record(req.Context(), totalDelay.Seconds(), attributes)
+
+	if attempts > 1 {
+		aulogging.Logger.Ctx(retryLogContext(req, attempts, totalDelay)).Info().WithErr(err).Printf("request %s %s -> %d completed after %d attempts",
+			req.Method, req.URL.Redacted(), statusCode(res), attempts)
+	}
+}
+
+// retryLogContext adds the attempt and delay fields to the context logger
+func retryLogContext(req *http.Request, attempt int, delay time.Duration) context.Context {
+	ctx := req.Context()
+	if logger := slogging.FromContext(ctx); logger != nil {
+		ctx = slogging.ContextWithLogger(ctx, logger.With(
+			logging.LogFieldRetryAttempt, attempt,
+			logging.LogFieldRetryDelay, delay.Milliseconds(),
+		))
+	}
+	return ctx
+}
+
+func retryAttributes(req *http.Request) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+}
+
+func statusCode(res *http.Response) int {
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
+
+// MirrorTransport //
+
+type MirrorTransportOptions struct {
+	// MirrorURL replaces scheme and host of mirrored requests. Mirroring is disabled if it is nil.
+	MirrorURL *url.URL
+	// MirrorRoundTripper sends the mirrored requests. Defaults to the wrapped round tripper.
+	MirrorRoundTripper http.RoundTripper
+	// Percentage of eligible requests that are mirrored, between 0 and 100. Defaults to 100.
+	Percentage float64
+	// Methods lists the methods eligible for mirroring. Defaults to GET, HEAD and OPTIONS, as mirroring
+	// non-safe methods would change the state of the mirror.
+	Methods []string
+	// Timeout limits the duration of mirrored requests. Defaults to 10s.
+	Timeout time.Duration
+	// MaxInFlight limits the number of concurrent mirrored requests, further requests are not mirrored.
+	// Defaults to 100.
+	MaxInFlight int
+}
+
+func DefaultMirrorTransportOptions() *MirrorTransportOptions {
+	return &MirrorTransportOptions{
+		Percentage:  100,
+		Methods:     []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+		Timeout:     10 * time.Second,
+		MaxInFlight: 100,
+	}
+}
+
+const (
+	MirrorOutcomeSuccess = "success"
+	MirrorOutcomeError   = "error"
+	MirrorOutcomeDropped = "dropped"
+)
+
+var _ http.RoundTripper = (*MirrorTransport)(nil)
+
+// MirrorTransport asynchronously sends a copy of sampled requests to the MirrorURL, e.g. to validate a
+// new version of an upstream with production traffic. Mirrored responses are discarded, failures are
+// only logged and counted. Requests with a body are only mirrored if it can be replayed via GetBody.
+type MirrorTransport struct {
+	base   http.RoundTripper
+	mirror http.RoundTripper
+	opts   *MirrorTransportOptions
+
+	slots    chan struct{}
+	inFlight sync.WaitGroup
+
+	mirroredRequests metric.Int64Counter
+}
+
+func NewMirrorTransport(rt http.RoundTripper, opts *MirrorTransportOptions) *MirrorTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultMirrorTransportOptions()
+	}
+	mirror := opts.MirrorRoundTripper
+	if mirror == nil {
+		mirror = rt
+	}
+	maxInFlight := opts.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMirrorTransportOptions().MaxInFlight
+	}
+
+	mirroredRequests, _ := instruments.Int64Counter(otel.GetMeterProvider(), "http.client.mirror",
+		"http.client.mirror.request.total",
+		metric.WithDescription("Total number of mirrored HTTP client requests by method, outcome and status code"),
+	)
+
+	return &MirrorTransport{
+		base:             rt,
+		mirror:           mirror,
+		opts:             opts,
+		slots:            make(chan struct{}, maxInFlight),
+		mirroredRequests: mirroredRequests,
+	}
+}
+
+func (t *MirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.shouldMirror(req) {
+		t.startMirror(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *MirrorTransport) Describe() (string, string) {
+	if t.opts.MirrorURL == nil {
+		return "mirror", "disabled"
+	}
+	return "mirror", fmt.Sprintf("url=%s, percentage=%g", t.opts.MirrorURL.Redacted(), t.opts.Percentage)
+}
+
+func (t *MirrorTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+// Wait blocks until all mirrored requests in flight have finished, e.g. during graceful shutdown.
+func (t *MirrorTransport) Wait() {
+	t.inFlight.Wait()
+}
+
+func (t *MirrorTransport) shouldMirror(req *http.Request) bool {
+	if t.opts.MirrorURL == nil || !slices.Contains(t.opts.Methods, req.Method) {
+		return false
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	return replayable && mathrand.Float64()*100 < t.opts.Percentage
+}
+
+func (t *MirrorTransport) startMirror(req *http.Request) {
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		t.record(req, MirrorOutcomeDropped, 0)
+		return
+	}
+
+	ctx, cancel := contextutils.Detach(req.Context()), context.CancelFunc(func() {})
+	if t.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+	}
+	mirrorReq := req.Clone(ctx)
+	mirrorReq.URL.Scheme = t.opts.MirrorURL.Scheme
+	mirrorReq.URL.Host = t.opts.MirrorURL.Host
+	mirrorReq.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			<-t.slots
+			t.record(req, MirrorOutcomeError, 0)
+			return
+		}
+		mirrorReq.Body = body
+	}
+
+	t.inFlight.Add(1)
+	go func() {
+		defer t.inFlight.Done()
+		defer func() { <-t.slots }()
+		defer cancel()
+
+		res, err := t.mirror.RoundTrip(mirrorReq)
+		if err != nil {
+			aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("mirrored request %s %s failed", mirrorReq.Method, mirrorReq.URL.Redacted())
+			t.record(req, MirrorOutcomeError, 0)
+			return
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		t.record(req, MirrorOutcomeSuccess, res.StatusCode)
+	}()
+}
+
+func (t *MirrorTransport) record(req *http.Request, outcome string, statusCode int) {
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("mirror.outcome", outcome),
+	}
+	if statusCode > 0 {
+		attributes = append(attributes, attribute.Int("http.response.status_code", statusCode))
 	}
+	t.mirroredRequests.Add(req.Context(), 1, metric.WithAttributes(attributes...))
 }
@@ -1,15 +1,24 @@
 package resiliency
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/sony/gobreaker/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 // MockRoundTripper is a test double for http.RoundTripper
@@ -52,6 +61,7 @@ func TestDefaultCircuitBreakerTransportOptions(t *testing.T) {
 	assert.Equal(t, 60*time.Second, opts.Settings.Interval)
 	assert.Equal(t, 60*time.Second, opts.Settings.Timeout)
 	assert.NotNil(t, opts.Settings.ReadyToTrip)
+	assert.NotNil(t, opts.Settings.OnStateChange)
 }
 
 func TestNewCircuitBreakerTransport(t *testing.T) {
@@ -91,6 +101,98 @@ func TestNewCircuitBreakerTransport(t *testing.T) {
 	})
 }
 
+// installMetricReader installs an in-memory meter provider as global OTel meter provider until the test finishes
+func installMetricReader(t *testing.T) *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	previousProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() {
+		otel.SetMeterProvider(previousProvider)
+	})
+	return reader
+}
+
+func TestCircuitBreakerTransport_Observability(t *testing.T) {
+	reader := installMetricReader(t)
+
+	var transitions []string
+	opts := &CircuitBreakerTransportOptions{
+		Settings: gobreaker.Settings{
+			Name:        "payments",
+			MaxRequests: 1,
+			Timeout:     time.Hour,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= 1
+			},
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				transitions = append(transitions, fmt.Sprintf("%s: %s -> %s", name, from, to))
+			},
+		},
+	}
+	transport := NewCircuitBreakerTransport(&MockRoundTripper{errorToReturn: errors.New("connection refused")}, opts)
+
+	for range 3 {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/test", nil))
+		require.Error(t, err)
+	}
+
+	assert.Equal(t, []string{"payments: closed -> open"}, transitions)
+	assert.Equal(t, gobreaker.StateOpen, transport.State())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	// Breakers of other tests report as well, so only data points of this breaker are considered.
+	values := map[string]int64{}
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			var points []metricdata.DataPoint[int64]
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				points = data.DataPoints
+			case metricdata.Gauge[int64]:
+				points = data.DataPoints
+			}
+			for _, point := range points {
+				if name, _ := point.Attributes.Value("circuit_breaker.name"); name.AsString() == "payments" {
+					values[m.Name] += point.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(2), values["circuit_breaker.rejected.total"])
+	assert.Equal(t, int64(gobreaker.StateOpen), values["circuit_breaker.state"])
+}
+
+func TestCircuitBreakerTransport_Close(t *testing.T) {
+	reader := installMetricReader(t)
+
+	opts := DefaultCircuitBreakerTransportOptions()
+	opts.Name = "inventory"
+	closed := NewCircuitBreakerTransport(&MockRoundTripper{}, opts)
+	open := NewCircuitBreakerTransport(&MockRoundTripper{}, opts)
+	require.NoError(t, closed.Close())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	reported := 0
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == "circuit_breaker.state" {
+				for _, point := range gauge.DataPoints {
+					if name, _ := point.Attributes.Value("circuit_breaker.name"); name.AsString() == "inventory" {
+						reported++
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, 1, reported)
+
+	require.NoError(t, open.Close())
+	_, err := closed.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/test", nil))
+	assert.NoError(t, err)
+}
+
 func TestCircuitBreakerTransport_RoundTrip(t *testing.T) {
 	t.Run("successful request passes through", func(t *testing.T) {
 		mockRT := &MockRoundTripper{
@@ -286,3 +388,860 @@ func TestCircuitBreakerTransport_ImplementsRoundTripper(t *testing.T) {
 	var _ http.RoundTripper = transport
 	assert.Implements(t, (*http.RoundTripper)(nil), transport)
 }
+
+func TestDefaultConcurrencyLimitTransportOptions(t *testing.T) {
+	opts := DefaultConcurrencyLimitTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 10, opts.MaxInFlightPerHost)
+}
+
+func TestNewConcurrencyLimitTransport(t *testing.T) {
+	t.Run("with nil round tripper and options uses defaults", func(t *testing.T) {
+		transport := NewConcurrencyLimitTransport(nil, nil)
+
+		require.NotNil(t, transport)
+		assert.Equal(t, http.DefaultTransport, transport.base)
+		assert.NotNil(t, transport.opts)
+	})
+}
+
+func TestConcurrencyLimitTransport_RoundTrip(t *testing.T) {
+	newTransport := func() *ConcurrencyLimitTransport {
+		return NewConcurrencyLimitTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Header: make(http.Header)}, nil
+		}), &ConcurrencyLimitTransportOptions{MaxInFlightPerHost: 1})
+	}
+
+	t.Run("blocks until response body is closed", func(t *testing.T) {
+		transport := newTransport()
+
+		first, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/b", nil).WithContext(ctx))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, first.Body.Close())
+		second, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/b", nil))
+		require.NoError(t, err)
+		require.NoError(t, second.Body.Close())
+	})
+
+	t.Run("limits hosts independently", func(t *testing.T) {
+		transport := newTransport()
+
+		first, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+		require.NoError(t, err)
+		defer first.Body.Close()
+
+		other, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://other.localhost/a", nil))
+		require.NoError(t, err)
+		require.NoError(t, other.Body.Close())
+	})
+
+	t.Run("releases slot on error", func(t *testing.T) {
+		transport := NewConcurrencyLimitTransport(&MockRoundTripper{errorToReturn: errors.New("network error")}, &ConcurrencyLimitTransportOptions{MaxInFlightPerHost: 1})
+
+		for i := 0; i < 3; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+			assert.EqualError(t, err, "network error")
+		}
+	})
+}
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDefaultHedgingTransportOptions(t *testing.T) {
+	opts := DefaultHedgingTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 100*time.Millisecond, opts.Delay)
+	assert.Equal(t, 1, opts.MaxHedges)
+	assert.Equal(t, []string{http.MethodGet, http.MethodHead, http.MethodOptions}, opts.Methods)
+}
+
+func TestNewHedgingTransport(t *testing.T) {
+	t.Run("with nil round tripper and options uses defaults", func(t *testing.T) {
+		transport := NewHedgingTransport(nil, nil)
+
+		require.NotNil(t, transport)
+		assert.Equal(t, http.DefaultTransport, transport.base)
+		assert.NotNil(t, transport.opts)
+	})
+}
+
+func TestHedgingTransport_RoundTrip(t *testing.T) {
+	newResponse := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}
+	}
+	newOpts := func() *HedgingTransportOptions {
+		opts := DefaultHedgingTransportOptions()
+		opts.Delay = 10 * time.Millisecond
+		return opts
+	}
+
+	t.Run("hedges slow requests and cancels the slow attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		firstCanceled := make(chan struct{})
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if calls.Add(1) == 1 {
+				<-req.Context().Done()
+				close(firstCanceled)
+				return nil, req.Context().Err()
+			}
+			return newResponse(http.StatusOK, "hedge"), nil
+		}), newOpts())
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, "hedge", string(body))
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, int32(2), calls.Load())
+		select {
+		case <-firstCanceled:
+		case <-time.After(time.Second):
+			t.Fatal("slow attempt was not canceled")
+		}
+	})
+
+	t.Run("does not hedge fast requests", func(t *testing.T) {
+		var calls atomic.Int32
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return newResponse(http.StatusOK, "ok"), nil
+		}), newOpts())
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("does not hedge non-idempotent methods", func(t *testing.T) {
+		var calls atomic.Int32
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			time.Sleep(30 * time.Millisecond)
+			return newResponse(http.StatusOK, "ok"), nil
+		}), newOpts())
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodPost, "https://api.localhost/a", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("hedges immediately after server error", func(t *testing.T) {
+		var calls atomic.Int32
+		opts := newOpts()
+		opts.Delay = time.Hour
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if calls.Add(1) == 1 {
+				return newResponse(http.StatusBadGateway, ""), nil
+			}
+			return newResponse(http.StatusOK, "ok"), nil
+		}), opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("returns last failure if all attempts fail", func(t *testing.T) {
+		var calls atomic.Int32
+		opts := newOpts()
+		opts.MaxHedges = 2
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			return nil, errors.New("network error")
+		}), opts)
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/a", nil))
+
+		assert.EqualError(t, err, "network error")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("replays request body for hedges", func(t *testing.T) {
+		var calls atomic.Int32
+		bodies := make(chan string, 2)
+		opts := newOpts()
+		opts.Methods = []string{http.MethodPut}
+		transport := NewHedgingTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies <- string(body)
+			if calls.Add(1) == 1 {
+				return newResponse(http.StatusServiceUnavailable, ""), nil
+			}
+			return newResponse(http.StatusOK, "ok"), nil
+		}), opts)
+
+		req, err := http.NewRequest(http.MethodPut, "https://api.localhost/a", strings.NewReader("payload"))
+		require.NoError(t, err)
+		_, err = transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "payload", <-bodies)
+		assert.Equal(t, "payload", <-bodies)
+	})
+}
+
+func TestDefaultFallbackTransportOptions(t *testing.T) {
+	opts := DefaultFallbackTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Nil(t, opts.FallbackURL)
+	assert.Nil(t, opts.FallbackResponseFn)
+	assert.NotNil(t, opts.ShouldFallbackFn)
+	assert.NotNil(t, opts.OnFallbackFn)
+}
+
+func TestDefaultShouldFallback(t *testing.T) {
+	assert.True(t, DefaultShouldFallback(nil, errors.New("network error")))
+	assert.True(t, DefaultShouldFallback(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(t, DefaultShouldFallback(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.True(t, DefaultShouldFallback(nil, gobreaker.ErrOpenState))
+}
+
+func TestFallbackTransport_RoundTrip(t *testing.T) {
+	fallbackURL, _ := url.Parse("https://fallback.localhost")
+	newResponse := func(status int) *http.Response {
+		return &http.Response{StatusCode: status, Body: http.NoBody, Header: make(http.Header)}
+	}
+	hostResponder := func(statusByHost map[string]int, hosts *[]string) RoundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			*hosts = append(*hosts, req.URL.Host)
+			status, ok := statusByHost[req.URL.Host]
+			if !ok {
+				return nil, errors.New("connection refused")
+			}
+			return newResponse(status), nil
+		}
+	}
+
+	t.Run("passes through successful responses", func(t *testing.T) {
+		var hosts []string
+		opts := DefaultFallbackTransportOptions()
+		opts.FallbackURL = fallbackURL
+		transport := NewFallbackTransport(hostResponder(map[string]int{"api.localhost": 200}, &hosts), opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"api.localhost"}, hosts)
+	})
+
+	t.Run("retries failed requests against fallback URL", func(t *testing.T) {
+		var hosts []string
+		var fallbackErr error
+		opts := DefaultFallbackTransportOptions()
+		opts.FallbackURL = fallbackURL
+		opts.OnFallbackFn = func(req *http.Request, res *http.Response, err error) {
+			fallbackErr = err
+		}
+		transport := NewFallbackTransport(hostResponder(map[string]int{"fallback.localhost": 200}, &hosts), opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items?page=2", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"api.localhost", "fallback.localhost"}, hosts)
+		assert.EqualError(t, fallbackErr, "connection refused")
+	})
+
+	t.Run("returns canned response if fallback fails too", func(t *testing.T) {
+		var hosts []string
+		opts := DefaultFallbackTransportOptions()
+		opts.FallbackURL = fallbackURL
+		opts.FallbackResponseFn = func(req *http.Request) *http.Response {
+			return newResponse(http.StatusNoContent)
+		}
+		transport := NewFallbackTransport(hostResponder(map[string]int{"api.localhost": 503, "fallback.localhost": 500}, &hosts), opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Len(t, hosts, 2)
+	})
+
+	t.Run("returns original failure without fallback", func(t *testing.T) {
+		var hosts []string
+		transport := NewFallbackTransport(hostResponder(map[string]int{"api.localhost": 503}, &hosts), nil)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+
+	t.Run("uses separate fallback round tripper", func(t *testing.T) {
+		var hosts, fallbackHosts []string
+		opts := DefaultFallbackTransportOptions()
+		opts.FallbackURL = fallbackURL
+		opts.FallbackRoundTripper = hostResponder(map[string]int{"fallback.localhost": 200}, &fallbackHosts)
+		transport := NewFallbackTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			hosts = append(hosts, req.URL.Host)
+			return nil, gobreaker.ErrOpenState
+		}), opts)
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"api.localhost"}, hosts)
+		assert.Equal(t, []string{"fallback.localhost"}, fallbackHosts)
+	})
+
+	t.Run("replays request body", func(t *testing.T) {
+		var bodies, urls []string
+		opts := DefaultFallbackTransportOptions()
+		opts.FallbackURL = fallbackURL
+		transport := NewFallbackTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			urls = append(urls, req.URL.String())
+			if req.URL.Host == "api.localhost" {
+				return newResponse(http.StatusBadGateway), nil
+			}
+			return newResponse(http.StatusOK), nil
+		}), opts)
+
+		req, err := http.NewRequest(http.MethodPost, "https://api.localhost/items?page=2", strings.NewReader("payload"))
+		require.NoError(t, err)
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"payload", "payload"}, bodies)
+		assert.Equal(t, []string{"https://api.localhost/items?page=2", "https://fallback.localhost/items?page=2"}, urls)
+	})
+}
+
+func TestDefaultFaultInjectionTransportOptions(t *testing.T) {
+	opts := DefaultFaultInjectionTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Empty(t, opts.Rules)
+	assert.True(t, opts.Enabled)
+}
+
+func TestFaultInjectionTransport_RoundTrip(t *testing.T) {
+	newTransport := func(rules ...FaultRule) (*FaultInjectionTransport, *MockRoundTripper) {
+		mockRT := &MockRoundTripper{}
+		opts := DefaultFaultInjectionTransportOptions()
+		opts.Rules = rules
+		return NewFaultInjectionTransport(mockRT, opts), mockRT
+	}
+
+	t.Run("passes through without matching rule", func(t *testing.T) {
+		transport, mockRT := newTransport(FaultRule{Host: "other.localhost", ErrorProbability: 1})
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 1, mockRT.callCount)
+	})
+
+	t.Run("injects connection errors", func(t *testing.T) {
+		transport, mockRT := newTransport(FaultRule{Host: "api.localhost", ErrorProbability: 1})
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		assert.ErrorIs(t, err, ErrInjectedFault)
+		assert.Equal(t, 0, mockRT.callCount)
+	})
+
+	t.Run("injects status codes per path", func(t *testing.T) {
+		transport, mockRT := newTransport(FaultRule{PathPattern: "/items/*", StatusCodeProbability: 1, StatusCode: http.StatusServiceUnavailable})
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items/1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+		res, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/users/1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 1, mockRT.callCount)
+	})
+
+	t.Run("injects latency", func(t *testing.T) {
+		transport, mockRT := newTransport(FaultRule{LatencyProbability: 1, Latency: 20 * time.Millisecond})
+
+		start := time.Now()
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, 1, mockRT.callCount)
+	})
+
+	t.Run("latency respects context cancellation", func(t *testing.T) {
+		transport, _ := newTransport(FaultRule{LatencyProbability: 1, Latency: time.Hour})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("can be disabled at runtime", func(t *testing.T) {
+		transport, mockRT := newTransport(FaultRule{ErrorProbability: 1})
+
+		transport.SetEnabled(false)
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+		assert.False(t, transport.Enabled())
+		assert.Equal(t, 1, mockRT.callCount)
+
+		transport.SetEnabled(true)
+		_, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		assert.ErrorIs(t, err, ErrInjectedFault)
+	})
+}
+
+func TestDefaultTimeoutTransportOptions(t *testing.T) {
+	opts := DefaultTimeoutTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 30*time.Second, opts.Timeout)
+	assert.Empty(t, opts.HostTimeouts)
+}
+
+func TestTimeoutTransport_RoundTrip(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	capturing := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, hasDeadline = req.Context().Deadline()
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+	})
+	newTransport := func() *TimeoutTransport {
+		return NewTimeoutTransport(capturing, &TimeoutTransportOptions{
+			Timeout:      time.Minute,
+			HostTimeouts: map[string]time.Duration{"slow.localhost": time.Hour},
+		})
+	}
+
+	t.Run("applies default timeout", func(t *testing.T) {
+		res, err := newTransport().RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("applies host override", func(t *testing.T) {
+		_, err := newTransport().RoundTrip(httptest.NewRequest(http.MethodGet, "https://slow.localhost/items", nil))
+
+		require.NoError(t, err)
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+	})
+
+	t.Run("keeps sooner caller deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		expected, _ := ctx.Deadline()
+
+		_, err := newTransport().RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, deadline)
+	})
+
+	t.Run("times out slow upstream", func(t *testing.T) {
+		transport := NewTimeoutTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}), &TimeoutTransportOptions{Timeout: 10 * time.Millisecond})
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestDeadlinePropagationTransport_RoundTrip(t *testing.T) {
+	t.Run("sends remaining budget", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewDeadlinePropagationTransport(mockRT, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+
+		require.NoError(t, err)
+		require.Len(t, mockRT.capturedRequests, 1)
+		budget, err := ParseTimeout(mockRT.capturedRequests[0].Header.Get("X-Request-Timeout"))
+		require.NoError(t, err)
+		assert.InDelta(t, 2*time.Second, budget, float64(100*time.Millisecond))
+	})
+
+	t.Run("omits header without deadline", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewDeadlinePropagationTransport(mockRT, nil)
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Empty(t, mockRT.capturedRequests[0].Header.Get("X-Request-Timeout"))
+	})
+
+	t.Run("fails fast on exceeded deadline", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewDeadlinePropagationTransport(mockRT, nil)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, mockRT.callCount)
+	})
+}
+
+func TestDefaultRetryTransportOptions(t *testing.T) {
+	opts := DefaultRetryTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 2, opts.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, opts.InitialBackoff)
+	assert.Equal(t, 2*time.Second, opts.MaxBackoff)
+	assert.NotContains(t, opts.Methods, http.MethodPost)
+	assert.NotNil(t, opts.ShouldRetryFn)
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	assert.True(t, DefaultShouldRetry(nil, errors.New("connection reset")))
+	assert.False(t, DefaultShouldRetry(nil, context.Canceled))
+	assert.True(t, DefaultShouldRetry(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil))
+	assert.False(t, DefaultShouldRetry(&http.Response{StatusCode: http.StatusInternalServerError}, nil))
+	assert.False(t, DefaultShouldRetry(&http.Response{StatusCode: http.StatusOK}, nil))
+}
+
+func TestRetryTransport_RoundTrip(t *testing.T) {
+	newOpts := func() *RetryTransportOptions {
+		opts := DefaultRetryTransportOptions()
+		opts.InitialBackoff = time.Millisecond
+		opts.MaxBackoff = time.Millisecond
+		return opts
+	}
+	statusSequence := func(calls *int, statuses ...int) RoundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			status := statuses[min(*calls, len(statuses)-1)]
+			*calls++
+			if req.Body != nil && req.Body != http.NoBody {
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != "payload" {
+					return nil, errors.New("body not replayed")
+				}
+			}
+			return &http.Response{StatusCode: status, Body: http.NoBody, Header: make(http.Header)}, nil
+		}
+	}
+
+	t.Run("retries until success", func(t *testing.T) {
+		calls := 0
+		transport := NewRetryTransport(statusSequence(&calls, 503, 502, 200), newOpts())
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns the last result once retries are exhausted", func(t *testing.T) {
+		calls := 0
+		transport := NewRetryTransport(statusSequence(&calls, 503), newOpts())
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("replays bodies", func(t *testing.T) {
+		calls := 0
+		transport := NewRetryTransport(statusSequence(&calls, 503, 200), newOpts())
+		req, err := http.NewRequest(http.MethodPut, "https://api.localhost/items/1", strings.NewReader("payload"))
+		require.NoError(t, err)
+
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does not retry non-idempotent methods", func(t *testing.T) {
+		calls := 0
+		transport := NewRetryTransport(statusSequence(&calls, 503, 200), newOpts())
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodPost, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not retry bodies that cannot be replayed", func(t *testing.T) {
+		calls := 0
+		transport := NewRetryTransport(statusSequence(&calls, 503, 200), newOpts())
+		req := httptest.NewRequest(http.MethodPut, "https://api.localhost/items/1", io.NopCloser(strings.NewReader("payload")))
+
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops retrying once the context is done", func(t *testing.T) {
+		calls := 0
+		opts := newOpts()
+		opts.InitialBackoff = time.Hour
+		opts.MaxBackoff = time.Hour
+		transport := NewRetryTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return nil, errors.New("connection reset")
+		}), opts)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx))
+
+		assert.ErrorContains(t, err, "connection reset")
+		assert.Equal(t, 1, calls)
+	})
+}
+
+type recordingRetryObserver struct {
+	retries  []string
+	complete []string
+}
+
+func (o *recordingRetryObserver) OnRetry(_ *http.Request, attempt int, _ time.Duration, res *http.Response, _ error) {
+	o.retries = append(o.retries, fmt.Sprintf("attempt %d -> %d", attempt, res.StatusCode))
+}
+
+func (o *recordingRetryObserver) OnComplete(_ *http.Request, attempts int, totalDelay time.Duration, res *http.Response, _ error) {
+	o.complete = append(o.complete, fmt.Sprintf("%d attempts -> %d, delayed: %t", attempts, res.StatusCode, totalDelay > 0))
+}
+
+func TestRetryTransport_Observer(t *testing.T) {
+	newTransport := func(observer RetryObserver, statuses ...int) *RetryTransport {
+		opts := DefaultRetryTransportOptions()
+		opts.InitialBackoff = time.Millisecond
+		opts.MaxBackoff = time.Millisecond
+		opts.Observer = observer
+		calls := 0
+		return NewRetryTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			status := statuses[min(calls, len(statuses)-1)]
+			calls++
+			return &http.Response{StatusCode: status, Body: http.NoBody, Header: make(http.Header)}, nil
+		}), opts)
+	}
+
+	t.Run("notifies about retries and the final attempt", func(t *testing.T) {
+		observer := &recordingRetryObserver{}
+
+		_, err := newTransport(observer, 503, 502, 200).RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"attempt 1 -> 503", "attempt 2 -> 502"}, observer.retries)
+		require.Len(t, observer.complete, 1)
+		assert.Contains(t, observer.complete[0], "3 attempts -> 200")
+	})
+
+	t.Run("notifies about requests succeeding at once", func(t *testing.T) {
+		observer := &recordingRetryObserver{}
+
+		_, err := newTransport(observer, 200).RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+
+		require.NoError(t, err)
+		assert.Empty(t, observer.retries)
+		assert.Equal(t, []string{"1 attempts -> 200, delayed: false"}, observer.complete)
+	})
+
+	t.Run("records metrics by default", func(t *testing.T) {
+		reader := installMetricReader(t)
+
+		_, err := newTransport(nil, 503, 503, 503).RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+		require.NoError(t, err)
+
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		recorded := map[string]float64{}
+		for _, scopeMetrics := range rm.ScopeMetrics {
+			for _, m := range scopeMetrics.Metrics {
+				switch data := m.Data.(type) {
+				case metricdata.Sum[int64]:
+					for _, point := range data.DataPoints {
+						recorded[m.Name] += float64(point.Value)
+					}
+				case metricdata.Histogram[int64]:
+					for _, point := range data.DataPoints {
+						recorded[m.Name] += float64(point.Sum)
+					}
+				case metricdata.Histogram[float64]:
+					for _, point := range data.DataPoints {
+						recorded[m.Name] += float64(point.Count)
+					}
+				}
+			}
+		}
+		assert.Equal(t, float64(2), recorded["http.client.retry.total"])
+		assert.Equal(t, float64(3), recorded["http.client.retry.attempts"])
+		assert.Equal(t, float64(1), recorded["http.client.retry.delay"])
+	})
+}
+
+func TestMirrorTransport_RoundTrip(t *testing.T) {
+	mirrorURL, _ := url.Parse("https://canary.localhost")
+	primary := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("primary")), Header: make(http.Header)}, nil
+	})
+	newOpts := func(mirror http.RoundTripper) *MirrorTransportOptions {
+		opts := DefaultMirrorTransportOptions()
+		opts.MirrorURL = mirrorURL
+		opts.MirrorRoundTripper = mirror
+		return opts
+	}
+
+	t.Run("mirrors requests to the mirror URL", func(t *testing.T) {
+		mirrored := make(chan *http.Request, 1)
+		mirror := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mirrored <- req
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody, Header: make(http.Header)}, nil
+		})
+		transport := NewMirrorTransport(primary, newOpts(mirror))
+
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items?page=2", nil))
+		transport.Wait()
+
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, "primary", string(body))
+		require.Len(t, mirrored, 1)
+		mirrorReq := <-mirrored
+		assert.Equal(t, "https://canary.localhost/items?page=2", mirrorReq.URL.String())
+	})
+
+	t.Run("mirrored requests outlive the original request", func(t *testing.T) {
+		var mirrorErr atomic.Value
+		mirror := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			time.Sleep(10 * time.Millisecond)
+			if err := req.Context().Err(); err != nil {
+				mirrorErr.Store(err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+		})
+		transport := NewMirrorTransport(primary, newOpts(mirror))
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil).WithContext(ctx)
+
+		_, err := transport.RoundTrip(req)
+		cancel()
+		transport.Wait()
+
+		require.NoError(t, err)
+		assert.Nil(t, mirrorErr.Load())
+	})
+
+	t.Run("replays bodies", func(t *testing.T) {
+		mirrored := make(chan string, 1)
+		opts := newOpts(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			mirrored <- string(body)
+			return nil, errors.New("connection refused")
+		}))
+		opts.Methods = []string{http.MethodPost}
+		transport := NewMirrorTransport(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, "payload", string(body))
+			return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody, Header: make(http.Header)}, nil
+		}), opts)
+		req, err := http.NewRequest(http.MethodPost, "https://api.localhost/items", strings.NewReader("payload"))
+		require.NoError(t, err)
+
+		res, err := transport.RoundTrip(req)
+		transport.Wait()
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		require.Len(t, mirrored, 1)
+		assert.Equal(t, "payload", <-mirrored)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+		modify func(opts *MirrorTransportOptions)
+	}{
+		{name: "does not mirror without mirror URL", method: http.MethodGet, modify: func(opts *MirrorTransportOptions) { opts.MirrorURL = nil }},
+		{name: "does not mirror ineligible methods", method: http.MethodPost},
+		{name: "does not mirror unsampled requests", method: http.MethodGet, modify: func(opts *MirrorTransportOptions) { opts.Percentage = 0 }},
+		{name: "does not mirror bodies that cannot be replayed", method: http.MethodGet, body: io.NopCloser(strings.NewReader("payload"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			opts := newOpts(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls.Add(1)
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+			}))
+			if tt.modify != nil {
+				tt.modify(opts)
+			}
+			transport := NewMirrorTransport(primary, opts)
+
+			res, err := transport.RoundTrip(httptest.NewRequest(tt.method, "https://api.localhost/items", tt.body))
+			transport.Wait()
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, int32(0), calls.Load())
+		})
+	}
+
+	t.Run("drops mirrored requests above the in-flight limit", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls atomic.Int32
+		opts := newOpts(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: make(http.Header)}, nil
+		}))
+		opts.MaxInFlight = 1
+		transport := NewMirrorTransport(primary, opts)
+
+		for range 3 {
+			_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/items", nil))
+			require.NoError(t, err)
+		}
+		close(release)
+		transport.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
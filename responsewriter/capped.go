@@ -0,0 +1,41 @@
+package responsewriter
+
+import "bytes"
+
+// CappedBuffer keeps the first bytes written to it up to its limit and discards the rest, so it can
+// tee streamed responses of any size, see ResponseWriter.Tee.
+type CappedBuffer struct {
+	limit     int
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func NewCappedBuffer(limit int) *CappedBuffer {
+	return &CappedBuffer{limit: limit}
+}
+
+// Write never fails, it reports all bytes as written even if they were discarded
+func (b *CappedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.Remaining(); len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// Remaining returns the number of bytes the buffer still keeps
+func (b *CappedBuffer) Remaining() int {
+	return max(b.limit-b.buf.Len(), 0)
+}
+
+// Bytes returns the kept bytes
+func (b *CappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Truncated reports whether bytes have been discarded
+func (b *CappedBuffer) Truncated() bool {
+	return b.truncated
+}
@@ -0,0 +1,98 @@
+package responsewriter
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connWriter mimics the response writer of HTTP/1.x connections
+type connWriter struct {
+	hijackingWriter
+	flushes  int
+	readFrom int64
+}
+
+func (w *connWriter) Flush() {
+	w.flushes++
+}
+
+func (w *connWriter) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(&w.body, r)
+	w.readFrom += n
+	return n, err
+}
+
+func TestCappedBuffer(t *testing.T) {
+	buf := NewCappedBuffer(5)
+
+	n, err := buf.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, buf.Truncated())
+	assert.Equal(t, 2, buf.Remaining())
+
+	n, err = buf.Write([]byte("defgh"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "abcde", string(buf.Bytes()))
+	assert.True(t, buf.Truncated())
+	assert.Equal(t, 0, buf.Remaining())
+}
+
+func TestResponseWriter_CappedTee(t *testing.T) {
+	t.Run("captures the first bytes of streamed responses", func(t *testing.T) {
+		w := &connWriter{hijackingWriter: hijackingWriter{plainWriter{header: http.Header{}}}}
+		ww := Wrap(w)
+		tee := NewCappedBuffer(4)
+		ww.Tee(tee)
+
+		for _, chunk := range []string{"ab", "cdef", "gh"} {
+			_, err := ww.Write([]byte(chunk))
+			require.NoError(t, err)
+			ww.(http.Flusher).Flush()
+		}
+
+		assert.Equal(t, "abcdefgh", w.body.String())
+		assert.Equal(t, 3, w.flushes)
+		assert.Equal(t, "abcd", string(tee.Bytes()))
+		assert.True(t, tee.Truncated())
+		assert.Equal(t, 8, ww.BytesWritten())
+	})
+
+	t.Run("returns to the fast path once full", func(t *testing.T) {
+		w := &connWriter{hijackingWriter: hijackingWriter{plainWriter{header: http.Header{}}}}
+		ww := Wrap(w)
+		tee := NewCappedBuffer(4)
+		ww.Tee(tee)
+
+		n, err := ww.(io.ReaderFrom).ReadFrom(strings.NewReader("0123456789"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), n)
+		assert.Equal(t, "0123456789", w.body.String())
+		assert.Equal(t, int64(6), w.readFrom)
+		assert.Equal(t, "0123", string(tee.Bytes()))
+		assert.True(t, tee.Truncated())
+		assert.Equal(t, 10, ww.BytesWritten())
+	})
+
+	t.Run("copies short bodies through the tee", func(t *testing.T) {
+		w := &connWriter{hijackingWriter: hijackingWriter{plainWriter{header: http.Header{}}}}
+		ww := Wrap(w)
+		tee := NewCappedBuffer(16)
+		ww.Tee(tee)
+
+		n, err := ww.(io.ReaderFrom).ReadFrom(strings.NewReader("short"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+		assert.Equal(t, "short", string(tee.Bytes()))
+		assert.False(t, tee.Truncated())
+		assert.Equal(t, http.StatusOK, ww.Status())
+	})
+}
@@ -0,0 +1,193 @@
+package responsewriter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter and records the status and size of the response, so
+// middlewares can report on it after the next handler returned.
+type ResponseWriter interface {
+	http.ResponseWriter
+	// Status returns the status code sent to the client, or 0 if the headers have not been sent yet.
+	Status() int
+	// BytesWritten returns the number of body bytes sent to the client.
+	BytesWritten() int
+	// Written reports whether the headers have been sent.
+	Written() bool
+	// Hijacked reports whether the connection has been hijacked, e.g. for a protocol upgrade.
+	Hijacked() bool
+	// Tee additionally writes the body to the given writer. Setting a second writer replaces the first.
+	Tee(w io.Writer)
+	// Unwrap returns the wrapped response writer, see http.ResponseController.
+	Unwrap() http.ResponseWriter
+}
+
+// Wrap wraps the response writer. The result implements http.Flusher and http.Hijacker exactly if the
+// wrapped writer does, so type assertions of later handlers keep working. Writers of HTTP/1.x
+// connections additionally keep implementing io.ReaderFrom.
+func Wrap(w http.ResponseWriter) ResponseWriter {
+	base := &writer{ResponseWriter: w}
+	_, flusher := w.(http.Flusher)
+	_, hijacker := w.(http.Hijacker)
+	_, readerFrom := w.(io.ReaderFrom)
+	switch {
+	case flusher && hijacker && readerFrom:
+		return &connectionWriter{base}
+	case flusher && hijacker:
+		return &flushHijackWriter{base}
+	case flusher:
+		return &flushWriter{base}
+	case hijacker:
+		return &hijackWriter{base}
+	default:
+		return base
+	}
+}
+
+type writer struct {
+	http.ResponseWriter
+	tee      io.Writer
+	status   int
+	bytes    int
+	hijacked bool
+}
+
+func (w *writer) WriteHeader(status int) {
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		// Informational responses precede the final one.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	if w.tee != nil {
+		if _, teeErr := w.tee.Write(b[:n]); err == nil {
+			err = teeErr
+		}
+	}
+	return n, err
+}
+
+func (w *writer) Status() int {
+	return w.status
+}
+
+func (w *writer) BytesWritten() int {
+	return w.bytes
+}
+
+func (w *writer) Written() bool {
+	return w.status != 0
+}
+
+func (w *writer) Hijacked() bool {
+	return w.hijacked
+}
+
+func (w *writer) Tee(tee io.Writer) {
+	w.tee = tee
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *writer) flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *writer) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+type flushWriter struct {
+	*writer
+}
+
+func (w *flushWriter) Flush() {
+	w.flush()
+}
+
+type hijackWriter struct {
+	*writer
+}
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type flushHijackWriter struct {
+	*writer
+}
+
+func (w *flushHijackWriter) Flush() {
+	w.flush()
+}
+
+func (w *flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// connectionWriter wraps the response writer of HTTP/1.x connections.
+type connectionWriter struct {
+	*writer
+}
+
+func (w *connectionWriter) Flush() {
+	w.flush()
+}
+
+func (w *connectionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// ReadFrom copies through the tee until a CappedBuffer tee is full, then returns to the fast path of
+// the wrapped writer, e.g. sendfile for file downloads.
+func (w *connectionWriter) ReadFrom(r io.Reader) (int64, error) {
+	capped, isCapped := w.tee.(*CappedBuffer)
+	if w.tee != nil && !isCapped {
+		return io.Copy(w.writer, r)
+	}
+
+	var copied int64
+	if isCapped {
+		n, err := io.CopyN(w.writer, r, int64(capped.Remaining()))
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return n, err
+		}
+		copied = n
+	}
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+	w.bytes += int(n)
+	if isCapped && n > 0 {
+		capped.truncated = true
+	}
+	return copied + n, err
+}
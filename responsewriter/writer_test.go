@@ -0,0 +1,179 @@
+package responsewriter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type plainWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *plainWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *plainWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *plainWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+type hijackingWriter struct {
+	plainWriter
+}
+
+func (w *hijackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	_ = client.Close()
+	return server, nil, nil
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("keeps optional interfaces of the wrapped writer", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			w            http.ResponseWriter
+			isFlusher    bool
+			isHijacker   bool
+			isReaderFrom bool
+		}{
+			{"plain", &plainWriter{header: http.Header{}}, false, false, false},
+			{"flusher", httptest.NewRecorder(), true, false, false},
+			{"hijacker", &hijackingWriter{plainWriter{header: http.Header{}}}, false, true, false},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				ww := Wrap(tc.w)
+
+				_, isFlusher := ww.(http.Flusher)
+				_, isHijacker := ww.(http.Hijacker)
+				_, isReaderFrom := ww.(io.ReaderFrom)
+				assert.Equal(t, tc.isFlusher, isFlusher)
+				assert.Equal(t, tc.isHijacker, isHijacker)
+				assert.Equal(t, tc.isReaderFrom, isReaderFrom)
+				assert.Equal(t, tc.w, ww.Unwrap())
+			})
+		}
+	})
+
+	t.Run("keeps optional interfaces of connection writers", func(t *testing.T) {
+		var isFlusher, isHijacker, isReaderFrom bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := Wrap(w)
+			_, isFlusher = ww.(http.Flusher)
+			_, isHijacker = ww.(http.Hijacker)
+			_, isReaderFrom = ww.(io.ReaderFrom)
+			_, _ = io.Copy(ww, strings.NewReader("streamed"))
+		}))
+		defer srv.Close()
+
+		res, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+
+		assert.True(t, isFlusher)
+		assert.True(t, isHijacker)
+		assert.True(t, isReaderFrom)
+		assert.Equal(t, "streamed", string(body))
+	})
+}
+
+func TestResponseWriter(t *testing.T) {
+	t.Run("records status and size", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ww := Wrap(rr)
+
+		assert.False(t, ww.Written())
+		assert.Equal(t, 0, ww.Status())
+
+		ww.WriteHeader(http.StatusCreated)
+		ww.WriteHeader(http.StatusInternalServerError)
+		_, err := ww.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		assert.True(t, ww.Written())
+		assert.Equal(t, http.StatusCreated, ww.Status())
+		assert.Equal(t, 5, ww.BytesWritten())
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	})
+
+	t.Run("implies 200 on first write", func(t *testing.T) {
+		ww := Wrap(httptest.NewRecorder())
+
+		_, err := ww.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, ww.Status())
+	})
+
+	t.Run("implies 200 on flush", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ww := Wrap(rr)
+
+		ww.(http.Flusher).Flush()
+
+		assert.Equal(t, http.StatusOK, ww.Status())
+		assert.True(t, rr.Flushed)
+	})
+
+	t.Run("passes informational responses through", func(t *testing.T) {
+		w := &plainWriter{header: http.Header{}}
+		ww := Wrap(w)
+
+		ww.WriteHeader(http.StatusEarlyHints)
+		assert.Equal(t, http.StatusEarlyHints, w.status)
+		assert.False(t, ww.Written())
+
+		ww.WriteHeader(http.StatusNoContent)
+		assert.Equal(t, http.StatusNoContent, ww.Status())
+	})
+
+	t.Run("tees the body", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ww := Wrap(rr)
+		var tee bytes.Buffer
+
+		ww.Tee(&tee)
+		_, err := ww.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello", rr.Body.String())
+		assert.Equal(t, "hello", tee.String())
+	})
+
+	t.Run("records hijacked connections", func(t *testing.T) {
+		ww := Wrap(&hijackingWriter{plainWriter{header: http.Header{}}})
+
+		conn, _, err := ww.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.True(t, ww.Hijacked())
+		assert.Equal(t, 0, ww.Status())
+	})
+
+	t.Run("supports response controllers", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		ww := Wrap(rr)
+
+		require.NoError(t, http.NewResponseController(ww).Flush())
+
+		assert.True(t, rr.Flushed)
+	})
+}
@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Variants derives differently configured instances of the same middleware from shared base options,
+// e.g. to apply stricter limits to a single chi route group. Middlewares recording metrics share their
+// instruments between instances.
+type Variants[O any] struct {
+	newFn  func(opts *O) func(next http.Handler) http.Handler
+	baseFn func() *O
+}
+
+// NewVariants creates variants of the middleware built by newFn. baseFn must return new options on
+// every call, like the Default...Options functions of this module do.
+func NewVariants[O any](newFn func(opts *O) func(next http.Handler) http.Handler, baseFn func() *O) *Variants[O] {
+	return &Variants[O]{
+		newFn:  newFn,
+		baseFn: baseFn,
+	}
+}
+
+// With creates an instance of the middleware with the base options adjusted by the modifiers.
+func (v *Variants[O]) With(modifiers ...func(opts *O)) func(next http.Handler) http.Handler {
+	opts := v.baseFn()
+	for _, modifier := range modifiers {
+		modifier(opts)
+	}
+	return v.newFn(opts)
+}
+
+// PathScopedMiddleware //
+
+// PathScope assigns a middleware to the requests below a path prefix
+type PathScope struct {
+	// Prefix matches the path itself and all paths below it, e.g. /auth matches /auth and /auth/login
+	// but not /authors.
+	Prefix string
+	// Middleware is applied to matching requests. Nil passes them on unchanged.
+	Middleware func(next http.Handler) http.Handler
+}
+
+// NewPathScopedMiddleware applies the middleware of the scope with the longest matching prefix, or the
+// fallback middleware to requests matching no scope. Unlike middlewares of chi route groups, it can be
+// used before routing, e.g. to exclude paths from a middleware mounted at the root router. Nil
+// middlewares pass requests on unchanged.
+func NewPathScopedMiddleware(fallback func(next http.Handler) http.Handler, scopes ...PathScope) func(next http.Handler) http.Handler {
+	scopes = slices.Clone(scopes)
+	for i := range scopes {
+		scopes[i].Prefix = strings.TrimSuffix(scopes[i].Prefix, "/")
+	}
+	// the longest prefix wins, so it is tested first
+	slices.SortStableFunc(scopes, func(a, b PathScope) int {
+		return len(b.Prefix) - len(a.Prefix)
+	})
+
+	return func(next http.Handler) http.Handler {
+		handlers := make([]http.Handler, len(scopes))
+		for i, scope := range scopes {
+			handlers[i] = apply(scope.Middleware, next)
+		}
+		fallbackHandler := apply(fallback, next)
+
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			for i, scope := range scopes {
+				if matchesPrefix(req.URL.Path, scope.Prefix) {
+					handlers[i].ServeHTTP(w, req)
+					return
+				}
+			}
+			fallbackHandler.ServeHTTP(w, req)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func apply(middleware func(next http.Handler) http.Handler, next http.Handler) http.Handler {
+	if middleware == nil {
+		return next
+	}
+	return middleware(next)
+}
+
+func matchesPrefix(path string, prefix string) bool {
+	rest, ok := strings.CutPrefix(path, prefix)
+	return ok && (rest == "" || strings.HasPrefix(rest, "/"))
+}
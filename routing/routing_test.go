@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type headerMiddlewareOptions struct {
+	Value string
+}
+
+func defaultHeaderMiddlewareOptions() *headerMiddlewareOptions {
+	return &headerMiddlewareOptions{
+		Value: "default",
+	}
+}
+
+func newHeaderMiddleware(opts *headerMiddlewareOptions) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Scope", opts.Value)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	variants := NewVariants(newHeaderMiddleware, defaultHeaderMiddlewareOptions)
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(variants.With())
+		r.Get("/items", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(variants.With(func(opts *headerMiddlewareOptions) {
+			opts.Value = "auth"
+		}))
+		r.Post("/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, []string{"default"}, rr.Header().Values("X-Scope"))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+	assert.Equal(t, []string{"auth"}, rr.Header().Values("X-Scope"))
+}
+
+func TestNewPathScopedMiddleware(t *testing.T) {
+	middleware := NewPathScopedMiddleware(
+		newHeaderMiddleware(&headerMiddlewareOptions{Value: "fallback"}),
+		PathScope{Prefix: "/auth", Middleware: newHeaderMiddleware(&headerMiddlewareOptions{Value: "auth"})},
+		PathScope{Prefix: "/auth/admin/", Middleware: newHeaderMiddleware(&headerMiddlewareOptions{Value: "admin"})},
+		PathScope{Prefix: "/health"},
+	)
+	handlerCalls := 0
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalls++
+	}))
+
+	testCases := []struct {
+		path     string
+		expected []string
+	}{
+		{"/auth", []string{"auth"}},
+		{"/auth/login", []string{"auth"}},
+		{"/auth/admin", []string{"admin"}},
+		{"/auth/admin/users", []string{"admin"}},
+		{"/authors", []string{"fallback"}},
+		{"/", []string{"fallback"}},
+		{"/health", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.expected, rr.Header().Values("X-Scope"))
+		})
+	}
+	assert.Equal(t, len(testCases), handlerCalls)
+}
+
+func TestNewPathScopedMiddleware_RootPrefix(t *testing.T) {
+	middleware := NewPathScopedMiddleware(nil, PathScope{Prefix: "/", Middleware: newHeaderMiddleware(&headerMiddlewareOptions{Value: "root"})})
+
+	rr := httptest.NewRecorder()
+	middleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/anything", nil))
+
+	assert.Equal(t, []string{"root"}, rr.Header().Values("X-Scope"))
+}
@@ -0,0 +1,23 @@
+package security
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+)
+
+type ClientIP netip.Addr
+
+func ClientIPFromContext(ctx context.Context) *netip.Addr {
+	clientIP := contextutils.GetValue[ClientIP](ctx)
+	if clientIP != nil {
+		addr := netip.Addr(*clientIP)
+		return &addr
+	}
+	return nil
+}
+
+func ContextWithClientIP(ctx context.Context, clientIP netip.Addr) context.Context {
+	return contextutils.WithValue(ctx, ClientIP(clientIP))
+}
@@ -0,0 +1,26 @@
+package security
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPFromContext(t *testing.T) {
+	t.Run("client IP exists", func(t *testing.T) {
+		expected := netip.MustParseAddr("192.0.2.1")
+		ctx := ContextWithClientIP(context.Background(), expected)
+
+		clientIP := ClientIPFromContext(ctx)
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, expected, *clientIP)
+	})
+
+	t.Run("client IP does not exist", func(t *testing.T) {
+		assert.Nil(t, ClientIPFromContext(context.Background()))
+	})
+}
@@ -3,19 +3,43 @@ package security
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
 	"strings"
 
+	weberrors "github.com/Roshick/go-autumn-web/errors"
 	"github.com/Roshick/go-autumn-web/header"
+	"github.com/go-chi/render"
 )
 
 // CORSMiddleware //
 
 type CORSMiddlewareOptions struct {
-	AllowOrigin             string
-	AllowCredentials        bool
-	MaxAge                  int
+	AllowOrigin      string
+	AllowCredentials bool
+	MaxAge           int
+	// AllowMethods lists the methods preflight requests may ask for. Defaults to GET, HEAD, POST, PUT,
+	// PATCH and DELETE.
+	AllowMethods []string
+	// AdditionalAllowHeaders extends the request headers preflight requests may ask for, in addition
+	// to Accept and Content-Type.
 	AdditionalAllowHeaders  []string
 	AdditionalExposeHeaders []string
+	// PreflightErrorResponse is rendered for preflight requests asking for a method or header that
+	// is not allowed. Defaults to 403 Forbidden.
+	PreflightErrorResponse render.Renderer
+	// AllowPrivateNetwork grants preflight requests of Private Network Access, sent by browsers before
+	// public websites access servers in private networks.
+	AllowPrivateNetwork bool
+	// AllowNullOrigin allows requests with Origin null, sent e.g. by sandboxed iframes and local files.
+	// Otherwise, such requests are not granted CORS access even for a wildcard AllowOrigin. Credentials
+	// are never allowed for them.
+	AllowNullOrigin bool
+	// SkipSameOrigin passes requests without Origin header or from the host of the request itself
+	// to the next handler without CORS headers. Schemes are not compared, since TLS is commonly
+	// terminated by a proxy.
+	SkipSameOrigin bool
 }
 
 func DefaultCORSMiddlewareOptions() *CORSMiddlewareOptions {
@@ -23,11 +47,33 @@ func DefaultCORSMiddlewareOptions() *CORSMiddlewareOptions {
 		AllowOrigin:             "*",
 		AllowCredentials:        false, // SECURITY FIX: Cannot be true with wildcard origin
 		MaxAge:                  3600,  // Cache preflight for 1 hour
+		AllowMethods:            defaultCORSAllowMethods(),
 		AdditionalAllowHeaders:  []string{},
 		AdditionalExposeHeaders: []string{},
+		PreflightErrorResponse:  newPreflightErrorResponse(),
 	}
 }
 
+func defaultCORSAllowMethods() []string {
+	return []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+	}
+}
+
+func newPreflightErrorResponse() render.Renderer {
+	return weberrors.NewForbiddenResponse("CORS preflight request not allowed")
+}
+
+// NewCORSMiddleware answers preflight requests and adds the CORS headers to all other responses.
+// Preflight requests are validated against the allowed methods and headers, and only the requested
+// values are reflected in the response. OPTIONS requests that are no preflight requests are answered
+// with the complete allowlists. Since the response depends on the Origin header, Vary: Origin is
+// always set.
 func NewCORSMiddleware(opts *CORSMiddlewareOptions) func(next http.Handler) http.Handler {
 	if opts == nil {
 		opts = DefaultCORSMiddlewareOptions()
@@ -37,42 +83,74 @@ func NewCORSMiddleware(opts *CORSMiddlewareOptions) func(next http.Handler) http
 		opts.AllowCredentials = false
 	}
 
+	allowMethods := opts.AllowMethods
+	if len(allowMethods) == 0 {
+		allowMethods = defaultCORSAllowMethods()
+	}
+	allowHeaders := append([]string{
+		header.Accept,
+		header.ContentType,
+	}, opts.AdditionalAllowHeaders...)
+	preflightErrorResponse := opts.PreflightErrorResponse
+	if preflightErrorResponse == nil {
+		preflightErrorResponse = newPreflightErrorResponse()
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
-			w.Header().Set(header.AccessControlAllowOrigin, opts.AllowOrigin)
-
-			w.Header().Set(header.AccessControlAllowMethods, strings.Join([]string{
-				http.MethodGet,
-				http.MethodHead,
-				http.MethodPost,
-				http.MethodPut,
-				http.MethodPatch,
-				http.MethodDelete,
-			}, ", "))
-
-			w.Header().Set(header.AccessControlAllowHeaders, strings.Join(append([]string{
-				header.Accept,
-				header.ContentType,
-			}, opts.AdditionalAllowHeaders...), ", "))
-
-			if opts.AllowCredentials && opts.AllowOrigin != "*" {
-				w.Header().Set(header.AccessControlAllowCredentials, "true")
+			w.Header().Add(header.Vary, header.Origin)
+
+			origin := req.Header.Get(header.Origin)
+			if opts.SkipSameOrigin && (origin == "" || isSameOrigin(origin, req)) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			isPreflight := req.Method == http.MethodOptions && req.Header.Get(header.AccessControlRequestMethod) != ""
+			if origin == "null" && !opts.AllowNullOrigin {
+				if isPreflight {
+					if err := weberrors.Render(w, req, preflightErrorResponse); err != nil {
+						panic(err)
+					}
+					return
+				}
+				next.ServeHTTP(w, req)
+				return
 			}
 
-			w.Header().Set(header.AccessControlExposeHeaders, strings.Join(append([]string{
-				header.CacheControl,
-				header.ContentSecurityPolicy,
-				header.ContentType,
-				header.Location,
-			}, opts.AdditionalExposeHeaders...), ", "))
+			if isPreflight {
+				w.Header().Add(header.Vary, header.AccessControlRequestMethod)
+				w.Header().Add(header.Vary, header.AccessControlRequestHeaders)
+				if opts.AllowPrivateNetwork {
+					w.Header().Add(header.Vary, header.AccessControlRequestPrivateNetwork)
+				}
 
-			if req.Method == http.MethodOptions {
-				// Add preflight cache control
-				if opts.MaxAge > 0 {
-					w.Header().Set("Access-Control-Max-Age", fmt.Sprintf("%d", opts.MaxAge))
+				requestMethod := req.Header.Get(header.AccessControlRequestMethod)
+				requestHeaders := parseHeaderList(req.Header.Values(header.AccessControlRequestHeaders))
+				if !slices.Contains(allowMethods, requestMethod) || !containsAllFold(allowHeaders, requestHeaders) {
+					if err := weberrors.Render(w, req, preflightErrorResponse); err != nil {
+						panic(err)
+					}
+					return
 				}
-				// FIX: Use 204 No Content instead of 200 OK for OPTIONS
-				w.WriteHeader(http.StatusNoContent)
+
+				setCORSHeaders(w, origin, opts)
+				w.Header().Set(header.AccessControlAllowMethods, requestMethod)
+				if len(requestHeaders) > 0 {
+					w.Header().Set(header.AccessControlAllowHeaders, strings.Join(requestHeaders, ", "))
+				}
+				if opts.AllowPrivateNetwork && strings.EqualFold(req.Header.Get(header.AccessControlRequestPrivateNetwork), "true") {
+					w.Header().Set(header.AccessControlAllowPrivateNetwork, "true")
+				}
+				writePreflightResponse(w, opts)
+				return
+			}
+
+			setCORSHeaders(w, origin, opts)
+			if req.Method == http.MethodOptions {
+				w.Header().Set(header.AccessControlAllowMethods, strings.Join(allowMethods, ", "))
+				w.Header().Set(header.AccessControlAllowHeaders, strings.Join(allowHeaders, ", "))
+				writePreflightResponse(w, opts)
 				return
 			}
 
@@ -81,3 +159,259 @@ func NewCORSMiddleware(opts *CORSMiddlewareOptions) func(next http.Handler) http
 		return http.HandlerFunc(fn)
 	}
 }
+
+func setCORSHeaders(w http.ResponseWriter, origin string, opts *CORSMiddlewareOptions) {
+	if origin == "null" {
+		w.Header().Set(header.AccessControlAllowOrigin, origin)
+	} else {
+		w.Header().Set(header.AccessControlAllowOrigin, opts.AllowOrigin)
+	}
+
+	if opts.AllowCredentials && opts.AllowOrigin != "*" && origin != "null" {
+		w.Header().Set(header.AccessControlAllowCredentials, "true")
+	}
+
+	w.Header().Set(header.AccessControlExposeHeaders, strings.Join(append([]string{
+		header.CacheControl,
+		header.ContentSecurityPolicy,
+		header.ContentType,
+		header.Location,
+	}, opts.AdditionalExposeHeaders...), ", "))
+}
+
+func writePreflightResponse(w http.ResponseWriter, opts *CORSMiddlewareOptions) {
+	// Add preflight cache control
+	if opts.MaxAge > 0 {
+		w.Header().Set(header.AccessControlMaxAge, fmt.Sprintf("%d", opts.MaxAge))
+	}
+	// FIX: Use 204 No Content instead of 200 OK for OPTIONS
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func isSameOrigin(origin string, req *http.Request) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, req.Host)
+}
+
+// parseHeaderList splits comma-separated header names, dropping empty elements
+func parseHeaderList(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func containsAllFold(allowed []string, names []string) bool {
+	for _, name := range names {
+		if !slices.ContainsFunc(allowed, func(candidate string) bool {
+			return strings.EqualFold(candidate, name)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// RealIPMiddleware //
+
+type RealIPMiddlewareOptions struct {
+	// TrustedProxies lists the networks of proxies whose forwarding headers are honored. Requests
+	// from other peers keep their peer address as client IP. Defaults to no trusted proxies.
+	TrustedProxies []netip.Prefix
+	// Headers lists the forwarding headers to evaluate, in order of precedence. Supported are
+	// Forwarded, X-Forwarded-For and X-Real-IP.
+	Headers []string
+}
+
+func DefaultRealIPMiddlewareOptions() *RealIPMiddlewareOptions {
+	return &RealIPMiddlewareOptions{
+		TrustedProxies: []netip.Prefix{},
+		Headers: []string{
+			header.Forwarded,
+			header.XForwardedFor,
+			header.XRealIP,
+		},
+	}
+}
+
+// NewRealIPMiddleware resolves the client IP, stores it in the request context and rewrites
+// req.RemoteAddr to it (with port 0), so downstream middlewares see the original client.
+func NewRealIPMiddleware(opts *RealIPMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultRealIPMiddlewareOptions()
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			peer, ok := parseRemoteAddr(req.RemoteAddr)
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			clientIP := peer
+			if containsAddr(peer, opts.TrustedProxies) {
+				clientIP = resolveForwardedClientIP(req, peer, opts)
+			}
+
+			req = req.WithContext(ContextWithClientIP(req.Context(), clientIP))
+			if clientIP != peer {
+				req.RemoteAddr = netip.AddrPortFrom(clientIP, 0).String()
+			}
+			next.ServeHTTP(w, req)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func resolveForwardedClientIP(req *http.Request, peer netip.Addr, opts *RealIPMiddlewareOptions) netip.Addr {
+	for _, name := range opts.Headers {
+		values := req.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		var chain []string
+		switch http.CanonicalHeaderKey(name) {
+		case http.CanonicalHeaderKey(header.Forwarded):
+			chain = parseForwardedFor(values)
+		case http.CanonicalHeaderKey(header.XRealIP):
+			chain = []string{strings.TrimSpace(values[len(values)-1])}
+		default:
+			for _, value := range values {
+				for _, part := range strings.Split(value, ",") {
+					chain = append(chain, strings.TrimSpace(part))
+				}
+			}
+		}
+
+		if clientIP, ok := rightmostUntrusted(chain, opts.TrustedProxies); ok {
+			return clientIP
+		}
+	}
+	return peer
+}
+
+// rightmostUntrusted walks the proxy chain from the closest hop and returns the first address
+// that is not a trusted proxy, or the leftmost address if every hop is trusted.
+func rightmostUntrusted(chain []string, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	var leftmost netip.Addr
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseRemoteAddr(chain[i])
+		if !ok {
+			return netip.Addr{}, false
+		}
+		if !containsAddr(addr, trustedProxies) {
+			return addr, true
+		}
+		leftmost = addr
+	}
+	return leftmost, leftmost.IsValid()
+}
+
+// parseForwardedFor extracts the for= parameters of a RFC 7239 Forwarded header.
+func parseForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(key, "for") {
+					continue
+				}
+				val = strings.Trim(val, `"`)
+				if strings.HasPrefix(val, "[") {
+					if end := strings.Index(val, "]"); end > 0 {
+						val = val[1:end]
+					}
+				}
+				chain = append(chain, val)
+			}
+		}
+	}
+	return chain
+}
+
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(remoteAddr); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func containsAddr(addr netip.Addr, prefixes []netip.Prefix) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilterMiddleware //
+
+type IPFilterMiddlewareOptions struct {
+	// Allow lists the networks permitted to access the handler. If empty, every address not denied is allowed.
+	Allow []netip.Prefix
+	// Deny lists the networks rejected. Deny entries take precedence over Allow entries.
+	Deny          []netip.Prefix
+	ErrorResponse render.Renderer
+}
+
+func DefaultIPFilterMiddlewareOptions() *IPFilterMiddlewareOptions {
+	return &IPFilterMiddlewareOptions{
+		Allow:         []netip.Prefix{},
+		Deny:          []netip.Prefix{},
+		ErrorResponse: weberrors.NewAccessDeniedResponse(),
+	}
+}
+
+// NewIPFilterMiddleware filters requests by client IP. The client IP resolved by the RealIP middleware
+// is used when present, otherwise the peer address. Requests whose address cannot be determined are
+// rejected if an allow list is configured.
+func NewIPFilterMiddleware(opts *IPFilterMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultIPFilterMiddlewareOptions()
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !isAllowedIP(req, opts) {
+				if err := weberrors.Render(w, req, opts.ErrorResponse); err != nil {
+					panic(err)
+				}
+				return
+			}
+			next.ServeHTTP(w, req)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func isAllowedIP(req *http.Request, opts *IPFilterMiddlewareOptions) bool {
+	var addr netip.Addr
+	if clientIP := ClientIPFromContext(req.Context()); clientIP != nil {
+		addr = *clientIP
+	} else if peer, ok := parseRemoteAddr(req.RemoteAddr); ok {
+		addr = peer
+	}
+
+	if !addr.IsValid() {
+		return len(opts.Allow) == 0
+	}
+	if containsAddr(addr, opts.Deny) {
+		return false
+	}
+	return len(opts.Allow) == 0 || containsAddr(addr, opts.Allow)
+}
@@ -3,8 +3,10 @@ package security
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
 
+	weberrors "github.com/Roshick/go-autumn-web/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +18,9 @@ func TestDefaultCORSMiddlewareOptions(t *testing.T) {
 	assert.Equal(t, "*", opts.AllowOrigin)
 	assert.False(t, opts.AllowCredentials) // FIXED: Should be false by default for security
 	assert.Equal(t, 3600, opts.MaxAge)
+	assert.Equal(t, []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}, opts.AllowMethods)
 	assert.NotNil(t, opts.AdditionalAllowHeaders)
+	assert.NotNil(t, opts.PreflightErrorResponse)
 	assert.NotNil(t, opts.AdditionalExposeHeaders)
 }
 
@@ -46,10 +50,9 @@ func TestNewCORSMiddleware(t *testing.T) {
 
 		// Check CORS headers
 		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
-		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), "GET")
-		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), "POST")
-		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "Accept")
-		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
+		// Allowed methods and headers are only relevant for preflight requests
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Headers"))
 		// FIXED: Credentials should not be set for wildcard origin
 		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
 		assert.NotEmpty(t, rr.Header().Get("Access-Control-Expose-Headers"))
@@ -106,8 +109,200 @@ func TestNewCORSMiddleware(t *testing.T) {
 		// Check custom headers
 		assert.Equal(t, "https://localhost", rr.Header().Get("Access-Control-Allow-Origin"))
 		assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials")) // Should work with specific origin
-		assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "X-Custom-Header")
 		assert.Contains(t, rr.Header().Get("Access-Control-Expose-Headers"), "X-Custom-Response")
+
+		req = httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://localhost")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "x-custom-header")
+		rr = httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "x-custom-header", rr.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "7200", rr.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight request reflects requested method and headers", func(t *testing.T) {
+		middleware := NewCORSMiddleware(nil)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		req.Header.Set("Access-Control-Request-Headers", "content-type, accept")
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "PUT", rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type, accept", rr.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "3600", rr.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rr.Header().Values("Vary"))
+	})
+
+	t.Run("preflight request without requested headers", func(t *testing.T) {
+		middleware := NewCORSMiddleware(nil)
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "DELETE", rr.Header().Get("Access-Control-Allow-Methods"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("preflight request with disallowed method is rejected", func(t *testing.T) {
+		opts := DefaultCORSMiddlewareOptions()
+		opts.AllowMethods = []string{http.MethodGet}
+		middleware := NewCORSMiddleware(opts)
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("preflight request with disallowed header is rejected", func(t *testing.T) {
+		middleware := NewCORSMiddleware(nil)
+
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-internal-token")
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("private network access", func(t *testing.T) {
+		newPreflightRequest := func() *http.Request {
+			req := httptest.NewRequest(http.MethodOptions, "/", nil)
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			req.Header.Set("Access-Control-Request-Private-Network", "true")
+			return req
+		}
+
+		rr := httptest.NewRecorder()
+		NewCORSMiddleware(nil)(http.NotFoundHandler()).ServeHTTP(rr, newPreflightRequest())
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Private-Network"))
+
+		opts := DefaultCORSMiddlewareOptions()
+		opts.AllowPrivateNetwork = true
+		rr = httptest.NewRecorder()
+		NewCORSMiddleware(opts)(http.NotFoundHandler()).ServeHTTP(rr, newPreflightRequest())
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Private-Network"))
+		assert.Contains(t, rr.Header().Values("Vary"), "Access-Control-Request-Private-Network")
+	})
+
+	t.Run("null origin is denied by default", func(t *testing.T) {
+		middleware := NewCORSMiddleware(nil)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "null")
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.True(t, handlerCalled)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+		req = httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", "null")
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rr = httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("null origin is allowed without credentials", func(t *testing.T) {
+		opts := &CORSMiddlewareOptions{
+			AllowOrigin:      "https://localhost",
+			AllowCredentials: true,
+			AllowNullOrigin:  true,
+		}
+		middleware := NewCORSMiddleware(opts)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", "null")
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, "null", rr.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("same-origin requests are skipped", func(t *testing.T) {
+		opts := DefaultCORSMiddlewareOptions()
+		opts.SkipSameOrigin = true
+		middleware := NewCORSMiddleware(opts)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		for _, origin := range []string{"", "https://api.example.com"} {
+			handlerCalled = false
+			req := httptest.NewRequest(http.MethodOptions, "https://api.example.com/", nil)
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+			rr := httptest.NewRecorder()
+
+			middleware(testHandler).ServeHTTP(rr, req)
+
+			assert.True(t, handlerCalled)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
 	})
 
 	// NEW TEST: Verify security fix for wildcard + credentials
@@ -132,3 +327,229 @@ func TestNewCORSMiddleware(t *testing.T) {
 		assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
 	})
 }
+
+func TestDefaultRealIPMiddlewareOptions(t *testing.T) {
+	opts := DefaultRealIPMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Empty(t, opts.TrustedProxies)
+	assert.Equal(t, []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}, opts.Headers)
+}
+
+func TestNewRealIPMiddleware(t *testing.T) {
+	trustedOpts := &RealIPMiddlewareOptions{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Headers:        DefaultRealIPMiddlewareOptions().Headers,
+	}
+
+	serve := func(opts *RealIPMiddlewareOptions, remoteAddr string, headers map[string]string) (*netip.Addr, string) {
+		var clientIP *netip.Addr
+		var downstreamRemoteAddr string
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP = ClientIPFromContext(r.Context())
+			downstreamRemoteAddr = r.RemoteAddr
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		NewRealIPMiddleware(opts)(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+		return clientIP, downstreamRemoteAddr
+	}
+
+	t.Run("with nil options", func(t *testing.T) {
+		middleware := NewRealIPMiddleware(nil)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("ignores forwarding headers from untrusted peers", func(t *testing.T) {
+		clientIP, remoteAddr := serve(nil, "192.0.2.1:1234", map[string]string{
+			"X-Forwarded-For": "203.0.113.7",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "192.0.2.1", clientIP.String())
+		assert.Equal(t, "192.0.2.1:1234", remoteAddr)
+	})
+
+	t.Run("uses X-Forwarded-For from trusted proxy", func(t *testing.T) {
+		clientIP, remoteAddr := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "203.0.113.7, 10.0.0.2",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "203.0.113.7", clientIP.String())
+		assert.Equal(t, "203.0.113.7:0", remoteAddr)
+	})
+
+	t.Run("skips spoofed entries left of the first untrusted hop", func(t *testing.T) {
+		clientIP, _ := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "1.1.1.1, 203.0.113.7",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "203.0.113.7", clientIP.String())
+	})
+
+	t.Run("uses leftmost entry when all hops are trusted", func(t *testing.T) {
+		clientIP, _ := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "10.0.0.3, 10.0.0.2",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "10.0.0.3", clientIP.String())
+	})
+
+	t.Run("uses Forwarded header", func(t *testing.T) {
+		clientIP, remoteAddr := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`,
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "2001:db8::1", clientIP.String())
+		assert.Equal(t, "[2001:db8::1]:0", remoteAddr)
+	})
+
+	t.Run("Forwarded takes precedence over X-Forwarded-For", func(t *testing.T) {
+		clientIP, _ := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"Forwarded":       "for=203.0.113.8",
+			"X-Forwarded-For": "203.0.113.7",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "203.0.113.8", clientIP.String())
+	})
+
+	t.Run("falls back to next header on obfuscated Forwarded identifier", func(t *testing.T) {
+		clientIP, _ := serve(trustedOpts, "10.0.0.1:1234", map[string]string{
+			"Forwarded": "for=_hidden",
+			"X-Real-IP": "203.0.113.9",
+		})
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "203.0.113.9", clientIP.String())
+	})
+
+	t.Run("uses peer address without forwarding headers", func(t *testing.T) {
+		clientIP, remoteAddr := serve(trustedOpts, "10.0.0.1:1234", nil)
+
+		require.NotNil(t, clientIP)
+		assert.Equal(t, "10.0.0.1", clientIP.String())
+		assert.Equal(t, "10.0.0.1:1234", remoteAddr)
+	})
+
+	t.Run("skips unparseable remote address", func(t *testing.T) {
+		clientIP, remoteAddr := serve(trustedOpts, "pipe", nil)
+
+		assert.Nil(t, clientIP)
+		assert.Equal(t, "pipe", remoteAddr)
+	})
+}
+
+func TestDefaultIPFilterMiddlewareOptions(t *testing.T) {
+	opts := DefaultIPFilterMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Empty(t, opts.Allow)
+	assert.Empty(t, opts.Deny)
+	assert.NotNil(t, opts.ErrorResponse)
+}
+
+func TestNewIPFilterMiddleware(t *testing.T) {
+	serve := func(opts *IPFilterMiddlewareOptions, req *http.Request) (bool, int) {
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rr := httptest.NewRecorder()
+		NewIPFilterMiddleware(opts)(testHandler).ServeHTTP(rr, req)
+		return handlerCalled, rr.Code
+	}
+	newRequest := func(remoteAddr string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		return req
+	}
+
+	t.Run("with nil options allows all", func(t *testing.T) {
+		handlerCalled, code := serve(nil, newRequest("192.0.2.1:1234"))
+
+		assert.True(t, handlerCalled)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Allow = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+		handlerCalled, code := serve(opts, newRequest("10.1.2.3:1234"))
+		assert.True(t, handlerCalled)
+		assert.Equal(t, http.StatusOK, code)
+
+		handlerCalled, code = serve(opts, newRequest("192.0.2.1:1234"))
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("deny takes precedence over allow", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Allow = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+		opts.Deny = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}
+
+		handlerCalled, code := serve(opts, newRequest("10.0.0.5:1234"))
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		handlerCalled, _ = serve(opts, newRequest("10.0.1.5:1234"))
+		assert.True(t, handlerCalled)
+	})
+
+	t.Run("deny list only", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Deny = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+
+		handlerCalled, _ := serve(opts, newRequest("192.0.2.1:1234"))
+		assert.False(t, handlerCalled)
+
+		handlerCalled, _ = serve(opts, newRequest("198.51.100.1:1234"))
+		assert.True(t, handlerCalled)
+	})
+
+	t.Run("uses client IP from context", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Allow = []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24")}
+
+		req := newRequest("10.0.0.1:1234")
+		req = req.WithContext(ContextWithClientIP(req.Context(), netip.MustParseAddr("203.0.113.7")))
+
+		handlerCalled, _ := serve(opts, req)
+		assert.True(t, handlerCalled)
+	})
+
+	t.Run("rejects unknown address when allow list is configured", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Allow = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+		handlerCalled, code := serve(opts, newRequest("pipe"))
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("custom error response", func(t *testing.T) {
+		opts := DefaultIPFilterMiddlewareOptions()
+		opts.Deny = []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")}
+		opts.ErrorResponse = weberrors.NewForbiddenResponse("Internal endpoint")
+
+		rr := httptest.NewRecorder()
+		NewIPFilterMiddleware(opts)(http.NotFoundHandler()).ServeHTTP(rr, newRequest("192.0.2.1:1234"))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Internal endpoint")
+	})
+}
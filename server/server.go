@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// ServerOptions configures the *http.Server built by NewServer. Fields tagged with env can be
+// overridden from the environment, see ObtainValuesFromEnv.
+type ServerOptions struct {
+	Address string `env:"HTTP_SERVER_ADDRESS"`
+
+	ReadHeaderTimeout time.Duration `env:"HTTP_SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `env:"HTTP_SERVER_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `env:"HTTP_SERVER_WRITE_TIMEOUT"`
+	// IdleTimeout closes idle HTTP/1.1 keep-alive and HTTP/2 connections.
+	IdleTimeout time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT"`
+
+	MaxHeaderBytes int `env:"HTTP_SERVER_MAX_HEADER_BYTES"`
+
+	// EnableHTTP2 serves HTTP/2 on TLS connections.
+	EnableHTTP2 bool `env:"HTTP_SERVER_ENABLE_HTTP2"`
+	// EnableH2C serves HTTP/2 without TLS (prior knowledge), e.g. behind a service mesh terminating TLS.
+	// Only enable it if the server is not reachable from untrusted networks.
+	EnableH2C bool `env:"HTTP_SERVER_ENABLE_H2C"`
+	// HTTP2MaxConcurrentStreams limits the streams per HTTP/2 connection. Zero uses the default of 250.
+	HTTP2MaxConcurrentStreams int `env:"HTTP_SERVER_HTTP2_MAX_CONCURRENT_STREAMS"`
+	// HTTP2PingTimeout closes HTTP/2 connections not answering health check pings in time. Zero uses
+	// the default of 15s.
+	HTTP2PingTimeout time.Duration `env:"HTTP_SERVER_HTTP2_PING_TIMEOUT"`
+}
+
+func DefaultServerOptions() *ServerOptions {
+	return &ServerOptions{
+		Address:                   ":8080",
+		ReadHeaderTimeout:         10 * time.Second,
+		IdleTimeout:               120 * time.Second,
+		MaxHeaderBytes:            http.DefaultMaxHeaderBytes,
+		EnableHTTP2:               true,
+		EnableH2C:                 false,
+		HTTP2MaxConcurrentStreams: 250,
+	}
+}
+
+// ObtainValuesFromEnv overrides the options with the values of all set environment variables.
+func (o *ServerOptions) ObtainValuesFromEnv() error {
+	return env.Parse(o)
+}
+
+func NewServer(handler http.Handler, opts *ServerOptions) *http.Server {
+	if opts == nil {
+		opts = DefaultServerOptions()
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(opts.EnableHTTP2)
+	protocols.SetUnencryptedHTTP2(opts.EnableH2C)
+
+	return &http.Server{
+		Addr:              opts.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		Protocols:         protocols,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: opts.HTTP2MaxConcurrentStreams,
+			PingTimeout:          opts.HTTP2PingTimeout,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestDefaultServerOptions(t *testing.T) {
+	opts := DefaultServerOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, ":8080", opts.Address)
+	assert.Equal(t, 10*time.Second, opts.ReadHeaderTimeout)
+	assert.Equal(t, 120*time.Second, opts.IdleTimeout)
+	assert.True(t, opts.EnableHTTP2)
+	assert.False(t, opts.EnableH2C)
+	assert.Equal(t, 250, opts.HTTP2MaxConcurrentStreams)
+}
+
+func TestServerOptions_ObtainValuesFromEnv(t *testing.T) {
+	t.Run("overrides set values only", func(t *testing.T) {
+		t.Setenv("HTTP_SERVER_ADDRESS", ":9090")
+		t.Setenv("HTTP_SERVER_ENABLE_H2C", "true")
+		t.Setenv("HTTP_SERVER_HTTP2_MAX_CONCURRENT_STREAMS", "100")
+
+		opts := DefaultServerOptions()
+		require.NoError(t, opts.ObtainValuesFromEnv())
+
+		assert.Equal(t, ":9090", opts.Address)
+		assert.True(t, opts.EnableH2C)
+		assert.Equal(t, 100, opts.HTTP2MaxConcurrentStreams)
+		assert.Equal(t, 120*time.Second, opts.IdleTimeout)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("HTTP_SERVER_IDLE_TIMEOUT", "later")
+
+		opts := DefaultServerOptions()
+		assert.Error(t, opts.ObtainValuesFromEnv())
+	})
+}
+
+func TestNewServer(t *testing.T) {
+	t.Run("with nil options uses defaults", func(t *testing.T) {
+		server := NewServer(http.NotFoundHandler(), nil)
+
+		assert.Equal(t, ":8080", server.Addr)
+		assert.Equal(t, 120*time.Second, server.IdleTimeout)
+		assert.True(t, server.Protocols.HTTP1())
+		assert.True(t, server.Protocols.HTTP2())
+		assert.False(t, server.Protocols.UnencryptedHTTP2())
+		assert.Equal(t, 250, server.HTTP2.MaxConcurrentStreams)
+	})
+
+	t.Run("without HTTP/2", func(t *testing.T) {
+		opts := DefaultServerOptions()
+		opts.EnableHTTP2 = false
+
+		server := NewServer(http.NotFoundHandler(), opts)
+
+		assert.True(t, server.Protocols.HTTP1())
+		assert.False(t, server.Protocols.HTTP2())
+	})
+
+	t.Run("serves h2c and records the protocol version", func(t *testing.T) {
+		recorder := testutils.NewMetricsRecorder(t)
+		handler := metrics.NewRequestMetricsMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		opts := DefaultServerOptions()
+		opts.EnableH2C = true
+		server := NewServer(handler, opts)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go func() {
+			if serveErr := server.Serve(listener); !errors.Is(serveErr, http.ErrServerClosed) {
+				t.Errorf("failed to serve: %s", serveErr)
+			}
+		}()
+		t.Cleanup(func() {
+			_ = server.Close()
+		})
+
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+		res, err := client.Get("http://" + listener.Addr().String() + "/")
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, 2, res.ProtoMajor)
+		recorder.AssertHistogram(t, "http.server.request.duration", 1,
+			attribute.String("network.protocol.name", "http"),
+			attribute.String("network.protocol.version", "2"),
+		)
+	})
+}
@@ -0,0 +1,199 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+var ErrStreamClosed = errors.New("event stream closed")
+
+type StreamOptions struct {
+	// HeartbeatInterval defines how often a comment is sent to keep idle connections open.
+	// Zero disables heartbeats. Defaults to 15s.
+	HeartbeatInterval time.Duration
+	// Retry is sent as reconnection delay hint when the stream starts. Zero omits it.
+	Retry time.Duration
+}
+
+func DefaultStreamOptions() *StreamOptions {
+	return &StreamOptions{
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+type Event struct {
+	// ID is stored by the client and sent back as Last-Event-ID header when reconnecting.
+	ID string
+	// Event names the event type. Empty dispatches the default "message" event.
+	Event string
+	Data  string
+	// Retry updates the reconnection delay hint of the client if positive.
+	Retry time.Duration
+}
+
+// Stream writes server-sent events. All methods are safe for concurrent use. The stream must be
+// closed before the handler returns.
+type Stream struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	ctx context.Context
+
+	m      sync.Mutex
+	closed bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewStream starts an event stream on the response. It fails without writing the response if the
+// response writer, including all middleware wrappers, does not support flushing, so the caller can
+// still respond with an error.
+func NewStream(w http.ResponseWriter, req *http.Request, opts *StreamOptions) (*Stream, error) {
+	if opts == nil {
+		opts = DefaultStreamOptions()
+	}
+	if !supportsFlush(w) {
+		return nil, fmt.Errorf("response writer does not support flushing: %w", http.ErrNotSupported)
+	}
+
+	s := &Stream{
+		w:    w,
+		rc:   http.NewResponseController(w),
+		ctx:  req.Context(),
+		stop: make(chan struct{}),
+	}
+
+	w.Header().Set(header.ContentType, "text/event-stream")
+	w.Header().Set(header.CacheControl, "no-cache")
+	w.Header().Set(header.XAccelBuffering, "no")
+	w.WriteHeader(http.StatusOK)
+	if opts.Retry > 0 {
+		writeRetry(w, opts.Retry)
+		_, _ = io.WriteString(w, "\n")
+	}
+	if err := s.rc.Flush(); err != nil {
+		return nil, fmt.Errorf("response writer does not support flushing: %w", err)
+	}
+
+	if opts.HeartbeatInterval > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(opts.HeartbeatInterval)
+	}
+	return s, nil
+}
+
+func (s *Stream) heartbeat(interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.write(func(w io.Writer) {
+				_, _ = io.WriteString(w, ": heartbeat\n\n")
+			})
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Send writes and flushes the event. It returns the context error once the client disconnected.
+func (s *Stream) Send(event Event) error {
+	return s.write(func(w io.Writer) {
+		if event.ID != "" {
+			_, _ = fmt.Fprintf(w, "id: %s\n", sanitizeField(event.ID))
+		}
+		if event.Event != "" {
+			_, _ = fmt.Fprintf(w, "event: %s\n", sanitizeField(event.Event))
+		}
+		if event.Retry > 0 {
+			writeRetry(w, event.Retry)
+		}
+		// CRLF, CR and LF all end lines of event streams
+		data := strings.ReplaceAll(strings.ReplaceAll(event.Data, "\r\n", "\n"), "\r", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			_, _ = fmt.Fprintf(w, "data: %s\n", line)
+		}
+		_, _ = io.WriteString(w, "\n")
+	})
+}
+
+// Comment writes a comment line, which clients ignore.
+func (s *Stream) Comment(comment string) error {
+	return s.write(func(w io.Writer) {
+		_, _ = fmt.Fprintf(w, ": %s\n\n", sanitizeField(comment))
+	})
+}
+
+// Done is closed when the client disconnected.
+func (s *Stream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Close stops the heartbeat. Further writes fail.
+func (s *Stream) Close() {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.stop)
+	s.m.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Stream) write(fn func(w io.Writer)) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	fn(s.w)
+	return s.rc.Flush()
+}
+
+// LastEventID returns the ID of the last event received by a reconnecting client.
+func LastEventID(req *http.Request) string {
+	return req.Header.Get(header.LastEventID)
+}
+
+func writeRetry(w io.Writer, retry time.Duration) {
+	_, _ = fmt.Fprintf(w, "retry: %s\n", strconv.FormatInt(retry.Milliseconds(), 10))
+}
+
+// supportsFlush reports whether the response writer or one of the writers it wraps can be flushed,
+// following the unwrapping of http.ResponseController
+func supportsFlush(w http.ResponseWriter) bool {
+	for {
+		switch typed := w.(type) {
+		case interface{ FlushError() error }, http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = typed.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+func sanitizeField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
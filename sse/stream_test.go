@@ -0,0 +1,190 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nonFlushingResponseWriter struct {
+	header      http.Header
+	wroteHeader bool
+}
+
+func (w *nonFlushingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *nonFlushingResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *nonFlushingResponseWriter) WriteHeader(int) {
+	w.wroteHeader = true
+}
+
+func TestDefaultStreamOptions(t *testing.T) {
+	opts := DefaultStreamOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 15*time.Second, opts.HeartbeatInterval)
+	assert.Zero(t, opts.Retry)
+}
+
+func TestNewStream(t *testing.T) {
+	t.Run("sets event stream headers", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		require.NoError(t, err)
+		stream.Close()
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+		assert.True(t, rr.Flushed)
+	})
+
+	t.Run("sends retry hint", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		opts := DefaultStreamOptions()
+		opts.Retry = 3 * time.Second
+
+		stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), opts)
+		require.NoError(t, err)
+		stream.Close()
+
+		assert.Equal(t, "retry: 3000\n\n", rr.Body.String())
+	})
+
+	t.Run("fails without flush support", func(t *testing.T) {
+		w := &nonFlushingResponseWriter{header: make(http.Header)}
+
+		_, err := NewStream(w, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+		assert.False(t, w.wroteHeader)
+		assert.Empty(t, w.header)
+	})
+}
+
+func TestStream_Send(t *testing.T) {
+	t.Run("formats events", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(Event{ID: "1", Event: "update", Data: "first\nsecond", Retry: time.Second}))
+		require.NoError(t, stream.Send(Event{Data: "plain"}))
+		require.NoError(t, stream.Comment("note"))
+		stream.Close()
+
+		assert.Equal(t, "id: 1\nevent: update\nretry: 1000\ndata: first\ndata: second\n\ndata: plain\n\n: note\n\n", rr.Body.String())
+	})
+
+	t.Run("strips line breaks from fields", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(Event{ID: "1\ndata: injected", Data: "x"}))
+		stream.Close()
+
+		assert.Equal(t, "id: 1data: injected\ndata: x\n\n", rr.Body.String())
+	})
+
+	t.Run("splits data at lone carriage returns", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(Event{Data: "x\revent: admin\r\ny"}))
+		stream.Close()
+
+		assert.Equal(t, "data: x\ndata: event: admin\ndata: y\n\n", rr.Body.String())
+	})
+
+	t.Run("fails after client disconnected", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		stream, err := NewStream(httptest.NewRecorder(), req, nil)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		cancel()
+
+		<-stream.Done()
+		assert.ErrorIs(t, stream.Send(Event{Data: "x"}), context.Canceled)
+	})
+
+	t.Run("fails after close", func(t *testing.T) {
+		stream, err := NewStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+		require.NoError(t, err)
+
+		stream.Close()
+		stream.Close()
+
+		assert.ErrorIs(t, stream.Send(Event{Data: "x"}), ErrStreamClosed)
+	})
+}
+
+func TestStream_Heartbeat(t *testing.T) {
+	rr := httptest.NewRecorder()
+	opts := DefaultStreamOptions()
+	opts.HeartbeatInterval = 5 * time.Millisecond
+
+	stream, err := NewStream(rr, httptest.NewRequest(http.MethodGet, "/events", nil), opts)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	stream.Close()
+
+	assert.Contains(t, rr.Body.String(), ": heartbeat\n\n")
+}
+
+func TestStream_ThroughMiddlewares(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := NewStream(w, r, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer stream.Close()
+
+		assert.NoError(t, stream.Send(Event{ID: "1", Data: "hello"}))
+		<-release
+	})
+
+	wrapped := logging.NewRequestLoggerMiddleware(nil)(metrics.NewRequestMetricsMiddleware(nil)(handler))
+	srv := httptest.NewServer(wrapped)
+	defer srv.Close()
+	defer close(release)
+
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	// The event arrives while the handler is still running, so nothing buffers the response.
+	reader := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	assert.Equal(t, []string{"id: 1", "data: hello"}, lines)
+}
+
+func TestLastEventID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	assert.Empty(t, LastEventID(req))
+
+	req.Header.Set("Last-Event-ID", "42")
+	assert.Equal(t, "42", LastEventID(req))
+}
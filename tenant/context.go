@@ -0,0 +1,24 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+)
+
+func init() {
+	contextutils.RegisterValue[Tenant]()
+}
+
+// Tenant identifies the tenant a request is processed for
+type Tenant struct {
+	ID string
+}
+
+func TenantFromContext(ctx context.Context) *Tenant {
+	return contextutils.GetValue[Tenant](ctx)
+}
+
+func ContextWithTenant(ctx context.Context, tenant Tenant) context.Context {
+	return contextutils.WithValue(ctx, tenant)
+}
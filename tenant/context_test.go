@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	t.Run("tenant exists", func(t *testing.T) {
+		ctx := ContextWithTenant(context.Background(), Tenant{ID: "acme"})
+
+		tenant := TenantFromContext(ctx)
+
+		require.NotNil(t, tenant)
+		assert.Equal(t, "acme", tenant.ID)
+	})
+
+	t.Run("tenant does not exist", func(t *testing.T) {
+		assert.Nil(t, TenantFromContext(context.Background()))
+	})
+
+	t.Run("tenant is captured", func(t *testing.T) {
+		ctx := ContextWithTenant(context.Background(), Tenant{ID: "acme"})
+
+		tenant := TenantFromContext(contextutils.Capture(ctx).Apply(context.Background()))
+
+		require.NotNil(t, tenant)
+		assert.Equal(t, "acme", tenant.ID)
+	})
+}
@@ -0,0 +1,149 @@
+package tenant
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	slogging "github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/auth"
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/go-chi/render"
+)
+
+// ResolverFn returns the ID of the tenant the request addresses, if it can tell
+type ResolverFn func(req *http.Request) (string, bool)
+
+// FromHeader resolves the tenant from the value of the header
+func FromHeader(name string) ResolverFn {
+	return func(req *http.Request) (string, bool) {
+		value := strings.TrimSpace(req.Header.Get(name))
+		return value, value != ""
+	}
+}
+
+// FromJWTClaim resolves the tenant from a string claim of the JWT stored by the ContextJWTMiddleware
+func FromJWTClaim(claim string) ResolverFn {
+	return func(req *http.Request) (string, bool) {
+		token := auth.JWTFromContext(req.Context())
+		if token == nil {
+			return "", false
+		}
+		var value any
+		if err := token.Get(claim, &value); err != nil {
+			return "", false
+		}
+		valueString, ok := value.(string)
+		return valueString, ok && valueString != ""
+	}
+}
+
+// FromSubdomain resolves the tenant from the subdomain of the base domain the request is addressed
+// to, e.g. "acme" for "acme.example.com" with base domain "example.com"
+func FromSubdomain(baseDomain string) ResolverFn {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(req *http.Request) (string, bool) {
+		host := req.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		subdomain, ok := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !ok || subdomain == "" || strings.Contains(subdomain, ".") {
+			return "", false
+		}
+		return subdomain, true
+	}
+}
+
+// FromPathPrefix resolves the tenant from the path segment following the prefix, e.g. "acme" for
+// "/tenants/acme/orders" with prefix "/tenants/"
+func FromPathPrefix(prefix string) ResolverFn {
+	return func(req *http.Request) (string, bool) {
+		rest, ok := strings.CutPrefix(req.URL.Path, prefix)
+		if !ok {
+			return "", false
+		}
+		segment, _, _ := strings.Cut(rest, "/")
+		return segment, segment != ""
+	}
+}
+
+// TenantMiddleware //
+
+type TenantMiddlewareOptions struct {
+	// Resolvers are asked in order, the first resolving a tenant wins
+	Resolvers []ResolverFn
+	// AllowedTenants restricts the accepted tenant IDs. If empty, every tenant is accepted.
+	AllowedTenants []string
+	// AllowFn decides whether the tenant may be accessed by the request, e.g. by comparing it with the
+	// tenants of the principal. If nil, every allowed tenant may be accessed.
+	AllowFn func(req *http.Request, tenant Tenant) bool
+	// Optional lets requests without tenant pass without tenant in context
+	Optional                 bool
+	LogFieldName             string
+	MissingTenantResponse    render.Renderer
+	TenantNotAllowedResponse render.Renderer
+}
+
+func DefaultTenantMiddlewareOptions() *TenantMiddlewareOptions {
+	return &TenantMiddlewareOptions{
+		Resolvers:                []ResolverFn{FromHeader(header.XTenantID)},
+		AllowedTenants:           []string{},
+		LogFieldName:             logging.LogFieldTenantID,
+		MissingTenantResponse:    weberrors.NewBadRequestResponse("Missing tenant"),
+		TenantNotAllowedResponse: weberrors.NewForbiddenResponse("Tenant not allowed"),
+	}
+}
+
+// NewTenantMiddleware resolves the tenant of the request, stores it in the request context and adds it
+// to the context logger. Requests without tenant are rejected with 400, unless the tenant is optional,
+// and requests for tenants not allowed with 403.
+func NewTenantMiddleware(opts *TenantMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultTenantMiddlewareOptions()
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			tenantID, ok := resolveTenantID(req, opts.Resolvers)
+			if !ok {
+				if opts.Optional {
+					next.ServeHTTP(w, req)
+					return
+				}
+				if err := weberrors.Render(w, req, opts.MissingTenantResponse); err != nil {
+					panic(err)
+				}
+				return
+			}
+
+			tenant := Tenant{ID: tenantID}
+			if (len(opts.AllowedTenants) > 0 && !slices.Contains(opts.AllowedTenants, tenantID)) ||
+				(opts.AllowFn != nil && !opts.AllowFn(req, tenant)) {
+				if err := weberrors.Render(w, req, opts.TenantNotAllowedResponse); err != nil {
+					panic(err)
+				}
+				return
+			}
+
+			ctx := ContextWithTenant(req.Context(), tenant)
+			if logger := slogging.FromContext(ctx); logger != nil {
+				ctx = slogging.ContextWithLogger(ctx, logger.With(opts.LogFieldName, tenantID))
+			}
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func resolveTenantID(req *http.Request, resolvers []ResolverFn) (string, bool) {
+	for _, resolver := range resolvers {
+		if tenantID, ok := resolver(req); ok {
+			return tenantID, true
+		}
+	}
+	return "", false
+}
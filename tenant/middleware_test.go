@@ -0,0 +1,220 @@
+package tenant
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	slogging "github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTenantMiddlewareOptions(t *testing.T) {
+	opts := DefaultTenantMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Len(t, opts.Resolvers, 1)
+	assert.Empty(t, opts.AllowedTenants)
+	assert.Equal(t, logging.LogFieldTenantID, opts.LogFieldName)
+	assert.NotNil(t, opts.MissingTenantResponse)
+	assert.NotNil(t, opts.TenantNotAllowedResponse)
+}
+
+func TestResolvers(t *testing.T) {
+	token, err := jwt.NewBuilder().Claim("tenant", "acme").Build()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resolver ResolverFn
+		prepare  func(req *http.Request) *http.Request
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "header",
+			resolver: FromHeader(header.XTenantID),
+			prepare: func(req *http.Request) *http.Request {
+				req.Header.Set(header.XTenantID, " acme ")
+				return req
+			},
+			expected: "acme",
+			ok:       true,
+		},
+		{
+			name:     "missing header",
+			resolver: FromHeader(header.XTenantID),
+		},
+		{
+			name:     "JWT claim",
+			resolver: FromJWTClaim("tenant"),
+			prepare: func(req *http.Request) *http.Request {
+				return req.WithContext(auth.ContextWithJWT(req.Context(), token))
+			},
+			expected: "acme",
+			ok:       true,
+		},
+		{
+			name:     "missing JWT claim",
+			resolver: FromJWTClaim("organization"),
+			prepare: func(req *http.Request) *http.Request {
+				return req.WithContext(auth.ContextWithJWT(req.Context(), token))
+			},
+		},
+		{
+			name:     "missing JWT",
+			resolver: FromJWTClaim("tenant"),
+		},
+		{
+			name:     "subdomain",
+			resolver: FromSubdomain("example.com"),
+			prepare: func(req *http.Request) *http.Request {
+				req.Host = "ACME.example.com:8080"
+				return req
+			},
+			expected: "acme",
+			ok:       true,
+		},
+		{
+			name:     "nested subdomain",
+			resolver: FromSubdomain("example.com"),
+			prepare: func(req *http.Request) *http.Request {
+				req.Host = "api.acme.example.com"
+				return req
+			},
+		},
+		{
+			name:     "base domain",
+			resolver: FromSubdomain("example.com"),
+			prepare: func(req *http.Request) *http.Request {
+				req.Host = "example.com"
+				return req
+			},
+		},
+		{
+			name:     "path prefix",
+			resolver: FromPathPrefix("/tenants/"),
+			prepare: func(req *http.Request) *http.Request {
+				req.URL.Path = "/tenants/acme/orders"
+				return req
+			},
+			expected: "acme",
+			ok:       true,
+		},
+		{
+			name:     "other path",
+			resolver: FromPathPrefix("/tenants/"),
+			prepare: func(req *http.Request) *http.Request {
+				req.URL.Path = "/orders"
+				return req
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.prepare != nil {
+				req = tt.prepare(req)
+			}
+
+			tenantID, ok := tt.resolver(req)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, tenantID)
+		})
+	}
+}
+
+func TestNewTenantMiddleware(t *testing.T) {
+	var tenant *Tenant
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = TenantFromContext(r.Context())
+		if logger := slogging.FromContext(r.Context()); logger != nil {
+			logger.Info("handled")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("stores the first resolved tenant", func(t *testing.T) {
+		tenant = nil
+		middleware := NewTenantMiddleware(&TenantMiddlewareOptions{
+			Resolvers:    []ResolverFn{FromPathPrefix("/tenants/"), FromHeader(header.XTenantID)},
+			LogFieldName: logging.LogFieldTenantID,
+		})
+		recorder := testutils.NewLogRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/tenants/acme", nil)
+		req.Header.Set(header.XTenantID, "other")
+		req = req.WithContext(slogging.ContextWithLogger(req.Context(), slog.New(recorder)))
+		w := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, tenant)
+		assert.Equal(t, "acme", tenant.ID)
+		entries := recorder.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, "acme", entries[0].Fields[logging.LogFieldTenantID])
+	})
+
+	t.Run("rejects missing tenant", func(t *testing.T) {
+		tenant = nil
+		middleware := NewTenantMiddleware(nil)
+		w := httptest.NewRecorder()
+
+		middleware(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Nil(t, tenant)
+	})
+
+	t.Run("passes missing optional tenant", func(t *testing.T) {
+		tenant = nil
+		opts := DefaultTenantMiddlewareOptions()
+		opts.Optional = true
+		w := httptest.NewRecorder()
+
+		NewTenantMiddleware(opts)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, tenant)
+	})
+
+	t.Run("rejects tenant not in allowed tenants", func(t *testing.T) {
+		tenant = nil
+		opts := DefaultTenantMiddlewareOptions()
+		opts.AllowedTenants = []string{"acme"}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header.XTenantID, "other")
+		w := httptest.NewRecorder()
+
+		NewTenantMiddleware(opts)(handler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Nil(t, tenant)
+	})
+
+	t.Run("rejects tenant denied by allow function", func(t *testing.T) {
+		tenant = nil
+		opts := DefaultTenantMiddlewareOptions()
+		opts.AllowFn = func(req *http.Request, tenant Tenant) bool {
+			return tenant.ID == "acme"
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header.XTenantID, "other")
+		w := httptest.NewRecorder()
+
+		NewTenantMiddleware(opts)(handler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Nil(t, tenant)
+	})
+}
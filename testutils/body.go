@@ -0,0 +1,169 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RequireBodyContains checks the parts of the body the test cares about. For string bodies, expected
+// has to be a substring. Otherwise, the body has to contain expected as JSON: objects have to hold all
+// expected keys with matching values and arrays an element matching each expected element, while
+// unrelated keys and elements are ignored.
+func (r *TestResponse) RequireBodyContains(t *testing.T, expected any) *TestResponse {
+	t.Helper()
+
+	if expectedString, ok := expected.(string); ok {
+		if bodyString, isString := r.Body.(string); isString {
+			require.Contains(t, bodyString, expectedString)
+			return r
+		}
+	}
+
+	actual := r.jsonBody(t)
+	normalizedExpected := toGenericJSON(t, expected)
+	if !containsJSON(actual, normalizedExpected) {
+		require.Fail(t, "body does not contain expected JSON",
+			"expected to contain: %s\nactual body        : %s", mustMarshal(t, normalizedExpected), mustMarshal(t, actual))
+	}
+	return r
+}
+
+// RequireJSONPath checks the value at the path of the JSON body, e.g. "$.items[0].id". Paths start at
+// the root $ and select object keys with .key or ['key'] and array elements with [index].
+func (r *TestResponse) RequireJSONPath(t *testing.T, path string, expected any) *TestResponse {
+	t.Helper()
+
+	actual, err := evaluateJSONPath(r.jsonBody(t), path)
+	if err != nil {
+		t.Fatalf("failed to evaluate JSON path %s: %s", path, err)
+	}
+	require.Equal(t, toGenericJSON(t, expected), actual, "unexpected value at JSON path %s", path)
+	return r
+}
+
+// jsonBody returns the body as generic JSON value, parsing string bodies that were not recognized as JSON
+func (r *TestResponse) jsonBody(t *testing.T) any {
+	if bodyString, ok := r.Body.(string); ok {
+		var body any
+		if err := json.Unmarshal([]byte(bodyString), &body); err != nil {
+			t.Fatalf("failed to parse body as JSON: %s", err)
+		}
+		return body
+	}
+	return toGenericJSON(t, r.Body)
+}
+
+// toGenericJSON converts the value into its generic JSON representation, e.g. ints into float64
+func toGenericJSON(t *testing.T, value any) any {
+	var generic any
+	if err := json.Unmarshal(mustMarshal(t, value), &generic); err != nil {
+		t.Fatalf("failed to parse JSON: %s", err)
+	}
+	return generic
+}
+
+func mustMarshal(t *testing.T, value any) []byte {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to marshal JSON: %s", err)
+	}
+	return valueBytes
+}
+
+// containsJSON checks if the generic JSON value contains the expected one
+func containsJSON(actual any, expected any) bool {
+	switch typedExpected := expected.(type) {
+	case map[string]any:
+		typedActual, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, expectedValue := range typedExpected {
+			actualValue, exists := typedActual[key]
+			if !exists || !containsJSON(actualValue, expectedValue) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		typedActual, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+		for _, expectedElement := range typedExpected {
+			if !slices.ContainsFunc(typedActual, func(actualElement any) bool {
+				return containsJSON(actualElement, expectedElement)
+			}) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(actual, expected)
+	}
+}
+
+// evaluateJSONPath returns the value selected by a JSON path of keys and array indices
+func evaluateJSONPath(value any, path string) (any, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("path must start with $")
+	}
+
+	current := value
+	for rest != "" {
+		var key string
+		index := -1
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key, rest = rest[1:end+1], rest[end+1:]
+			if key == "" {
+				return nil, fmt.Errorf("empty key")
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket")
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if unquoted, err := strconv.Unquote(strings.ReplaceAll(selector, "'", "\"")); err == nil {
+				key = unquoted
+			} else if index, err = strconv.Atoi(selector); err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid selector [%s]", selector)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+
+		if index >= 0 {
+			array, isArray := current.([]any)
+			if !isArray || index >= len(array) {
+				return nil, fmt.Errorf("no element at index %d", index)
+			}
+			current = array[index]
+			continue
+		}
+		object, isObject := current.(map[string]any)
+		if !isObject {
+			return nil, fmt.Errorf("no object to select key %s from", key)
+		}
+		element, exists := object[key]
+		if !exists {
+			return nil, fmt.Errorf("key %s does not exist", key)
+		}
+		current = element
+	}
+	return current, nil
+}
@@ -0,0 +1,114 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newItemsResponse() *TestResponse {
+	return &TestResponse{
+		Status: 200,
+		Body: map[string]any{
+			"total": float64(2),
+			"items": []any{
+				map[string]any{"id": "a", "name": "first", "tags": []any{"x", "y"}},
+				map[string]any{"id": "b", "name": "second", "meta": map[string]any{"dotted.key": true}},
+			},
+		},
+	}
+}
+
+func TestTestResponse_RequireBodyContains(t *testing.T) {
+	t.Run("matches partial objects and arrays", func(t *testing.T) {
+		newItemsResponse().
+			RequireBodyContains(t, map[string]any{"total": 2}).
+			RequireBodyContains(t, map[string]any{"items": []any{map[string]any{"id": "b"}}}).
+			RequireBodyContains(t, map[string]any{"items": []any{map[string]any{"tags": []string{"y"}}}})
+	})
+
+	t.Run("matches structs by their JSON representation", func(t *testing.T) {
+		type item struct {
+			ID string `json:"id"`
+		}
+		newItemsResponse().RequireBodyContains(t, map[string]any{"items": []item{{ID: "a"}, {ID: "b"}}})
+	})
+
+	t.Run("matches substrings of string bodies", func(t *testing.T) {
+		(&TestResponse{Body: "hello world"}).RequireBodyContains(t, "world")
+	})
+
+	t.Run("matches unparsed JSON string bodies", func(t *testing.T) {
+		(&TestResponse{Body: `{"id":"a","name":"first"}`}).RequireBodyContains(t, map[string]any{"id": "a"})
+	})
+}
+
+func TestContainsJSON(t *testing.T) {
+	actual := toGenericJSON(t, newItemsResponse().Body)
+
+	testCases := []struct {
+		name     string
+		expected any
+		contains bool
+	}{
+		{name: "empty object", expected: map[string]any{}, contains: true},
+		{name: "different value", expected: map[string]any{"total": 3}, contains: false},
+		{name: "missing key", expected: map[string]any{"missing": nil}, contains: false},
+		{name: "missing array element", expected: map[string]any{"items": []any{map[string]any{"id": "c"}}}, contains: false},
+		{name: "mismatching type", expected: map[string]any{"items": map[string]any{}}, contains: false},
+		{name: "nested partial object", expected: map[string]any{"items": []any{map[string]any{"meta": map[string]any{}}}}, contains: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.contains, containsJSON(actual, toGenericJSON(t, tc.expected)))
+		})
+	}
+}
+
+func TestTestResponse_RequireJSONPath(t *testing.T) {
+	newItemsResponse().
+		RequireJSONPath(t, "$", toGenericJSON(t, newItemsResponse().Body)).
+		RequireJSONPath(t, "$.total", 2).
+		RequireJSONPath(t, "$.items[0].id", "a").
+		RequireJSONPath(t, "$.items[0].tags", []string{"x", "y"}).
+		RequireJSONPath(t, "$.items[1]['name']", "second").
+		RequireJSONPath(t, `$.items[1].meta["dotted.key"]`, true)
+}
+
+func TestEvaluateJSONPath(t *testing.T) {
+	body := toGenericJSON(t, newItemsResponse().Body)
+
+	testCases := []struct {
+		name          string
+		path          string
+		expected      any
+		expectedError string
+	}{
+		{name: "nested key", path: "$.items[1].name", expected: "second"},
+		{name: "quoted key", path: "$['items'][0]['tags'][1]", expected: "y"},
+		{name: "missing root", path: "items", expectedError: "must start with $"},
+		{name: "missing key", path: "$.missing", expectedError: "key missing does not exist"},
+		{name: "index out of range", path: "$.items[2]", expectedError: "no element at index 2"},
+		{name: "index on object", path: "$[0]", expectedError: "no element at index 0"},
+		{name: "key on array", path: "$.items.id", expectedError: "no object to select key id from"},
+		{name: "invalid selector", path: "$.items[-1]", expectedError: "invalid selector [-1]"},
+		{name: "unterminated bracket", path: "$.items[0", expectedError: "unterminated bracket"},
+		{name: "empty key", path: "$..items", expectedError: "empty key"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := evaluateJSONPath(body, tc.path)
+
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
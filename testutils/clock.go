@@ -0,0 +1,105 @@
+package testutils
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/clock"
+)
+
+// FakeClock is a clock.Clock that only moves when advanced, so retry backoffs, reload intervals or
+// logged durations can be tested deterministically without sleeping
+type FakeClock struct {
+	m      sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+var _ clock.Clock = (*FakeClock)(nil)
+
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.m)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// NewTimer returns a timer firing once the clock has been advanced by d. Timers with d <= 0 fire
+// immediately.
+func (c *FakeClock) NewTimer(d time.Duration) clock.Timer {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	timer := &fakeTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer
+	}
+	c.timers = append(c.timers, timer)
+	c.cond.Broadcast()
+	return timer
+}
+
+// Advance moves the clock forward by d, firing all timers that are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.now = c.now.Add(d)
+	c.timers = slices.DeleteFunc(c.timers, func(timer *fakeTimer) bool {
+		if timer.deadline.After(c.now) {
+			return false
+		}
+		timer.c <- c.now
+		return true
+	})
+}
+
+// PendingTimers returns the number of timers that have neither fired nor been stopped
+func (c *FakeClock) PendingTimers() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until at least n timers are pending, e.g. until code under test running in
+// another goroutine waits for a backoff, so the clock can be advanced past it
+func (c *FakeClock) WaitForTimers(n int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.m.Lock()
+	defer t.clock.m.Unlock()
+
+	pending := len(t.clock.timers)
+	t.clock.timers = slices.DeleteFunc(t.clock.timers, func(timer *fakeTimer) bool {
+		return timer == t
+	})
+	return len(t.clock.timers) < pending
+}
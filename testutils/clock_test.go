@@ -0,0 +1,131 @@
+package testutils
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("moves only when advanced", func(t *testing.T) {
+		c := NewFakeClock(start)
+
+		assert.Equal(t, start, c.Now())
+		c.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Minute), c.Now())
+		assert.Equal(t, time.Minute, c.Since(start))
+	})
+
+	t.Run("fires timers once due", func(t *testing.T) {
+		c := NewFakeClock(start)
+		timer := c.NewTimer(time.Second)
+
+		c.Advance(999 * time.Millisecond)
+		assert.Empty(t, timer.C())
+		assert.Equal(t, 1, c.PendingTimers())
+
+		c.Advance(time.Millisecond)
+		assert.Equal(t, start.Add(time.Second), <-timer.C())
+		assert.Equal(t, 0, c.PendingTimers())
+		assert.False(t, timer.Stop())
+	})
+
+	t.Run("fires timers without duration immediately", func(t *testing.T) {
+		c := NewFakeClock(start)
+
+		assert.Equal(t, start, <-c.NewTimer(0).C())
+	})
+
+	t.Run("stops timers", func(t *testing.T) {
+		c := NewFakeClock(start)
+		timer := c.NewTimer(time.Second)
+
+		assert.True(t, timer.Stop())
+		c.Advance(time.Second)
+		assert.Empty(t, timer.C())
+	})
+
+	t.Run("waits for timers of other goroutines", func(t *testing.T) {
+		c := NewFakeClock(start)
+		done := make(chan struct{})
+		go func() {
+			<-c.NewTimer(time.Hour).C()
+			close(done)
+		}()
+
+		c.WaitForTimers(1)
+		c.Advance(time.Hour)
+		<-done
+	})
+}
+
+func TestFakeClock_RetryTransport(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodGet, URL: "https://api.example.com/items"}).
+		WillReturnResponses(&TestResponse{Status: http.StatusServiceUnavailable}, &TestResponse{Status: http.StatusOK})
+	opts := resiliency.DefaultRetryTransportOptions()
+	opts.Clock = c
+	retryTransport := resiliency.NewRetryTransport(transport, opts)
+
+	result := make(chan int)
+	go func() {
+		res, err := retryTransport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/items", nil))
+		assert.NoError(t, err)
+		result <- res.StatusCode
+	}()
+
+	c.WaitForTimers(1)
+	c.Advance(opts.InitialBackoff)
+
+	assert.Equal(t, http.StatusOK, <-result)
+	transport.Verify(t)
+}
+
+func TestFakeClock_RateLimitSampler(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	sampler := logging.NewRateLimitSamplerWithClock(2, c)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert.True(t, sampler(req))
+	assert.True(t, sampler(req))
+	assert.False(t, sampler(req))
+
+	c.Advance(time.Second)
+	assert.True(t, sampler(req))
+}
+
+func TestFakeClock_RequestLoggerTransport(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	var elapsed time.Duration
+	opts := logging.DefaultRequestLoggerTransportOptions()
+	opts.Clock = c
+	opts.LevelFn = func(status int, err error, duration time.Duration) slog.Level {
+		elapsed = duration
+		return slog.LevelInfo
+	}
+	transport := logging.NewRequestLoggerTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		c.Advance(1500 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}), opts)
+
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/items", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1500*time.Millisecond, elapsed)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
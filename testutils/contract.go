@@ -0,0 +1,167 @@
+package testutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+type ContractValidatorOptions struct {
+	// AllowUndocumentedStatus accepts status codes the operation does not declare. Defaults to false.
+	AllowUndocumentedStatus bool
+	// AllowUndocumentedRoutes accepts responses to requests that match no operation. Defaults to false.
+	AllowUndocumentedRoutes bool
+	// MatchServers matches requests against the servers of the spec. By default, operations are
+	// matched on method and path only, so handlers can be tested regardless of host and scheme.
+	MatchServers bool
+}
+
+func DefaultContractValidatorOptions() *ContractValidatorOptions {
+	return &ContractValidatorOptions{}
+}
+
+// ContractValidator asserts that responses conform to an OpenAPI spec: status codes, declared
+// headers and body schemas are validated and every violation fails the test.
+type ContractValidator struct {
+	t      *testing.T
+	opts   *ContractValidatorOptions
+	router routers.Router
+}
+
+// NewContractValidator loads the OpenAPI spec at the given path, resolving references relative to it.
+func NewContractValidator(t *testing.T, specPath string, opts *ContractValidatorOptions) *ContractValidator {
+	doc, err := openapi3.NewLoader().LoadFromFile(specPath)
+	if err != nil {
+		t.Fatalf("failed to load OpenAPI spec: %s", err)
+	}
+	return newContractValidator(t, doc, opts)
+}
+
+// NewContractValidatorFromData parses the given OpenAPI spec in JSON or YAML format.
+func NewContractValidatorFromData(t *testing.T, spec []byte, opts *ContractValidatorOptions) *ContractValidator {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		t.Fatalf("failed to load OpenAPI spec: %s", err)
+	}
+	return newContractValidator(t, doc, opts)
+}
+
+func newContractValidator(t *testing.T, doc *openapi3.T, opts *ContractValidatorOptions) *ContractValidator {
+	if opts == nil {
+		opts = DefaultContractValidatorOptions()
+	}
+	if err := doc.Validate(t.Context()); err != nil {
+		t.Fatalf("invalid OpenAPI spec: %s", err)
+	}
+	if !opts.MatchServers {
+		doc.Servers = nil
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("failed to create OpenAPI router: %s", err)
+	}
+
+	return &ContractValidator{
+		t:      t,
+		opts:   opts,
+		router: router,
+	}
+}
+
+// ValidateResponse checks a single response to the given request against the spec.
+func (v *ContractValidator) ValidateResponse(req *http.Request, status int, header http.Header, body []byte) error {
+	route, pathParams, err := v.router.FindRoute(req)
+	if err != nil {
+		if v.opts.AllowUndocumentedRoutes {
+			return nil
+		}
+		return fmt.Errorf("no operation found for %s %s: %w", req.Method, req.URL.Path, err)
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: status,
+		Header: header,
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: !v.opts.AllowUndocumentedStatus,
+			MultiError:            true,
+		},
+	}
+	input.SetBodyBytes(body)
+	if err = openapi3filter.ValidateResponse(req.Context(), input); err != nil {
+		return fmt.Errorf("%s %s responded with %d violating the contract: %w", req.Method, req.URL.Path, status, err)
+	}
+	return nil
+}
+
+// Handler wraps the given handler, validating each of its responses. The response is buffered and
+// passed on to the actual writer once validated.
+func (v *ContractValidator) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		rr := httptest.NewRecorder()
+		next.ServeHTTP(rr, req)
+
+		if err := v.ValidateResponse(req, rr.Code, rr.Header(), rr.Body.Bytes()); err != nil {
+			v.t.Error(err)
+		}
+
+		for key, values := range rr.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rr.Code)
+		_, _ = w.Write(rr.Body.Bytes())
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Transport wraps the given transport, validating each of its responses. Uses http.DefaultTransport
+// if rt is nil.
+func (v *ContractValidator) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &contractTransport{
+		base:      rt,
+		validator: v,
+	}
+}
+
+type contractTransport struct {
+	base      http.RoundTripper
+	validator *ContractValidator
+}
+
+var _ http.RoundTripper = (*contractTransport)(nil)
+
+func (c *contractTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := c.base.RoundTrip(req)
+	if err != nil || res == nil {
+		return res, err
+	}
+
+	var body []byte
+	if res.Body != nil {
+		body, err = io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err = c.validator.ValidateResponse(req, res.StatusCode, res.Header, body); err != nil {
+		c.validator.t.Error(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,155 @@
+package testutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const contractTestSpec = `
+openapi: 3.0.3
+info:
+  title: Users
+  version: 1.0.0
+servers:
+  - url: https://api.localhost/v1
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: The user
+          headers:
+            X-Request-ID:
+              required: true
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id:
+                    type: string
+                  name:
+                    type: string
+                  age:
+                    type: integer
+                    minimum: 0
+        "404":
+          description: User not found
+`
+
+func writeUser(w http.ResponseWriter, user any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-ID", "request-1")
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+func TestContractValidator_ValidateResponse(t *testing.T) {
+	validator := NewContractValidatorFromData(t, []byte(contractTestSpec), nil)
+	jsonHeader := http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"request-1"}}
+
+	tests := []struct {
+		name          string
+		path          string
+		status        int
+		header        http.Header
+		body          string
+		expectedError string
+	}{
+		{name: "valid response", path: "/users/1", status: http.StatusOK, header: jsonHeader, body: `{"id":"1","name":"John"}`},
+		{name: "documented status without content", path: "/users/1", status: http.StatusNotFound, header: http.Header{}},
+		{name: "missing required property", path: "/users/1", status: http.StatusOK, header: jsonHeader, body: `{"id":"1"}`, expectedError: `property "name" is missing`},
+		{name: "schema violation", path: "/users/1", status: http.StatusOK, header: jsonHeader, body: `{"id":"1","name":"John","age":-1}`, expectedError: "number must be at least 0"},
+		{name: "missing header", path: "/users/1", status: http.StatusOK, header: http.Header{"Content-Type": {"application/json"}}, body: `{"id":"1","name":"John"}`, expectedError: "X-Request-ID"},
+		{name: "undocumented status", path: "/users/1", status: http.StatusInternalServerError, header: http.Header{}, expectedError: "status is not supported"},
+		{name: "undocumented content type", path: "/users/1", status: http.StatusOK, header: http.Header{"Content-Type": {"text/plain"}, "X-Request-Id": {"request-1"}}, body: "John", expectedError: "text/plain"},
+		{name: "undocumented route", path: "/orders", status: http.StatusOK, header: http.Header{}, expectedError: "no operation found for GET /orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			err := validator.ValidateResponse(req, tt.status, tt.header, []byte(tt.body))
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestContractValidator_Options(t *testing.T) {
+	t.Run("allows undocumented status and routes", func(t *testing.T) {
+		validator := NewContractValidatorFromData(t, []byte(contractTestSpec), &ContractValidatorOptions{
+			AllowUndocumentedStatus: true,
+			AllowUndocumentedRoutes: true,
+		})
+
+		assert.NoError(t, validator.ValidateResponse(httptest.NewRequest(http.MethodGet, "/users/1", nil), http.StatusInternalServerError, http.Header{}, nil))
+		assert.NoError(t, validator.ValidateResponse(httptest.NewRequest(http.MethodGet, "/orders", nil), http.StatusOK, http.Header{}, nil))
+	})
+
+	t.Run("matches servers", func(t *testing.T) {
+		validator := NewContractValidatorFromData(t, []byte(contractTestSpec), &ContractValidatorOptions{MatchServers: true})
+
+		assert.Error(t, validator.ValidateResponse(httptest.NewRequest(http.MethodGet, "/users/1", nil), http.StatusNotFound, http.Header{}, nil))
+		assert.NoError(t, validator.ValidateResponse(httptest.NewRequest(http.MethodGet, "https://api.localhost/v1/users/1", nil), http.StatusNotFound, http.Header{}, nil))
+	})
+}
+
+func TestContractValidator_Handler(t *testing.T) {
+	validator := NewContractValidatorFromData(t, []byte(contractTestSpec), nil)
+	handler := validator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeUser(w, map[string]any{"id": "1", "name": "John"})
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "request-1", rr.Header().Get("X-Request-ID"))
+	assert.JSONEq(t, `{"id":"1","name":"John"}`, rr.Body.String())
+}
+
+func TestContractValidator_Transport(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(contractTestSpec), 0o600))
+	validator := NewContractValidator(t, specPath, &ContractValidatorOptions{MatchServers: true})
+
+	mockTransport := NewMockInteractionTransport(t, nil)
+	mockTransport.ExpectRequest(TestRequest{
+		Method: http.MethodGet,
+		URL:    "https://api.localhost/v1/users/1",
+	}).WillReturnResponse(&TestResponse{
+		Status: http.StatusOK,
+		Header: http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"request-1"}},
+		Body:   map[string]any{"id": "1", "name": "John"},
+	})
+	client := &http.Client{Transport: validator.Transport(mockTransport)}
+
+	res, err := client.Get("https://api.localhost/v1/users/1")
+	require.NoError(t, err)
+
+	response := MustParseResponse(t, res)
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.Equal(t, map[string]any{"id": "1", "name": "John"}, response.Body)
+}
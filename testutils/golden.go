@@ -0,0 +1,111 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// IgnoredValue replaces the values of ignored paths in golden files
+const IgnoredValue = "<ignored>"
+
+// updateGoldenFiles is namespaced, so it does not collide with the -update flags of test packages
+var updateGoldenFiles = flag.Bool("testutils.update", false, "update golden files instead of comparing against them")
+
+// RequireMatchesGolden compares the response with the golden file at the given path, which uses the
+// format of MustReadResponseFromFile. Running the tests with -testutils.update (re)writes the golden
+// file instead. Values at the ignore paths, e.g. volatile timestamps or IDs, are replaced by
+// IgnoredValue on both sides. Paths are dot-separated keys of the JSON representation of the response,
+// e.g. "header.Date" or "body.items.*.id", where * matches all keys or elements and numbers index into
+// arrays.
+func (r *TestResponse) RequireMatchesGolden(t *testing.T, path string, ignorePaths ...string) *TestResponse {
+	t.Helper()
+
+	actual := normalizeGolden(t, r, ignorePaths)
+	if *updateGoldenFiles {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(actual+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %s", err)
+		}
+		return r
+	}
+
+	goldenBytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s does not exist, run the test with -testutils.update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("failed to read golden file: %s", err)
+	}
+	var golden any
+	if err = json.Unmarshal(goldenBytes, &golden); err != nil {
+		t.Fatalf("failed to parse golden file: %s", err)
+	}
+
+	require.Equal(t, normalizeGolden(t, golden, ignorePaths), actual, "response does not match golden file %s", path)
+	return r
+}
+
+// normalizeGolden converts the value into indented JSON with sorted keys and ignored values replaced
+func normalizeGolden(t *testing.T, value any, ignorePaths []string) string {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to marshal response: %s", err)
+	}
+	var generic any
+	if err = json.Unmarshal(valueBytes, &generic); err != nil {
+		t.Fatalf("failed to parse response: %s", err)
+	}
+
+	for _, path := range ignorePaths {
+		generic = replacePath(generic, strings.Split(path, "."))
+	}
+
+	var normalized bytes.Buffer
+	encoder := json.NewEncoder(&normalized)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(generic); err != nil {
+		t.Fatalf("failed to marshal response: %s", err)
+	}
+	return strings.TrimSuffix(normalized.String(), "\n")
+}
+
+// replacePath replaces the values at the path with IgnoredValue, skipping missing keys and elements.
+// Arrays are replaced by an array holding IgnoredValue, so header values keep their type.
+func replacePath(value any, segments []string) any {
+	if len(segments) == 0 {
+		if _, ok := value.([]any); ok {
+			return []any{IgnoredValue}
+		}
+		return IgnoredValue
+	}
+	segment, rest := segments[0], segments[1:]
+
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, element := range typedValue {
+			if segment == "*" || segment == key {
+				typedValue[key] = replacePath(element, rest)
+			}
+		}
+	case []any:
+		if segment == "*" {
+			for i, element := range typedValue {
+				typedValue[i] = replacePath(element, rest)
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(typedValue) {
+			typedValue[i] = replacePath(typedValue[i], rest)
+		}
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package testutils
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consumers commonly define an -update flag for their own golden files, which must not panic at init
+var _ = flag.Bool("update", false, "update golden files of the consumer")
+
+func TestTestResponse_RequireMatchesGolden(t *testing.T) {
+	newResponse := func(id string, date string) *TestResponse {
+		return &TestResponse{
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": {"application/json"}, "Date": {date}},
+			Body: map[string]any{
+				"name":  "users",
+				"items": []any{map[string]any{"id": id, "name": "John"}},
+			},
+		}
+	}
+	ignorePaths := []string{"header.Date", "body.items.*.id"}
+
+	t.Run("writes golden file on update and matches it afterwards", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "golden", "users.json")
+
+		*updateGoldenFiles = true
+		newResponse("1", "Mon, 01 Jan 2024 00:00:00 GMT").RequireMatchesGolden(t, path, ignorePaths...)
+		*updateGoldenFiles = false
+
+		golden, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `{
+  "body": {
+    "items": [
+      {
+        "id": "<ignored>",
+        "name": "John"
+      }
+    ],
+    "name": "users"
+  },
+  "header": {
+    "Content-Type": [
+      "application/json"
+    ],
+    "Date": [
+      "<ignored>"
+    ]
+  },
+  "status": 200
+}
+`, string(golden))
+
+		newResponse("2", "Tue, 02 Jan 2024 00:00:00 GMT").RequireMatchesGolden(t, path, ignorePaths...)
+		MustReadResponseFromFile(t, path).RequireEqualStatus(t, &TestResponse{Status: http.StatusOK})
+	})
+
+	t.Run("matches hand-written golden file regardless of key order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{
+  "status": 200,
+  "header": {"Date": ["Mon, 01 Jan 2024 00:00:00 GMT"], "Content-Type": ["application/json"]},
+  "body": {"name": "users", "items": [{"name": "John", "id": "7"}]}
+}`), 0o644))
+
+		newResponse("1", "Tue, 02 Jan 2024 00:00:00 GMT").RequireMatchesGolden(t, path, ignorePaths...)
+	})
+}
+
+func TestNormalizeGolden(t *testing.T) {
+	value := map[string]any{
+		"list":   []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+		"nested": map[string]any{"at": "now", "keep": true},
+	}
+
+	testCases := []struct {
+		name        string
+		ignorePaths []string
+		expected    string
+	}{
+		{
+			name:     "sorts keys",
+			expected: `{"list":[{"id":1},{"id":2}],"nested":{"at":"now","keep":true}}`,
+		},
+		{
+			name:        "replaces nested key",
+			ignorePaths: []string{"nested.at"},
+			expected:    `{"list":[{"id":1},{"id":2}],"nested":{"at":"<ignored>","keep":true}}`,
+		},
+		{
+			name:        "replaces indexed element",
+			ignorePaths: []string{"list.1.id"},
+			expected:    `{"list":[{"id":1},{"id":"<ignored>"}],"nested":{"at":"now","keep":true}}`,
+		},
+		{
+			name:        "replaces all keys with wildcard",
+			ignorePaths: []string{"nested.*"},
+			expected:    `{"list":[{"id":1},{"id":2}],"nested":{"at":"<ignored>","keep":"<ignored>"}}`,
+		},
+		{
+			name:        "replaces arrays by array of ignored value",
+			ignorePaths: []string{"list"},
+			expected:    `{"list":["<ignored>"],"nested":{"at":"now","keep":true}}`,
+		},
+		{
+			name:        "skips missing paths",
+			ignorePaths: []string{"missing.id", "list.5.id", "nested.at.deeper"},
+			expected:    `{"list":[{"id":1},{"id":2}],"nested":{"at":"now","keep":true}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.JSONEq(t, tc.expected, normalizeGolden(t, value, tc.ignorePaths))
+		})
+	}
+}
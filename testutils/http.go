@@ -1,10 +1,11 @@
 package testutils
 
 import (
-	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -12,16 +13,66 @@ import (
 )
 
 type TestRequest struct {
-	Method string      `json:"method"`
-	URL    string      `json:"url"`
-	Header http.Header `json:"header"`
-	Body   any         `json:"body,omitempty"`
+	Method string `json:"method"`
+	// URL may contain wildcards (*), each matching a single path segment, when used as expectation
+	URL string `json:"url"`
+	// URLPattern is a regular expression the entire URL has to match when used as expectation. Takes
+	// precedence over URL.
+	URLPattern string      `json:"urlPattern,omitempty"`
+	Header     http.Header `json:"header"`
+	// Cookies are added to the Cookie header of performed requests
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+	Body    any            `json:"body,omitempty"`
 }
 
 type TestResponse struct {
 	Status int         `json:"status"`
 	Header http.Header `json:"header"`
-	Body   any         `json:"body,omitempty"`
+	// Body is encoded as JSON for JSON content types. Strings and byte slices are sent as is, the
+	// latter are stored base64 encoded as bodyBase64 in files.
+	Body any `json:"body,omitempty"`
+	// Cookies are parsed from the Set-Cookie headers of performed requests and added to them by mocks
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+}
+
+// testResponseFile is the file representation of a TestResponse
+type testResponseFile struct {
+	Status     int            `json:"status"`
+	Header     http.Header    `json:"header"`
+	Body       any            `json:"body,omitempty"`
+	BodyBase64 []byte         `json:"bodyBase64,omitempty"`
+	Cookies    []*http.Cookie `json:"cookies,omitempty"`
+}
+
+func (r TestResponse) MarshalJSON() ([]byte, error) {
+	file := testResponseFile{
+		Status:  r.Status,
+		Header:  r.Header,
+		Body:    r.Body,
+		Cookies: r.Cookies,
+	}
+	if bodyBytes, ok := r.Body.([]byte); ok {
+		file.Body = nil
+		file.BodyBase64 = bodyBytes
+	}
+	return json.Marshal(file)
+}
+
+func (r *TestResponse) UnmarshalJSON(data []byte) error {
+	var file testResponseFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	*r = TestResponse{
+		Status:  file.Status,
+		Header:  file.Header,
+		Body:    file.Body,
+		Cookies: file.Cookies,
+	}
+	if file.BodyBase64 != nil {
+		r.Body = file.BodyBase64
+	}
+	return nil
 }
 
 func (r *TestResponse) RequireEqual(t *testing.T, other *TestResponse) *TestResponse {
@@ -50,6 +101,32 @@ func (r *TestResponse) RequireContainsHeader(t *testing.T, key string, value str
 	return r
 }
 
+// RequireSetsCookie checks that the response sets the cookie and, unless matcher is nil, that the cookie
+// satisfies the matcher, e.g. to check its value or attributes like HttpOnly
+func (r *TestResponse) RequireSetsCookie(t *testing.T, name string, matcher func(cookie *http.Cookie) bool) *TestResponse {
+	t.Helper()
+
+	cookies := r.Cookies
+	if len(cookies) == 0 {
+		for _, line := range r.Header.Values("Set-Cookie") {
+			if cookie, err := http.ParseSetCookie(line); err == nil {
+				cookies = append(cookies, cookie)
+			}
+		}
+	}
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			continue
+		}
+		if matcher != nil {
+			require.True(t, matcher(cookie), "cookie %s does not match: %s", name, cookie.String())
+		}
+		return r
+	}
+	require.Fail(t, "cookie not set", "response does not set cookie %s", name)
+	return r
+}
+
 func MustParseResponse(t *testing.T, res *http.Response) *TestResponse {
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -63,14 +140,22 @@ func MustParseResponse(t *testing.T, res *http.Response) *TestResponse {
 		if innerErr := json.Unmarshal(body, &parsedBody); innerErr != nil {
 			t.Fatalf("failed to parse response: %s", err)
 		}
+	case "application/octet-stream":
+		parsedBody = body
 	default:
 		parsedBody = string(body)
 	}
 
+	var cookies []*http.Cookie
+	if setCookies := res.Cookies(); len(setCookies) > 0 {
+		cookies = setCookies
+	}
+
 	return &TestResponse{
-		Status: res.StatusCode,
-		Header: res.Header,
-		Body:   parsedBody,
+		Status:  res.StatusCode,
+		Header:  res.Header,
+		Body:    parsedBody,
+		Cookies: cookies,
 	}
 }
 
@@ -89,19 +174,52 @@ func MustReadResponseFromFile(t *testing.T, path string) *TestResponse {
 }
 
 func PerformHTTPRequest(t *testing.T, req TestRequest) *TestResponse {
-	bodyBytes, err := json.Marshal(req.Body)
-	if err != nil {
-		t.Fatalf("failed to marshal request body: %s", err.Error())
-	}
+	return performHTTPRequest(t, http.DefaultClient, req)
+}
+
+// PerformHTTPRequestWithClient performs the request with the client, e.g. one whose transport stack or
+// mock transport is under test. Bodies are encoded like by PerformHandlerRequest.
+func PerformHTTPRequestWithClient(t *testing.T, client *http.Client, req TestRequest) *TestResponse {
+	return performHTTPRequest(t, client, req)
+}
 
-	request, err := http.NewRequest(req.Method, req.URL, bytes.NewReader(bodyBytes))
+// PerformHandlerRequest serves the request by the handler, e.g. a router, without opening sockets.
+// String and byte slice bodies are sent as is, other bodies are encoded as JSON.
+func PerformHandlerRequest(t *testing.T, handler http.Handler, req TestRequest) *TestResponse {
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newHTTPRequest(t, t.Context(), req))
+	return MustParseResponse(t, rr.Result())
+}
+
+// HTTPSession performs requests sharing a cookie jar, so cookies set by responses are sent with later
+// requests, e.g. to test login and session flows
+type HTTPSession struct {
+	t      *testing.T
+	client *http.Client
+}
+
+func NewHTTPSession(t *testing.T) *HTTPSession {
+	jar, err := cookiejar.New(nil)
 	if err != nil {
-		t.Fatalf("failed to create request: %s", err.Error())
+		t.Fatalf("failed to create cookie jar: %s", err.Error())
+	}
+	return &HTTPSession{
+		t:      t,
+		client: &http.Client{Jar: jar},
 	}
+}
+
+// Jar returns the cookie jar of the session, e.g. to inspect or preset cookies
+func (s *HTTPSession) Jar() http.CookieJar {
+	return s.client.Jar
+}
 
-	request.Header = req.Header
+func (s *HTTPSession) PerformHTTPRequest(req TestRequest) *TestResponse {
+	return performHTTPRequest(s.t, s.client, req)
+}
 
-	res, err := http.DefaultClient.Do(request)
+func performHTTPRequest(t *testing.T, client *http.Client, req TestRequest) *TestResponse {
+	res, err := client.Do(newClientRequest(t, t.Context(), req))
 	if err != nil {
 		t.Fatalf("failed to perform request: %s", err.Error())
 	}
@@ -0,0 +1,186 @@
+package testutils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestResponse_JSON(t *testing.T) {
+	t.Run("stores byte slice bodies base64 encoded", func(t *testing.T) {
+		response := TestResponse{
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": {"application/octet-stream"}},
+			Body:   []byte{0x00, 0xff, 'a'},
+		}
+
+		data, err := json.Marshal(response)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":200,"header":{"Content-Type":["application/octet-stream"]},"bodyBase64":"AP9h"}`, string(data))
+
+		var parsed TestResponse
+		require.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, response, parsed)
+	})
+
+	t.Run("keeps other bodies", func(t *testing.T) {
+		response := &TestResponse{
+			Status: http.StatusOK,
+			Body:   map[string]any{"id": "1"},
+		}
+
+		data, err := json.Marshal(response)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":200,"header":null,"body":{"id":"1"}}`, string(data))
+
+		var parsed TestResponse
+		require.NoError(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, *response, parsed)
+	})
+
+	t.Run("reads base64 bodies from files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "download.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"status":200,"bodyBase64":"AP9h"}`), 0o644))
+
+		response := MustReadResponseFromFile(t, path)
+
+		assert.Equal(t, []byte{0x00, 0xff, 'a'}, response.Body)
+	})
+}
+
+func TestTestResponse_RequireSetsCookie(t *testing.T) {
+	t.Run("checks parsed cookies", func(t *testing.T) {
+		response := &TestResponse{
+			Cookies: []*http.Cookie{{Name: "session", Value: "abc", HttpOnly: true}},
+		}
+
+		response.
+			RequireSetsCookie(t, "session", nil).
+			RequireSetsCookie(t, "session", func(cookie *http.Cookie) bool {
+				return cookie.Value == "abc" && cookie.HttpOnly
+			})
+	})
+
+	t.Run("falls back to Set-Cookie headers", func(t *testing.T) {
+		response := &TestResponse{
+			Header: http.Header{"Set-Cookie": {"theme=dark; Path=/", "session=abc; Secure"}},
+		}
+
+		response.RequireSetsCookie(t, "session", func(cookie *http.Cookie) bool {
+			return cookie.Secure
+		})
+	})
+}
+
+func TestHTTPSession(t *testing.T) {
+	hasSessionCookie := func(req *http.Request) bool {
+		cookie, err := req.Cookie("session")
+		return err == nil && cookie.Value == "abc"
+	}
+
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodPost, URL: "/login"}).
+		WillReturnResponse(&TestResponse{
+			Status:  http.StatusNoContent,
+			Cookies: []*http.Cookie{{Name: "session", Value: "abc", Path: "/", HttpOnly: true}},
+		})
+	transport.ExpectRequest(TestRequest{Method: http.MethodGet, URL: "/profile"}).
+		MatchWith(hasSessionCookie).
+		MatchWith(func(req *http.Request) bool {
+			cookie, err := req.Cookie("theme")
+			return err == nil && cookie.Value == "dark"
+		}).
+		WillReturnResponse(&TestResponse{Status: http.StatusOK})
+	server := transport.Serve(t)
+
+	session := NewHTTPSession(t)
+
+	session.PerformHTTPRequest(TestRequest{Method: http.MethodPost, URL: server.URL + "/login"}).
+		RequireSetsCookie(t, "session", func(cookie *http.Cookie) bool {
+			return cookie.Value == "abc" && cookie.HttpOnly
+		})
+	response := session.PerformHTTPRequest(TestRequest{
+		Method:  http.MethodGet,
+		URL:     server.URL + "/profile",
+		Cookies: []*http.Cookie{{Name: "theme", Value: "dark"}},
+	})
+
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.Nil(t, response.Cookies)
+	transport.Verify(t)
+}
+
+func TestPerformHTTPRequestWithClient(t *testing.T) {
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodGet, URL: "https://example.com/items"}).
+		WillReturnResponse(&TestResponse{
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   map[string]any{"id": "1"},
+		})
+
+	response := PerformHTTPRequestWithClient(t, &http.Client{Transport: transport}, TestRequest{
+		Method: http.MethodGet,
+		URL:    "https://example.com/items",
+	})
+
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.Equal(t, map[string]any{"id": "1"}, response.Body)
+	transport.Verify(t)
+}
+
+func TestPerformHTTPRequestWithClient_Body(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = append(received, r.Header.Get("Content-Type")+" "+string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	for _, req := range []TestRequest{
+		NewRequest(http.MethodPost, server.URL).WithBody("text/plain", []byte("hello")).TestRequest(),
+		{Method: http.MethodPost, URL: server.URL, Body: "raw"},
+		{Method: http.MethodPost, URL: server.URL, Body: map[string]any{"name": "apple"}},
+		{Method: http.MethodGet, URL: server.URL},
+	} {
+		PerformHTTPRequestWithClient(t, server.Client(), req)
+	}
+
+	assert.Equal(t, []string{
+		"text/plain hello",
+		" raw",
+		`application/json {"name":"apple"}`,
+		" ",
+	}, received)
+}
+
+func TestPerformHandlerRequest(t *testing.T) {
+	router := chi.NewRouter()
+	router.Post("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		body["id"] = chi.URLParam(r, "id")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	})
+
+	response := PerformHandlerRequest(t, router, TestRequest{
+		Method: http.MethodPost,
+		URL:    "/items/1",
+		Body:   map[string]any{"name": "apple"},
+	})
+
+	assert.Equal(t, http.StatusCreated, response.Status)
+	assert.Equal(t, map[string]any{"id": "1", "name": "apple"}, response.Body)
+}
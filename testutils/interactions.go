@@ -0,0 +1,153 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// interactionsFile is the file representation of the interactions of a MockInteractionTransport.
+// Responses use the format of MustReadResponseFromFile.
+type interactionsFile struct {
+	Interactions []interactionFile `json:"interactions"`
+	Requests     []TestRequest     `json:"requests,omitempty"`
+}
+
+type interactionFile struct {
+	Request   TestRequest     `json:"request"`
+	Response  *TestResponse   `json:"response,omitempty"`
+	Responses []*TestResponse `json:"responses,omitempty"`
+}
+
+// SaveInteractions writes the expected interactions and the requests observed so far as JSON to the
+// file at the given path, e.g. to share fixtures with API documentation. Only static responses are
+// saved; responders, errors, delays, matchers and scenarios are not.
+func (c *MockInteractionTransport) SaveInteractions(path string) {
+	c.m.RLock()
+	file := interactionsFile{
+		Interactions: make([]interactionFile, 0, len(c.declaredInteractions)),
+		Requests:     c.observedRequests,
+	}
+	for _, interaction := range c.declaredInteractions {
+		file.Interactions = append(file.Interactions, interactionFile{
+			Request:   interaction.request,
+			Response:  interaction.response,
+			Responses: interaction.responses,
+		})
+	}
+	c.m.RUnlock()
+
+	fileBytes, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		c.t.Fatalf("failed to marshal interactions: %s", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		c.t.Fatalf("failed to create interactions directory: %s", err)
+	}
+	if err = os.WriteFile(path, append(fileBytes, '\n'), 0o644); err != nil {
+		c.t.Fatalf("failed to write interactions: %s", err)
+	}
+}
+
+// LoadInteractions expects the interactions of the file at the given path, which uses the format of
+// SaveInteractions, in their saved order. Observed requests of the file are ignored. The loaded
+// interactions are returned, so they can be refined, e.g. with matchers.
+func (c *MockInteractionTransport) LoadInteractions(t testing.TB, path string) []*ExpectedInteraction {
+	t.Helper()
+
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read interactions: %s", err)
+	}
+	var file interactionsFile
+	if err = json.Unmarshal(fileBytes, &file); err != nil {
+		t.Fatalf("failed to parse interactions: %s", err)
+	}
+
+	interactions := make([]*ExpectedInteraction, 0, len(file.Interactions))
+	for _, entry := range file.Interactions {
+		interaction := c.ExpectRequest(entry.Request)
+		if len(entry.Responses) > 0 {
+			interaction.WillReturnResponses(entry.Responses...)
+		} else {
+			interaction.WillReturnResponse(entry.Response)
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions
+}
+
+// observe records the request for SaveInteractions. The request is not modified, as required for
+// round trippers: its body is read through GetBody if possible, otherwise a clone carrying the read
+// body is returned, so it can still be read by matchers and responders.
+func (c *MockInteractionTransport) observe(req *http.Request) (*http.Request, error) {
+	observed := TestRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		bodyBytes, next, err := readRequestBody(req)
+		if err != nil {
+			c.t.Errorf("failed to read request body: %s", err)
+			return nil, fmt.Errorf("mock transport: failed to read request body: %w", err)
+		}
+		req = next
+		observed.Body = parseObservedBody(req.Header.Get("Content-Type"), bodyBytes)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.observedRequests = append(c.observedRequests, observed)
+	return req, nil
+}
+
+// readRequestBody returns the body of the request and the request to continue with, which is the
+// request itself if its body could be read through GetBody
+func readRequestBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close()
+		bodyBytes, err := io.ReadAll(body)
+		return bodyBytes, req, err
+	}
+
+	bodyBytes, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+	}
+	return bodyBytes, clone, nil
+}
+
+// parseObservedBody decodes JSON bodies and keeps binary bodies as byte slices and others as strings
+func parseObservedBody(contentType string, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		var parsed any
+		if err := json.Unmarshal(body, &parsed); err == nil {
+			return parsed
+		}
+	case "application/octet-stream":
+		return body
+	}
+	return string(body)
+}
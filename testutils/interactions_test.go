@@ -0,0 +1,134 @@
+package testutils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockInteractionTransport_SaveInteractions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures", "users.json")
+
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodPost, URL: "https://api.example.com/users"}).
+		WillReturnResponse(&TestResponse{
+			Status: http.StatusCreated,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   map[string]any{"id": "1"},
+		})
+	transport.ExpectRequest(TestRequest{Method: http.MethodGet, URL: "https://api.example.com/avatars/1"}).
+		WillReturnResponses(
+			&TestResponse{Status: http.StatusServiceUnavailable},
+			&TestResponse{Status: http.StatusOK, Header: http.Header{"Content-Type": {"application/octet-stream"}}, Body: []byte{0x00, 0xff}},
+		)
+	client := &http.Client{Transport: transport}
+
+	res, err := client.Post("https://api.example.com/users", "application/json", strings.NewReader(`{"name":"alice"}`))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	transport.SaveInteractions(path)
+
+	fileBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var file map[string]any
+	require.NoError(t, json.Unmarshal(fileBytes, &file))
+	assert.Equal(t, map[string]any{
+		"method": http.MethodPost,
+		"url":    "https://api.example.com/users",
+		"header": map[string]any{"Content-Type": []any{"application/json"}},
+		"body":   map[string]any{"name": "alice"},
+	}, file["requests"].([]any)[0])
+	interactions := file["interactions"].([]any)
+	require.Len(t, interactions, 2)
+	assert.Equal(t, "AP8=", interactions[1].(map[string]any)["responses"].([]any)[1].(map[string]any)["bodyBase64"])
+
+	t.Run("loads saved interactions", func(t *testing.T) {
+		loaded := NewMockInteractionTransport(t, nil)
+		loadedInteractions := loaded.LoadInteractions(t, path)
+		require.Len(t, loadedInteractions, 2)
+		client := &http.Client{Transport: loaded}
+
+		res, err := client.Post("https://api.example.com/users", "application/json", strings.NewReader(`{"name":"bob"}`))
+		require.NoError(t, err)
+		response := MustParseResponse(t, res)
+		assert.Equal(t, http.StatusCreated, response.Status)
+		assert.Equal(t, map[string]any{"id": "1"}, response.Body)
+
+		res, err = client.Get("https://api.example.com/avatars/1")
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		res, err = client.Get("https://api.example.com/avatars/1")
+		require.NoError(t, err)
+		response = MustParseResponse(t, res)
+		assert.Equal(t, []byte{0x00, 0xff}, response.Body)
+
+		loaded.Verify(t)
+	})
+}
+
+func TestMockInteractionTransport_ObservedBodiesRemainReadable(t *testing.T) {
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodPut, URL: "https://api.example.com/notes/1"}).
+		WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+			body := make([]byte, 5)
+			_, err := req.Body.Read(body)
+			require.NoError(t, err)
+			return &TestResponse{Status: http.StatusOK, Body: string(body)}, nil
+		})
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequest(http.MethodPut, "https://api.example.com/notes/1", strings.NewReader("hello"))
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", MustParseResponse(t, res).Body)
+	assert.Equal(t, "hello", transport.observedRequests[0].Body)
+}
+
+func TestMockInteractionTransport_ObserveKeepsRequest(t *testing.T) {
+	transport := NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(TestRequest{Method: http.MethodPut, URL: "https://api.example.com/notes/1"}).
+		Times(2).
+		WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			return &TestResponse{Status: http.StatusOK, Body: string(body)}, nil
+		})
+
+	t.Run("reads replayable bodies through GetBody", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "https://api.example.com/notes/1", strings.NewReader("hello"))
+		require.NoError(t, err)
+		body := req.Body
+
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello", MustParseResponse(t, res).Body)
+		assert.True(t, body == req.Body, "request body was replaced")
+	})
+
+	t.Run("passes a clone on for other bodies", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "https://api.example.com/notes/1", io.NopCloser(strings.NewReader("world")))
+		require.NoError(t, err)
+		body := req.Body
+
+		res, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "world", MustParseResponse(t, res).Body)
+		assert.True(t, body == req.Body, "request body was replaced")
+		assert.Nil(t, req.GetBody)
+	})
+
+	assert.Equal(t, "hello", transport.observedRequests[0].Body)
+	assert.Equal(t, "world", transport.observedRequests[1].Body)
+}
@@ -0,0 +1,189 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	slogging "github.com/Roshick/go-autumn-slog"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+// LogRecorder is a slog.Handler recording every log record of all levels, so tests can assert on logs
+type LogRecorder struct {
+	attrs []slog.Attr
+	store *logStore
+}
+
+type logStore struct {
+	records []slog.Record
+	m       sync.RWMutex
+}
+
+var _ slog.Handler = (*LogRecorder)(nil)
+
+func NewLogRecorder() *LogRecorder {
+	return &LogRecorder{
+		store: &logStore{},
+	}
+}
+
+// Logger returns a logger writing to the recorder
+func (r *LogRecorder) Logger() *slog.Logger {
+	return slog.New(r)
+}
+
+// Records returns a copy of the records logged so far, including the attributes of derived loggers
+func (r *LogRecorder) Records() []slog.Record {
+	r.store.m.RLock()
+	defer r.store.m.RUnlock()
+	return slices.Clone(r.store.records)
+}
+
+// Reset discards the records logged so far
+func (r *LogRecorder) Reset() {
+	r.store.m.Lock()
+	defer r.store.m.Unlock()
+	r.store.records = nil
+}
+
+func (r *LogRecorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (r *LogRecorder) Handle(_ context.Context, record slog.Record) error {
+	record = record.Clone()
+	record.AddAttrs(r.attrs...)
+
+	r.store.m.Lock()
+	defer r.store.m.Unlock()
+	r.store.records = append(r.store.records, record)
+	return nil
+}
+
+func (r *LogRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogRecorder{
+		attrs: append(slices.Clone(r.attrs), attrs...),
+		store: r.store,
+	}
+}
+
+func (r *LogRecorder) WithGroup(string) slog.Handler {
+	return r
+}
+
+// LogEntry is a recorded log record with its attributes flattened into fields. Attributes of groups
+// are prefixed with the group name, e.g. "request.method".
+type LogEntry struct {
+	Level   slog.Level
+	Message string
+	Fields  map[string]any
+}
+
+// NewTestLogger creates a log recorder and installs it as global aulogging logger until the test
+// finishes, so logs of the middlewares of this module are recorded. Tests using it must not run in parallel.
+func NewTestLogger(t *testing.T) *LogRecorder {
+	recorder := NewLogRecorder()
+	previousLogger := aulogging.Logger
+	aulogging.Logger = slogging.New().WithLogger(recorder.Logger())
+	t.Cleanup(func() {
+		aulogging.Logger = previousLogger
+	})
+	return recorder
+}
+
+// Entries returns the records logged so far as entries
+func (r *LogRecorder) Entries() []LogEntry {
+	records := r.Records()
+	entries := make([]LogEntry, 0, len(records))
+	for _, record := range records {
+		fields := make(map[string]any)
+		record.Attrs(func(attr slog.Attr) bool {
+			addFields(fields, "", attr)
+			return true
+		})
+		entries = append(entries, LogEntry{
+			Level:   record.Level,
+			Message: record.Message,
+			Fields:  fields,
+		})
+	}
+	return entries
+}
+
+func addFields(fields map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			addFields(fields, groupPrefix, groupAttr)
+		}
+		return
+	}
+	fields[prefix+attr.Key] = value.Any()
+}
+
+// matches checks if the entry has the level, a message containing msgContains and the fields given as
+// alternating keys and values. Field values are compared after conversion to the type of the logged value.
+func (e LogEntry) matches(level slog.Level, msgContains string, fields []any) bool {
+	if e.Level != level || !strings.Contains(e.Message, msgContains) {
+		return false
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		value, ok := e.Fields[key]
+		if !ok || !assert.ObjectsAreEqualValues(fields[i+1], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLogged asserts that an entry with the level, a message containing msgContains and the fields
+// given as alternating keys and values has been logged
+func (r *LogRecorder) AssertLogged(t *testing.T, level slog.Level, msgContains string, fields ...any) bool {
+	t.Helper()
+
+	entries := r.Entries()
+	for _, entry := range entries {
+		if entry.matches(level, msgContains, fields) {
+			return true
+		}
+	}
+	return assert.Fail(t, "expected log entry not found",
+		"level   : %s\nmessage : %q\nfields  : %v\nlogged  :\n%s", level, msgContains, fields, formatEntries(entries))
+}
+
+// AssertNotLogged asserts that no entry with the level, a message containing msgContains and the fields
+// given as alternating keys and values has been logged
+func (r *LogRecorder) AssertNotLogged(t *testing.T, level slog.Level, msgContains string, fields ...any) bool {
+	t.Helper()
+
+	entries := r.Entries()
+	for _, entry := range entries {
+		if entry.matches(level, msgContains, fields) {
+			return assert.Fail(t, "unexpected log entry found",
+				"level   : %s\nmessage : %q\nfields  : %v\nlogged  :\n%s", level, msgContains, fields, formatEntries(entries))
+		}
+	}
+	return true
+}
+
+func formatEntries(entries []LogEntry) string {
+	if len(entries) == 0 {
+		return "\t<none>"
+	}
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("\t%s %q %v", entry.Level, entry.Message, entry.Fields))
+	}
+	return strings.Join(lines, "\n")
+}
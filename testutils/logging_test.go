@@ -0,0 +1,86 @@
+package testutils
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRecorder(t *testing.T) {
+	t.Run("records entries with fields of derived loggers and groups", func(t *testing.T) {
+		recorder := NewLogRecorder()
+		logger := recorder.Logger().With("service", "users")
+
+		logger.Debug("lookup", slog.Group("request", slog.String("method", "GET")), slog.Int("attempt", 2))
+
+		entries := recorder.Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, LogEntry{
+			Level:   slog.LevelDebug,
+			Message: "lookup",
+			Fields: map[string]any{
+				"service":        "users",
+				"request.method": "GET",
+				"attempt":        int64(2),
+			},
+		}, entries[0])
+	})
+
+	t.Run("reset discards entries", func(t *testing.T) {
+		recorder := NewLogRecorder()
+		recorder.Logger().Info("message")
+
+		recorder.Reset()
+
+		assert.Empty(t, recorder.Entries())
+	})
+}
+
+func TestNewTestLogger(t *testing.T) {
+	previousLogger := aulogging.Logger
+
+	t.Run("records aulogging entries", func(t *testing.T) {
+		recorder := NewTestLogger(t)
+
+		aulogging.Logger.NoCtx().Warn().With("user", "john").WithErr(errors.New("denied")).Print("access denied")
+
+		assert.True(t, recorder.AssertLogged(t, slog.LevelWarn, "denied", "user", "john"))
+		assert.True(t, recorder.AssertNotLogged(t, slog.LevelInfo, "denied"))
+		assert.True(t, recorder.AssertNotLogged(t, slog.LevelWarn, "denied", "user", "jane"))
+	})
+
+	assert.Same(t, previousLogger, aulogging.Logger)
+}
+
+func TestLogEntry_matches(t *testing.T) {
+	entry := LogEntry{
+		Level:   slog.LevelWarn,
+		Message: "response GET /users -> 500",
+		Fields:  map[string]any{"response-status": int64(500), "url-path": "/users"},
+	}
+
+	testCases := []struct {
+		name        string
+		level       slog.Level
+		msgContains string
+		fields      []any
+		expected    bool
+	}{
+		{name: "level and message", level: slog.LevelWarn, msgContains: "GET /users", expected: true},
+		{name: "fields converted to logged type", level: slog.LevelWarn, fields: []any{"response-status", 500, "url-path", "/users"}, expected: true},
+		{name: "different level", level: slog.LevelInfo, msgContains: "GET /users", expected: false},
+		{name: "different message", level: slog.LevelWarn, msgContains: "POST", expected: false},
+		{name: "different field value", level: slog.LevelWarn, fields: []any{"response-status", 404}, expected: false},
+		{name: "missing field", level: slog.LevelWarn, fields: []any{"request-id", "1"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, entry.matches(tc.level, tc.msgContains, tc.fields))
+		})
+	}
+}
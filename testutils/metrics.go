@@ -0,0 +1,197 @@
+package testutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// MetricsRecorder collects the metrics recorded by an in-memory OTel meter provider
+type MetricsRecorder struct {
+	reader   *sdkmetric.ManualReader
+	provider *sdkmetric.MeterProvider
+}
+
+// NewMetricsRecorder installs an in-memory meter provider as global OTel meter provider until the test
+// finishes. Middlewares and transports obtain their instruments on creation, so create them afterwards.
+// Tests using it must not run in parallel.
+func NewMetricsRecorder(t *testing.T) *MetricsRecorder {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	previousProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(provider)
+	t.Cleanup(func() {
+		otel.SetMeterProvider(previousProvider)
+		_ = provider.Shutdown(t.Context())
+	})
+
+	return &MetricsRecorder{
+		reader:   reader,
+		provider: provider,
+	}
+}
+
+// MeterProvider returns the in-memory meter provider, e.g. to pass it explicitly
+func (m *MetricsRecorder) MeterProvider() *sdkmetric.MeterProvider {
+	return m.provider
+}
+
+// Collect returns all metrics recorded so far
+func (m *MetricsRecorder) Collect(t testing.TB) metricdata.ResourceMetrics {
+	var rm metricdata.ResourceMetrics
+	if err := m.reader.Collect(t.Context(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %s", err)
+	}
+	return rm
+}
+
+// AssertCounter asserts that the values of the counter, up-down counter or gauge, summed up over all
+// data points having the given attributes, equal the expected value
+func (m *MetricsRecorder) AssertCounter(t testing.TB, name string, expected float64, attrs ...attribute.KeyValue) bool {
+	t.Helper()
+
+	points, ok := m.points(t, name, attrs)
+	if !ok {
+		return false
+	}
+	var sum float64
+	for _, point := range points {
+		sum += point.value
+	}
+	return assert.InDelta(t, expected, sum, 1e-9, "unexpected value of metric %s with attributes %v", name, attrs)
+}
+
+// AssertHistogram asserts that the histogram recorded the expected number of values over all data points
+// having the given attributes
+func (m *MetricsRecorder) AssertHistogram(t testing.TB, name string, expectedCount uint64, attrs ...attribute.KeyValue) bool {
+	t.Helper()
+
+	points, ok := m.points(t, name, attrs)
+	if !ok {
+		return false
+	}
+	var count uint64
+	for _, point := range points {
+		count += point.count
+	}
+	return assert.Equal(t, expectedCount, count, "unexpected number of values recorded by metric %s with attributes %v", name, attrs)
+}
+
+// AssertHistogramRange asserts that all values the histogram recorded for data points having the given
+// attributes lie within min and max, both inclusive
+func (m *MetricsRecorder) AssertHistogramRange(t testing.TB, name string, min float64, max float64, attrs ...attribute.KeyValue) bool {
+	t.Helper()
+
+	points, ok := m.points(t, name, attrs)
+	if !ok {
+		return false
+	}
+	for _, point := range points {
+		if point.count == 0 {
+			continue
+		}
+		if point.min < min || point.max > max {
+			return assert.Fail(t, "recorded values out of range",
+				"metric %s with attributes %s recorded values in [%g, %g], expected within [%g, %g]",
+				name, point.attributes.Encoded(attribute.DefaultEncoder()), point.min, point.max, min, max)
+		}
+	}
+	return true
+}
+
+type metricPoint struct {
+	attributes attribute.Set
+	value      float64
+	count      uint64
+	min        float64
+	max        float64
+}
+
+// points returns the data points of the metric having the given attributes, failing the test if there are none
+func (m *MetricsRecorder) points(t testing.TB, name string, attrs []attribute.KeyValue) ([]metricPoint, bool) {
+	t.Helper()
+
+	rm := m.Collect(t)
+	var recorded []string
+	var points []metricPoint
+	for _, scopeMetrics := range rm.ScopeMetrics {
+		for _, metrics := range scopeMetrics.Metrics {
+			recorded = append(recorded, metrics.Name)
+			if metrics.Name != name {
+				continue
+			}
+			for _, point := range toMetricPoints(metrics.Data) {
+				if hasAttributes(point.attributes, attrs) {
+					points = append(points, point)
+				}
+			}
+		}
+	}
+
+	if len(points) == 0 {
+		return nil, assert.Fail(t, "metric not recorded",
+			"no data points of metric %s with attributes %v, recorded metrics: [%s]", name, attrs, strings.Join(recorded, ", "))
+	}
+	return points, true
+}
+
+func hasAttributes(set attribute.Set, attrs []attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if value, ok := set.Value(attr.Key); !ok || value != attr.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func toMetricPoints(data metricdata.Aggregation) []metricPoint {
+	switch typedData := data.(type) {
+	case metricdata.Sum[int64]:
+		return toValuePoints(typedData.DataPoints)
+	case metricdata.Sum[float64]:
+		return toValuePoints(typedData.DataPoints)
+	case metricdata.Gauge[int64]:
+		return toValuePoints(typedData.DataPoints)
+	case metricdata.Gauge[float64]:
+		return toValuePoints(typedData.DataPoints)
+	case metricdata.Histogram[int64]:
+		return toHistogramPoints(typedData.DataPoints)
+	case metricdata.Histogram[float64]:
+		return toHistogramPoints(typedData.DataPoints)
+	default:
+		return nil
+	}
+}
+
+func toValuePoints[N int64 | float64](dataPoints []metricdata.DataPoint[N]) []metricPoint {
+	points := make([]metricPoint, 0, len(dataPoints))
+	for _, dataPoint := range dataPoints {
+		points = append(points, metricPoint{
+			attributes: dataPoint.Attributes,
+			value:      float64(dataPoint.Value),
+		})
+	}
+	return points
+}
+
+func toHistogramPoints[N int64 | float64](dataPoints []metricdata.HistogramDataPoint[N]) []metricPoint {
+	points := make([]metricPoint, 0, len(dataPoints))
+	for _, dataPoint := range dataPoints {
+		minValue, _ := dataPoint.Min.Value()
+		maxValue, _ := dataPoint.Max.Value()
+		points = append(points, metricPoint{
+			attributes: dataPoint.Attributes,
+			value:      float64(dataPoint.Sum),
+			count:      dataPoint.Count,
+			min:        float64(minValue),
+			max:        float64(maxValue),
+		})
+	}
+	return points
+}
@@ -0,0 +1,94 @@
+package testutils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func TestMetricsRecorder(t *testing.T) {
+	t.Run("records server metrics of the middleware", func(t *testing.T) {
+		recorder := NewMetricsRecorder(t)
+
+		router := chi.NewRouter()
+		router.Use(metrics.NewRequestMetricsMiddleware(nil))
+		router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		for i := 0; i < 3; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		}
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		recorder.AssertHistogram(t, "http.server.request.duration", 4)
+		recorder.AssertHistogram(t, "http.server.request.duration", 3,
+			attribute.String("http.route", "/users/{id}"),
+			attribute.Int("http.response.status_code", http.StatusNoContent),
+		)
+		recorder.AssertHistogramRange(t, "http.server.request.duration", 0, 1)
+	})
+
+	t.Run("records client metrics of the transport", func(t *testing.T) {
+		recorder := NewMetricsRecorder(t)
+
+		mockTransport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{Algorithm: FirstMatch})
+		mockTransport.ExpectRequest(TestRequest{Method: http.MethodGet}).
+			WillReturnResponse(&TestResponse{Status: http.StatusOK, Body: "ok"})
+		client := &http.Client{Transport: metrics.NewRequestMetricsTransport(mockTransport, "users", nil)}
+		for i := 0; i < 2; i++ {
+			res, err := client.Get("https://api.localhost/users")
+			require.NoError(t, err)
+			_ = res.Body.Close()
+		}
+
+		recorder.AssertCounter(t, "http.client.request.total", 2)
+	})
+
+	t.Run("sums counter values with matching attributes", func(t *testing.T) {
+		recorder := NewMetricsRecorder(t)
+
+		counter, err := otel.GetMeterProvider().Meter("test").Float64Counter("jobs")
+		require.NoError(t, err)
+		counter.Add(context.Background(), 1.5, metric.WithAttributes(attribute.String("queue", "a")))
+		counter.Add(context.Background(), 2, metric.WithAttributes(attribute.String("queue", "b")))
+
+		recorder.AssertCounter(t, "jobs", 3.5)
+		recorder.AssertCounter(t, "jobs", 2, attribute.String("queue", "b"))
+	})
+
+	t.Run("reports missing metrics and range violations", func(t *testing.T) {
+		recorder := NewMetricsRecorder(t)
+
+		histogram, err := recorder.MeterProvider().Meter("test").Int64Histogram("sizes")
+		require.NoError(t, err)
+		histogram.Record(context.Background(), 10)
+		histogram.Record(context.Background(), 250)
+
+		fake := &recordingT{TB: t}
+		assert.False(t, recorder.AssertHistogramRange(fake, "sizes", 0, 100))
+		assert.False(t, recorder.AssertHistogram(fake, "sizes", 2, attribute.String("missing", "attribute")))
+		assert.False(t, recorder.AssertCounter(fake, "unknown", 1))
+		assert.Len(t, fake.errors, 3)
+		assert.True(t, recorder.AssertHistogramRange(t, "sizes", 10, 250))
+	})
+
+	t.Run("restores global meter provider on cleanup", func(t *testing.T) {
+		previousProvider := otel.GetMeterProvider()
+
+		t.Run("recorder", func(t *testing.T) {
+			recorder := NewMetricsRecorder(t)
+			assert.Same(t, recorder.MeterProvider(), otel.GetMeterProvider())
+		})
+
+		assert.Equal(t, previousProvider, otel.GetMeterProvider())
+	})
+}
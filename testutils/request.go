@@ -0,0 +1,150 @@
+package testutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// RequestBuilder builds a TestRequest step by step, e.g.
+// NewRequest(http.MethodPost, "/items").WithJSONBody(item).WithBearer(token).TestRequest()
+type RequestBuilder struct {
+	req   TestRequest
+	query url.Values
+}
+
+func NewRequest(method string, rawURL string) *RequestBuilder {
+	return &RequestBuilder{
+		req: TestRequest{
+			Method: method,
+			URL:    rawURL,
+			Header: make(http.Header),
+		},
+		query: make(url.Values),
+	}
+}
+
+// WithHeader adds a value to the header
+func (b *RequestBuilder) WithHeader(key string, value string) *RequestBuilder {
+	b.req.Header.Add(key, value)
+	return b
+}
+
+// WithQuery adds a value to the query parameter
+func (b *RequestBuilder) WithQuery(key string, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// WithBearer sets the Authorization header to the bearer token
+func (b *RequestBuilder) WithBearer(token string) *RequestBuilder {
+	b.req.Header.Set("Authorization", "Bearer "+token)
+	return b
+}
+
+// WithBasicAuth sets the Authorization header to the basic auth credentials
+func (b *RequestBuilder) WithBasicAuth(username string, password string) *RequestBuilder {
+	req := http.Request{Header: b.req.Header}
+	req.SetBasicAuth(username, password)
+	return b
+}
+
+// WithCookie adds the cookie to the Cookie header
+func (b *RequestBuilder) WithCookie(cookie *http.Cookie) *RequestBuilder {
+	b.req.Cookies = append(b.req.Cookies, cookie)
+	return b
+}
+
+// WithJSONBody sets the body, which is encoded as JSON, and the JSON content type
+func (b *RequestBuilder) WithJSONBody(body any) *RequestBuilder {
+	b.req.Body = body
+	b.req.Header.Set("Content-Type", "application/json")
+	return b
+}
+
+// WithBody sets the body, which is sent as is, and its content type
+func (b *RequestBuilder) WithBody(contentType string, body []byte) *RequestBuilder {
+	b.req.Body = body
+	b.req.Header.Set("Content-Type", contentType)
+	return b
+}
+
+// TestRequest returns the built request, e.g. for TestServer.PerformRequest or as mock expectation
+func (b *RequestBuilder) TestRequest() TestRequest {
+	req := b.req
+	req.Header = b.req.Header.Clone()
+	req.Cookies = append([]*http.Cookie(nil), b.req.Cookies...)
+	if len(b.query) > 0 {
+		if u, err := url.Parse(req.URL); err == nil {
+			query := u.Query()
+			for key, values := range b.query {
+				for _, value := range values {
+					query.Add(key, value)
+				}
+			}
+			u.RawQuery = query.Encode()
+			req.URL = u.String()
+		}
+	}
+	return req
+}
+
+// HTTPRequest returns the built request as server request, e.g. for calling handlers directly
+func (b *RequestBuilder) HTTPRequest(t *testing.T) *http.Request {
+	return newHTTPRequest(t, t.Context(), b.TestRequest())
+}
+
+// newHTTPRequest creates a server request. String and byte slice bodies are sent as is, other bodies
+// are encoded as JSON, setting the content type unless present.
+func newHTTPRequest(t *testing.T, ctx context.Context, req TestRequest) *http.Request {
+	body, contentType := encodeRequestBody(t, req.Body)
+	request := httptest.NewRequestWithContext(ctx, req.Method, req.URL, body)
+	applyRequestHeader(request, req, contentType)
+	return request
+}
+
+// newClientRequest creates a client request, encoding the body like newHTTPRequest
+func newClientRequest(t *testing.T, ctx context.Context, req TestRequest) *http.Request {
+	body, contentType := encodeRequestBody(t, req.Body)
+	request, err := http.NewRequestWithContext(ctx, req.Method, req.URL, body)
+	if err != nil {
+		t.Fatalf("failed to create request: %s", err.Error())
+	}
+	applyRequestHeader(request, req, contentType)
+	return request
+}
+
+// encodeRequestBody returns the body to send and the content type it implies, if any
+func encodeRequestBody(t *testing.T, body any) (io.Reader, string) {
+	switch typedBody := body.(type) {
+	case nil:
+		return nil, ""
+	case string:
+		return bytes.NewBufferString(typedBody), ""
+	case []byte:
+		return bytes.NewReader(typedBody), ""
+	default:
+		bodyBytes, err := json.Marshal(typedBody)
+		if err != nil {
+			t.Fatalf("failed to marshal request body: %s", err.Error())
+		}
+		return bytes.NewReader(bodyBytes), "application/json"
+	}
+}
+
+func applyRequestHeader(request *http.Request, req TestRequest, contentType string) {
+	for key, values := range req.Header {
+		request.Header[key] = values
+	}
+	if contentType != "" && request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range req.Cookies {
+		request.AddCookie(cookie)
+	}
+}
@@ -0,0 +1,79 @@
+package testutils
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequest(t *testing.T) {
+	t.Run("builds test requests", func(t *testing.T) {
+		req := NewRequest(http.MethodPost, "/items?sort=name").
+			WithJSONBody(map[string]any{"name": "apple"}).
+			WithHeader("X-Tenant-ID", "tenant-1").
+			WithHeader("X-Tenant-ID", "tenant-2").
+			WithQuery("filter", "a b").
+			WithQuery("filter", "c").
+			WithBearer("token").
+			WithCookie(&http.Cookie{Name: "session", Value: "abc"}).
+			TestRequest()
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/items?filter=a+b&filter=c&sort=name", req.URL)
+		assert.Equal(t, []string{"tenant-1", "tenant-2"}, req.Header.Values("X-Tenant-ID"))
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, map[string]any{"name": "apple"}, req.Body)
+		require.Len(t, req.Cookies, 1)
+		assert.Equal(t, "session", req.Cookies[0].Name)
+	})
+
+	t.Run("built requests are independent of the builder", func(t *testing.T) {
+		builder := NewRequest(http.MethodGet, "/items")
+		first := builder.TestRequest()
+		builder.WithHeader("X-Later", "value")
+
+		assert.Empty(t, first.Header.Get("X-Later"))
+		assert.Equal(t, "value", builder.TestRequest().Header.Get("X-Later"))
+	})
+
+	t.Run("builds server requests", func(t *testing.T) {
+		req := NewRequest(http.MethodPut, "/files/1").
+			WithBody("text/plain", []byte("content")).
+			WithBasicAuth("user", "secret").
+			WithCookie(&http.Cookie{Name: "session", Value: "abc"}).
+			HTTPRequest(t)
+
+		assert.Equal(t, http.MethodPut, req.Method)
+		assert.Equal(t, "/files/1", req.URL.Path)
+		assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "secret", password)
+		cookie, err := req.Cookie("session")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", cookie.Value)
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(body))
+	})
+
+	t.Run("performs built requests", func(t *testing.T) {
+		server := NewTestServer(t, func(r chi.Router) {
+			r.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.Copy(w, r.Body)
+			})
+		}, nil)
+
+		res := server.PerformRequest(NewRequest(http.MethodPost, "/echo").WithJSONBody(map[string]any{"id": 1.0}).TestRequest())
+
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, map[string]any{"id": 1.0}, res.Body)
+	})
+}
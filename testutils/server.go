@@ -0,0 +1,90 @@
+package testutils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/go-chi/chi/v5"
+)
+
+type TestServerOptions struct {
+	// PanicRecoveryOptions configures the panic recovery middleware. Nil uses its defaults.
+	PanicRecoveryOptions *resiliency.PanicRecoveryMiddlewareOptions
+	// RequestIDHeaderOptions configures the request ID header middleware. Nil uses its defaults.
+	RequestIDHeaderOptions *tracing.RequestIDHeaderMiddlewareOptions
+	// RequestLoggerOptions configures the request logger middleware. Nil uses its defaults.
+	RequestLoggerOptions *logging.RequestLoggerMiddlewareOptions
+	// Middlewares are applied after the standard middlewares, e.g. authentication.
+	Middlewares []func(next http.Handler) http.Handler
+}
+
+func DefaultTestServerOptions() *TestServerOptions {
+	return &TestServerOptions{
+		Middlewares: []func(next http.Handler) http.Handler{},
+	}
+}
+
+// TestServer serves routes behind the standard middleware stack of this module and records all logs.
+// Requests are served directly by the handler without opening sockets.
+type TestServer struct {
+	t      *testing.T
+	router chi.Router
+	logs   *LogRecorder
+}
+
+// NewTestServer builds a chi router with panic recovery, request ID and logging middlewares and
+// registers the routes on it. The global aulogging logger is replaced by one writing to the log
+// recorder of the server until the test finishes, so tests using it must not run in parallel.
+func NewTestServer(t *testing.T, routes func(r chi.Router), opts *TestServerOptions) *TestServer {
+	if opts == nil {
+		opts = DefaultTestServerOptions()
+	}
+
+	logs := NewTestLogger(t)
+
+	router := chi.NewRouter()
+	router.Use(
+		resiliency.NewPanicRecoveryMiddleware(opts.PanicRecoveryOptions),
+		tracing.NewRequestIDHeaderMiddleware(opts.RequestIDHeaderOptions),
+		logging.NewContextLoggerMiddleware(nil),
+		tracing.NewRequestIDLoggerMiddleware(nil),
+		logging.NewRequestLoggerMiddleware(opts.RequestLoggerOptions),
+	)
+	router.Use(opts.Middlewares...)
+	if routes != nil {
+		routes(router)
+	}
+
+	return &TestServer{
+		t:      t,
+		router: router,
+		logs:   logs,
+	}
+}
+
+// Router returns the router of the server, e.g. to register further routes
+func (s *TestServer) Router() chi.Router {
+	return s.router
+}
+
+// Logs returns the recorder of all logs written while serving requests
+func (s *TestServer) Logs() *LogRecorder {
+	return s.logs
+}
+
+// PerformRequest serves the request and returns the parsed response. String and byte slice bodies are
+// sent as is, other bodies are encoded as JSON, setting the content type unless present.
+func (s *TestServer) PerformRequest(req TestRequest) *TestResponse {
+	return s.Perform(newHTTPRequest(s.t, s.t.Context(), req))
+}
+
+// Perform serves the request and returns the parsed response
+func (s *TestServer) Perform(req *http.Request) *TestResponse {
+	rr := httptest.NewRecorder()
+	s.router.ServeHTTP(rr, req)
+	return MustParseResponse(s.t, rr.Result())
+}
@@ -0,0 +1,142 @@
+package testutils
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/tracing"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestServer(t *testing.T) {
+	routes := func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": chi.URLParam(r, "id")})
+		})
+		r.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+			_, _ = io.Copy(w, r.Body)
+		})
+		r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+	}
+
+	t.Run("serves routes and logs requests with request ID", func(t *testing.T) {
+		server := NewTestServer(t, routes, nil)
+
+		response := server.PerformRequest(TestRequest{
+			Method: http.MethodGet,
+			URL:    "/users/1",
+			Header: http.Header{"X-Request-Id": {"request-1"}},
+		})
+
+		assert.Equal(t, http.StatusOK, response.Status)
+		assert.Equal(t, map[string]any{"id": "1"}, response.Body)
+		assert.Equal(t, "request-1", response.Header.Get("X-Request-ID"))
+
+		require.Len(t, server.Logs().Records(), 1)
+		server.Logs().AssertLogged(t, slog.LevelInfo, "response GET /users/1",
+			logging.LogFieldRequestID, "request-1",
+			logging.LogFieldResponseStatus, http.StatusOK,
+		)
+	})
+
+	t.Run("encodes request bodies", func(t *testing.T) {
+		server := NewTestServer(t, routes, nil)
+
+		response := server.PerformRequest(TestRequest{
+			Method: http.MethodPost,
+			URL:    "/echo",
+			Body:   map[string]any{"name": "John"},
+		})
+		assert.Equal(t, map[string]any{"name": "John"}, response.Body)
+
+		response = server.PerformRequest(TestRequest{
+			Method: http.MethodPost,
+			URL:    "/echo",
+			Header: http.Header{"Content-Type": {"text/plain"}},
+			Body:   "plain",
+		})
+		assert.Equal(t, "plain", response.Body)
+	})
+
+	t.Run("recovers from panics", func(t *testing.T) {
+		server := NewTestServer(t, routes, nil)
+
+		response := server.PerformRequest(TestRequest{Method: http.MethodGet, URL: "/panic"})
+
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+		entries := server.Logs().Entries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, slog.LevelError, entries[0].Level)
+		assert.Equal(t, "recovered from panic", entries[0].Message)
+		assert.Contains(t, entries[0].Fields, logging.LogFieldStackTrace)
+	})
+
+	t.Run("applies options and custom middlewares", func(t *testing.T) {
+		opts := DefaultTestServerOptions()
+		opts.RequestIDHeaderOptions = tracing.DefaultRequestIDHeaderMiddlewareOptions()
+		opts.RequestIDHeaderOptions.GeneratorFn = func() string { return "generated" }
+		opts.RequestLoggerOptions = logging.DefaultRequestLoggerMiddlewareOptions()
+		opts.RequestLoggerOptions.ExcludedPaths = []string{"/users/*"}
+		opts.Middlewares = append(opts.Middlewares, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Custom", "applied")
+				next.ServeHTTP(w, r)
+			})
+		})
+		server := NewTestServer(t, routes, opts)
+
+		response := server.PerformRequest(TestRequest{Method: http.MethodGet, URL: "/users/1"})
+
+		assert.Equal(t, "generated", response.Header.Get("X-Request-ID"))
+		assert.Equal(t, "applied", response.Header.Get("X-Custom"))
+		assert.Empty(t, server.Logs().Records())
+	})
+
+	t.Run("restores global logger on cleanup", func(t *testing.T) {
+		previousLogger := aulogging.Logger
+
+		t.Run("server", func(t *testing.T) {
+			NewTestServer(t, routes, nil)
+			assert.NotSame(t, previousLogger, aulogging.Logger)
+		})
+
+		assert.Same(t, previousLogger, aulogging.Logger)
+	})
+}
+
+func TestTestServer_PerformRequest_Cookies(t *testing.T) {
+	server := NewTestServer(t, func(r chi.Router) {
+		r.Get("/session", func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: cookie.Value + "-renewed"})
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, server.PerformRequest(TestRequest{Method: http.MethodGet, URL: "/session"}).Status)
+
+	response := server.PerformRequest(TestRequest{
+		Method:  http.MethodGet,
+		URL:     "/session",
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+	})
+	assert.Equal(t, http.StatusNoContent, response.Status)
+	response.RequireSetsCookie(t, "session", func(cookie *http.Cookie) bool {
+		return cookie.Value == "abc-renewed"
+	})
+}
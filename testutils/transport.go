@@ -2,37 +2,140 @@ package testutils
 
 import (
 	"bytes"
-	"encoding/json"
+	"cmp"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 
+	"github.com/Roshick/go-autumn-web/contextutils"
 	"github.com/stretchr/testify/require"
 
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // MatchingAlgorithm represents the strategy for selecting expected interactions
 type MatchingAlgorithm int
 
+// ScenarioStarted is the state every scenario starts in
+const ScenarioStarted = "Started"
+
 const (
 	// Exact uses interactions in the exact order they were added, consuming them as they are used
 	Exact MatchingAlgorithm = iota
 	// FirstMatch returns the first interaction that matches the request, keeping the interaction in the pool
 	FirstMatch
+	// ExactPerHost works like Exact but keeps a separate queue per host of the expected URLs, so requests to
+	// different hosts may interleave in any order. Interactions without host, e.g. URL patterns, are queued
+	// for every host.
+	ExactPerHost
+	// FirstMatchConsume works like FirstMatch but consumes interactions once they have been used as often
+	// as expected, e.g. to serve each of several identical expectations exactly once to concurrent clients
+	FirstMatchConsume
+	// BestMatch returns the most specific interaction that matches the request, keeping the interaction
+	// in the pool, so overlapping interactions do not depend on the order they were added in. Exact URLs
+	// are more specific than wildcard URLs, which are more specific than URL patterns; among these, more
+	// constraints, i.e. method, query parameters, custom matchers and required scenario state, are more
+	// specific. Ties are resolved by order.
+	BestMatch
 )
 
 type ExpectedInteraction struct {
 	request           TestRequest
 	response          *TestResponse
+	responses         []*TestResponse
+	responder         func(req *http.Request) (*TestResponse, error)
+	err               error
+	timeout           bool
+	delay             time.Duration
 	ignoreQueryParams bool
+	queryParams       url.Values
+	urlPattern        *regexp.Regexp
+	matchers          []func(req *http.Request) bool
+	chunkSize         int
+	chunkDelay        time.Duration
+	scenario          string
+	requiredState     string
+	newState          string
+	times             int64
+
+	uses atomic.Int64
 }
 
 func (r *ExpectedInteraction) WillReturnResponse(response *TestResponse) {
 	r.response = response
+	r.responses = nil
+}
+
+// WillReturnResponses makes successive matches of the interaction yield successive responses, e.g. a
+// 503 followed by a 200. Once the sequence is exhausted, the last response is repeated. With the Exact
+// algorithm the interaction is only consumed after every response has been returned.
+func (r *ExpectedInteraction) WillReturnResponses(responses ...*TestResponse) {
+	r.response = nil
+	r.responses = responses
+}
+
+// WillRespondWith computes the response from the actual request, e.g. to echo IDs or request bodies
+func (r *ExpectedInteraction) WillRespondWith(responder func(req *http.Request) (*TestResponse, error)) {
+	r.responder = responder
+}
+
+// WillReturnError makes the transport fail the request with the given error, e.g. a connection error
+func (r *ExpectedInteraction) WillReturnError(err error) {
+	r.err = err
+}
+
+// WillTimeout makes the transport block until the request context is done and return its error.
+// Requests without a cancellable context fail immediately with a net.Error reporting a timeout.
+func (r *ExpectedInteraction) WillTimeout() {
+	r.timeout = true
+}
+
+// WithDelay delays the outcome of the interaction, aborting early if the request context is done
+func (r *ExpectedInteraction) WithDelay(delay time.Duration) *ExpectedInteraction {
+	r.delay = delay
+	return r
+}
+
+// WithStreaming streams the response body in chunks of the given size, waiting for the delay between
+// chunks, e.g. to test download progress or read timeouts. The response has no content length and is
+// served with chunked transfer encoding.
+func (r *ExpectedInteraction) WithStreaming(chunkSize int, delay time.Duration) *ExpectedInteraction {
+	r.chunkSize = chunkSize
+	r.chunkDelay = delay
+	return r
+}
+
+// InScenario binds the interaction to the named scenario, so it only matches while the scenario is in
+// the required state, e.g. to model stateful upstreams. An empty required state matches any state.
+func (r *ExpectedInteraction) InScenario(name string, requiredState string) *ExpectedInteraction {
+	r.scenario = name
+	r.requiredState = requiredState
+	return r
+}
+
+// WillSetScenarioState transitions the scenario of the interaction into the new state once the
+// interaction is matched. Has no effect on interactions not bound to a scenario.
+func (r *ExpectedInteraction) WillSetScenarioState(newState string) *ExpectedInteraction {
+	r.newState = newState
+	return r
+}
+
+// Times sets how often the interaction is expected to be used before Exact and FirstMatchConsume
+// consume it and Verify is satisfied. Defaults to once, or once per response of a sequence.
+func (r *ExpectedInteraction) Times(n int) *ExpectedInteraction {
+	r.times = int64(n)
+	return r
 }
 
 // IgnoreQueryParams sets whether to ignore query parameters when matching URLs
@@ -41,6 +144,23 @@ func (r *ExpectedInteraction) IgnoreQueryParams(ignore bool) *ExpectedInteractio
 	return r
 }
 
+// WithQueryParam expects the request to carry the query parameter with the given value. Once a
+// parameter is expected, the query of the expected URL is not compared and other parameters are ignored.
+func (r *ExpectedInteraction) WithQueryParam(key string, value string) *ExpectedInteraction {
+	if r.queryParams == nil {
+		r.queryParams = make(url.Values)
+	}
+	r.queryParams.Add(key, value)
+	return r
+}
+
+// MatchWith adds a custom matcher the request has to satisfy in addition to the built-in criteria, e.g.
+// to match on body contents or signatures. Matchers reading the body must restore it for later use.
+func (r *ExpectedInteraction) MatchWith(matcher func(req *http.Request) bool) *ExpectedInteraction {
+	r.matchers = append(r.matchers, matcher)
+	return r
+}
+
 // extractBaseURL removes query parameters from a URL string
 func (r *ExpectedInteraction) extractBaseURL(urlStr string) string {
 	if parsedURL, err := url.Parse(urlStr); err == nil {
@@ -51,31 +171,363 @@ func (r *ExpectedInteraction) extractBaseURL(urlStr string) string {
 	return urlStr
 }
 
-// matches checks if this interaction matches the given request
-func (r *ExpectedInteraction) matches(req *http.Request) bool {
-	if r.request.Method != "" && r.request.Method != req.Method {
-		return false
+// normalizeURL sorts the query parameters of a URL string by key and value, so URLs can be compared
+// regardless of the order of their parameters
+func normalizeURL(urlStr string) string {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return urlStr
+	}
+	query := parsedURL.Query()
+	for _, values := range query {
+		slices.Sort(values)
 	}
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String()
+}
 
-	if r.request.URL != "" {
-		expectedURL := r.request.URL
-		actualURL := req.URL.String()
+// comparableURLs returns the expected and actual URL in the form they are compared in: without query
+// parameters if these are ignored or expected individually, otherwise with normalized query parameters
+func (r *ExpectedInteraction) comparableURLs(req *http.Request) (string, string) {
+	if r.ignoreQueryParams || len(r.queryParams) > 0 {
+		return r.extractBaseURL(r.request.URL), r.extractBaseURL(req.URL.String())
+	}
+	return normalizeURL(r.request.URL), normalizeURL(req.URL.String())
+}
 
-		if r.ignoreQueryParams {
-			expectedURL = r.extractBaseURL(expectedURL)
+// matchURL checks if the request URL matches the expected URL or pattern and returns the capture
+// groups of the pattern. URL patterns are matched against the URL as sent, wildcard URLs against the
+// URL in its comparable form.
+func (r *ExpectedInteraction) matchURL(req *http.Request) ([]string, bool) {
+	switch {
+	case r.urlPattern != nil:
+		actualURL := req.URL.String()
+		if r.ignoreQueryParams || len(r.queryParams) > 0 {
 			actualURL = r.extractBaseURL(actualURL)
 		}
+		return matchRegexp(r.urlPattern, actualURL)
+	case strings.Contains(r.request.URL, "*"):
+		expectedURL, actualURL := r.comparableURLs(req)
+		return matchRegexp(wildcardRegexp(expectedURL), actualURL)
+	case r.request.URL != "":
+		expectedURL, actualURL := r.comparableURLs(req)
+		return nil, expectedURL == actualURL
+	default:
+		return nil, true
+	}
+}
+
+// matchRegexp returns the capture groups of the pattern if it matches the string
+func matchRegexp(pattern *regexp.Regexp, s string) ([]string, bool) {
+	match := pattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, false
+	}
+	return match[1:], true
+}
+
+// wildcardRegexp converts a URL with wildcards into a regular expression in which each wildcard
+// captures a single path segment
+func wildcardRegexp(urlStr string) *regexp.Regexp {
+	parts := strings.Split(urlStr, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "([^/?#]*)") + "$")
+}
+
+type urlCaptures []string
+
+// URLCaptures returns the values captured by the URL pattern or the wildcards of the expected URL
+// that matched the request, e.g. generated IDs to echo in dynamic responses
+func URLCaptures(req *http.Request) []string {
+	if captures := contextutils.GetValue[urlCaptures](req.Context()); captures != nil {
+		return *captures
+	}
+	return nil
+}
+
+// host returns the host of the expected URL, which is empty for URL patterns and relative URLs
+func (r *ExpectedInteraction) host() string {
+	if r.request.URLPattern != "" {
+		return ""
+	}
+	if parsedURL, err := url.Parse(r.request.URL); err == nil {
+		return parsedURL.Host
+	}
+	return ""
+}
+
+// describe returns a human-readable summary of the expected request
+func (r *ExpectedInteraction) describe() string {
+	method := r.request.Method
+	if method == "" {
+		method = "*"
+	}
+	target := r.request.URL
+	switch {
+	case r.request.URLPattern != "":
+		target = "~" + r.request.URLPattern
+	case target == "":
+		target = "*"
+	}
+	description := method + " " + target
+	if len(r.queryParams) > 0 {
+		description += " with query " + r.queryParams.Encode()
+	}
+	if len(r.matchers) > 0 {
+		description += fmt.Sprintf(" with %d custom matchers", len(r.matchers))
+	}
+	if r.scenario != "" {
+		description += fmt.Sprintf(" in scenario %s", r.scenario)
+		if r.requiredState != "" {
+			description += fmt.Sprintf(" at state %s", r.requiredState)
+		}
+	}
+	return description
+}
 
-		if expectedURL != actualURL {
+// specificity scores how specific the interaction is for BestMatch. The kind of URL match outweighs
+// any number of further constraints.
+func (r *ExpectedInteraction) specificity() int {
+	const urlWeight = 1 << 16
+
+	score := 0
+	switch {
+	case r.urlPattern != nil:
+		score = 1 * urlWeight
+	case strings.Contains(r.request.URL, "*"):
+		score = 2 * urlWeight
+	case r.request.URL != "" && (r.ignoreQueryParams || len(r.queryParams) > 0):
+		score = 3 * urlWeight
+	case r.request.URL != "":
+		score = 4 * urlWeight
+	}
+	if r.request.Method != "" {
+		score++
+	}
+	for _, values := range r.queryParams {
+		score += len(values)
+	}
+	score += len(r.matchers)
+	if r.scenario != "" && r.requiredState != "" {
+		score++
+	}
+	return score
+}
+
+// matchesCustom checks if the request satisfies all custom matchers
+func (r *ExpectedInteraction) matchesCustom(req *http.Request) bool {
+	for _, matcher := range r.matchers {
+		if !matcher(req) {
 			return false
 		}
 	}
+	return true
+}
 
+// matchesQueryParams checks if the query contains all individually expected parameters
+func (r *ExpectedInteraction) matchesQueryParams(query url.Values) bool {
+	for key, values := range r.queryParams {
+		for _, value := range values {
+			if !slices.Contains(query[key], value) {
+				return false
+			}
+		}
+	}
 	return true
 }
 
+// expectedUses returns how many requests the interaction serves before it is consumed
+func (r *ExpectedInteraction) expectedUses() int64 {
+	if r.times > 0 {
+		return r.times
+	}
+	return max(1, int64(len(r.responses)))
+}
+
+// respond simulates the configured latency and faults and builds the mocked response for the n-th use
+func (r *ExpectedInteraction) respond(t *testing.T, req *http.Request, use int64) (*http.Response, error) {
+	ctx := req.Context()
+
+	if r.delay > 0 {
+		timer := time.NewTimer(r.delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if r.timeout {
+		if ctx.Done() == nil {
+			return nil, &timeoutError{}
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	response := r.response
+	if len(r.responses) > 0 {
+		response = r.responses[min(use, int64(len(r.responses))-1)]
+	}
+	if r.responder != nil {
+		var err error
+		if response, err = r.responder(req); err != nil {
+			return nil, err
+		}
+	}
+	res := buildResponse(t, response)
+	if r.chunkSize > 0 {
+		if err := streamResponse(ctx, res, r.chunkSize, r.chunkDelay); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// buildResponse converts a TestResponse into an http.Response, encoding the body based on its content
+// type. Byte slices are sent as is. The content length is taken from the Content-Length header if set,
+// so truncated bodies can be simulated, and from the body otherwise.
+func buildResponse(t *testing.T, response *TestResponse) *http.Response {
+	if response == nil {
+		return nil
+	}
+
+	mockRes := *response
+	var body io.ReadCloser
+	var contentLength int64
+	if mockRes.Body != nil {
+		var bodyBytes []byte
+		ct := mockRes.Header.Get("Content-Type")
+		switch bodyValue := mockRes.Body.(type) {
+		case []byte:
+			bodyBytes = bodyValue
+		case string:
+			if strings.HasPrefix(ct, "application/json") {
+				bodyBytes = mustMarshal(t, bodyValue)
+			} else {
+				bodyBytes = []byte(bodyValue)
+			}
+		default:
+			if strings.HasPrefix(ct, "application/json") {
+				bodyBytes = mustMarshal(t, bodyValue)
+			}
+		}
+		body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		contentLength = int64(len(bodyBytes))
+	}
+	if len(mockRes.Cookies) > 0 {
+		mockRes.Header = mockRes.Header.Clone()
+		if mockRes.Header == nil {
+			mockRes.Header = make(http.Header)
+		}
+		for _, cookie := range mockRes.Cookies {
+			mockRes.Header.Add("Set-Cookie", cookie.String())
+		}
+	}
+	if value := mockRes.Header.Get("Content-Length"); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			contentLength = parsed
+		}
+	}
+	return &http.Response{
+		StatusCode:    mockRes.Status,
+		Header:        mockRes.Header,
+		Body:          body,
+		ContentLength: contentLength,
+	}
+}
+
+// streamResponse replaces the body of the response with one returning chunks of the given size, waiting
+// for the delay before each chunk but the first and aborting once the context is done
+func streamResponse(ctx context.Context, res *http.Response, chunkSize int, delay time.Duration) error {
+	if res == nil || res.Body == nil {
+		return nil
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = io.NopCloser(&chunkedReader{
+		ctx:       ctx,
+		data:      data,
+		chunkSize: chunkSize,
+		delay:     delay,
+	})
+	res.ContentLength = -1
+	res.TransferEncoding = []string{"chunked"}
+	return nil
+}
+
+type chunkedReader struct {
+	ctx       context.Context
+	data      []byte
+	chunkSize int
+	chunkLeft int
+	delay     time.Duration
+	started   bool
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	if r.chunkLeft == 0 {
+		if r.started && r.delay > 0 {
+			timer := time.NewTimer(r.delay)
+			select {
+			case <-r.ctx.Done():
+				timer.Stop()
+				return 0, r.ctx.Err()
+			case <-timer.C:
+			}
+		}
+		r.started = true
+		r.chunkLeft = r.chunkSize
+	}
+
+	n := copy(p, r.data[:min(r.chunkLeft, len(r.data))])
+	r.data = r.data[n:]
+	r.chunkLeft -= n
+	return n, nil
+}
+
+// timeoutError mimics the error returned by net/http when a request times out
+type timeoutError struct{}
+
+var _ net.Error = (*timeoutError)(nil)
+
+func (e *timeoutError) Error() string   { return "mock transport: request timed out" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// matches checks if this interaction matches the given request
+func (r *ExpectedInteraction) matches(req *http.Request) bool {
+	if r.request.Method != "" && r.request.Method != req.Method {
+		return false
+	}
+
+	if _, ok := r.matchURL(req); !ok {
+		return false
+	}
+
+	return r.matchesQueryParams(req.URL.Query()) && r.matchesCustom(req)
+}
+
 type MockInteractionTransportOptions struct {
 	Algorithm MatchingAlgorithm
+	// Strict records requests without matching interaction and answers them with an error instead of
+	// failing the test right away, so Verify reports all of them. With the Exact algorithm, a request
+	// not matching the next interaction leaves it unconsumed. Defaults to false.
+	Strict bool
+	// VerifyOnCleanup registers Verify as cleanup of the test. Defaults to false.
+	VerifyOnCleanup bool
 }
 
 type MockInteractionTransport struct {
@@ -83,6 +535,10 @@ type MockInteractionTransport struct {
 	opts *MockInteractionTransportOptions
 
 	expectedInteractions []*ExpectedInteraction
+	declaredInteractions []*ExpectedInteraction
+	observedRequests     []TestRequest
+	unexpectedRequests   []string
+	scenarioStates       map[string]string
 	m                    sync.RWMutex
 }
 
@@ -99,28 +555,31 @@ func NewMockInteractionTransport(t *testing.T, opts *MockInteractionTransportOpt
 		opts = DefaultMockInteractionTransportOptions()
 	}
 
-	return &MockInteractionTransport{
+	transport := &MockInteractionTransport{
 		t:                    t,
 		opts:                 opts, // Add the missing opts field
 		expectedInteractions: make([]*ExpectedInteraction, 0),
 		m:                    sync.RWMutex{},
 	}
+	if opts.VerifyOnCleanup {
+		t.Cleanup(func() {
+			transport.Verify(t)
+		})
+	}
+	return transport
 }
 
 func (c *MockInteractionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	var next *ExpectedInteraction
-
-	switch c.opts.Algorithm {
-	case Exact:
+	req, err := c.observe(req)
+	if err != nil {
+		return nil, err
+	}
+	next, use := c.selectInteraction(req)
+	if next == nil && c.opts.Strict {
 		c.m.Lock()
-		defer c.m.Unlock()
-		next = c.selectExact()
-	case FirstMatch:
-		c.m.RLock()
-		defer c.m.RUnlock()
-		next = c.selectFirstMatch(req)
-	default:
-		c.t.Fatalf("unknown matching algorithm: %v", c.opts.Algorithm)
+		c.unexpectedRequests = append(c.unexpectedRequests, fmt.Sprintf("%s %s", req.Method, req.URL.String()))
+		c.m.Unlock()
+		return nil, fmt.Errorf("mock transport: no matching expected interaction found for %s to %s", req.Method, req.URL.String())
 	}
 
 	require.NotNil(c.t, next, fmt.Sprintf("no matching expected interaction found for %s to %s", req.Method, req.URL.String()))
@@ -129,67 +588,218 @@ func (c *MockInteractionTransport) RoundTrip(req *http.Request) (*http.Response,
 	if next.request.Method != "" {
 		require.Equal(c.t, next.request.Method, req.Method)
 	}
-	if next.request.URL != "" {
-		expectedURL := next.request.URL
-		actualURL := req.URL.String()
-
-		if next.ignoreQueryParams {
-			expectedURL = next.extractBaseURL(expectedURL)
-			actualURL = next.extractBaseURL(actualURL)
+	captures, ok := next.matchURL(req)
+	if !ok {
+		expectedURL, actualURL := next.comparableURLs(req)
+		if next.urlPattern != nil {
+			require.Failf(c.t, "URL does not match expected pattern", "pattern: %s\nactual : %s", next.request.URLPattern, req.URL.String())
 		}
-
 		require.Equal(c.t, expectedURL, actualURL)
 	}
+	for key, values := range next.queryParams {
+		require.Subset(c.t, req.URL.Query()[key], values, fmt.Sprintf("missing values of query parameter %s", key))
+	}
+	require.True(c.t, next.matchesCustom(req), fmt.Sprintf("custom matcher rejected %s to %s", req.Method, req.URL.String()))
+
+	if len(captures) > 0 {
+		req = req.WithContext(contextutils.WithValue(req.Context(), urlCaptures(captures)))
+	}
+	return next.respond(c.t, req, use)
+}
+
+// Serve starts an HTTP server answering with the expected interactions, so they can back black-box
+// tests exercising real network I/O. Requests are matched with their URL relative to the server, e.g.
+// "/users?page=1". Interactions returning errors abort the connection. The server is closed on cleanup.
+func (c *MockInteractionTransport) Serve(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(c.serveHTTP))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// ServeTLS is like Serve but starts an HTTPS server. Use the client of the server to trust its certificate.
+func (c *MockInteractionTransport) ServeTLS(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(c.serveHTTP))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (c *MockInteractionTransport) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	res, err := c.RoundTrip(req)
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if res == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	for key, values := range res.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(cmp.Or(res.StatusCode, http.StatusOK))
+	if res.Body == nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.ContentLength >= 0 {
+		_, _ = io.Copy(w, res.Body)
+		return
+	}
 
-	if next.response != nil {
-		mockRes := *next.response
-		var body io.ReadCloser
-		if mockRes.Body != nil {
-			var bodyBytes []byte
-			ct := mockRes.Header.Get("Content-Type")
-			switch {
-			case strings.HasPrefix(ct, "application/json"):
-				var innerErr error
-				if bodyBytes, innerErr = json.Marshal(mockRes.Body); innerErr != nil {
-					c.t.Fatalf("failed to parse response: %s", innerErr)
-				}
-				break
-			default:
-				if bodyString, ok := mockRes.Body.(string); ok {
-					bodyBytes = []byte(bodyString)
-				}
+	// Streamed bodies are flushed chunk by chunk
+	controller := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := res.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return
 			}
-			body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			_ = controller.Flush()
+		}
+		if readErr != nil {
+			return
 		}
-		return &http.Response{
-			StatusCode: mockRes.Status,
-			Header:     mockRes.Header,
-			Body:       body,
-		}, nil
 	}
-	return nil, nil
 }
 
-// selectExact returns the first unused interaction
-func (c *MockInteractionTransport) selectExact() *ExpectedInteraction {
+// selectInteraction picks the next interaction according to the configured matching algorithm and
+// returns it together with the zero-based number of its use. The use is counted while holding the lock,
+// so concurrent requests never share a use or exceed the uses of consumable interactions.
+func (c *MockInteractionTransport) selectInteraction(req *http.Request) (*ExpectedInteraction, int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var next *ExpectedInteraction
+	switch c.opts.Algorithm {
+	case Exact:
+		next = c.selectExact(req)
+	case FirstMatch:
+		next = c.selectFirstMatch(req, false)
+	case ExactPerHost:
+		next = c.selectExactPerHost(req)
+	case FirstMatchConsume:
+		next = c.selectFirstMatch(req, true)
+	case BestMatch:
+		next = c.selectBestMatch(req)
+	default:
+		c.t.Fatalf("unknown matching algorithm: %v", c.opts.Algorithm)
+	}
+
+	if next == nil {
+		return nil, 0
+	}
+	if next.scenario != "" && next.newState != "" {
+		c.setScenarioState(next.scenario, next.newState)
+	}
+	return next, next.uses.Add(1) - 1
+}
+
+// selectExact returns the first unused interaction if its scenario is in the required state. In strict
+// mode, it has to match the request.
+func (c *MockInteractionTransport) selectExact(req *http.Request) *ExpectedInteraction {
 	if len(c.expectedInteractions) == 0 {
 		return nil
 	}
-	i := c.expectedInteractions[0]
-	c.expectedInteractions = c.expectedInteractions[1:]
+	return c.selectExactAt(req, 0)
+}
+
+// selectExactPerHost returns the first unused interaction queued for the host of the request if its
+// scenario is in the required state. In strict mode, it has to match the request.
+func (c *MockInteractionTransport) selectExactPerHost(req *http.Request) *ExpectedInteraction {
+	for index, interaction := range c.expectedInteractions {
+		if host := interaction.host(); host == "" || strings.EqualFold(host, req.URL.Host) {
+			return c.selectExactAt(req, index)
+		}
+	}
+	return nil
+}
+
+// selectExactAt returns the interaction at the index if its scenario is in the required state and, in
+// strict mode, it matches the request, consuming it once it has been used as often as expected
+func (c *MockInteractionTransport) selectExactAt(req *http.Request, index int) *ExpectedInteraction {
+	i := c.expectedInteractions[index]
+	if !c.inRequiredState(i) || (c.opts.Strict && !i.matches(req)) {
+		return nil
+	}
+	c.consumeIfUsedUp(index)
 	return i
 }
 
-// selectFirstMatch returns the first interaction that matches the request
-func (c *MockInteractionTransport) selectFirstMatch(req *http.Request) *ExpectedInteraction {
-	for _, interaction := range c.expectedInteractions {
-		if interaction.matches(req) {
+// selectFirstMatch returns the first interaction that matches the request and whose scenario is in
+// the required state, consuming it on its last expected use if requested
+func (c *MockInteractionTransport) selectFirstMatch(req *http.Request, consume bool) *ExpectedInteraction {
+	for index, interaction := range c.expectedInteractions {
+		if c.inRequiredState(interaction) && interaction.matches(req) {
+			if consume {
+				c.consumeIfUsedUp(index)
+			}
 			return interaction
 		}
 	}
 	return nil
 }
 
+// selectBestMatch returns the most specific interaction that matches the request and whose scenario is
+// in the required state, preferring earlier interactions on ties
+func (c *MockInteractionTransport) selectBestMatch(req *http.Request) *ExpectedInteraction {
+	var best *ExpectedInteraction
+	bestScore := -1
+	for _, interaction := range c.expectedInteractions {
+		if !c.inRequiredState(interaction) || !interaction.matches(req) {
+			continue
+		}
+		if score := interaction.specificity(); score > bestScore {
+			best, bestScore = interaction, score
+		}
+	}
+	return best
+}
+
+// consumeIfUsedUp removes the interaction at the index if the upcoming use is its last expected one
+func (c *MockInteractionTransport) consumeIfUsedUp(index int) {
+	i := c.expectedInteractions[index]
+	if i.uses.Load()+1 >= i.expectedUses() {
+		c.expectedInteractions = slices.Delete(c.expectedInteractions, index, index+1)
+	}
+}
+
+// inRequiredState checks if the scenario of the interaction is in the state the interaction requires
+func (c *MockInteractionTransport) inRequiredState(interaction *ExpectedInteraction) bool {
+	if interaction.scenario == "" || interaction.requiredState == "" {
+		return true
+	}
+	return c.scenarioState(interaction.scenario) == interaction.requiredState
+}
+
+func (c *MockInteractionTransport) scenarioState(name string) string {
+	if state, ok := c.scenarioStates[name]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+func (c *MockInteractionTransport) setScenarioState(name string, state string) {
+	if c.scenarioStates == nil {
+		c.scenarioStates = make(map[string]string)
+	}
+	c.scenarioStates[name] = state
+}
+
+// ScenarioState returns the current state of the named scenario
+func (c *MockInteractionTransport) ScenarioState(name string) string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.scenarioState(name)
+}
+
+// SetScenarioState moves the named scenario into the given state, e.g. to start a test mid-flow
+func (c *MockInteractionTransport) SetScenarioState(name string, state string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.setScenarioState(name, state)
+}
+
 func (c *MockInteractionTransport) ExpectRequest(req TestRequest) *ExpectedInteraction {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -197,7 +807,15 @@ func (c *MockInteractionTransport) ExpectRequest(req TestRequest) *ExpectedInter
 		request:           req,
 		ignoreQueryParams: false,
 	}
+	if req.URLPattern != "" {
+		pattern, err := regexp.Compile("^(?:" + req.URLPattern + ")$")
+		if err != nil {
+			c.t.Fatalf("invalid URL pattern %q: %s", req.URLPattern, err)
+		}
+		e.urlPattern = pattern
+	}
 	c.expectedInteractions = append(c.expectedInteractions, e)
+	c.declaredInteractions = append(c.declaredInteractions, e)
 	return e
 }
 
@@ -205,4 +823,30 @@ func (c *MockInteractionTransport) Reset() {
 	c.m.Lock()
 	defer c.m.Unlock()
 	c.expectedInteractions = make([]*ExpectedInteraction, 0)
+	c.declaredInteractions = nil
+	c.observedRequests = nil
+	c.unexpectedRequests = nil
+	c.scenarioStates = nil
+}
+
+// Verify fails the test if expected interactions have not been used as often as they expect, i.e. once
+// or once per response of a sequence, and, in strict mode, if requests without matching interaction
+// have been received
+func (c *MockInteractionTransport) Verify(t testing.TB) {
+	t.Helper()
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	var unmet []string
+	for _, interaction := range c.expectedInteractions {
+		if uses := interaction.uses.Load(); uses < interaction.expectedUses() {
+			unmet = append(unmet, fmt.Sprintf("%s (used %d of %d times)", interaction.describe(), uses, interaction.expectedUses()))
+		}
+	}
+	if len(unmet) > 0 {
+		t.Errorf("mock transport: %d expected interactions not met:\n\t%s", len(unmet), strings.Join(unmet, "\n\t"))
+	}
+	if len(c.unexpectedRequests) > 0 {
+		t.Errorf("mock transport: %d unexpected requests received:\n\t%s", len(c.unexpectedRequests), strings.Join(c.unexpectedRequests, "\n\t"))
+	}
 }
@@ -1,9 +1,18 @@
 package testutils
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -287,6 +296,69 @@ func TestMockInteractionTransport_RoundTrip_ExactAlgorithm(t *testing.T) {
 	})
 }
 
+func TestMockInteractionTransport_RoundTrip_ExactPerHostAlgorithm(t *testing.T) {
+	t.Run("keeps the order per host only", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: ExactPerHost,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://users.localhost/first"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://users.localhost/second"}).
+			WillReturnResponse(&TestResponse{Status: 201})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://orders.localhost/first"}).
+			WillReturnResponse(&TestResponse{Status: 202})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://orders.localhost/first", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 202, resp.StatusCode)
+
+		resp, err = transport.RoundTrip(httptest.NewRequest("GET", "https://users.localhost/first", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		resp, err = transport.RoundTrip(httptest.NewRequest("GET", "https://users.localhost/second", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+
+		assert.Len(t, transport.expectedInteractions, 0)
+	})
+
+	t.Run("queues interactions without host for every host", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: ExactPerHost,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://users.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URLPattern: `https://.*/health`}).
+			WillReturnResponse(&TestResponse{Status: 204})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://orders.localhost/health", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+		assert.Len(t, transport.expectedInteractions, 1)
+	})
+
+	t.Run("records requests to hosts without queued interactions in strict mode", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: ExactPerHost,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://users.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://orders.localhost/orders", nil))
+		require.Error(t, err)
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 2)
+		assert.Contains(t, recorder.errors[1], "GET https://orders.localhost/orders")
+	})
+}
+
 func TestMockInteractionTransport_RoundTrip_FirstMatchAlgorithm(t *testing.T) {
 	t.Run("matches first matching interaction", func(t *testing.T) {
 		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
@@ -324,6 +396,156 @@ func TestMockInteractionTransport_RoundTrip_FirstMatchAlgorithm(t *testing.T) {
 	})
 }
 
+func TestMockInteractionTransport_RoundTrip_FirstMatchConsumeAlgorithm(t *testing.T) {
+	t.Run("consumes interactions once used", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatchConsume,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 304})
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "https://api.localhost/posts"}).
+			WillReturnResponse(&TestResponse{Status: 201})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("POST", "https://api.localhost/posts", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		resp, err = transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		resp, err = transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 304, resp.StatusCode)
+
+		_, err = transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("consumes interactions after the expected number of uses", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatchConsume,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"}).
+			Times(2).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		for i := 0; i < 2; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/test", nil))
+			require.NoError(t, err)
+		}
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/test", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("serves each use once to concurrent requests", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatchConsume,
+			Strict:    true,
+		})
+
+		const requests = 50
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"}).
+			Times(requests).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		var wg sync.WaitGroup
+		var failures atomic.Int64
+		for i := 0; i < requests+10; i++ {
+			wg.Go(func() {
+				if _, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/test", nil)); err != nil {
+					failures.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(10), failures.Load())
+	})
+}
+
+func TestMockInteractionTransport_RoundTrip_BestMatchAlgorithm(t *testing.T) {
+	newTransport := func(t *testing.T) *MockInteractionTransport {
+		return NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: BestMatch,
+		})
+	}
+	roundTrip := func(t *testing.T, transport *MockInteractionTransport, method string, url string) int {
+		resp, err := transport.RoundTrip(httptest.NewRequest(method, url, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("prefers exact URLs over wildcards and patterns", func(t *testing.T) {
+		transport := newTransport(t)
+
+		transport.ExpectRequest(TestRequest{URLPattern: `https://api\.localhost/users/.*`}).
+			WillReturnResponse(&TestResponse{Status: 201})
+		transport.ExpectRequest(TestRequest{URL: "https://api.localhost/users/*"}).
+			WillReturnResponse(&TestResponse{Status: 202})
+		transport.ExpectRequest(TestRequest{URL: "https://api.localhost/users/1"}).
+			WillReturnResponse(&TestResponse{Status: 203})
+
+		assert.Equal(t, 203, roundTrip(t, transport, "GET", "https://api.localhost/users/1"))
+		assert.Equal(t, 202, roundTrip(t, transport, "GET", "https://api.localhost/users/2"))
+		assert.Equal(t, 201, roundTrip(t, transport, "GET", "https://api.localhost/users/2/posts"))
+	})
+
+	t.Run("prefers interactions with more constraints", func(t *testing.T) {
+		transport := newTransport(t)
+
+		transport.ExpectRequest(TestRequest{URL: "https://api.localhost/search"}).
+			IgnoreQueryParams(true).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{URL: "https://api.localhost/search"}).
+			WithQueryParam("q", "go").
+			WithQueryParam("page", "2").
+			WillReturnResponse(&TestResponse{Status: 202})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/search"}).
+			WithQueryParam("q", "go").
+			WillReturnResponse(&TestResponse{Status: 201})
+
+		assert.Equal(t, 202, roundTrip(t, transport, "GET", "https://api.localhost/search?q=go&page=2"))
+		assert.Equal(t, 201, roundTrip(t, transport, "GET", "https://api.localhost/search?q=go"))
+		assert.Equal(t, 200, roundTrip(t, transport, "GET", "https://api.localhost/search?q=rust"))
+	})
+
+	t.Run("prefers earlier interactions on ties", func(t *testing.T) {
+		transport := newTransport(t)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"}).
+			WillReturnResponse(&TestResponse{Status: 201})
+
+		assert.Equal(t, 200, roundTrip(t, transport, "GET", "https://api.localhost/test"))
+		assert.Equal(t, 200, roundTrip(t, transport, "GET", "https://api.localhost/test"))
+	})
+}
+
+func TestExpectedInteraction_Times(t *testing.T) {
+	recorder := &recordingT{TB: t}
+	transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+		Algorithm: FirstMatch,
+	})
+
+	transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"}).
+		Times(3).
+		WillReturnResponse(&TestResponse{Status: 200})
+
+	for i := 0; i < 2; i++ {
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/test", nil))
+		require.NoError(t, err)
+	}
+	transport.Verify(recorder)
+	require.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "used 2 of 3 times")
+}
+
 func TestMockInteractionTransport_RoundTrip_ResponseHandling(t *testing.T) {
 	t.Run("returns JSON response body", func(t *testing.T) {
 		transport := NewMockInteractionTransport(t, nil)
@@ -392,3 +614,914 @@ func TestMockInteractionTransport_ImplementsRoundTripper(t *testing.T) {
 	var _ http.RoundTripper = transport
 	assert.Implements(t, (*http.RoundTripper)(nil), transport)
 }
+
+func TestMockInteractionTransport_RoundTrip_Faults(t *testing.T) {
+	t.Run("returns configured error", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+		expectedErr := errors.New("connection refused")
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/fail"}).
+			WillReturnError(expectedErr)
+
+		req := httptest.NewRequest("GET", "https://api.localhost/fail", nil)
+		resp, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("delays response", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/slow"}).
+			WithDelay(20 * time.Millisecond).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/slow", nil)
+		start := time.Now()
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("delay is aborted by context deadline", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/slow"}).
+			WithDelay(time.Second).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest("GET", "https://api.localhost/slow", nil).WithContext(ctx)
+		resp, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("timeout waits for context deadline", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/hang"}).
+			WillTimeout()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest("GET", "https://api.localhost/hang", nil).WithContext(ctx)
+		resp, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("timeout without cancellable context returns timeout error", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/hang"}).
+			WillTimeout()
+
+		req := httptest.NewRequest("GET", "https://api.localhost/hang", nil)
+		resp, err := transport.RoundTrip(req)
+
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+		assert.Nil(t, resp)
+	})
+
+	t.Run("http client timeout surfaces as client error", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/hang"}).
+			WillTimeout()
+
+		client := &http.Client{Transport: transport, Timeout: 10 * time.Millisecond}
+		_, err := client.Get("https://api.localhost/hang")
+
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+	})
+}
+
+func TestExpectedInteraction_WillRespondWith(t *testing.T) {
+	t.Run("computes response from request", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "https://api.localhost/echo"}).
+			WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				return &TestResponse{
+					Status: 201,
+					Header: http.Header{"X-Request-Id": []string{req.Header.Get("X-Request-ID")}},
+					Body:   string(body),
+				}, nil
+			})
+
+		req := httptest.NewRequest("POST", "https://api.localhost/echo", strings.NewReader("ping"))
+		req.Header.Set("X-Request-ID", "abc")
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, "abc", resp.Header.Get("X-Request-ID"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(body))
+	})
+
+	t.Run("returns responder error", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+		expectedErr := errors.New("responder failed")
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/fail"}).
+			WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+				return nil, expectedErr
+			})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/fail", nil)
+		resp, err := transport.RoundTrip(req)
+
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Nil(t, resp)
+	})
+
+	t.Run("encodes JSON bodies of computed responses", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET"}).
+			WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+				return &TestResponse{
+					Status: 200,
+					Header: http.Header{"Content-Type": []string{"application/json"}},
+					Body:   map[string]string{"cursor": req.URL.Query().Get("cursor")},
+				}, nil
+			})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/items?cursor=next", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"cursor":"next"}`, string(body))
+	})
+}
+
+func TestExpectedInteraction_WillReturnResponses(t *testing.T) {
+	t.Run("returns responses in sequence with FirstMatch", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/flaky"}).
+			WillReturnResponses(
+				&TestResponse{Status: 503},
+				&TestResponse{Status: 503},
+				&TestResponse{Status: 200},
+			)
+
+		var statuses []int
+		for i := 0; i < 4; i++ {
+			req := httptest.NewRequest("GET", "https://api.localhost/flaky", nil)
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			statuses = append(statuses, resp.StatusCode)
+		}
+
+		// The last response repeats once the sequence is exhausted
+		assert.Equal(t, []int{503, 503, 200, 200}, statuses)
+	})
+
+	t.Run("consumes interaction after sequence with Exact", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: Exact,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/flaky"}).
+			WillReturnResponses(&TestResponse{Status: 503}, &TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/next"}).
+			WillReturnResponse(&TestResponse{Status: 204})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/flaky", nil)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 503, resp.StatusCode)
+		assert.Len(t, transport.expectedInteractions, 2)
+
+		req = httptest.NewRequest("GET", "https://api.localhost/flaky", nil)
+		resp, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Len(t, transport.expectedInteractions, 1)
+
+		req = httptest.NewRequest("GET", "https://api.localhost/next", nil)
+		resp, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+		assert.Len(t, transport.expectedInteractions, 0)
+	})
+
+	t.Run("WillReturnResponse replaces a configured sequence", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		interaction := transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/test"})
+		interaction.WillReturnResponses(&TestResponse{Status: 503}, &TestResponse{Status: 200})
+		interaction.WillReturnResponse(&TestResponse{Status: 201})
+
+		assert.Nil(t, interaction.responses)
+		assert.Equal(t, int64(1), interaction.expectedUses())
+	})
+}
+
+func TestExpectedInteraction_matches_QueryParams(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expectedURL string
+		queryParams map[string]string
+		actualURL   string
+		expected    bool
+	}{
+		{
+			name:        "matches query params in different order",
+			expectedURL: "https://api.localhost/users?a=1&b=2",
+			actualURL:   "https://api.localhost/users?b=2&a=1",
+			expected:    true,
+		},
+		{
+			name:        "matches repeated query params in different order",
+			expectedURL: "https://api.localhost/users?id=1&id=2",
+			actualURL:   "https://api.localhost/users?id=2&id=1",
+			expected:    true,
+		},
+		{
+			name:        "does not match missing query param",
+			expectedURL: "https://api.localhost/users?a=1&b=2",
+			actualURL:   "https://api.localhost/users?a=1",
+			expected:    false,
+		},
+		{
+			name:        "does not match different query param value",
+			expectedURL: "https://api.localhost/users?a=1",
+			actualURL:   "https://api.localhost/users?a=2",
+			expected:    false,
+		},
+		{
+			name:        "matches individual query param ignoring others",
+			expectedURL: "https://api.localhost/users",
+			queryParams: map[string]string{"page": "2"},
+			actualURL:   "https://api.localhost/users?limit=10&page=2",
+			expected:    true,
+		},
+		{
+			name:        "matches individual query param among repeated values",
+			expectedURL: "https://api.localhost/users",
+			queryParams: map[string]string{"id": "2"},
+			actualURL:   "https://api.localhost/users?id=1&id=2",
+			expected:    true,
+		},
+		{
+			name:        "does not match missing individual query param",
+			expectedURL: "https://api.localhost/users",
+			queryParams: map[string]string{"page": "2"},
+			actualURL:   "https://api.localhost/users?limit=10",
+			expected:    false,
+		},
+		{
+			name:        "matches individual query param without expected URL",
+			queryParams: map[string]string{"page": "2"},
+			actualURL:   "https://api.localhost/anything?page=2",
+			expected:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interaction := &ExpectedInteraction{
+				request: TestRequest{Method: "GET", URL: tc.expectedURL},
+			}
+			for key, value := range tc.queryParams {
+				interaction.WithQueryParam(key, value)
+			}
+
+			req := httptest.NewRequest("GET", tc.actualURL, nil)
+
+			assert.Equal(t, tc.expected, interaction.matches(req))
+		})
+	}
+}
+
+func TestMockInteractionTransport_RoundTrip_QueryParams(t *testing.T) {
+	t.Run("accepts reordered query params with Exact", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users?limit=10&page=2"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users?page=2&limit=10", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("accepts expected individual query params with Exact", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WithQueryParam("page", "2").
+			WithQueryParam("sort", "name").
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users?sort=name&page=2&limit=10", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("selects interaction by individual query params with FirstMatch", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WithQueryParam("page", "1").
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WithQueryParam("page", "2").
+			WillReturnResponse(&TestResponse{Status: 206})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users?limit=10&page=2", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 206, resp.StatusCode)
+	})
+}
+
+func TestExpectedInteraction_matches_URLPatterns(t *testing.T) {
+	testCases := []struct {
+		name      string
+		request   TestRequest
+		actualURL string
+		expected  bool
+	}{
+		{
+			name:      "wildcard matches path segment",
+			request:   TestRequest{URL: "https://api.localhost/users/*/orders"},
+			actualURL: "https://api.localhost/users/7f3c/orders",
+			expected:  true,
+		},
+		{
+			name:      "wildcard does not match multiple path segments",
+			request:   TestRequest{URL: "https://api.localhost/users/*/orders"},
+			actualURL: "https://api.localhost/users/7f3c/extra/orders",
+			expected:  false,
+		},
+		{
+			name:      "wildcard URL compares query params regardless of order",
+			request:   TestRequest{URL: "https://api.localhost/users/*?a=1&b=2"},
+			actualURL: "https://api.localhost/users/7f3c?b=2&a=1",
+			expected:  true,
+		},
+		{
+			name:      "pattern matches entire URL",
+			request:   TestRequest{URLPattern: `https://api\.localhost/users/[0-9]+`},
+			actualURL: "https://api.localhost/users/42",
+			expected:  true,
+		},
+		{
+			name:      "pattern does not match partially",
+			request:   TestRequest{URLPattern: `https://api\.localhost/users/[0-9]+`},
+			actualURL: "https://api.localhost/users/42/orders",
+			expected:  false,
+		},
+		{
+			name:      "pattern takes precedence over URL",
+			request:   TestRequest{URL: "https://api.localhost/other", URLPattern: `.*/users/.*`},
+			actualURL: "https://api.localhost/users/42",
+			expected:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := NewMockInteractionTransport(t, nil)
+			interaction := transport.ExpectRequest(tc.request)
+
+			req := httptest.NewRequest("GET", tc.actualURL, nil)
+
+			assert.Equal(t, tc.expected, interaction.matches(req))
+		})
+	}
+}
+
+func TestMockInteractionTransport_RoundTrip_URLPatterns(t *testing.T) {
+	t.Run("passes wildcard captures to responder", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users/*/orders/*"}).
+			WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+				captures := URLCaptures(req)
+				return &TestResponse{Status: 200, Body: strings.Join(captures, ",")}, nil
+			})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users/u-1/orders/o-2", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "u-1,o-2", string(body))
+	})
+
+	t.Run("passes pattern captures to responder", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URLPattern: `https://api\.localhost/users/([0-9]+)`}).
+			WillRespondWith(func(req *http.Request) (*TestResponse, error) {
+				return &TestResponse{Status: 200, Body: URLCaptures(req)[0]}, nil
+			})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users/42", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "42", string(body))
+	})
+
+	t.Run("ignores query params of pattern matches when configured", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URLPattern: `https://api\.localhost/users/[0-9]+`}).
+			IgnoreQueryParams(true).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users/42?expand=orders", nil)
+		resp, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("has no captures for literal URLs", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://api.localhost/users", nil)
+
+		assert.Nil(t, URLCaptures(req))
+	})
+}
+
+func TestExpectedInteraction_MatchWith(t *testing.T) {
+	hasHeader := func(key, value string) func(req *http.Request) bool {
+		return func(req *http.Request) bool {
+			return req.Header.Get(key) == value
+		}
+	}
+
+	t.Run("requires all custom matchers", func(t *testing.T) {
+		interaction := (&ExpectedInteraction{request: TestRequest{Method: "GET"}}).
+			MatchWith(hasHeader("X-Tenant", "acme")).
+			MatchWith(hasHeader("X-Signature", "valid"))
+
+		req := httptest.NewRequest("GET", "https://api.localhost/users", nil)
+		req.Header.Set("X-Tenant", "acme")
+		assert.False(t, interaction.matches(req))
+
+		req.Header.Set("X-Signature", "valid")
+		assert.True(t, interaction.matches(req))
+	})
+
+	t.Run("selects interaction by custom matcher with FirstMatch", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "https://api.localhost/search"}).
+			MatchWith(func(req *http.Request) bool {
+				body, _ := io.ReadAll(req.Body)
+				req.Body = io.NopCloser(strings.NewReader(string(body)))
+				return strings.Contains(string(body), "acme")
+			}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "https://api.localhost/search"}).
+			WillReturnResponse(&TestResponse{Status: 404})
+
+		req := httptest.NewRequest("POST", "https://api.localhost/search", strings.NewReader(`{"query":"acme"}`))
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		req = httptest.NewRequest("POST", "https://api.localhost/search", strings.NewReader(`{"query":"other"}`))
+		resp, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
+
+// recordingT captures errors reported to it instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockInteractionTransport_Verify(t *testing.T) {
+	t.Run("passes when all interactions are met", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		require.NoError(t, err)
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		assert.Empty(t, recorder.errors)
+	})
+
+	t.Run("reports unmet interactions with Exact", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/flaky"}).
+			WillReturnResponses(&TestResponse{Status: 503}, &TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{Method: "DELETE", URLPattern: `https://api\.localhost/users/[0-9]+`})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/flaky", nil))
+		require.NoError(t, err)
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 1)
+		assert.Contains(t, recorder.errors[0], "2 expected interactions not met")
+		assert.Contains(t, recorder.errors[0], "GET https://api.localhost/flaky (used 1 of 2 times)")
+		assert.Contains(t, recorder.errors[0], `DELETE ~https://api\.localhost/users/[0-9]+ (used 0 of 1 times)`)
+	})
+
+	t.Run("reports unused interactions with FirstMatch", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+		transport.ExpectRequest(TestRequest{URL: "https://api.localhost/orders"}).
+			WithQueryParam("page", "1")
+
+		for i := 0; i < 2; i++ {
+			_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+			require.NoError(t, err)
+		}
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 1)
+		assert.Contains(t, recorder.errors[0], "1 expected interactions not met")
+		assert.Contains(t, recorder.errors[0], "* https://api.localhost/orders with query page=1 (used 0 of 1 times)")
+	})
+
+	t.Run("reports unexpected requests in strict mode", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: Exact,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("POST", "https://api.localhost/users", nil))
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		// The mismatching request does not consume the interaction
+		assert.Len(t, transport.expectedInteractions, 1)
+
+		resp, err = transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		_, err = transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/orders", nil))
+		assert.Error(t, err)
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 1)
+		assert.Contains(t, recorder.errors[0], "2 unexpected requests received")
+		assert.Contains(t, recorder.errors[0], "POST https://api.localhost/users")
+		assert.Contains(t, recorder.errors[0], "GET https://api.localhost/orders")
+	})
+
+	t.Run("reset clears unexpected requests", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+			Strict:    true,
+		})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		assert.Error(t, err)
+
+		transport.Reset()
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		assert.Empty(t, recorder.errors)
+	})
+}
+
+func TestMockInteractionTransport_VerifyOnCleanup(t *testing.T) {
+	var transport *MockInteractionTransport
+	t.Run("registers verification", func(t *testing.T) {
+		transport = NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm:       Exact,
+			VerifyOnCleanup: true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/users"}).
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/users", nil))
+		require.NoError(t, err)
+	})
+
+	assert.Empty(t, transport.expectedInteractions)
+}
+
+func TestMockInteractionTransport_Serve(t *testing.T) {
+	t.Run("answers requests over the network", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "/users?limit=10&page=2"}).
+			WillReturnResponse(&TestResponse{
+				Status: 200,
+				Header: http.Header{"Content-Type": []string{"application/json"}},
+				Body:   map[string]any{"users": []any{}},
+			})
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "/users"}).
+			WillReturnResponse(&TestResponse{Status: 201})
+
+		server := transport.Serve(t)
+
+		res, err := http.Get(server.URL + "/users?page=2&limit=10")
+		require.NoError(t, err)
+		response := MustParseResponse(t, res)
+		assert.Equal(t, 200, response.Status)
+		assert.Equal(t, map[string]any{"users": []any{}}, response.Body)
+
+		res, err = http.Post(server.URL+"/users", "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, 201, res.StatusCode)
+	})
+
+	t.Run("aborts connection on error", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "/fail"}).
+			WillReturnError(errors.New("connection reset"))
+
+		server := transport.Serve(t)
+
+		_, err := http.Get(server.URL + "/fail")
+		assert.Error(t, err)
+	})
+
+	t.Run("answers requests over TLS", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "/secure"}).
+			WillReturnResponse(&TestResponse{Status: 204})
+
+		server := transport.ServeTLS(t)
+
+		res, err := server.Client().Get(server.URL + "/secure")
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, 204, res.StatusCode)
+		assert.NotNil(t, res.TLS)
+	})
+}
+
+func TestMockInteractionTransport_RoundTrip_BinaryBodies(t *testing.T) {
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+
+	t.Run("returns byte slice bodies as is with content length", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/image"}).
+			WillReturnResponse(&TestResponse{
+				Status: 200,
+				Header: http.Header{"Content-Type": []string{"application/json"}},
+				Body:   payload,
+			})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/image", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(len(payload)), resp.ContentLength)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, body)
+	})
+
+	t.Run("takes content length from header", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/file"}).
+			WillReturnResponse(&TestResponse{
+				Status: 200,
+				Header: http.Header{"Content-Length": []string{"100"}},
+				Body:   payload,
+			})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/file", nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(100), resp.ContentLength)
+	})
+
+	t.Run("streams body in chunks", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/file"}).
+			WithStreaming(2, 5*time.Millisecond).
+			WillReturnResponse(&TestResponse{Status: 200, Body: payload})
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/file", nil))
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), resp.ContentLength)
+		assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+		var chunks []string
+		buf := make([]byte, 16)
+		start := time.Now()
+		for {
+			n, readErr := resp.Body.Read(buf)
+			if n > 0 {
+				chunks = append(chunks, string(buf[:n]))
+			}
+			if readErr == io.EOF {
+				break
+			}
+			require.NoError(t, readErr)
+		}
+		assert.Equal(t, []string{"\x89P", "NG", "\x00\xff"}, chunks)
+		assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	})
+
+	t.Run("aborts stream once context is done", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/file"}).
+			WithStreaming(2, time.Second).
+			WillReturnResponse(&TestResponse{Status: 200, Body: payload})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/file", nil).WithContext(ctx))
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("serves streamed and truncated bodies", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "/stream"}).
+			WithStreaming(2, time.Millisecond).
+			WillReturnResponse(&TestResponse{
+				Status: 200,
+				Header: http.Header{"Content-Type": []string{"application/octet-stream"}},
+				Body:   payload,
+			})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "/truncated"}).
+			WillReturnResponse(&TestResponse{
+				Status: 200,
+				Header: http.Header{"Content-Length": []string{"100"}},
+				Body:   payload,
+			})
+		server := transport.Serve(t)
+
+		res, err := http.Get(server.URL + "/stream")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"chunked"}, res.TransferEncoding)
+		response := MustParseResponse(t, res)
+		assert.Equal(t, payload, response.Body)
+
+		res, err = http.Get(server.URL + "/truncated")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		_, err = io.ReadAll(res.Body)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
+
+func TestMockInteractionTransport_Scenarios(t *testing.T) {
+	t.Run("transitions scenario state with FirstMatch", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/cart"}).
+			InScenario("cart", ScenarioStarted).
+			WillReturnResponse(&TestResponse{Status: 200, Body: "empty"})
+		transport.ExpectRequest(TestRequest{Method: "POST", URL: "https://api.localhost/cart/items"}).
+			InScenario("cart", "").
+			WillSetScenarioState("has item").
+			WillReturnResponse(&TestResponse{Status: 201})
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/cart"}).
+			InScenario("cart", "has item").
+			WillReturnResponse(&TestResponse{Status: 200, Body: "1 item"})
+
+		getCart := func() string {
+			resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/cart", nil))
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return string(body)
+		}
+
+		assert.Equal(t, "empty", getCart())
+		assert.Equal(t, ScenarioStarted, transport.ScenarioState("cart"))
+
+		resp, err := transport.RoundTrip(httptest.NewRequest("POST", "https://api.localhost/cart/items", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, "has item", transport.ScenarioState("cart"))
+
+		assert.Equal(t, "1 item", getCart())
+		assert.Equal(t, "1 item", getCart())
+	})
+
+	t.Run("presets scenario state", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: FirstMatch,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/payment"}).
+			InScenario("payment", "authorized").
+			WillSetScenarioState("captured").
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/payment", nil))
+		assert.Error(t, err)
+
+		transport.SetScenarioState("payment", "authorized")
+		resp, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/payment", nil))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "captured", transport.ScenarioState("payment"))
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 1)
+		assert.Contains(t, recorder.errors[0], "1 unexpected requests received")
+	})
+
+	t.Run("keeps interaction of Exact until scenario reaches required state", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, &MockInteractionTransportOptions{
+			Algorithm: Exact,
+			Strict:    true,
+		})
+
+		transport.ExpectRequest(TestRequest{Method: "GET", URL: "https://api.localhost/order"}).
+			InScenario("order", "shipped").
+			WillReturnResponse(&TestResponse{Status: 200})
+
+		_, err := transport.RoundTrip(httptest.NewRequest("GET", "https://api.localhost/order", nil))
+		assert.Error(t, err)
+		assert.Len(t, transport.expectedInteractions, 1)
+
+		recorder := &recordingT{TB: t}
+		transport.Verify(recorder)
+		require.Len(t, recorder.errors, 2)
+		assert.Contains(t, recorder.errors[0], "GET https://api.localhost/order in scenario order at state shipped (used 0 of 1 times)")
+	})
+
+	t.Run("reset restores initial scenario states", func(t *testing.T) {
+		transport := NewMockInteractionTransport(t, nil)
+		transport.SetScenarioState("cart", "has item")
+
+		transport.Reset()
+
+		assert.Equal(t, ScenarioStarted, transport.ScenarioState("cart"))
+	})
+}
@@ -4,8 +4,23 @@ import (
 	"context"
 
 	"github.com/Roshick/go-autumn-web/contextutils"
+	"go.opentelemetry.io/otel/trace"
 )
 
+func init() {
+	contextutils.RegisterValue[RequestID]()
+	contextutils.RegisterValue[ParentRequestID]()
+	contextutils.RegisterCapturer(func(ctx context.Context) func(context.Context) context.Context {
+		spanCtx := trace.SpanContextFromContext(ctx)
+		if !spanCtx.IsValid() {
+			return nil
+		}
+		return func(ctx context.Context) context.Context {
+			return trace.ContextWithSpanContext(ctx, spanCtx)
+		}
+	})
+}
+
 type RequestID string
 
 func RequestIDFromContext(ctx context.Context) *string {
@@ -20,3 +35,18 @@ func RequestIDFromContext(ctx context.Context) *string {
 func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
 	return contextutils.WithValue(ctx, RequestID(requestID))
 }
+
+type ParentRequestID string
+
+func ParentRequestIDFromContext(ctx context.Context) *string {
+	parentRequestID := contextutils.GetValue[ParentRequestID](ctx)
+	if parentRequestID != nil {
+		parentRequestIDString := string(*parentRequestID)
+		return &parentRequestIDString
+	}
+	return nil
+}
+
+func ContextWithParentRequestID(ctx context.Context, parentRequestID string) context.Context {
+	return contextutils.WithValue(ctx, ParentRequestID(parentRequestID))
+}
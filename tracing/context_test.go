@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCapture(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := ContextWithRequestID(context.Background(), "request-id")
+	ctx = ContextWithParentRequestID(ctx, "parent-request-id")
+	ctx = trace.ContextWithSpanContext(ctx, spanCtx)
+
+	restored := contextutils.Capture(ctx).Apply(context.Background())
+
+	requestID := RequestIDFromContext(restored)
+	require.NotNil(t, requestID)
+	assert.Equal(t, "request-id", *requestID)
+	parentRequestID := ParentRequestIDFromContext(restored)
+	require.NotNil(t, parentRequestID)
+	assert.Equal(t, "parent-request-id", *parentRequestID)
+	assert.Equal(t, spanCtx, trace.SpanContextFromContext(restored))
+}
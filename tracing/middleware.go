@@ -5,6 +5,7 @@ import (
 	"fmt"
 	mathrand "math/rand/v2"
 	"net/http"
+	"net/netip"
 	"time"
 
 	slogging "github.com/Roshick/go-autumn-slog"
@@ -58,12 +59,22 @@ func NewTracingLoggerMiddleware(opts *TracingLoggerMiddlewareOptions) func(next
 type RequestIDHeaderMiddlewareOptions struct {
 	HeaderName  string
 	GeneratorFn func() string
+	// ValidatorFn decides whether an inbound request ID is accepted, e.g.
+	// NewRequestIDValidator(DefaultRequestIDMaxLength). Rejected IDs are replaced by a freshly
+	// generated one. If nil, the default, every non-empty inbound ID is accepted.
+	ValidatorFn func(requestID string) bool
+	// TrustedProxies restricts accepting inbound request IDs to requests whose peer address is part
+	// of one of the given networks. If empty, inbound IDs are accepted from every peer. The peer
+	// address is read from req.RemoteAddr, which security.NewRealIPMiddleware rewrites to the client
+	// IP, so this middleware has to be mounted before it.
+	TrustedProxies []netip.Prefix
 }
 
 func DefaultRequestIDHeaderMiddlewareOptions() *RequestIDHeaderMiddlewareOptions {
 	return &RequestIDHeaderMiddlewareOptions{
-		HeaderName:  header.XRequestID,
-		GeneratorFn: DefaultRequestIDGenerator,
+		HeaderName:     header.XRequestID,
+		GeneratorFn:    DefaultRequestIDGenerator,
+		TrustedProxies: []netip.Prefix{},
 	}
 }
 
@@ -77,6 +88,12 @@ func NewRequestIDHeaderMiddleware(opts *RequestIDHeaderMiddlewareOptions) func(n
 			ctx := req.Context()
 
 			requestID := req.Header.Get(opts.HeaderName)
+			if requestID != "" && !isTrustedPeer(req, opts.TrustedProxies) {
+				requestID = ""
+			}
+			if requestID != "" && opts.ValidatorFn != nil && !opts.ValidatorFn(requestID) {
+				requestID = ""
+			}
 			if requestID == "" {
 				requestID = opts.GeneratorFn()
 			}
@@ -126,6 +143,65 @@ func NewRequestIDLoggerMiddleware(opts *RequestIDLoggerMiddlewareOptions) func(n
 	}
 }
 
+const DefaultRequestIDMaxLength = 128
+
+// NewRequestIDValidator accepts request IDs of at most maxLength characters consisting of ASCII
+// letters, digits and the characters '-', '_', '.' and ':'. A maxLength <= 0 disables the length check.
+func NewRequestIDValidator(maxLength int) func(requestID string) bool {
+	return func(requestID string) bool {
+		if maxLength > 0 && len(requestID) > maxLength {
+			return false
+		}
+		for _, c := range requestID {
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			case c == '-', c == '_', c == '.', c == ':':
+			default:
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// IsUUIDRequestID accepts only request IDs in the canonical 8-4-4-4-12 hexadecimal UUID format.
+func IsUUIDRequestID(requestID string) bool {
+	if len(requestID) != 36 {
+		return false
+	}
+	for i, c := range requestID {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isTrustedPeer(req *http.Request, trustedProxies []netip.Prefix) bool {
+	if len(trustedProxies) == 0 {
+		return true
+	}
+	var addr netip.Addr
+	if addrPort, err := netip.ParseAddrPort(req.RemoteAddr); err == nil {
+		addr = addrPort.Addr().Unmap()
+	} else if addr, err = netip.ParseAddr(req.RemoteAddr); err != nil {
+		return false
+	}
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // DefaultRequestIDGenerator generates a UUID v4 style request ID
 func DefaultRequestIDGenerator() string {
 	b := make([]byte, 16)
@@ -4,9 +4,11 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"strings"
 	"testing"
 
+	"github.com/Roshick/go-autumn-web/header"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace"
@@ -89,6 +91,7 @@ func TestDefaultRequestIDHeaderMiddlewareOptions(t *testing.T) {
 	require.NotNil(t, opts)
 	assert.NotEmpty(t, opts.HeaderName)
 	assert.NotNil(t, opts.GeneratorFn)
+	assert.Nil(t, opts.ValidatorFn)
 }
 
 func TestNewRequestIDHeaderMiddleware(t *testing.T) {
@@ -139,6 +142,97 @@ func TestNewRequestIDHeaderMiddleware(t *testing.T) {
 	})
 }
 
+func TestNewRequestIDHeaderMiddleware_TrustPolicy(t *testing.T) {
+	serve := func(opts *RequestIDHeaderMiddlewareOptions, remoteAddr string, requestID string) (string, string) {
+		var contextRequestID string
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := RequestIDFromContext(r.Context()); id != nil {
+				contextRequestID = *id
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(header.XRequestID, requestID)
+		rr := httptest.NewRecorder()
+
+		NewRequestIDHeaderMiddleware(opts)(testHandler).ServeHTTP(rr, req)
+		return rr.Header().Get(header.XRequestID), contextRequestID
+	}
+
+	validated := func() *RequestIDHeaderMiddlewareOptions {
+		opts := DefaultRequestIDHeaderMiddlewareOptions()
+		opts.ValidatorFn = NewRequestIDValidator(DefaultRequestIDMaxLength)
+		return opts
+	}
+
+	t.Run("rejects request IDs with invalid characters", func(t *testing.T) {
+		responseID, contextID := serve(validated(), "192.0.2.1:1234", "bad id\n<script>")
+
+		assert.NotEqual(t, "bad id\n<script>", responseID)
+		assert.True(t, IsUUIDRequestID(responseID))
+		assert.Equal(t, responseID, contextID)
+	})
+
+	t.Run("rejects request IDs exceeding the maximum length", func(t *testing.T) {
+		longID := strings.Repeat("a", DefaultRequestIDMaxLength+1)
+
+		responseID, _ := serve(validated(), "192.0.2.1:1234", longID)
+
+		assert.NotEqual(t, longID, responseID)
+	})
+
+	t.Run("UUID-only validation", func(t *testing.T) {
+		opts := DefaultRequestIDHeaderMiddlewareOptions()
+		opts.ValidatorFn = IsUUIDRequestID
+
+		responseID, _ := serve(opts, "192.0.2.1:1234", "not-a-uuid")
+		assert.NotEqual(t, "not-a-uuid", responseID)
+
+		uuid := "123e4567-e89b-42d3-a456-426614174000"
+		responseID, _ = serve(opts, "192.0.2.1:1234", uuid)
+		assert.Equal(t, uuid, responseID)
+	})
+
+	t.Run("nil validator accepts any request ID", func(t *testing.T) {
+		responseID, _ := serve(DefaultRequestIDHeaderMiddlewareOptions(), "192.0.2.1:1234", "any value at all")
+
+		assert.Equal(t, "any value at all", responseID)
+	})
+
+	t.Run("accepts request IDs from trusted proxies only", func(t *testing.T) {
+		opts := DefaultRequestIDHeaderMiddlewareOptions()
+		opts.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+		responseID, _ := serve(opts, "10.1.2.3:1234", "trusted-id")
+		assert.Equal(t, "trusted-id", responseID)
+
+		responseID, _ = serve(opts, "192.0.2.1:1234", "untrusted-id")
+		assert.NotEqual(t, "untrusted-id", responseID)
+		assert.NotEmpty(t, responseID)
+
+		responseID, _ = serve(opts, "10.1.2.3", "trusted-without-port")
+		assert.Equal(t, "trusted-without-port", responseID)
+	})
+}
+
+func TestNewRequestIDValidator(t *testing.T) {
+	validator := NewRequestIDValidator(10)
+
+	assert.True(t, validator("abc-123_.:"))
+	assert.False(t, validator("abc-123_.:x"))
+	assert.False(t, validator("abc 123"))
+	assert.True(t, NewRequestIDValidator(0)(strings.Repeat("a", 1000)))
+}
+
+func TestIsUUIDRequestID(t *testing.T) {
+	assert.True(t, IsUUIDRequestID(DefaultRequestIDGenerator()))
+	assert.True(t, IsUUIDRequestID("123E4567-E89B-42D3-A456-426614174000"))
+	assert.False(t, IsUUIDRequestID("123e4567e89b42d3a456426614174000"))
+	assert.False(t, IsUUIDRequestID("123e4567-e89b-42d3-a456-42661417400g"))
+}
+
 func TestDefaultRequestIDLoggerMiddlewareOptions(t *testing.T) {
 	opts := DefaultRequestIDLoggerMiddlewareOptions()
 	assert.NotEmpty(t, opts.LogFieldName)
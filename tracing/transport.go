@@ -1,15 +1,87 @@
 package tracing
 
 import (
+	"fmt"
 	"net/http"
 
+	slogging "github.com/Roshick/go-autumn-slog"
 	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// TracingLoggerTransport //
+
+type TracingLoggerTransportOptions struct {
+	LogFieldTraceID string
+	LogFieldSpanID  string
+}
+
+// TracingLoggerTransport adds the trace and span ID of the outgoing request to the context logger, so
+// log entries of inner transports, e.g. logging.RequestLoggerTransport, correlate with the entries of
+// the incoming request. Wrapped by an instrumented transport, e.g. otelhttp, the span ID is the one of
+// the client span.
+type TracingLoggerTransport struct {
+	base http.RoundTripper
+	opts *TracingLoggerTransportOptions
+}
+
+var _ http.RoundTripper = (*TracingLoggerTransport)(nil)
+
+func DefaultTracingLoggerTransportOptions() *TracingLoggerTransportOptions {
+	return &TracingLoggerTransportOptions{
+		LogFieldTraceID: logging.LogFieldTraceID,
+		LogFieldSpanID:  logging.LogFieldSpanID,
+	}
+}
+
+func NewTracingLoggerTransport(rt http.RoundTripper, opts *TracingLoggerTransportOptions) *TracingLoggerTransport {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = DefaultTracingLoggerTransportOptions()
+	}
+
+	return &TracingLoggerTransport{
+		base: rt,
+		opts: opts,
+	}
+}
+
+func (t *TracingLoggerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	logger := slogging.FromContext(ctx)
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if logger == nil || !spanCtx.HasTraceID() {
+		return t.base.RoundTrip(req)
+	}
+
+	logger = logger.With(t.opts.LogFieldTraceID, spanCtx.TraceID().String())
+	if spanCtx.HasSpanID() {
+		logger = logger.With(t.opts.LogFieldSpanID, spanCtx.SpanID().String())
+	}
+	return t.base.RoundTrip(req.WithContext(slogging.ContextWithLogger(ctx, logger)))
+}
+
+func (t *TracingLoggerTransport) Describe() (string, string) {
+	return "tracing", ""
+}
+
+func (t *TracingLoggerTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
+
 // RequestIDHeaderTransport
 
 type RequestIDHeaderTransportOptions struct {
 	HeaderName string
+	// ChildGeneratorFn, if set, generates a new request ID for every outgoing request. The inbound
+	// request ID is then sent in ParentHeaderName, and both are recorded in the request context.
+	ChildGeneratorFn func() string
+	// ParentHeaderName carries the inbound request ID if child IDs are generated. Empty omits it.
+	ParentHeaderName string
 }
 
 type RequestIDHeaderTransport struct {
@@ -21,7 +93,8 @@ var _ http.RoundTripper = (*RequestIDHeaderTransport)(nil)
 
 func DefaultRequestIDHeaderTransportOptions() *RequestIDHeaderTransportOptions {
 	return &RequestIDHeaderTransportOptions{
-		HeaderName: header.XRequestID,
+		HeaderName:       header.XRequestID,
+		ParentHeaderName: header.XParentRequestID,
 	}
 }
 
@@ -43,6 +116,20 @@ func (t *RequestIDHeaderTransport) RoundTrip(req *http.Request) (*http.Response,
 	ctx := req.Context()
 
 	requestID := RequestIDFromContext(ctx)
+	if t.opts.ChildGeneratorFn != nil {
+		childID := t.opts.ChildGeneratorFn()
+		ctx = ContextWithRequestID(ctx, childID)
+		if requestID != nil && *requestID != "" {
+			ctx = ContextWithParentRequestID(ctx, *requestID)
+		}
+
+		reqCopy := req.Clone(ctx)
+		reqCopy.Header.Set(t.opts.HeaderName, childID)
+		if requestID != nil && *requestID != "" && t.opts.ParentHeaderName != "" {
+			reqCopy.Header.Set(t.opts.ParentHeaderName, *requestID)
+		}
+		return t.base.RoundTrip(reqCopy)
+	}
 	if requestID != nil && *requestID != "" {
 		// Clone the request to avoid modifying the original
 		reqCopy := req.Clone(req.Context())
@@ -52,3 +139,11 @@ func (t *RequestIDHeaderTransport) RoundTrip(req *http.Request) (*http.Response,
 
 	return t.base.RoundTrip(req)
 }
+
+func (t *RequestIDHeaderTransport) Describe() (string, string) {
+	return "requestid", fmt.Sprintf("header=%s", t.opts.HeaderName)
+}
+
+func (t *RequestIDHeaderTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
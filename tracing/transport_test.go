@@ -1,13 +1,19 @@
 package tracing
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	slogging "github.com/Roshick/go-autumn-slog"
+	"github.com/Roshick/go-autumn-web/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MockRoundTripper is a test double for http.RoundTripper
@@ -250,6 +256,50 @@ func TestRequestIDHeaderTransport_RoundTrip(t *testing.T) {
 	})
 }
 
+func TestRequestIDHeaderTransport_ChildRequestID(t *testing.T) {
+	newOpts := func() *RequestIDHeaderTransportOptions {
+		opts := DefaultRequestIDHeaderTransportOptions()
+		opts.ChildGeneratorFn = func() string { return "child-id" }
+		return opts
+	}
+
+	t.Run("sends child ID and preserves inbound ID", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewRequestIDHeaderTransport(mockRT, newOpts())
+
+		ctx := ContextWithRequestID(context.Background(), "parent-id")
+		req := httptest.NewRequest(http.MethodGet, "https://api.localhost/data", nil).WithContext(ctx)
+
+		_, err := transport.RoundTrip(req)
+
+		require.NoError(t, err)
+		require.NotNil(t, mockRT.capturedRequest)
+		assert.Equal(t, "child-id", mockRT.capturedRequest.Header.Get("X-Request-ID"))
+		assert.Equal(t, "parent-id", mockRT.capturedRequest.Header.Get("X-Parent-Request-ID"))
+
+		downstreamCtx := mockRT.capturedRequest.Context()
+		require.NotNil(t, RequestIDFromContext(downstreamCtx))
+		assert.Equal(t, "child-id", *RequestIDFromContext(downstreamCtx))
+		require.NotNil(t, ParentRequestIDFromContext(downstreamCtx))
+		assert.Equal(t, "parent-id", *ParentRequestIDFromContext(downstreamCtx))
+
+		assert.Equal(t, "parent-id", *RequestIDFromContext(req.Context()))
+		assert.Empty(t, req.Header.Get("X-Request-ID"))
+	})
+
+	t.Run("sends child ID without inbound ID", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		transport := NewRequestIDHeaderTransport(mockRT, newOpts())
+
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.localhost/data", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "child-id", mockRT.capturedRequest.Header.Get("X-Request-ID"))
+		assert.Empty(t, mockRT.capturedRequest.Header.Get("X-Parent-Request-ID"))
+		assert.Nil(t, ParentRequestIDFromContext(mockRT.capturedRequest.Context()))
+	})
+}
+
 func TestRequestIDHeaderTransport_ImplementsRoundTripper(t *testing.T) {
 	transport := NewRequestIDHeaderTransport(nil, nil)
 
@@ -257,3 +307,64 @@ func TestRequestIDHeaderTransport_ImplementsRoundTripper(t *testing.T) {
 	var _ http.RoundTripper = transport
 	assert.Implements(t, (*http.RoundTripper)(nil), transport)
 }
+
+func TestNewTracingLoggerTransport(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("12345678901234567890123456789012")
+	spanID, _ := trace.SpanIDFromHex("1234567890123456")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	})
+
+	logFields := func(req *http.Request) map[string]any {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		req = req.WithContext(slogging.ContextWithLogger(req.Context(), logger))
+
+		mockRT := &MockRoundTripper{}
+		_, err := NewTracingLoggerTransport(mockRT, nil).RoundTrip(req)
+		require.NoError(t, err)
+
+		slogging.FromContext(mockRT.capturedRequest.Context()).Info("request")
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+		return fields
+	}
+
+	t.Run("with nil round tripper and options uses defaults", func(t *testing.T) {
+		transport := NewTracingLoggerTransport(nil, nil)
+
+		assert.Equal(t, http.DefaultTransport, transport.base)
+		assert.Equal(t, DefaultTracingLoggerTransportOptions(), transport.opts)
+	})
+
+	t.Run("adds trace and span ID to the context logger", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/users", nil).WithContext(ctx)
+
+		fields := logFields(req)
+
+		assert.Equal(t, traceID.String(), fields[logging.LogFieldTraceID])
+		assert.Equal(t, spanID.String(), fields[logging.LogFieldSpanID])
+	})
+
+	t.Run("keeps the logger without span context", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/users", nil)
+
+		fields := logFields(req)
+
+		assert.NotContains(t, fields, logging.LogFieldTraceID)
+		assert.NotContains(t, fields, logging.LogFieldSpanID)
+	})
+
+	t.Run("passes requests without context logger", func(t *testing.T) {
+		mockRT := &MockRoundTripper{}
+		ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/users", nil).WithContext(ctx)
+
+		_, err := NewTracingLoggerTransport(mockRT, nil).RoundTrip(req)
+
+		require.NoError(t, err)
+		assert.Same(t, req, mockRT.capturedRequest)
+	})
+}
@@ -0,0 +1,190 @@
+package transportconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/internal/instruments"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+)
+
+// Resolver resolves host names to addresses. *net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type DialContextFn func(ctx context.Context, network string, address string) (net.Conn, error)
+
+type CachingDialerOptions struct {
+	Resolver Resolver
+	// DialContextFn dials the resolved addresses.
+	DialContextFn DialContextFn
+	// TTL is the time successful lookups are cached for.
+	TTL time.Duration
+	// NegativeTTL is the time failed lookups are cached for. Zero disables negative caching.
+	NegativeTTL time.Duration
+}
+
+func DefaultCachingDialerOptions() *CachingDialerOptions {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return &CachingDialerOptions{
+		Resolver:      net.DefaultResolver,
+		DialContextFn: dialer.DialContext,
+		TTL:           30 * time.Second,
+		NegativeTTL:   5 * time.Second,
+	}
+}
+
+// CachingDialer caches DNS lookups in front of a dialer. Use its DialContext as
+// TransportOptions.DialContextFn or http.Transport.DialContext.
+type CachingDialer struct {
+	opts *CachingDialerOptions
+
+	m       sync.Mutex
+	entries map[string]dnsCacheEntry
+	group   singleflight.Group
+
+	lookupDuration metric.Float64Histogram
+	cacheRequests  metric.Int64Counter
+}
+
+type dnsCacheEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+func NewCachingDialer(opts *CachingDialerOptions) *CachingDialer {
+	if opts == nil {
+		opts = DefaultCachingDialerOptions()
+	}
+
+	d := &CachingDialer{
+		opts:    opts,
+		entries: make(map[string]dnsCacheEntry),
+	}
+	d.init()
+	return d
+}
+
+func (d *CachingDialer) init() {
+	provider := otel.GetMeterProvider()
+
+	d.lookupDuration, _ = instruments.Float64Histogram(provider, "dns.cache",
+		"dns.lookup.duration",
+		metric.WithDescription("Duration of DNS lookups in seconds by result"),
+		metric.WithUnit("s"),
+	)
+	d.cacheRequests, _ = instruments.Int64Counter(provider, "dns.cache",
+		"dns.cache.requests.total",
+		metric.WithDescription("Total number of DNS cache requests by result (hit, negative_hit or miss)"),
+	)
+}
+
+func (d *CachingDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.opts.DialContextFn(ctx, network, address)
+	}
+
+	addrs, err := d.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialErr error
+	for _, addr := range addrs {
+		conn, err := d.opts.DialContextFn(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = errors.Join(dialErr, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, dialErr
+}
+
+func (d *CachingDialer) lookupHost(ctx context.Context, host string) ([]string, error) {
+	d.m.Lock()
+	entry, ok := d.entries[host]
+	if ok && time.Now().After(entry.expires) {
+		delete(d.entries, host)
+		ok = false
+	}
+	d.m.Unlock()
+
+	if ok {
+		result := "hit"
+		if entry.err != nil {
+			result = "negative_hit"
+		}
+		d.cacheRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("dns.cache.result", result)))
+		return entry.addrs, entry.err
+	}
+	d.cacheRequests.Add(ctx, 1, metric.WithAttributes(attribute.String("dns.cache.result", "miss")))
+
+	ch := d.group.DoChan(host, func() (any, error) {
+		// Detached from the caller, so that one cancelled dial does not fail all waiting callers.
+		lookupCtx := context.WithoutCancel(ctx)
+		start := time.Now()
+		addrs, err := d.opts.Resolver.LookupHost(lookupCtx, host)
+
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		d.lookupDuration.Record(lookupCtx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("dns.lookup.result", result)))
+
+		d.store(host, addrs, err)
+		return addrs, err
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]string), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (d *CachingDialer) store(host string, addrs []string, err error) {
+	ttl := d.opts.TTL
+	if err != nil {
+		// Cancellations and timeouts say nothing about the host and are not cached.
+		if d.opts.NegativeTTL <= 0 || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		ttl = d.opts.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	now := time.Now()
+	for key, entry := range d.entries {
+		if now.After(entry.expires) {
+			delete(d.entries, key)
+		}
+	}
+	d.entries[host] = dnsCacheEntry{addrs: addrs, err: err, expires: now.Add(ttl)}
+}
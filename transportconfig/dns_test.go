@@ -0,0 +1,176 @@
+package transportconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockResolver struct {
+	calls atomic.Int32
+	addrs []string
+	err   error
+	delay time.Duration
+}
+
+func (r *MockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.calls.Add(1)
+	if r.delay > 0 {
+		time.Sleep(r.delay)
+	}
+	return r.addrs, r.err
+}
+
+type MockDialer struct {
+	m        sync.Mutex
+	dialed   []string
+	failAddr string
+}
+
+func (d *MockDialer) DialContext(_ context.Context, _ string, address string) (net.Conn, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.dialed = append(d.dialed, address)
+	if address == d.failAddr {
+		return nil, errors.New("connection refused")
+	}
+	client, server := net.Pipe()
+	_ = server.Close()
+	return client, nil
+}
+
+func newTestCachingDialer(resolver *MockResolver, dialer *MockDialer) *CachingDialer {
+	opts := DefaultCachingDialerOptions()
+	opts.Resolver = resolver
+	opts.DialContextFn = dialer.DialContext
+	return NewCachingDialer(opts)
+}
+
+func TestDefaultCachingDialerOptions(t *testing.T) {
+	opts := DefaultCachingDialerOptions()
+
+	require.NotNil(t, opts)
+	assert.NotNil(t, opts.Resolver)
+	assert.NotNil(t, opts.DialContextFn)
+	assert.Equal(t, 30*time.Second, opts.TTL)
+	assert.Equal(t, 5*time.Second, opts.NegativeTTL)
+}
+
+func TestCachingDialer_DialContext(t *testing.T) {
+	t.Run("caches successful lookups", func(t *testing.T) {
+		resolver := &MockResolver{addrs: []string{"10.0.0.1"}}
+		dialer := &MockDialer{}
+		d := newTestCachingDialer(resolver, dialer)
+
+		for range 3 {
+			conn, err := d.DialContext(context.Background(), "tcp", "example.com:443")
+			require.NoError(t, err)
+			_ = conn.Close()
+		}
+
+		assert.Equal(t, int32(1), resolver.calls.Load())
+		assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.1:443", "10.0.0.1:443"}, dialer.dialed)
+	})
+
+	t.Run("caches failed lookups", func(t *testing.T) {
+		resolver := &MockResolver{err: &net.DNSError{Err: "no such host", Name: "missing.example.com", IsNotFound: true}}
+		d := newTestCachingDialer(resolver, &MockDialer{})
+
+		for range 2 {
+			_, err := d.DialContext(context.Background(), "tcp", "missing.example.com:443")
+			assert.Error(t, err)
+		}
+
+		assert.Equal(t, int32(1), resolver.calls.Load())
+	})
+
+	t.Run("disabled negative caching", func(t *testing.T) {
+		resolver := &MockResolver{err: errors.New("lookup failed")}
+		opts := DefaultCachingDialerOptions()
+		opts.Resolver = resolver
+		opts.DialContextFn = (&MockDialer{}).DialContext
+		opts.NegativeTTL = 0
+		d := NewCachingDialer(opts)
+
+		for range 2 {
+			_, err := d.DialContext(context.Background(), "tcp", "missing.example.com:443")
+			assert.Error(t, err)
+		}
+
+		assert.Equal(t, int32(2), resolver.calls.Load())
+	})
+
+	t.Run("expired entries are resolved again", func(t *testing.T) {
+		resolver := &MockResolver{addrs: []string{"10.0.0.1"}}
+		opts := DefaultCachingDialerOptions()
+		opts.Resolver = resolver
+		opts.DialContextFn = (&MockDialer{}).DialContext
+		opts.TTL = 10 * time.Millisecond
+		d := NewCachingDialer(opts)
+
+		_, err := d.DialContext(context.Background(), "tcp", "example.com:443")
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = d.DialContext(context.Background(), "tcp", "example.com:443")
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(2), resolver.calls.Load())
+	})
+
+	t.Run("tries next address if dial fails", func(t *testing.T) {
+		resolver := &MockResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+		dialer := &MockDialer{failAddr: "10.0.0.1:80"}
+		d := newTestCachingDialer(resolver, dialer)
+
+		conn, err := d.DialContext(context.Background(), "tcp", "example.com:80")
+		require.NoError(t, err)
+		_ = conn.Close()
+
+		assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, dialer.dialed)
+	})
+
+	t.Run("dials IP addresses directly", func(t *testing.T) {
+		resolver := &MockResolver{}
+		dialer := &MockDialer{}
+		d := newTestCachingDialer(resolver, dialer)
+
+		conn, err := d.DialContext(context.Background(), "tcp", "127.0.0.1:8080")
+		require.NoError(t, err)
+		_ = conn.Close()
+
+		assert.Equal(t, int32(0), resolver.calls.Load())
+		assert.Equal(t, []string{"127.0.0.1:8080"}, dialer.dialed)
+	})
+
+	t.Run("deduplicates concurrent lookups", func(t *testing.T) {
+		resolver := &MockResolver{addrs: []string{"10.0.0.1"}, delay: 20 * time.Millisecond}
+		d := newTestCachingDialer(resolver, &MockDialer{})
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				conn, err := d.DialContext(context.Background(), "tcp", "example.com:443")
+				if assert.NoError(t, err) {
+					_ = conn.Close()
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), resolver.calls.Load())
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		d := newTestCachingDialer(&MockResolver{}, &MockDialer{})
+
+		_, err := d.DialContext(context.Background(), "tcp", "example.com")
+		assert.Error(t, err)
+	})
+}
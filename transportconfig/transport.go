@@ -0,0 +1,141 @@
+package transportconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// TransportOptions configures the *http.Transport built by NewTransport. Fields tagged with env can
+// be overridden from the environment, see ObtainValuesFromEnv.
+type TransportOptions struct {
+	// ProxyURL routes all requests through the given proxy. If empty, the proxy is taken from the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyURL string `env:"HTTP_CLIENT_PROXY_URL"`
+	// DisableProxy ignores any proxy configuration.
+	DisableProxy bool `env:"HTTP_CLIENT_DISABLE_PROXY"`
+	// SocketURL connects to a unix domain socket, e.g. unix:///var/run/sidecar.sock, instead of the
+	// hosts of the requests. Requests keep their host names, so metrics and logs record logical hosts.
+	// Proxies are ignored then.
+	SocketURL string `env:"HTTP_CLIENT_SOCKET_URL"`
+
+	DialTimeout           time.Duration `env:"HTTP_CLIENT_DIAL_TIMEOUT"`
+	KeepAlive             time.Duration `env:"HTTP_CLIENT_KEEP_ALIVE"`
+	TLSHandshakeTimeout   time.Duration `env:"HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT"`
+	ResponseHeaderTimeout time.Duration `env:"HTTP_CLIENT_RESPONSE_HEADER_TIMEOUT"`
+	ExpectContinueTimeout time.Duration `env:"HTTP_CLIENT_EXPECT_CONTINUE_TIMEOUT"`
+	IdleConnTimeout       time.Duration `env:"HTTP_CLIENT_IDLE_CONN_TIMEOUT"`
+
+	MaxIdleConns        int `env:"HTTP_CLIENT_MAX_IDLE_CONNS"`
+	MaxIdleConnsPerHost int `env:"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST"`
+	// MaxConnsPerHost limits dialing, active and idle connections per host. Zero means no limit.
+	MaxConnsPerHost int `env:"HTTP_CLIENT_MAX_CONNS_PER_HOST"`
+
+	EnableHTTP2 bool `env:"HTTP_CLIENT_ENABLE_HTTP2"`
+
+	// DialContextFn replaces the dialer configured by DialTimeout and KeepAlive, e.g. with
+	// the DialContext of a CachingDialer.
+	DialContextFn DialContextFn
+
+	// TLSConfig is cloned into the transport if set.
+	TLSConfig *tls.Config
+}
+
+func DefaultTransportOptions() *TransportOptions {
+	return &TransportOptions{
+		DialTimeout:           30 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		EnableHTTP2:           true,
+	}
+}
+
+// ObtainValuesFromEnv overrides the options with the values of all set environment variables.
+func (o *TransportOptions) ObtainValuesFromEnv() error {
+	return env.Parse(o)
+}
+
+func NewTransport(opts *TransportOptions) (*http.Transport, error) {
+	if opts == nil {
+		opts = DefaultTransportOptions()
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if opts.DisableProxy || opts.SocketURL != "" {
+		proxy = nil
+	} else if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialContextFn := opts.DialContextFn
+	if dialContextFn == nil {
+		dialer := &net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: opts.KeepAlive,
+		}
+		dialContextFn = dialer.DialContext
+	}
+	if opts.SocketURL != "" {
+		socketPath, err := unixSocketPath(opts.SocketURL)
+		if err != nil {
+			return nil, err
+		}
+		dialContextFn = NewUnixSocketDialContextFn(socketPath, dialContextFn)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialContextFn,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: opts.ExpectContinueTimeout,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		ForceAttemptHTTP2:     opts.EnableHTTP2,
+	}
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig.Clone()
+	}
+	if !opts.EnableHTTP2 {
+		// A non-nil empty map disables the automatic HTTP/2 upgrade for TLS connections.
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport, nil
+}
+
+// NewUnixSocketDialContextFn dials the unix domain socket with the given dial function, regardless of
+// the address to dial.
+func NewUnixSocketDialContextFn(socketPath string, dialContextFn DialContextFn) DialContextFn {
+	return func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+		return dialContextFn(ctx, "unix", socketPath)
+	}
+}
+
+// unixSocketPath returns the socket path of unix:///path/to.sock or unix://@abstract URLs
+func unixSocketPath(socketURL string) (string, error) {
+	socketPath, ok := strings.CutPrefix(socketURL, "unix://")
+	if !ok {
+		return "", fmt.Errorf("invalid socket URL %s: scheme must be unix", socketURL)
+	}
+	if socketPath == "" {
+		return "", fmt.Errorf("invalid socket URL %s: missing socket path", socketURL)
+	}
+	return socketPath, nil
+}
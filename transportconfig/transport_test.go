@@ -0,0 +1,184 @@
+package transportconfig
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTransportOptions(t *testing.T) {
+	opts := DefaultTransportOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 30*time.Second, opts.DialTimeout)
+	assert.Equal(t, 100, opts.MaxIdleConns)
+	assert.Equal(t, 10, opts.MaxIdleConnsPerHost)
+	assert.True(t, opts.EnableHTTP2)
+}
+
+func TestTransportOptions_ObtainValuesFromEnv(t *testing.T) {
+	t.Run("overrides set values only", func(t *testing.T) {
+		t.Setenv("HTTP_CLIENT_PROXY_URL", "http://proxy.example.com:3128")
+		t.Setenv("HTTP_CLIENT_DIAL_TIMEOUT", "5s")
+		t.Setenv("HTTP_CLIENT_MAX_CONNS_PER_HOST", "25")
+		t.Setenv("HTTP_CLIENT_ENABLE_HTTP2", "false")
+
+		opts := DefaultTransportOptions()
+		require.NoError(t, opts.ObtainValuesFromEnv())
+
+		assert.Equal(t, "http://proxy.example.com:3128", opts.ProxyURL)
+		assert.Equal(t, 5*time.Second, opts.DialTimeout)
+		assert.Equal(t, 25, opts.MaxConnsPerHost)
+		assert.False(t, opts.EnableHTTP2)
+		assert.Equal(t, 100, opts.MaxIdleConns)
+		assert.Equal(t, 90*time.Second, opts.IdleConnTimeout)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("HTTP_CLIENT_DIAL_TIMEOUT", "soon")
+
+		opts := DefaultTransportOptions()
+		assert.Error(t, opts.ObtainValuesFromEnv())
+	})
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Run("with nil options uses defaults", func(t *testing.T) {
+		transport, err := NewTransport(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 100, transport.MaxIdleConns)
+		assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+		assert.True(t, transport.ForceAttemptHTTP2)
+		assert.Nil(t, transport.TLSNextProto)
+		assert.NotNil(t, transport.Proxy)
+	})
+
+	t.Run("explicit proxy", func(t *testing.T) {
+		opts := DefaultTransportOptions()
+		opts.ProxyURL = "http://proxy.example.com:3128"
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+
+		proxyURL, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "example.com"}})
+		require.NoError(t, err)
+		assert.Equal(t, "proxy.example.com:3128", proxyURL.Host)
+	})
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		opts := DefaultTransportOptions()
+		opts.ProxyURL = "://invalid"
+
+		_, err := NewTransport(opts)
+		assert.Error(t, err)
+	})
+
+	t.Run("disabled proxy", func(t *testing.T) {
+		opts := DefaultTransportOptions()
+		opts.ProxyURL = "http://proxy.example.com:3128"
+		opts.DisableProxy = true
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+		assert.Nil(t, transport.Proxy)
+	})
+
+	t.Run("disabled HTTP/2", func(t *testing.T) {
+		opts := DefaultTransportOptions()
+		opts.EnableHTTP2 = false
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+		assert.False(t, transport.ForceAttemptHTTP2)
+		assert.NotNil(t, transport.TLSNextProto)
+		assert.Empty(t, transport.TLSNextProto)
+	})
+
+	t.Run("custom dialer", func(t *testing.T) {
+		dialer := NewCachingDialer(nil)
+		opts := DefaultTransportOptions()
+		opts.DialContextFn = dialer.DialContext
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+		assert.NotNil(t, transport.DialContext)
+	})
+
+	t.Run("clones TLS config", func(t *testing.T) {
+		opts := DefaultTransportOptions()
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS13}
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+		require.NotNil(t, transport.TLSClientConfig)
+		assert.NotSame(t, opts.TLSConfig, transport.TLSClientConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+	})
+
+	t.Run("unix socket", func(t *testing.T) {
+		socketDir, err := os.MkdirTemp("", "sock")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = os.RemoveAll(socketDir)
+		})
+		socketPath := filepath.Join(socketDir, "sidecar.sock")
+		listener, err := net.Listen("unix", socketPath)
+		require.NoError(t, err)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Host))
+		})}
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		t.Cleanup(func() {
+			_ = server.Close()
+		})
+
+		opts := DefaultTransportOptions()
+		opts.ProxyURL = "http://proxy.example.com:3128"
+		opts.SocketURL = "unix://" + socketPath
+
+		transport, err := NewTransport(opts)
+		require.NoError(t, err)
+		assert.Nil(t, transport.Proxy)
+
+		res, err := (&http.Client{Transport: transport}).Get("http://users-api/users")
+		require.NoError(t, err)
+		defer func() {
+			_ = res.Body.Close()
+		}()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "users-api", string(body))
+	})
+
+	t.Run("invalid socket URL", func(t *testing.T) {
+		for _, socketURL := range []string{"tcp://localhost:80", "unix://", "://invalid"} {
+			opts := DefaultTransportOptions()
+			opts.SocketURL = socketURL
+
+			_, err := NewTransport(opts)
+			assert.Error(t, err, socketURL)
+		}
+	})
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	socketPath, err := unixSocketPath("unix:///var/run/sidecar.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "/var/run/sidecar.sock", socketPath)
+
+	socketPath, err = unixSocketPath("unix://@sidecar")
+	require.NoError(t, err)
+	assert.Equal(t, "@sidecar", socketPath)
+}
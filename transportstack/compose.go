@@ -0,0 +1,206 @@
+package transportstack
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Wrapper wraps a round tripper, e.g. a transport constructor of this module bound to its options
+type Wrapper func(next http.RoundTripper) http.RoundTripper
+
+// OrderingRule requires the layer named Outer to wrap the layer named Inner if a stack contains both
+type OrderingRule struct {
+	Outer  string
+	Inner  string
+	Reason string
+}
+
+func (r OrderingRule) String() string {
+	return fmt.Sprintf("%s has to wrap %s: %s", r.Outer, r.Inner, r.Reason)
+}
+
+// DefaultOrderingRules returns the rules for the transports of this module
+func DefaultOrderingRules() []OrderingRule {
+	return []OrderingRule{
+		{
+			Outer:  "retry",
+			Inner:  "metrics",
+			Reason: "metrics outside of retry record all attempts as a single request, which the retry metrics count again",
+		},
+		{
+			Outer:  "breaker",
+			Inner:  "retry",
+			Reason: "a breaker inside of retry is consulted by every attempt, so retries keep hitting an open breaker instead of failing fast",
+		},
+		{
+			Outer:  "tracing",
+			Inner:  "logging",
+			Reason: "the trace and span IDs are only added to the log entries of transports inside of tracing",
+		},
+		{
+			Outer:  "logging",
+			Inner:  "basicauth",
+			Reason: "auth outside of logging adds the credentials before the request is logged",
+		},
+		{
+			Outer:  "logging",
+			Inner:  "digestauth",
+			Reason: "auth outside of logging adds the credentials before the request is logged",
+		},
+	}
+}
+
+type ComposeOptions struct {
+	Rules []OrderingRule
+	// Reorder moves wrappers to satisfy the rules instead of failing. The wrappers are called again
+	// then, so they must not have side effects besides creating the transport.
+	Reorder bool
+}
+
+func DefaultComposeOptions() *ComposeOptions {
+	return &ComposeOptions{
+		Rules:   DefaultOrderingRules(),
+		Reorder: false,
+	}
+}
+
+// OrderingError is returned by Compose for stacks violating ordering rules
+type OrderingError struct {
+	Stack      string
+	Violations []OrderingRule
+}
+
+func (e *OrderingError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		violations = append(violations, violation.String())
+	}
+	return fmt.Sprintf("misordered transport stack %s: %s", e.Stack, strings.Join(violations, "; "))
+}
+
+// Compose wraps the base round tripper with the wrappers, the first one being the outermost, and
+// validates the resulting stack against the ordering rules. Violations either result in an
+// *OrderingError or, with Reorder enabled, in the wrappers being reordered.
+func Compose(base http.RoundTripper, opts *ComposeOptions, wrappers ...Wrapper) (http.RoundTripper, error) {
+	if opts == nil {
+		opts = DefaultComposeOptions()
+	}
+
+	rt, layerNames := compose(base, wrappers)
+	violations := validate(slices.Concat(layerNames...), opts.Rules)
+	if len(violations) == 0 {
+		return rt, nil
+	}
+	if !opts.Reorder {
+		return nil, &OrderingError{Stack: String(rt), Violations: violations}
+	}
+
+	order, ok := reorder(layerNames, opts.Rules)
+	if !ok {
+		return nil, &OrderingError{Stack: String(rt), Violations: violations}
+	}
+	reordered := make([]Wrapper, 0, len(wrappers))
+	for _, index := range order {
+		reordered = append(reordered, wrappers[index])
+	}
+	rt, layerNames = compose(base, reordered)
+	if violations = validate(slices.Concat(layerNames...), opts.Rules); len(violations) > 0 {
+		return nil, &OrderingError{Stack: String(rt), Violations: violations}
+	}
+	return rt, nil
+}
+
+// compose applies the wrappers and returns the names of the layers each of them added, followed by
+// those of the base round tripper
+func compose(base http.RoundTripper, wrappers []Wrapper) (http.RoundTripper, [][]string) {
+	rt := base
+	previous := Describe(rt)
+	layerNames := make([][]string, len(wrappers)+1)
+	layerNames[len(wrappers)] = layerNamesOf(previous)
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		rt = wrappers[i](rt)
+		layers := Describe(rt)
+		// wrappers hiding the round tripper they wrap count as a single layer
+		added := max(len(layers)-len(previous), min(len(layers), 1))
+		layerNames[i] = layerNamesOf(layers[:added])
+		previous = layers
+	}
+	return rt, layerNames
+}
+
+func layerNamesOf(layers []Layer) []string {
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+// validate returns the rules violated by the layer names, outermost first
+func validate(names []string, rules []OrderingRule) []OrderingRule {
+	var violations []OrderingRule
+	for _, rule := range rules {
+		outermostInner := slices.Index(names, rule.Inner)
+		innermostOuter := lastIndex(names, rule.Outer)
+		if outermostInner < 0 || innermostOuter < 0 {
+			continue
+		}
+		if outermostInner < innermostOuter {
+			violations = append(violations, rule)
+		}
+	}
+	return violations
+}
+
+// reorder returns the order of the wrappers satisfying the rules that is closest to the given one. The
+// last entry of layerNames belongs to the base round tripper, which cannot be moved.
+func reorder(layerNames [][]string, rules []OrderingRule) ([]int, bool) {
+	wrapperCount := len(layerNames) - 1
+	mustPrecede := func(outer int, inner int) bool {
+		for _, rule := range rules {
+			if slices.Contains(layerNames[outer], rule.Outer) && slices.Contains(layerNames[inner], rule.Inner) {
+				return true
+			}
+		}
+		return false
+	}
+
+	order := make([]int, 0, wrapperCount)
+	placed := make([]bool, wrapperCount)
+	for len(order) < wrapperCount {
+		next := -1
+		for candidate := range wrapperCount {
+			if !placed[candidate] && !isPreceded(candidate, placed, mustPrecede) {
+				next = candidate
+				break
+			}
+		}
+		if next < 0 {
+			return nil, false
+		}
+		order = append(order, next)
+		placed[next] = true
+	}
+	return order, true
+}
+
+// isPreceded checks if an unplaced wrapper has to precede the candidate
+func isPreceded(candidate int, placed []bool, mustPrecede func(outer int, inner int) bool) bool {
+	for other, isPlaced := range placed {
+		if other != candidate && !isPlaced && mustPrecede(other, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastIndex(names []string, name string) int {
+	for i := len(names) - 1; i >= 0; i-- {
+		if names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package transportstack
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/auth"
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestIDWrapper(next http.RoundTripper) http.RoundTripper {
+	return tracing.NewRequestIDHeaderTransport(next, nil)
+}
+
+func loggingWrapper(next http.RoundTripper) http.RoundTripper {
+	return logging.NewRequestLoggerTransport(next, nil)
+}
+
+func metricsWrapper(next http.RoundTripper) http.RoundTripper {
+	return metrics.NewRequestMetricsTransport(next, "users", nil)
+}
+
+func retryWrapper(next http.RoundTripper) http.RoundTripper {
+	return resiliency.NewRetryTransport(next, nil)
+}
+
+func breakerWrapper(next http.RoundTripper) http.RoundTripper {
+	return resiliency.NewCircuitBreakerTransport(next, nil)
+}
+
+func basicAuthWrapper(next http.RoundTripper) http.RoundTripper {
+	return auth.NewBasicAuthTransport(next, "user", "secret", nil)
+}
+
+func TestCompose(t *testing.T) {
+	t.Run("composes valid stacks in the given order", func(t *testing.T) {
+		rt, err := Compose(&http.Transport{}, nil,
+			requestIDWrapper, loggingWrapper, basicAuthWrapper, breakerWrapper, retryWrapper, metricsWrapper)
+
+		require.NoError(t, err)
+		assert.Equal(t, "requestid→logging→basicauth→breaker→retry→metrics→base", String(rt))
+	})
+
+	t.Run("returns descriptive errors for misordered stacks", func(t *testing.T) {
+		rt, err := Compose(&http.Transport{}, nil,
+			requestIDWrapper, basicAuthWrapper, loggingWrapper, metricsWrapper, retryWrapper, breakerWrapper)
+
+		assert.Nil(t, rt)
+		var orderingErr *OrderingError
+		require.True(t, errors.As(err, &orderingErr))
+		assert.Equal(t, "requestid→basicauth→logging→metrics→retry→breaker→base", orderingErr.Stack)
+		assert.Equal(t, []string{"retry", "breaker", "logging"}, outersOf(orderingErr.Violations))
+		assert.Contains(t, err.Error(), "misordered transport stack requestid→basicauth→logging→metrics→retry→breaker→base")
+		assert.Contains(t, err.Error(), "breaker has to wrap retry")
+	})
+
+	t.Run("reorders misordered stacks", func(t *testing.T) {
+		opts := DefaultComposeOptions()
+		opts.Reorder = true
+
+		rt, err := Compose(&http.Transport{}, opts,
+			requestIDWrapper, basicAuthWrapper, loggingWrapper, metricsWrapper, retryWrapper, breakerWrapper)
+
+		require.NoError(t, err)
+		assert.Equal(t, "requestid→logging→basicauth→breaker→retry→metrics→base", String(rt))
+	})
+
+	t.Run("fails for rules that cannot be satisfied", func(t *testing.T) {
+		opts := DefaultComposeOptions()
+		opts.Rules = append(opts.Rules, OrderingRule{Outer: "metrics", Inner: "retry", Reason: "contradiction"})
+		opts.Reorder = true
+
+		_, err := Compose(&http.Transport{}, opts, metricsWrapper, retryWrapper)
+
+		var orderingErr *OrderingError
+		assert.True(t, errors.As(err, &orderingErr))
+	})
+
+	t.Run("validates layers hidden by unknown wrappers", func(t *testing.T) {
+		opaqueWrapper := func(next http.RoundTripper) http.RoundTripper {
+			return &opaqueTransport{base: next}
+		}
+
+		_, err := Compose(http.DefaultTransport, nil, metricsWrapper, opaqueWrapper, retryWrapper)
+
+		var orderingErr *OrderingError
+		require.True(t, errors.As(err, &orderingErr))
+		assert.Equal(t, "metrics→*transportstack.opaqueTransport", orderingErr.Stack)
+	})
+
+	t.Run("without wrappers", func(t *testing.T) {
+		rt, err := Compose(http.DefaultTransport, nil)
+
+		require.NoError(t, err)
+		assert.Same(t, http.DefaultTransport, rt)
+	})
+}
+
+func outersOf(rules []OrderingRule) []string {
+	outers := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		outers = append(outers, rule.Outer)
+	}
+	return outers
+}
@@ -0,0 +1,109 @@
+package transportstack
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Describer is implemented by transports describing themselves, e.g. all transports of this module.
+// Implementing it requires no dependency on this package.
+type Describer interface {
+	// Describe returns a short name and a summary of the configuration of the transport.
+	Describe() (name string, summary string)
+}
+
+// Unwrapper is implemented by transports wrapping another round tripper.
+type Unwrapper interface {
+	// Unwrap returns the wrapped round tripper.
+	Unwrap() http.RoundTripper
+}
+
+// Layer describes a single round tripper of a transport stack
+type Layer struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary,omitempty"`
+}
+
+func (l Layer) String() string {
+	if l.Summary == "" {
+		return l.Name
+	}
+	return fmt.Sprintf("%s(%s)", l.Name, l.Summary)
+}
+
+// DescribeFn describes round trippers that cannot implement Describer and Unwrapper themselves, e.g.
+// those of other libraries. Next is nil for round trippers not wrapping another one.
+type DescribeFn func(rt http.RoundTripper) (layer Layer, next http.RoundTripper)
+
+var (
+	describeFnsMu sync.RWMutex
+	describeFns   = map[reflect.Type]DescribeFn{}
+)
+
+// Register describes round trippers of type T with the function. It is meant to be called during
+// application startup.
+func Register[T http.RoundTripper](fn func(rt T) (layer Layer, next http.RoundTripper)) {
+	describeFnsMu.Lock()
+	defer describeFnsMu.Unlock()
+	describeFns[reflect.TypeFor[T]()] = func(rt http.RoundTripper) (Layer, http.RoundTripper) {
+		return fn(rt.(T))
+	}
+}
+
+func init() {
+	Register(func(rt *http.Transport) (Layer, http.RoundTripper) {
+		summary := ""
+		if rt == http.DefaultTransport {
+			summary = "default"
+		}
+		return Layer{Name: "base", Summary: summary}, nil
+	})
+}
+
+// maxDepth guards against cyclic stacks
+const maxDepth = 64
+
+// Describe returns the layers of the transport stack, outermost first. Round trippers that neither
+// describe themselves nor are registered are listed with their type name and end the chain, unless
+// they implement Unwrapper.
+func Describe(rt http.RoundTripper) []Layer {
+	var layers []Layer
+	for rt != nil && len(layers) < maxDepth {
+		layer, next := describe(rt)
+		layers = append(layers, layer)
+		rt = next
+	}
+	return layers
+}
+
+// String formats the layers of the transport stack, e.g. "requestid→metrics→retry→breaker→base".
+func String(rt http.RoundTripper) string {
+	layers := Describe(rt)
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	return strings.Join(names, "→")
+}
+
+func describe(rt http.RoundTripper) (Layer, http.RoundTripper) {
+	describeFnsMu.RLock()
+	fn, ok := describeFns[reflect.TypeOf(rt)]
+	describeFnsMu.RUnlock()
+	if ok {
+		return fn(rt)
+	}
+
+	layer := Layer{Name: fmt.Sprintf("%T", rt)}
+	if describer, isDescriber := rt.(Describer); isDescriber {
+		layer.Name, layer.Summary = describer.Describe()
+	}
+	var next http.RoundTripper
+	if unwrapper, isUnwrapper := rt.(Unwrapper); isUnwrapper {
+		next = unwrapper.Unwrap()
+	}
+	return layer, next
+}
@@ -0,0 +1,99 @@
+package transportstack
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Roshick/go-autumn-web/logging"
+	"github.com/Roshick/go-autumn-web/metrics"
+	"github.com/Roshick/go-autumn-web/resiliency"
+	"github.com/Roshick/go-autumn-web/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+type opaqueTransport struct {
+	base http.RoundTripper
+}
+
+func (t *opaqueTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req)
+}
+
+type cyclicTransport struct {
+	next http.RoundTripper
+}
+
+func (t *cyclicTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req)
+}
+
+func (t *cyclicTransport) Unwrap() http.RoundTripper {
+	return t.next
+}
+
+func TestDescribe(t *testing.T) {
+	t.Run("describes the transports of this module", func(t *testing.T) {
+		var rt http.RoundTripper = &http.Transport{}
+		rt = resiliency.NewCircuitBreakerTransport(rt, nil)
+		rt = resiliency.NewRetryTransport(rt, nil)
+		rt = metrics.NewRequestMetricsTransport(rt, "users", nil)
+		rt = logging.NewRequestLoggerTransport(rt, nil)
+		rt = tracing.NewRequestIDHeaderTransport(rt, nil)
+
+		layers := Describe(rt)
+
+		assert.Equal(t, []Layer{
+			{Name: "requestid", Summary: "header=X-Request-ID"},
+			{Name: "logging"},
+			{Name: "metrics", Summary: "client=users"},
+			{Name: "retry", Summary: "maxRetries=2, initialBackoff=100ms, maxBackoff=2s"},
+			{Name: "breaker", Summary: "name=default"},
+			{Name: "base"},
+		}, layers)
+		assert.Equal(t, "requestid→logging→metrics→retry→breaker→base", String(rt))
+	})
+
+	t.Run("marks the default transport", func(t *testing.T) {
+		assert.Equal(t, []Layer{{Name: "retry", Summary: "maxRetries=2, initialBackoff=100ms, maxBackoff=2s"}, {Name: "base", Summary: "default"}},
+			Describe(resiliency.NewRetryTransport(nil, nil)))
+	})
+
+	t.Run("ends the chain at unknown transports", func(t *testing.T) {
+		rt := resiliency.NewTimeoutTransport(&opaqueTransport{base: http.DefaultTransport}, nil)
+
+		assert.Equal(t, "timeout→*transportstack.opaqueTransport", String(rt))
+	})
+
+	t.Run("uses registered describe functions", func(t *testing.T) {
+		Register(func(rt *opaqueTransport) (Layer, http.RoundTripper) {
+			return Layer{Name: "opaque", Summary: "registered"}, rt.base
+		})
+		t.Cleanup(func() {
+			describeFnsMu.Lock()
+			defer describeFnsMu.Unlock()
+			delete(describeFns, reflect.TypeFor[*opaqueTransport]())
+		})
+
+		layers := Describe(&opaqueTransport{base: http.DefaultTransport})
+
+		assert.Equal(t, []Layer{{Name: "opaque", Summary: "registered"}, {Name: "base", Summary: "default"}}, layers)
+	})
+
+	t.Run("stops at cycles", func(t *testing.T) {
+		rt := &cyclicTransport{}
+		rt.next = rt
+
+		assert.Len(t, Describe(rt), maxDepth)
+	})
+
+	t.Run("with nil round tripper", func(t *testing.T) {
+		assert.Empty(t, Describe(nil))
+		assert.Equal(t, "", String(nil))
+	})
+}
+
+func TestLayer_String(t *testing.T) {
+	assert.Equal(t, "base", Layer{Name: "base"}.String())
+	assert.Equal(t, "timeout(timeout=5s)", Layer{Name: "timeout", Summary: "timeout=5s"}.String())
+}
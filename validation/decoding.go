@@ -0,0 +1,169 @@
+package validation
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+// ErrUnsupportedMediaType is returned for request bodies whose Content-Type cannot be decoded.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// FormValueError describes a form value that could not be converted to the type of its field.
+type FormValueError struct {
+	Field string
+	Type  reflect.Type
+	Value string
+}
+
+func (e *FormValueError) Error() string {
+	return fmt.Sprintf("form: cannot decode %q into field %s of type %s", e.Value, e.Field, e.Type)
+}
+
+// decodeBody decodes the request body based on its Content-Type. Requests without Content-Type are
+// decoded as JSON.
+func decodeBody(req *http.Request, v any) error {
+	contentType := req.Header.Get(header.ContentType)
+	if contentType == "" {
+		return json.NewDecoder(req.Body).Decode(v)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return json.NewDecoder(req.Body).Decode(v)
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return xml.NewDecoder(req.Body).Decode(v)
+	case mediaType == "application/x-www-form-urlencoded":
+		raw, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		values, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return err
+		}
+		return decodeForm(values, v)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// decodeForm sets the fields of the struct pointed to by v from the given values. Fields are matched
+// by their `form` tag, falling back to the field name; fields tagged `form:"-"` are skipped.
+func decodeForm(values url.Values, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: cannot decode into %T", v)
+	}
+	return decodeFormStruct(values, rv.Elem())
+}
+
+func decodeFormStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := decodeFormStruct(values, fieldValue); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("form"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fieldValues, ok := values[name]
+		if !ok || len(fieldValues) == 0 {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice && !fieldValue.Addr().Type().Implements(textUnmarshalerType) {
+			slice := reflect.MakeSlice(fieldValue.Type(), len(fieldValues), len(fieldValues))
+			for j, value := range fieldValues {
+				if err := setFormValue(slice.Index(j), value); err != nil {
+					return &FormValueError{Field: name, Type: field.Type, Value: value}
+				}
+			}
+			fieldValue.Set(slice)
+			continue
+		}
+		if err := setFormValue(fieldValue, fieldValues[0]); err != nil {
+			return &FormValueError{Field: name, Type: field.Type, Value: fieldValues[0]}
+		}
+	}
+	return nil
+}
+
+func setFormValue(rv reflect.Value, value string) error {
+	if rv.Kind() == reflect.Pointer {
+		ptr := reflect.New(rv.Type().Elem())
+		if err := setFormValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		rv.Set(ptr)
+		return nil
+	}
+	if unmarshaler, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("form: unsupported field type %s", rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodingTestBody struct {
+	Name string `json:"name" xml:"name" form:"name"`
+	Age  int    `json:"age" xml:"age" form:"age"`
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "without content type", body: `{"name":"John","age":42}`},
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"name":"John","age":42}`},
+		{name: "json suffix", contentType: "application/merge-patch+json", body: `{"name":"John","age":42}`},
+		{name: "xml", contentType: "application/xml", body: `<body><name>John</name><age>42</age></body>`},
+		{name: "text xml", contentType: "text/xml; charset=utf-8", body: `<body><name>John</name><age>42</age></body>`},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "name=John&age=42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var body decodingTestBody
+			require.NoError(t, decodeBody(req, &body))
+			assert.Equal(t, decodingTestBody{Name: "John", Age: 42}, body)
+		})
+	}
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("John"))
+		req.Header.Set("Content-Type", "text/plain")
+
+		var body decodingTestBody
+		assert.ErrorIs(t, decodeBody(req, &body), ErrUnsupportedMediaType)
+	})
+
+	t.Run("invalid content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("John"))
+		req.Header.Set("Content-Type", "/;")
+
+		var body decodingTestBody
+		assert.ErrorIs(t, decodeBody(req, &body), ErrUnsupportedMediaType)
+	})
+}
+
+type embeddedFormBody struct {
+	ID string `form:"id"`
+}
+
+type formTestBody struct {
+	embeddedFormBody
+	Name     string
+	Enabled  bool      `form:"enabled"`
+	Count    *uint8    `form:"count"`
+	Ratio    float64   `form:"ratio"`
+	Tags     []string  `form:"tag"`
+	Scores   []int     `form:"score"`
+	Since    time.Time `form:"since"`
+	Ignored  string    `form:"-"`
+	internal string
+}
+
+func TestDecodeForm(t *testing.T) {
+	t.Run("decodes supported field types", func(t *testing.T) {
+		values := url.Values{
+			"id":      {"item-1"},
+			"Name":    {"John"},
+			"enabled": {"true"},
+			"count":   {"7"},
+			"ratio":   {"0.5"},
+			"tag":     {"a", "b"},
+			"score":   {"1", "2"},
+			"since":   {"2024-01-02T03:04:05Z"},
+			"-":       {"x"},
+			"Ignored": {"x"},
+		}
+
+		var body formTestBody
+		require.NoError(t, decodeForm(values, &body))
+
+		count := uint8(7)
+		assert.Equal(t, formTestBody{
+			embeddedFormBody: embeddedFormBody{ID: "item-1"},
+			Name:             "John",
+			Enabled:          true,
+			Count:            &count,
+			Ratio:            0.5,
+			Tags:             []string{"a", "b"},
+			Scores:           []int{1, 2},
+			Since:            time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}, body)
+	})
+
+	t.Run("reports invalid values", func(t *testing.T) {
+		var body formTestBody
+		err := decodeForm(url.Values{"score": {"1", "two"}}, &body)
+
+		var formErr *FormValueError
+		require.ErrorAs(t, err, &formErr)
+		assert.Equal(t, "score", formErr.Field)
+		assert.Equal(t, "two", formErr.Value)
+	})
+
+	t.Run("reports overflows", func(t *testing.T) {
+		var body formTestBody
+		err := decodeForm(url.Values{"count": {"300"}}, &body)
+
+		var formErr *FormValueError
+		assert.ErrorAs(t, err, &formErr)
+	})
+
+	t.Run("rejects non struct targets", func(t *testing.T) {
+		var target map[string]string
+		assert.Error(t, decodeForm(url.Values{}, &target))
+	})
+}
@@ -3,6 +3,8 @@ package validation
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	weberrors "github.com/Roshick/go-autumn-web/errors"
@@ -12,25 +14,49 @@ import (
 // ContextRequestBodyMiddleware //
 
 type ContextRequestBodyMiddlewareOptions struct {
+	// ErrorResponse, if set, is rendered for every invalid body instead of the response built by ErrorResponseFn.
 	ErrorResponse render.Renderer
+	// ErrorResponseFn builds the response for decoding and validation errors. Defaults to NewBodyErrorResponse.
+	ErrorResponseFn func(err error) render.Renderer
 }
 
 func DefaultContextRequestBodyMiddlewareOptions() *ContextRequestBodyMiddlewareOptions {
 	return &ContextRequestBodyMiddlewareOptions{
-		ErrorResponse: weberrors.NewInvalidRequestBodyResponse(),
+		ErrorResponseFn: NewBodyErrorResponse,
 	}
 }
 
+// Validatable is implemented by request bodies that validate themselves after decoding. Returning
+// weberrors.FieldErrors results in field-level details in the default error response.
+type Validatable interface {
+	Validate() error
+}
+
+// NewContextRequestBodyMiddleware decodes the request body into B based on its Content-Type: JSON,
+// XML and form-urlencoded bodies (fields matched by `form` tag) are supported; bodies without
+// Content-Type are decoded as JSON.
 func NewContextRequestBodyMiddleware[B any](opts *ContextRequestBodyMiddlewareOptions) func(next http.Handler) http.Handler {
 	if opts == nil {
 		opts = DefaultContextRequestBodyMiddlewareOptions()
 	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = NewBodyErrorResponse
+	}
 
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
 			body := new(B)
-			if err := json.NewDecoder(req.Body).Decode(body); err != nil {
-				if err = render.Render(w, req, opts.ErrorResponse); err != nil {
+			err := decodeBody(req, body)
+			if validatable, ok := any(body).(Validatable); ok && err == nil {
+				err = validatable.Validate()
+			}
+			if err != nil {
+				errorResponse := opts.ErrorResponse
+				if errorResponse == nil {
+					errorResponse = errorResponseFn(err)
+				}
+				if err = weberrors.Render(w, req, errorResponse); err != nil {
 					panic(err)
 				}
 				return
@@ -42,6 +68,39 @@ func NewContextRequestBodyMiddleware[B any](opts *ContextRequestBodyMiddlewareOp
 	}
 }
 
+// NewBodyErrorResponse translates request body decoding and validation errors into a
+// weberrors.ValidationErrorResponse listing the offending fields. Bodies of unsupported media types
+// result in a weberrors.UnsupportedMediaTypeResponse.
+func NewBodyErrorResponse(err error) render.Renderer {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		return weberrors.NewUnsupportedMediaTypeResponse("")
+	}
+	var fieldErrors weberrors.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		return weberrors.NewValidationErrorResponse("", fieldErrors...)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return weberrors.NewValidationErrorResponse("Invalid request body", weberrors.FieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("expected %s but got %s", typeErr.Type, typeErr.Value),
+		})
+	}
+	var formErr *FormValueError
+	if errors.As(err, &formErr) {
+		return weberrors.NewValidationErrorResponse("Invalid request body", weberrors.FieldError{
+			Field:   formErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("expected %s but got %q", formErr.Type, formErr.Value),
+		})
+	}
+	return weberrors.NewValidationErrorResponse("Invalid request body", weberrors.FieldError{
+		Code:    "malformed_body",
+		Message: err.Error(),
+	})
+}
+
 // RequiredHeaderMiddleware //
 
 type RequiredHeaderMiddlewareOptions struct {
@@ -62,7 +121,7 @@ func NewRequiredHeaderMiddleware(headerName string, opts *RequiredHeaderMiddlewa
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, req *http.Request) {
 			if req.Header.Get(headerName) == "" {
-				if err := render.Render(w, req, opts.ErrorResponse); err != nil {
+				if err := weberrors.Render(w, req, opts.ErrorResponse); err != nil {
 					panic(err)
 				}
 				return
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	weberrors "github.com/Roshick/go-autumn-web/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,7 +34,8 @@ func TestDefaultContextRequestBodyMiddlewareOptions(t *testing.T) {
 	opts := DefaultContextRequestBodyMiddlewareOptions()
 
 	require.NotNil(t, opts)
-	assert.NotNil(t, opts.ErrorResponse)
+	assert.Nil(t, opts.ErrorResponse)
+	assert.NotNil(t, opts.ErrorResponseFn)
 }
 
 func TestNewContextRequestBodyMiddleware(t *testing.T) {
@@ -104,6 +107,146 @@ func TestNewContextRequestBodyMiddleware(t *testing.T) {
 		assert.False(t, handlerCalled)
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
+
+	t.Run("wrong field type reports field", func(t *testing.T) {
+		middleware := NewContextRequestBodyMiddleware[TestRequestBody](nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"name": 42}`)))
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		response := decodeValidationErrorResponse(t, rr)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "name", response.Errors[0].Field)
+		assert.Equal(t, "invalid_type", response.Errors[0].Code)
+	})
+
+	t.Run("validatable body reports field errors", func(t *testing.T) {
+		middleware := NewContextRequestBodyMiddleware[ValidatedRequestBody](nil)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"name": ""}`)))
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		response := decodeValidationErrorResponse(t, rr)
+		assert.Equal(t, weberrors.FieldErrors{{Field: "name", Code: "required", Message: "name is required"}}, response.Errors)
+	})
+
+	t.Run("valid validatable body", func(t *testing.T) {
+		middleware := NewContextRequestBodyMiddleware[ValidatedRequestBody](nil)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"name": "John"}`)))
+		middleware(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.True(t, handlerCalled)
+	})
+
+	t.Run("static error response takes precedence", func(t *testing.T) {
+		opts := &ContextRequestBodyMiddlewareOptions{
+			ErrorResponse: weberrors.NewInvalidRequestBodyResponse(),
+		}
+		middleware := NewContextRequestBodyMiddleware[TestRequestBody](opts)
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"name": 42}`)))
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "errors")
+	})
+
+	t.Run("form body", func(t *testing.T) {
+		type formBody struct {
+			Name  string `form:"name"`
+			Email string `form:"email"`
+		}
+		middleware := NewContextRequestBodyMiddleware[formBody](nil)
+
+		var receivedBody formBody
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody = RequestBodyFromContext[formBody](r.Context())
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=John&email=john%40localhost"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, formBody{Name: "John", Email: "john@localhost"}, receivedBody)
+	})
+
+	t.Run("invalid form value", func(t *testing.T) {
+		type formBody struct {
+			Age int `form:"age"`
+		}
+		middleware := NewContextRequestBodyMiddleware[formBody](nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("age=old"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+
+		middleware(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var response weberrors.ValidationErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "age", response.Errors[0].Field)
+		assert.Equal(t, "invalid_type", response.Errors[0].Code)
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		middleware := NewContextRequestBodyMiddleware[TestRequestBody](nil)
+
+		handlerCalled := false
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("John"))
+		req.Header.Set("Content-Type", "text/plain")
+		rr := httptest.NewRecorder()
+
+		middleware(testHandler).ServeHTTP(rr, req)
+
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	})
+}
+
+type ValidatedRequestBody struct {
+	Name string `json:"name"`
+}
+
+func (b *ValidatedRequestBody) Validate() error {
+	if b.Name == "" {
+		return weberrors.FieldErrors{{Field: "name", Code: "required", Message: "name is required"}}
+	}
+	return nil
+}
+
+func decodeValidationErrorResponse(t *testing.T, rr *httptest.ResponseRecorder) weberrors.ValidationErrorResponse {
+	var response weberrors.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response
 }
 
 func TestDefaultRequiredHeaderMiddlewareOptions(t *testing.T) {
@@ -0,0 +1,263 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Roshick/go-autumn-web/contextutils"
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/go-chi/render"
+)
+
+var (
+	// ErrRequestTooLarge is returned for multipart bodies exceeding MultipartFormMiddlewareOptions.MaxBodySize.
+	ErrRequestTooLarge = errors.New("request body too large")
+	// ErrFileTooLarge is returned for files exceeding FileFieldRule.MaxSize.
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrFileTypeNotAllowed is returned for files whose detected media type is not in FileFieldRule.AllowedTypes.
+	ErrFileTypeNotAllowed = errors.New("file type not allowed")
+	// ErrMissingField is returned for required form values and files that are absent.
+	ErrMissingField = errors.New("missing field")
+)
+
+// MultipartFieldError reports which form field violated its rule.
+type MultipartFieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *MultipartFieldError) Error() string {
+	return fmt.Sprintf("field %s: %s", e.Field, e.Err)
+}
+
+func (e *MultipartFieldError) Unwrap() error {
+	return e.Err
+}
+
+// FileFieldRule restricts the files uploaded for a single form field.
+type FileFieldRule struct {
+	// Required rejects requests without a file for the field.
+	Required bool
+	// MaxSize limits the size of each file in bytes. Zero disables the limit.
+	MaxSize int64
+	// AllowedTypes lists the accepted media types, detected from the file content rather than the
+	// declared Content-Type. Entries such as "image/*" match all subtypes. Empty allows all types.
+	AllowedTypes []string
+}
+
+// UploadedFile is a file part of a multipart body.
+type UploadedFile struct {
+	*multipart.FileHeader
+	// ContentType is the media type detected from the first 512 bytes of the file.
+	ContentType string
+}
+
+// MultipartForm holds the parsed values and files of a multipart body.
+type MultipartForm struct {
+	Values map[string][]string
+	Files  map[string][]*UploadedFile
+}
+
+// Value returns the first value for the given field, or an empty string.
+func (f *MultipartForm) Value(field string) string {
+	if values := f.Values[field]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// File returns the first file for the given field, or nil.
+func (f *MultipartForm) File(field string) *UploadedFile {
+	if files := f.Files[field]; len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// MultipartFormFromContext returns the form parsed by the multipart form middleware, or nil.
+func MultipartFormFromContext(ctx context.Context) *MultipartForm {
+	return contextutils.GetValue[MultipartForm](ctx)
+}
+
+// MultipartFormMiddleware //
+
+type MultipartFormMiddlewareOptions struct {
+	// MaxBodySize limits the size of the whole request body in bytes. Defaults to 32 MiB.
+	MaxBodySize int64
+	// MaxMemory is the number of bytes of file parts kept in memory; larger files are spilled to
+	// temporary files, which are removed once the request has been handled. Defaults to 10 MiB.
+	MaxMemory int64
+	// FileFields holds the rules for file fields, keyed by field name.
+	FileFields map[string]FileFieldRule
+	// RequiredValues lists the names of form values that must be present.
+	RequiredValues []string
+	// ErrorResponseFn builds the response for rejected bodies. Defaults to NewMultipartErrorResponse.
+	ErrorResponseFn func(err error) render.Renderer
+}
+
+func DefaultMultipartFormMiddlewareOptions() *MultipartFormMiddlewareOptions {
+	return &MultipartFormMiddlewareOptions{
+		MaxBodySize:     32 << 20,
+		MaxMemory:       10 << 20,
+		ErrorResponseFn: NewMultipartErrorResponse,
+	}
+}
+
+// NewMultipartFormMiddleware parses multipart/form-data bodies, validates them against the
+// configured rules and stores the result in the request context, see MultipartFormFromContext.
+func NewMultipartFormMiddleware(opts *MultipartFormMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultMultipartFormMiddlewareOptions()
+	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = NewMultipartErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			form, err := parseMultipartForm(w, req, opts)
+			if req.MultipartForm != nil {
+				defer func() {
+					_ = req.MultipartForm.RemoveAll()
+				}()
+			}
+			if err != nil {
+				if err = weberrors.Render(w, req, errorResponseFn(err)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			ctx := contextutils.WithValue(req.Context(), *form)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func parseMultipartForm(w http.ResponseWriter, req *http.Request, opts *MultipartFormMiddlewareOptions) (*MultipartForm, error) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get(header.ContentType))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("%w: expected multipart/form-data", ErrUnsupportedMediaType)
+	}
+
+	if opts.MaxBodySize > 0 {
+		req.Body = http.MaxBytesReader(w, req.Body, opts.MaxBodySize)
+	}
+	if err = req.ParseMultipartForm(opts.MaxMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrRequestTooLarge
+		}
+		return nil, err
+	}
+
+	form := &MultipartForm{
+		Values: req.MultipartForm.Value,
+		Files:  make(map[string][]*UploadedFile, len(req.MultipartForm.File)),
+	}
+	for field, fileHeaders := range req.MultipartForm.File {
+		files := make([]*UploadedFile, 0, len(fileHeaders))
+		for _, fileHeader := range fileHeaders {
+			contentType, err := detectContentType(fileHeader)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, &UploadedFile{FileHeader: fileHeader, ContentType: contentType})
+		}
+		form.Files[field] = files
+	}
+
+	for _, field := range opts.RequiredValues {
+		if len(form.Values[field]) == 0 {
+			return nil, &MultipartFieldError{Field: field, Err: ErrMissingField}
+		}
+	}
+	for field, rule := range opts.FileFields {
+		if err = validateFiles(form.Files[field], rule); err != nil {
+			return nil, &MultipartFieldError{Field: field, Err: err}
+		}
+	}
+	return form, nil
+}
+
+func detectContentType(fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mediaType, nil
+}
+
+func validateFiles(files []*UploadedFile, rule FileFieldRule) error {
+	if len(files) == 0 && rule.Required {
+		return ErrMissingField
+	}
+	for _, file := range files {
+		if rule.MaxSize > 0 && file.Size > rule.MaxSize {
+			return ErrFileTooLarge
+		}
+		if len(rule.AllowedTypes) > 0 && !slices.ContainsFunc(rule.AllowedTypes, func(allowed string) bool {
+			return matchMediaType(allowed, file.ContentType)
+		}) {
+			return fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, file.ContentType)
+		}
+	}
+	return nil
+}
+
+func matchMediaType(pattern string, mediaType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return strings.EqualFold(pattern, mediaType)
+}
+
+// NewMultipartErrorResponse translates multipart parsing and validation errors into responses:
+// 413 for oversized bodies and files, 415 for unexpected media types, 422 for missing fields and
+// 400 for malformed bodies.
+func NewMultipartErrorResponse(err error) render.Renderer {
+	var field string
+	var fieldErr *MultipartFieldError
+	if errors.As(err, &fieldErr) {
+		field = fieldErr.Field
+	}
+
+	switch {
+	case errors.Is(err, ErrRequestTooLarge):
+		return weberrors.NewRequestEntityTooLargeResponse("")
+	case errors.Is(err, ErrFileTooLarge):
+		return weberrors.NewRequestEntityTooLargeResponse(fmt.Sprintf("File too large: %s", field))
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return weberrors.NewUnsupportedMediaTypeResponse("Expected multipart/form-data")
+	case errors.Is(err, ErrFileTypeNotAllowed):
+		return weberrors.NewUnsupportedMediaTypeResponse(fmt.Sprintf("File type not allowed: %s", field))
+	case errors.Is(err, ErrMissingField):
+		response := weberrors.NewValidationErrorResponse("", weberrors.FieldError{
+			Field:   field,
+			Code:    "required",
+			Message: field + " is required",
+		})
+		response.HTTPStatusCode = http.StatusUnprocessableEntity
+		response.StatusText = "Unprocessable Entity"
+		return response
+	default:
+		return weberrors.NewBadRequestResponse("Invalid multipart body")
+	}
+}
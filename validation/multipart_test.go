@@ -0,0 +1,219 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type multipartPart struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func newMultipartRequest(t *testing.T, parts ...multipartPart) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, part := range parts {
+		if part.filename == "" {
+			require.NoError(t, writer.WriteField(part.field, string(part.content)))
+			continue
+		}
+		fileWriter, err := writer.CreateFormFile(part.field, part.filename)
+		require.NoError(t, err)
+		_, err = fileWriter.Write(part.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestNewMultipartFormMiddleware(t *testing.T) {
+	avatarOpts := func() *MultipartFormMiddlewareOptions {
+		opts := DefaultMultipartFormMiddlewareOptions()
+		opts.RequiredValues = []string{"name"}
+		opts.FileFields = map[string]FileFieldRule{
+			"avatar": {Required: true, MaxSize: 1024, AllowedTypes: []string{"image/*"}},
+		}
+		return opts
+	}
+
+	t.Run("stores parsed form in context", func(t *testing.T) {
+		var form *MultipartForm
+		var content []byte
+		handler := NewMultipartFormMiddleware(avatarOpts())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			form = MultipartFormFromContext(r.Context())
+			file, err := form.File("avatar").Open()
+			require.NoError(t, err)
+			defer file.Close()
+			content, err = io.ReadAll(file)
+			require.NoError(t, err)
+		}))
+
+		req := newMultipartRequest(t,
+			multipartPart{field: "name", content: []byte("John")},
+			multipartPart{field: "avatar", filename: "avatar.txt", content: pngHeader},
+		)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.NotNil(t, form)
+		assert.Equal(t, "John", form.Value("name"))
+		avatar := form.File("avatar")
+		require.NotNil(t, avatar)
+		assert.Equal(t, "avatar.txt", avatar.Filename)
+		assert.Equal(t, "image/png", avatar.ContentType)
+		assert.Equal(t, pngHeader, content)
+	})
+
+	t.Run("spills large files to disk", func(t *testing.T) {
+		opts := DefaultMultipartFormMiddlewareOptions()
+		opts.MaxMemory = 1
+
+		var spilled bool
+		handler := NewMultipartFormMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file, err := MultipartFormFromContext(r.Context()).File("doc").Open()
+			require.NoError(t, err)
+			defer file.Close()
+			_, spilled = file.(interface{ Name() string })
+		}))
+
+		req := newMultipartRequest(t, multipartPart{field: "doc", filename: "doc.txt", content: bytes.Repeat([]byte("a"), 4096)})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.True(t, spilled)
+	})
+
+	tests := []struct {
+		name           string
+		opts           func() *MultipartFormMiddlewareOptions
+		request        func(t *testing.T) *http.Request
+		expectedStatus int
+	}{
+		{
+			name: "rejects non multipart bodies",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{}`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "rejects oversized bodies",
+			opts: func() *MultipartFormMiddlewareOptions {
+				opts := DefaultMultipartFormMiddlewareOptions()
+				opts.MaxBodySize = 128
+				return opts
+			},
+			request: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t, multipartPart{field: "doc", filename: "doc.txt", content: bytes.Repeat([]byte("a"), 1024)})
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "rejects oversized files",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t,
+					multipartPart{field: "name", content: []byte("John")},
+					multipartPart{field: "avatar", filename: "avatar.png", content: append(pngHeader, make([]byte, 2048)...)},
+				)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "rejects disallowed file types",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t,
+					multipartPart{field: "name", content: []byte("John")},
+					multipartPart{field: "avatar", filename: "avatar.png", content: []byte("plain text")},
+				)
+			},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "rejects missing files",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t, multipartPart{field: "name", content: []byte("John")})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "rejects missing values",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t, multipartPart{field: "avatar", filename: "avatar.png", content: pngHeader})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "rejects malformed bodies",
+			opts: avatarOpts,
+			request: func(t *testing.T) *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("garbage"))
+				req.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+				return req
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerCalled := false
+			handler := NewMultipartFormMiddleware(tt.opts())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+			}))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, tt.request(t))
+
+			assert.False(t, handlerCalled)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestNewMultipartErrorResponse(t *testing.T) {
+	response := NewMultipartErrorResponse(&MultipartFieldError{Field: "avatar", Err: ErrMissingField})
+
+	rr := httptest.NewRecorder()
+	require.NoError(t, weberrors.Render(rr, httptest.NewRequest(http.MethodPost, "/", nil), response))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var body weberrors.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, "avatar", body.Errors[0].Field)
+	assert.Equal(t, "required", body.Errors[0].Code)
+}
+
+func TestMatchMediaType(t *testing.T) {
+	assert.True(t, matchMediaType("image/*", "image/png"))
+	assert.True(t, matchMediaType("application/pdf", "application/pdf"))
+	assert.False(t, matchMediaType("image/*", "text/plain"))
+	assert.False(t, matchMediaType("image/png", "image/jpeg"))
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	weberrors "github.com/Roshick/go-autumn-web/errors"
+	"github.com/Roshick/go-autumn-web/requestbody"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/go-chi/render"
+)
+
+// ErrSignatureExpired is returned for signatures whose timestamp lies outside the replay window
+var ErrSignatureExpired = errors.New("webhook signature expired")
+
+// RawBodyFromContext returns the raw body verified by the signature verification middleware, or nil.
+func RawBodyFromContext(ctx context.Context) []byte {
+	body, _ := requestbody.FromContext(ctx)
+	return body
+}
+
+// SignatureVerificationMiddleware //
+
+type SignatureVerificationMiddlewareOptions struct {
+	// Tolerance is the replay window: signed timestamps may deviate this much from now. Zero disables
+	// the check. Schemes without timestamps are not affected. Defaults to 5m.
+	Tolerance time.Duration
+	// MaxBodySize limits the size of the body read for verification in bytes. Bodies captured before,
+	// e.g. by requestbody.NewCaptureMiddleware, are not read again. Defaults to 1 MiB.
+	MaxBodySize int64
+	// ErrorResponseFn builds the response for rejected requests. Defaults to NewSignatureErrorResponse.
+	ErrorResponseFn func(err error) render.Renderer
+}
+
+func DefaultSignatureVerificationMiddlewareOptions() *SignatureVerificationMiddlewareOptions {
+	return &SignatureVerificationMiddlewareOptions{
+		Tolerance:       5 * time.Minute,
+		MaxBodySize:     1 << 20,
+		ErrorResponseFn: NewSignatureErrorResponse,
+	}
+}
+
+// NewSignatureVerificationMiddleware rejects webhooks not signed according to the scheme. The body is
+// captured with requestbody.Capture, so body decoding middlewares can run afterwards, and is also
+// available via RawBodyFromContext.
+func NewSignatureVerificationMiddleware(scheme SignatureScheme, opts *SignatureVerificationMiddlewareOptions) func(next http.Handler) http.Handler {
+	if opts == nil {
+		opts = DefaultSignatureVerificationMiddlewareOptions()
+	}
+	errorResponseFn := opts.ErrorResponseFn
+	if errorResponseFn == nil {
+		errorResponseFn = NewSignatureErrorResponse
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			capturedReq, err := verifySignature(w, req, scheme, opts)
+			if err != nil {
+				aulogging.Logger.Ctx(req.Context()).Info().WithErr(err).Print("rejected webhook")
+				if err = weberrors.Render(w, req, errorResponseFn(err)); err != nil {
+					panic(err)
+				}
+				return
+			}
+			next.ServeHTTP(w, capturedReq)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func verifySignature(w http.ResponseWriter, req *http.Request, scheme SignatureScheme, opts *SignatureVerificationMiddlewareOptions) (*http.Request, error) {
+	req, body, err := requestbody.Capture(w, req, opts.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := scheme.Verify(req, body)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Tolerance > 0 && !timestamp.IsZero() {
+		if age := time.Since(timestamp); age > opts.Tolerance || age < -opts.Tolerance {
+			return nil, ErrSignatureExpired
+		}
+	}
+	return req, nil
+}
+
+// NewSignatureErrorResponse responds with 413 Request Entity Too Large for oversized bodies and 401
+// Unauthorized otherwise, without revealing why the signature was rejected.
+func NewSignatureErrorResponse(err error) render.Renderer {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return weberrors.NewRequestEntityTooLargeResponse("")
+	}
+	return weberrors.NewUnauthorizedResponse("Invalid webhook signature")
+}
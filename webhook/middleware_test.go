@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderEvent struct {
+	ID string `json:"id"`
+}
+
+func TestDefaultSignatureVerificationMiddlewareOptions(t *testing.T) {
+	opts := DefaultSignatureVerificationMiddlewareOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, 5*time.Minute, opts.Tolerance)
+	assert.Equal(t, int64(1<<20), opts.MaxBodySize)
+	assert.NotNil(t, opts.ErrorResponseFn)
+}
+
+func TestNewSignatureVerificationMiddleware(t *testing.T) {
+	secret := []byte("secret")
+	body := `{"id":"42"}`
+
+	newHandler := func(opts *SignatureVerificationMiddlewareOptions) (http.Handler, *orderEvent, *[]byte) {
+		var event orderEvent
+		var raw []byte
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event = validation.RequestBodyFromContext[orderEvent](r.Context())
+			raw = RawBodyFromContext(r.Context())
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return NewSignatureVerificationMiddleware(TimestampSignatureScheme{Secret: secret}, opts)(
+			validation.NewContextRequestBodyMiddleware[orderEvent](nil)(handler),
+		), &event, &raw
+	}
+	newRequest := func(signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+		req.Header.Set(header.ContentType, "application/json")
+		req.Header.Set(header.XWebhookSignature, signature)
+		return req
+	}
+
+	t.Run("passes verified webhooks with restored body", func(t *testing.T) {
+		handler, event, raw := newHandler(nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(Sign(secret, time.Now(), []byte(body))))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "42", event.ID)
+		assert.Equal(t, body, string(*raw))
+	})
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		handler, _, _ := newHandler(nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(Sign([]byte("other"), time.Now(), []byte(body))))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("rejects replayed webhooks", func(t *testing.T) {
+		handler, _, _ := newHandler(nil)
+		for _, signedAt := range []time.Time{time.Now().Add(-10 * time.Minute), time.Now().Add(10 * time.Minute)} {
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, newRequest(Sign(secret, signedAt, []byte(body))))
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		}
+	})
+
+	t.Run("accepts old webhooks without tolerance", func(t *testing.T) {
+		opts := DefaultSignatureVerificationMiddlewareOptions()
+		opts.Tolerance = 0
+		handler, _, _ := newHandler(opts)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(Sign(secret, time.Now().Add(-time.Hour), []byte(body))))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		opts := DefaultSignatureVerificationMiddlewareOptions()
+		opts.MaxBodySize = 4
+		handler, _, _ := newHandler(opts)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(Sign(secret, time.Now(), []byte(body))))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	})
+
+	t.Run("body can be read again", func(t *testing.T) {
+		var bodies []string
+		handler := NewSignatureVerificationMiddleware(TimestampSignatureScheme{Secret: secret}, nil)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				first, _ := io.ReadAll(r.Body)
+				replay, err := r.GetBody()
+				require.NoError(t, err)
+				second, _ := io.ReadAll(replay)
+				bodies = append(bodies, string(first), string(second))
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest(Sign(secret, time.Now(), []byte(body))))
+
+		assert.Equal(t, []string{body, body}, bodies)
+	})
+}
+
+func TestRawBodyFromContext_WithoutMiddleware(t *testing.T) {
+	assert.Nil(t, RawBodyFromContext(t.Context()))
+}
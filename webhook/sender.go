@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/internal/instruments"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	OutcomeDelivered = "delivered"
+	OutcomeFailed    = "failed"
+)
+
+// Notification is a webhook to deliver
+type Notification struct {
+	// ID identifies the notification, so receivers can deduplicate redelivered notifications
+	ID string
+	// Event names the type of the notification, e.g. "order.created"
+	Event string
+	// URL is the endpoint of the receiver
+	URL string
+	// Payload is sent as JSON body
+	Payload any
+}
+
+// DeadLetterFn receives notifications that could not be delivered with the error of the last attempt
+type DeadLetterFn func(ctx context.Context, notification Notification, err error)
+
+// ShouldRetryFn decides whether a failed attempt is retried
+type ShouldRetryFn func(res *http.Response, err error) bool
+
+type SenderOptions struct {
+	// Client sends the notifications. Use a client with the transports of this module to get logging,
+	// metrics, circuit breaking and timeouts. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Secret keys the HMAC signature sent in the X-Webhook-Signature header. Notifications are sent
+	// unsigned if empty.
+	Secret []byte
+	// MaxAttempts limits the number of delivery attempts per notification. Defaults to 5.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled for every further retry. Defaults to 1s.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including delays requested by Retry-After headers.
+	// Defaults to 1m.
+	MaxBackoff time.Duration
+	// Jitter randomizes delays between half and the full backoff, spreading retries of many
+	// notifications. Defaults to true.
+	Jitter bool
+	// ShouldRetryFn decides whether a failed attempt is retried. Defaults to DefaultShouldRetry.
+	ShouldRetryFn ShouldRetryFn
+	// DeadLetterFn receives notifications that could not be delivered, e.g. to persist them for manual
+	// redelivery. Optional.
+	DeadLetterFn DeadLetterFn
+}
+
+func DefaultSenderOptions() *SenderOptions {
+	return &SenderOptions{
+		Client:         http.DefaultClient,
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Jitter:         true,
+		ShouldRetryFn:  DefaultShouldRetry,
+	}
+}
+
+// DefaultShouldRetry retries transport errors, timeouts, rate limiting and server errors
+func DefaultShouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return res.StatusCode == http.StatusRequestTimeout ||
+		res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode >= http.StatusInternalServerError
+}
+
+// Sender delivers signed webhook notifications with retries and exponential backoff
+type Sender struct {
+	opts          *SenderOptions
+	client        *http.Client
+	shouldRetryFn ShouldRetryFn
+
+	deliveryAttempts metric.Int64Counter
+	deliveries       metric.Int64Counter
+	deliveryDuration metric.Float64Histogram
+}
+
+func NewSender(opts *SenderOptions) *Sender {
+	if opts == nil {
+		opts = DefaultSenderOptions()
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	shouldRetryFn := opts.ShouldRetryFn
+	if shouldRetryFn == nil {
+		shouldRetryFn = DefaultShouldRetry
+	}
+
+	provider := otel.GetMeterProvider()
+	deliveryAttempts, _ := instruments.Int64Counter(provider, "webhook",
+		"webhook.delivery.attempts",
+		metric.WithDescription("Number of webhook delivery attempts by event and status code"),
+	)
+	deliveries, _ := instruments.Int64Counter(provider, "webhook",
+		"webhook.delivery.total",
+		metric.WithDescription("Number of webhook notifications by event and outcome"),
+	)
+	deliveryDuration, _ := instruments.Float64Histogram(provider, "webhook",
+		"webhook.delivery.duration",
+		metric.WithDescription("Duration of webhook deliveries including retries in seconds, by event and outcome"),
+	)
+
+	return &Sender{
+		opts:             opts,
+		client:           client,
+		shouldRetryFn:    shouldRetryFn,
+		deliveryAttempts: deliveryAttempts,
+		deliveries:       deliveries,
+		deliveryDuration: deliveryDuration,
+	}
+}
+
+// Send delivers the notification, retrying failed attempts until MaxAttempts is reached or the context
+// is done. Notifications that cannot be delivered are passed to the DeadLetterFn and reported as error.
+func (s *Sender) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	start := time.Now()
+	err = s.deliver(ctx, notification, body)
+
+	outcome := OutcomeDelivered
+	if err != nil {
+		outcome = OutcomeFailed
+	}
+	attributes := metric.WithAttributes(
+		attribute.String("webhook.event", notification.Event),
+		attribute.String("webhook.outcome", outcome),
+	)
+	s.deliveries.Add(ctx, 1, attributes)
+	s.deliveryDuration.Record(ctx, time.Since(start).Seconds(), attributes)
+
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("failed to deliver webhook %s %s to %s", notification.Event, notification.ID, notification.URL)
+		if s.opts.DeadLetterFn != nil {
+			s.opts.DeadLetterFn(ctx, notification, err)
+		}
+	}
+	return err
+}
+
+func (s *Sender) deliver(ctx context.Context, notification Notification, body []byte) error {
+	maxAttempts := max(s.opts.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		res, err := s.attempt(ctx, notification, body)
+		if err == nil && res.StatusCode < http.StatusMultipleChoices {
+			return nil
+		}
+
+		retry := attempt < maxAttempts && s.shouldRetryFn(res, err)
+		var retryAfter time.Duration
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("webhook receiver responded with status %d", res.StatusCode)
+			retryAfter = parseRetryAfter(res)
+		}
+		if !retry {
+			return fmt.Errorf("webhook delivery failed after %d attempts: %w", attempt, lastErr)
+		}
+
+		timer := time.NewTimer(s.backoff(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("webhook delivery aborted after %d attempts: %w", attempt, errors.Join(ctx.Err(), lastErr))
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends the notification once, draining and closing the response body
+func (s *Sender) attempt(ctx context.Context, notification Notification, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header.ContentType, "application/json")
+	if notification.ID != "" {
+		req.Header.Set(header.XWebhookID, notification.ID)
+	}
+	if notification.Event != "" {
+		req.Header.Set(header.XWebhookEvent, notification.Event)
+	}
+	if len(s.opts.Secret) > 0 {
+		req.Header.Set(header.XWebhookSignature, Sign(s.opts.Secret, time.Now(), body))
+	}
+
+	res, err := s.client.Do(req)
+	statusCode := 0
+	if res != nil {
+		statusCode = res.StatusCode
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+	s.deliveryAttempts.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("webhook.event", notification.Event),
+		attribute.Int("http.response.status_code", statusCode),
+	))
+	return res, err
+}
+
+// backoff returns the delay before the retry following the attempt
+func (s *Sender) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := s.opts.InitialBackoff
+	for i := 1; i < attempt && delay < s.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if s.opts.Jitter && delay > 0 {
+		delay = delay/2 + mathrand.N(delay/2+1)
+	}
+	delay = max(delay, retryAfter)
+	if s.opts.MaxBackoff > 0 {
+		delay = min(delay, s.opts.MaxBackoff)
+	}
+	return delay
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header in seconds, or zero
+func parseRetryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get(header.RetryAfter))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
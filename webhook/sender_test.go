@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/Roshick/go-autumn-web/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestDefaultSenderOptions(t *testing.T) {
+	opts := DefaultSenderOptions()
+
+	require.NotNil(t, opts)
+	assert.Equal(t, http.DefaultClient, opts.Client)
+	assert.Equal(t, 5, opts.MaxAttempts)
+	assert.Equal(t, time.Second, opts.InitialBackoff)
+	assert.Equal(t, time.Minute, opts.MaxBackoff)
+	assert.True(t, opts.Jitter)
+	assert.NotNil(t, opts.ShouldRetryFn)
+}
+
+func TestDefaultShouldRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      *http.Response
+		err      error
+		expected bool
+	}{
+		{name: "transport error", err: errors.New("connection refused"), expected: true},
+		{name: "canceled", err: context.Canceled, expected: false},
+		{name: "timeout status", res: &http.Response{StatusCode: http.StatusRequestTimeout}, expected: true},
+		{name: "rate limited", res: &http.Response{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{name: "server error", res: &http.Response{StatusCode: http.StatusBadGateway}, expected: true},
+		{name: "client error", res: &http.Response{StatusCode: http.StatusBadRequest}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DefaultShouldRetry(tt.res, tt.err))
+		})
+	}
+}
+
+func newTestSender(t *testing.T, transport http.RoundTripper, configure func(opts *SenderOptions)) *Sender {
+	opts := DefaultSenderOptions()
+	opts.Client = &http.Client{Transport: transport}
+	opts.InitialBackoff = time.Millisecond
+	opts.MaxBackoff = 10 * time.Millisecond
+	opts.Jitter = false
+	if configure != nil {
+		configure(opts)
+	}
+	return NewSender(opts)
+}
+
+func testNotification() Notification {
+	return Notification{
+		ID:      "notification-1",
+		Event:   "order.created",
+		URL:     "https://receiver.localhost/hooks",
+		Payload: map[string]any{"orderId": "42"},
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Run("sends signed notifications", func(t *testing.T) {
+		transport := testutils.NewMockInteractionTransport(t, nil)
+		transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+			MatchWith(func(req *http.Request) bool {
+				body, _ := io.ReadAll(req.Body)
+				signature := req.Header.Get(header.XWebhookSignature)
+				timestamp, _, _ := strings.Cut(strings.TrimPrefix(signature, "t="), ",")
+				unix, err := strconv.ParseInt(timestamp, 10, 64)
+				return err == nil &&
+					string(body) == `{"orderId":"42"}` &&
+					req.Header.Get(header.ContentType) == "application/json" &&
+					req.Header.Get(header.XWebhookID) == "notification-1" &&
+					req.Header.Get(header.XWebhookEvent) == "order.created" &&
+					signature == Sign([]byte("secret"), time.Unix(unix, 0), body)
+			}).
+			WillReturnResponse(&testutils.TestResponse{Status: http.StatusNoContent})
+		sender := newTestSender(t, transport, func(opts *SenderOptions) {
+			opts.Secret = []byte("secret")
+		})
+
+		require.NoError(t, sender.Send(context.Background(), testNotification()))
+		transport.Verify(t)
+	})
+
+	t.Run("retries failed attempts", func(t *testing.T) {
+		transport := testutils.NewMockInteractionTransport(t, nil)
+		transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+			WillReturnResponses(
+				&testutils.TestResponse{Status: http.StatusServiceUnavailable},
+				&testutils.TestResponse{Status: http.StatusTooManyRequests},
+				&testutils.TestResponse{Status: http.StatusOK},
+			)
+		sender := newTestSender(t, transport, nil)
+
+		require.NoError(t, sender.Send(context.Background(), testNotification()))
+		transport.Verify(t)
+	})
+
+	t.Run("passes undeliverable notifications to the dead letter function", func(t *testing.T) {
+		transport := testutils.NewMockInteractionTransport(t, nil)
+		transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+			WillReturnResponses(
+				&testutils.TestResponse{Status: http.StatusInternalServerError},
+				&testutils.TestResponse{Status: http.StatusInternalServerError},
+			)
+		var deadLetters []Notification
+		sender := newTestSender(t, transport, func(opts *SenderOptions) {
+			opts.MaxAttempts = 2
+			opts.DeadLetterFn = func(_ context.Context, notification Notification, err error) {
+				assert.ErrorContains(t, err, "status 500")
+				deadLetters = append(deadLetters, notification)
+			}
+		})
+
+		err := sender.Send(context.Background(), testNotification())
+
+		assert.ErrorContains(t, err, "after 2 attempts")
+		assert.Equal(t, []Notification{testNotification()}, deadLetters)
+		transport.Verify(t)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		transport := testutils.NewMockInteractionTransport(t, nil)
+		transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+			WillReturnResponse(&testutils.TestResponse{Status: http.StatusGone})
+		sender := newTestSender(t, transport, nil)
+
+		err := sender.Send(context.Background(), testNotification())
+
+		assert.ErrorContains(t, err, "after 1 attempts")
+		transport.Verify(t)
+	})
+
+	t.Run("aborts retries once the context is done", func(t *testing.T) {
+		transport := testutils.NewMockInteractionTransport(t, nil)
+		transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+			WillReturnResponse(&testutils.TestResponse{Status: http.StatusServiceUnavailable})
+		sender := newTestSender(t, transport, func(opts *SenderOptions) {
+			opts.InitialBackoff = time.Hour
+			opts.MaxBackoff = time.Hour
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := sender.Send(ctx, testNotification())
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("rejects payloads that cannot be marshalled", func(t *testing.T) {
+		sender := newTestSender(t, testutils.NewMockInteractionTransport(t, nil), nil)
+		notification := testNotification()
+		notification.Payload = make(chan int)
+
+		assert.ErrorContains(t, sender.Send(context.Background(), notification), "marshal")
+	})
+}
+
+func TestSender_Metrics(t *testing.T) {
+	recorder := testutils.NewMetricsRecorder(t)
+	transport := testutils.NewMockInteractionTransport(t, nil)
+	transport.ExpectRequest(testutils.TestRequest{Method: http.MethodPost, URL: "https://receiver.localhost/hooks"}).
+		WillReturnResponses(
+			&testutils.TestResponse{Status: http.StatusBadGateway},
+			&testutils.TestResponse{Status: http.StatusAccepted},
+		)
+	sender := newTestSender(t, transport, nil)
+
+	require.NoError(t, sender.Send(context.Background(), testNotification()))
+
+	event := attribute.String("webhook.event", "order.created")
+	recorder.AssertCounter(t, "webhook.delivery.attempts", 1, event, attribute.Int("http.response.status_code", http.StatusBadGateway))
+	recorder.AssertCounter(t, "webhook.delivery.attempts", 1, event, attribute.Int("http.response.status_code", http.StatusAccepted))
+	recorder.AssertCounter(t, "webhook.delivery.total", 1, event, attribute.String("webhook.outcome", OutcomeDelivered))
+	recorder.AssertHistogram(t, "webhook.delivery.duration", 1, event)
+}
+
+func TestSender_backoff(t *testing.T) {
+	sender := NewSender(&SenderOptions{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+	})
+
+	assert.Equal(t, time.Second, sender.backoff(1, 0))
+	assert.Equal(t, 2*time.Second, sender.backoff(2, 0))
+	assert.Equal(t, 8*time.Second, sender.backoff(4, 0))
+	assert.Equal(t, 10*time.Second, sender.backoff(10, 0))
+	assert.Equal(t, 5*time.Second, sender.backoff(1, 5*time.Second))
+	assert.Equal(t, 10*time.Second, sender.backoff(1, time.Minute))
+
+	sender.opts.Jitter = true
+	for range 10 {
+		delay := sender.backoff(2, 0)
+		assert.GreaterOrEqual(t, delay, time.Second)
+		assert.LessOrEqual(t, delay, 2*time.Second)
+	}
+}
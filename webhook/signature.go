@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+)
+
+// SignatureVersion prefixes the HMAC-SHA256 signature in the signature header
+const SignatureVersion = "v1"
+
+// Sign returns the value of the signature header for the body sent at the timestamp, e.g.
+// "t=1700000000,v1=5257a8...". The signature is the hex-encoded HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the secret, so receivers can reject replayed deliveries by their timestamp.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + "," + SignatureVersion + "=" + hex.EncodeToString(computeHMAC(secret, unix, body))
+}
+
+func computeHMAC(secret []byte, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// SignatureScheme verifies the signature of received webhooks
+type SignatureScheme interface {
+	// Verify checks the signature of the request over the raw body and returns the signed timestamp,
+	// or the zero time for schemes not signing one.
+	Verify(req *http.Request, body []byte) (time.Time, error)
+}
+
+// TimestampSignatureScheme verifies signatures of the form "t=<unix>,v1=<hex hmac>" over
+// "<timestamp>.<body>", as created by Sign and used by Stripe. Several v1 entries are accepted, e.g.
+// during secret rotation.
+type TimestampSignatureScheme struct {
+	// HeaderName defaults to X-Webhook-Signature, use header.StripeSignature for Stripe.
+	HeaderName string
+	Secret     []byte
+}
+
+func (s TimestampSignatureScheme) Verify(req *http.Request, body []byte) (time.Time, error) {
+	headerName := s.HeaderName
+	if headerName == "" {
+		headerName = header.XWebhookSignature
+	}
+	value := req.Header.Get(headerName)
+	if value == "" {
+		return time.Time{}, ErrMissingSignature
+	}
+
+	var unix string
+	var signatures [][]byte
+	for _, part := range strings.Split(value, ",") {
+		key, partValue, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = partValue
+		case SignatureVersion:
+			if signature, err := hex.DecodeString(partValue); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	expected := computeHMAC(s.Secret, unix, body)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, ErrInvalidSignature
+}
+
+// HMACSignatureScheme verifies an HMAC of the body in a header, e.g. "sha256=<hex>" as sent by GitHub
+// in X-Hub-Signature-256, see NewGitHubSignatureScheme.
+type HMACSignatureScheme struct {
+	HeaderName string
+	Secret     []byte
+	// HashFn defaults to sha256.New.
+	HashFn func() hash.Hash
+	// Prefix precedes the encoded HMAC, e.g. "sha256=".
+	Prefix string
+	// Base64 expects the HMAC base64 instead of hex encoded.
+	Base64 bool
+}
+
+// NewGitHubSignatureScheme verifies the X-Hub-Signature-256 header of GitHub webhooks
+func NewGitHubSignatureScheme(secret []byte) HMACSignatureScheme {
+	return HMACSignatureScheme{
+		HeaderName: header.XHubSignature256,
+		Secret:     secret,
+		HashFn:     sha256.New,
+		Prefix:     "sha256=",
+	}
+}
+
+func (s HMACSignatureScheme) Verify(req *http.Request, body []byte) (time.Time, error) {
+	value := req.Header.Get(s.HeaderName)
+	if value == "" {
+		return time.Time{}, ErrMissingSignature
+	}
+	encoded, ok := strings.CutPrefix(value, s.Prefix)
+	if !ok {
+		return time.Time{}, ErrInvalidSignature
+	}
+	decodeFn := hex.DecodeString
+	if s.Base64 {
+		decodeFn = base64.StdEncoding.DecodeString
+	}
+	signature, err := decodeFn(encoded)
+	if err != nil {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	hashFn := s.HashFn
+	if hashFn == nil {
+		hashFn = sha256.New
+	}
+	mac := hmac.New(hashFn, s.Secret)
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return time.Time{}, ErrInvalidSignature
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roshick/go-autumn-web/header"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"42"}`)
+
+	signature := Sign([]byte("secret"), timestamp, body)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1700000000.{"id":"42"}`))
+	assert.Equal(t, "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)), signature)
+	assert.NotEqual(t, signature, Sign([]byte("other"), timestamp, body))
+	assert.NotEqual(t, signature, Sign([]byte("secret"), timestamp.Add(time.Second), body))
+}
+
+func TestTimestampSignatureScheme_Verify(t *testing.T) {
+	body := []byte(`{"id":"42"}`)
+	timestamp := time.Unix(1700000000, 0)
+	scheme := TimestampSignatureScheme{Secret: []byte("secret")}
+
+	newRequest := func(headerName string, signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", nil)
+		req.Header.Set(headerName, signature)
+		return req
+	}
+
+	t.Run("accepts signatures created by Sign", func(t *testing.T) {
+		signedAt, err := scheme.Verify(newRequest(header.XWebhookSignature, Sign([]byte("secret"), timestamp, body)), body)
+
+		require.NoError(t, err)
+		assert.Equal(t, timestamp, signedAt)
+	})
+
+	t.Run("accepts any of several signatures", func(t *testing.T) {
+		stripeScheme := TimestampSignatureScheme{HeaderName: header.StripeSignature, Secret: []byte("secret")}
+		signature := Sign([]byte("secret"), timestamp, body)
+		_, v1, _ := strings.Cut(signature, ",v1=")
+
+		_, err := stripeScheme.Verify(newRequest(header.StripeSignature, "t=1700000000,v1=00ff,v1="+v1+",v0=ignored"), body)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects invalid signatures", func(t *testing.T) {
+		for _, signature := range []string{
+			Sign([]byte("other"), timestamp, body),
+			strings.Replace(Sign([]byte("secret"), timestamp, body), "t=1700000000", "t=1700000001", 1),
+			"v1=abc",
+			"t=1700000000",
+			"t=soon,v1=00",
+		} {
+			_, err := scheme.Verify(newRequest(header.XWebhookSignature, signature), body)
+			assert.ErrorIs(t, err, ErrInvalidSignature, signature)
+		}
+	})
+
+	t.Run("rejects missing signatures", func(t *testing.T) {
+		_, err := scheme.Verify(httptest.NewRequest(http.MethodPost, "/hooks", nil), body)
+
+		assert.ErrorIs(t, err, ErrMissingSignature)
+	})
+}
+
+func TestHMACSignatureScheme_Verify(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	digest := mac.Sum(nil)
+
+	t.Run("GitHub", func(t *testing.T) {
+		scheme := NewGitHubSignatureScheme([]byte("secret"))
+		req := httptest.NewRequest(http.MethodPost, "/hooks", nil)
+		req.Header.Set(header.XHubSignature256, "sha256="+hex.EncodeToString(digest))
+
+		signedAt, err := scheme.Verify(req, body)
+
+		require.NoError(t, err)
+		assert.True(t, signedAt.IsZero())
+		_, err = scheme.Verify(req, []byte(`{"action":"closed"}`))
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("base64 without prefix", func(t *testing.T) {
+		scheme := HMACSignatureScheme{HeaderName: "X-Signature", Secret: []byte("secret"), Base64: true}
+		req := httptest.NewRequest(http.MethodPost, "/hooks", nil)
+		req.Header.Set("X-Signature", base64.StdEncoding.EncodeToString(digest))
+
+		_, err := scheme.Verify(req, body)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects malformed signatures", func(t *testing.T) {
+		scheme := NewGitHubSignatureScheme([]byte("secret"))
+		for _, signature := range []string{hex.EncodeToString(digest), "sha256=xyz"} {
+			req := httptest.NewRequest(http.MethodPost, "/hooks", nil)
+			req.Header.Set(header.XHubSignature256, signature)
+
+			_, err := scheme.Verify(req, body)
+			assert.ErrorIs(t, err, ErrInvalidSignature, signature)
+		}
+	})
+}